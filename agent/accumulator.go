@@ -1,7 +1,7 @@
 package agent
 
 import (
-	"log"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -10,30 +10,46 @@ import (
 )
 
 var (
-	NErrors = selfstat.Register("agent", "gather_errors", map[string]string{})
+	NErrors   = selfstat.Register("agent", "gather_errors", map[string]string{})
+	NTimeouts = selfstat.Register("agent", "gather_timeouts", map[string]string{})
 )
 
 type MetricMaker interface {
 	LogName() string
 	MakeMetric(metric telegraf.Metric) telegraf.Metric
+	Log() telegraf.Logger
 }
 
 type accumulator struct {
 	maker     MetricMaker
 	metrics   chan<- telegraf.Metric
 	precision time.Duration
+
+	// mu is held while a metric is sent, so that once stopped no metric is
+	// sent on a channel that may be closed.
+	mu      sync.RWMutex
+	stopped bool
+
+	// done, when set, drops the metrics no longer received once closed.
+	done <-chan struct{}
 }
 
 func NewAccumulator(
 	maker MetricMaker,
 	metrics chan<- telegraf.Metric,
 ) telegraf.Accumulator {
-	acc := accumulator{
+	return newAccumulator(maker, metrics)
+}
+
+func newAccumulator(
+	maker MetricMaker,
+	metrics chan<- telegraf.Metric,
+) *accumulator {
+	return &accumulator{
 		maker:     maker,
 		metrics:   metrics,
 		precision: time.Nanosecond,
 	}
-	return &acc
 }
 
 func (ac *accumulator) AddFields(
@@ -84,7 +100,7 @@ func (ac *accumulator) AddHistogram(
 func (ac *accumulator) AddMetric(m telegraf.Metric) {
 	m.SetTime(m.Time().Round(ac.precision))
 	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+		ac.send(m)
 	}
 }
 
@@ -100,18 +116,42 @@ func (ac *accumulator) addFields(
 		return
 	}
 	if m := ac.maker.MakeMetric(m); m != nil {
-		ac.metrics <- m
+		ac.send(m)
+	}
+}
+
+func (ac *accumulator) send(m telegraf.Metric) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if ac.stopped {
+		m.Drop()
+		return
 	}
+	select {
+	case ac.metrics <- m:
+	case <-ac.done:
+		m.Drop()
+	}
+}
+
+// stop drops the metrics added afterwards, it returns once the metrics
+// being sent are received.  The metrics channel may be closed after stop
+// returns, even while a Gather abandoned after a timeout is still running.
+func (ac *accumulator) stop() {
+	ac.mu.Lock()
+	ac.stopped = true
+	ac.mu.Unlock()
 }
 
 // AddError passes a runtime error to the accumulator.
-// The error will be tagged with the plugin name and written to the log.
+// The error will be tagged with the plugin name, written to the log and
+// counted in the errors of the plugin.
 func (ac *accumulator) AddError(err error) {
 	if err == nil {
 		return
 	}
 	NErrors.Incr(1)
-	log.Printf("E! [%s] Error in plugin: %v", ac.maker.LogName(), err)
+	ac.maker.Log().Errorf("Error in plugin: %v", err)
 }
 
 func (ac *accumulator) SetPrecision(precision time.Duration) {
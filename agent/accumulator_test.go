@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -125,6 +127,21 @@ func TestSetPrecision(t *testing.T) {
 	}
 }
 
+func TestStopDropsMetrics(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	a := newAccumulator(&TestMetricMaker{}, metrics)
+
+	fields := map[string]interface{}{"usage": float64(99)}
+	a.AddFields("acctest", fields, nil)
+	a.stop()
+	close(metrics)
+
+	// Sending on the closed channel would panic.
+	a.AddFields("acctest", fields, nil)
+
+	require.Len(t, metrics, 1)
+}
+
 func TestAddTrackingMetricGroupEmpty(t *testing.T) {
 	ch := make(chan telegraf.Metric, 10)
 	metrics := []telegraf.Metric{}
@@ -154,3 +171,10 @@ func (tm *TestMetricMaker) LogName() string {
 func (tm *TestMetricMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	return metric
 }
+
+func (tm *TestMetricMaker) Log() telegraf.Logger {
+	return &models.Logger{
+		Name: tm.Name(),
+		Errs: selfstat.Register("test", "errors", map[string]string{}),
+	}
+}
@@ -5,29 +5,139 @@ import (
 	"fmt"
 	"log"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cache"
 	"github.com/influxdata/telegraf/internal/config"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/state"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// reloadC receives the reloads requested through the control server.
+	reloadC chan struct{}
+	// tap receives the metrics sent to the outputs when the control server
+	// is enabled.
+	tap *metricTap
+
+	// store holds the state of the inputs once the agent is prepared.
+	store    *state.Store
+	prepared bool
+
+	// serviceC receives the metrics of the service inputs.  It is shared
+	// with the agent replacing this one on reload, so that the service
+	// inputs handed over keep running.
+	serviceC chan telegraf.Metric
+	// adopted holds the service inputs taken over running from the
+	// previous agent, which are neither initialized nor started again.
+	adopted map[*models.RunningInput]bool
+
+	// handedOverMu protects handedOver, the service inputs left running
+	// for the next agent, which are not stopped when this one stops.
+	handedOverMu sync.Mutex
+	handedOver   map[*models.RunningInput]bool
 }
 
 // NewAgent returns an Agent for the given Config.
 func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
-		Config: config,
+		Config:   config,
+		reloadC:  make(chan struct{}, 1),
+		serviceC: make(chan telegraf.Metric, 100),
 	}
 	return a, nil
 }
 
+// Prepare loads the state and initializes the plugins, it is called by Run
+// when needed.  On reload it is called with the running agent, while it is
+// still running, so that an invalid configuration is reported before the
+// running agent is stopped.  The state store of prev is then shared, and
+// its service inputs whose configuration did not change are taken over
+// running, rather than stopped and started again; they are only handed over
+// when all the other plugins initialize.
+func (a *Agent) Prepare(prev *Agent) error {
+	var store *state.Store
+	if prev != nil && prev.store != nil && prev.Config.Agent.Statefile == a.Config.Agent.Statefile {
+		store = prev.store
+	} else {
+		var err error
+		store, err = loadState(a.Config.Agent.Statefile)
+		if err != nil {
+			return err
+		}
+	}
+
+	adopted := make(map[*models.RunningInput]bool)
+	if prev != nil {
+		adopted = a.adoptServiceInputs(prev)
+	}
+
+	a.setState(store, adopted)
+	a.initCache(adopted)
+
+	log.Printf("D! [agent] Initializing plugins")
+	if err := a.initPlugins(adopted); err != nil {
+		return err
+	}
+
+	a.store = store
+	a.adopted = adopted
+	a.prepared = true
+	if prev != nil {
+		a.serviceC = prev.serviceC
+		prev.handOver(adopted)
+	}
+	return nil
+}
+
+// adoptServiceInputs replaces the service inputs configured like a running
+// service input of prev with the running one, and returns those replaced.
+func (a *Agent) adoptServiceInputs(prev *Agent) map[*models.RunningInput]bool {
+	adopted := make(map[*models.RunningInput]bool)
+	for i, input := range a.Config.Inputs {
+		if _, ok := input.Input.(telegraf.ServiceInput); !ok {
+			continue
+		}
+		for _, running := range prev.Config.Inputs {
+			if !adopted[running] && running.SameConfig(input) {
+				a.Config.Inputs[i] = running
+				adopted[running] = true
+				break
+			}
+		}
+	}
+	return adopted
+}
+
+// handOver marks the service inputs taken over by the next agent, so that
+// they are not stopped with this one.
+func (a *Agent) handOver(inputs map[*models.RunningInput]bool) {
+	a.handedOverMu.Lock()
+	defer a.handedOverMu.Unlock()
+	a.handedOver = inputs
+}
+
+func (a *Agent) isHandedOver(input *models.RunningInput) bool {
+	a.handedOverMu.Lock()
+	defer a.handedOverMu.Unlock()
+	return a.handedOver[input]
+}
+
+// ReloadRequested returns a channel receiving a value when a reload of the
+// configuration is requested through the control server.
+func (a *Agent) ReloadRequested() <-chan struct{} {
+	return a.reloadC
+}
+
 // Run starts and runs the Agent until the context is done.
 func (a *Agent) Run(ctx context.Context) error {
 	log.Printf("I! [agent] Config: Interval:%s, Quiet:%#v, Hostname:%#v, "+
@@ -39,15 +149,29 @@ func (a *Agent) Run(ctx context.Context) error {
 		return ctx.Err()
 	}
 
-	log.Printf("D! [agent] Initializing plugins")
-	err := a.initPlugins()
-	if err != nil {
-		return err
+	if !a.prepared {
+		if err := a.Prepare(nil); err != nil {
+			return err
+		}
+	}
+	store := a.store
+
+	var health *healthServer
+	if a.Config.Agent.HealthAddress != "" {
+		health = newHealthServer(a)
+		err := health.Start()
+		if err != nil {
+			// The service inputs adopted from the previous agent are running.
+			a.stopServiceInputs()
+			return err
+		}
+		defer health.Stop()
 	}
 
 	log.Printf("D! [agent] Connecting outputs")
-	err = a.connectOutputs(ctx)
+	err := a.connectOutputs(ctx)
 	if err != nil {
+		a.stopServiceInputs()
 		return err
 	}
 
@@ -57,12 +181,45 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	startTime := time.Now()
 
+	if a.Config.Agent.SnapshotDir != "" {
+		for _, input := range a.Config.Inputs {
+			input.RecordBatches()
+		}
+		go a.snapshotOnSignal(ctx)
+	}
+
 	log.Printf("D! [agent] Starting service inputs")
-	err = a.startServiceInputs(ctx, inputC)
+	err = a.startServiceInputs(ctx, a.serviceC)
 	if err != nil {
+		a.closeOutputs()
 		return err
 	}
 
+	relayStop := make(chan struct{})
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		a.relayServiceMetrics(relayStop, inputC)
+	}()
+
+	var control *controlServer
+	if a.Config.Agent.ControlAddress != "" {
+		a.tap = newMetricTap()
+		control = newControlServer(a, inputC)
+		err = control.Start()
+		if err != nil {
+			control.Stop()
+			a.stopServiceInputs()
+			close(relayStop)
+			a.closeOutputs()
+			return err
+		}
+	}
+
+	if health != nil {
+		health.SetReady(true)
+	}
+
 	var wg sync.WaitGroup
 
 	src := inputC
@@ -77,9 +234,17 @@ func (a *Agent) Run(ctx context.Context) error {
 			log.Printf("E! [agent] Error running inputs: %v", err)
 		}
 
+		if control != nil {
+			control.Stop()
+		}
+
 		log.Printf("D! [agent] Stopping service inputs")
 		a.stopServiceInputs()
 
+		close(relayStop)
+		<-relayDone
+		a.drainServiceMetrics(dst)
+
 		close(dst)
 		log.Printf("D! [agent] Input channel closed")
 	}(dst)
@@ -132,17 +297,61 @@ func (a *Agent) Run(ctx context.Context) error {
 		}
 	}(src)
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.saveState(ctx, store, a.Config.Agent.FlushInterval.Duration)
+	}()
+
 	wg.Wait()
 
+	log.Printf("D! [agent] Stopping processors")
+	a.stopProcessors()
+
 	log.Printf("D! [agent] Closing outputs")
 	a.closeOutputs()
 
+	if err := store.Save(); err != nil {
+		log.Printf("E! [agent] Error saving state: %v", err)
+	}
+
 	log.Printf("D! [agent] Stopped Successfully")
 	return nil
 }
 
 // Test runs the inputs once and prints the output to stdout in line protocol.
 func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
+	s := influx.NewSerializer()
+	s.SetFieldSortOrder(influx.SortFields)
+	err := a.test(ctx, waitDuration, func(metric telegraf.Metric) {
+		octets, err := s.Serialize(metric)
+		if err == nil {
+			fmt.Print("> ", string(octets))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if NErrors.Get() > 0 {
+		return fmt.Errorf("One or more input plugins had an error")
+	}
+	return nil
+}
+
+// TestMetrics runs the inputs once, as Test does, and returns the metrics.
+// Errors of the inputs are logged and not returned.
+func (a *Agent) TestMetrics(ctx context.Context, waitDuration time.Duration) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	err := a.test(ctx, waitDuration, func(metric telegraf.Metric) {
+		metrics = append(metrics, metric)
+	})
+	return metrics, err
+}
+
+// test runs the inputs once and calls fn with each metric, from a single
+// goroutine.
+func (a *Agent) test(ctx context.Context, waitDuration time.Duration, fn func(telegraf.Metric)) error {
 	var wg sync.WaitGroup
 	metricC := make(chan telegraf.Metric)
 	nulC := make(chan telegraf.Metric)
@@ -155,14 +364,8 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-
-		s := influx.NewSerializer()
-		s.SetFieldSortOrder(influx.SortFields)
 		for metric := range metricC {
-			octets, err := s.Serialize(metric)
-			if err == nil {
-				fmt.Print("> ", string(octets))
-			}
+			fn(metric)
 			metric.Reject()
 		}
 	}()
@@ -182,8 +385,15 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 		}
 	}
 
+	// The state is not persisted by test runs, so they neither depend on
+	// nor advance the cursors of the running agent.
+	if _, err := a.initState(""); err != nil {
+		return err
+	}
+	a.initCache(nil)
+
 	log.Printf("D! [agent] Initializing plugins")
-	err := a.initPlugins()
+	err := a.initPlugins(nil)
 	if err != nil {
 		return err
 	}
@@ -196,6 +406,10 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 		}
 	}
 
+	// With a wait duration, service inputs are gathered after the wait so
+	// that inputs reporting what they received since they started, such as
+	// streamed statistics, have something to report.
+	deferService := hasServiceInputs && waitDuration > 0
 	for _, input := range a.Config.Inputs {
 		select {
 		case <-ctx.Done():
@@ -204,47 +418,62 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 			break
 		}
 
-		acc := NewAccumulator(input, metricC)
-		acc.SetPrecision(a.Precision())
-
-		// Special instructions for some inputs. cpu, for example, needs to be
-		// run twice in order to return cpu usage percentages.
-		switch input.Config.Name {
-		case "cpu", "mongodb", "procstat":
-			nulAcc := NewAccumulator(input, nulC)
-			nulAcc.SetPrecision(a.Precision())
-			if err := input.Input.Gather(nulAcc); err != nil {
-				acc.AddError(err)
-			}
-
-			time.Sleep(500 * time.Millisecond)
-			if err := input.Input.Gather(acc); err != nil {
-				acc.AddError(err)
-			}
-		default:
-			if err := input.Input.Gather(acc); err != nil {
-				acc.AddError(err)
-			}
+		if _, ok := input.Input.(telegraf.ServiceInput); ok && deferService {
+			continue
 		}
+		a.testGather(input, metricC, nulC)
 	}
 
 	if hasServiceInputs {
 		log.Printf("D! [agent] Waiting for service inputs")
 		internal.SleepContext(ctx, waitDuration)
+
+		if deferService && ctx.Err() == nil {
+			for _, input := range a.Config.Inputs {
+				if _, ok := input.Input.(telegraf.ServiceInput); ok {
+					a.testGather(input, metricC, nulC)
+				}
+			}
+		}
+
 		log.Printf("D! [agent] Stopping service inputs")
 		a.stopServiceInputs()
 	}
+	return nil
+}
 
-	if NErrors.Get() > 0 {
-		return fmt.Errorf("One or more input plugins had an error")
+// testGather gathers the input once for the test mode.
+func (a *Agent) testGather(input *models.RunningInput, metricC, nulC chan<- telegraf.Metric) {
+	acc := NewAccumulator(input, metricC)
+	acc.SetPrecision(a.InputPrecision(input))
+
+	// Special instructions for some inputs. cpu, for example, needs to be
+	// run twice in order to return cpu usage percentages.
+	switch input.Config.Name {
+	case "cpu", "mongodb", "procstat":
+		nulAcc := NewAccumulator(input, nulC)
+		nulAcc.SetPrecision(a.InputPrecision(input))
+		if err := input.Input.Gather(nulAcc); err != nil {
+			acc.AddError(err)
+		}
+
+		time.Sleep(500 * time.Millisecond)
+		if err := input.Input.Gather(acc); err != nil {
+			acc.AddError(err)
+		}
+	default:
+		if err := input.Input.Gather(acc); err != nil {
+			acc.AddError(err)
+		}
 	}
-	return nil
 }
 
 // runInputs starts and triggers the periodic gather for Inputs.
 //
 // When the context is done the timers are stopped and this function returns
-// after all ongoing Gather calls complete.
+// after all ongoing Gather calls complete, except those abandoned after the
+// gather timeout; the metrics these add once the function returns are
+// dropped.
 func (a *Agent) runInputs(
 	ctx context.Context,
 	startTime time.Time,
@@ -255,19 +484,23 @@ func (a *Agent) runInputs(
 		interval := a.Config.Agent.Interval.Duration
 		jitter := a.Config.Agent.CollectionJitter.Duration
 
-		// Overwrite agent interval if this plugin has its own.
+		// Overwrite agent interval and jitter if this plugin has its own.
 		if input.Config.Interval != 0 {
 			interval = input.Config.Interval
 		}
+		if input.Config.CollectionJitter != 0 {
+			jitter = input.Config.CollectionJitter
+		}
 
-		acc := NewAccumulator(input, dst)
-		acc.SetPrecision(a.Precision())
+		acc := newAccumulator(input, dst)
+		acc.SetPrecision(a.InputPrecision(input))
 
 		wg.Add(1)
 		go func(input *models.RunningInput) {
 			defer wg.Done()
+			defer acc.stop()
 
-			if a.Config.Agent.RoundInterval {
+			if a.InputRoundInterval(input) {
 				err := internal.SleepContext(
 					ctx, internal.AlignDuration(startTime, interval))
 				if err != nil {
@@ -297,15 +530,35 @@ func (a *Agent) gatherOnInterval(
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	timeout := a.Config.Agent.GatherTimeout.Duration
+	if input.Config.GatherTimeout != 0 {
+		timeout = input.Config.GatherTimeout
+	}
+
+	// pending is set while a Gather call abandoned after a timeout has not
+	// yet returned.
+	var pending <-chan struct{}
 	for {
 		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
 		if err != nil {
 			return
 		}
 
-		err = a.gatherOnce(acc, input, interval)
-		if err != nil {
-			acc.AddError(err)
+		if pending != nil {
+			select {
+			case <-pending:
+				pending = nil
+			default:
+				log.Printf("W! [agent] [%s] skipping collection, previous gather has not returned",
+					input.LogName())
+			}
+		}
+
+		if pending == nil {
+			pending, err = a.gatherOnce(acc, input, interval, timeout)
+			if err != nil {
+				acc.AddError(err)
+			}
 		}
 
 		select {
@@ -319,31 +572,71 @@ func (a *Agent) gatherOnInterval(
 
 // gatherOnce runs the input's Gather function once, logging a warning each
 // interval it fails to complete before.
+//
+// When timeout is set and Gather does not return in time, the call is
+// abandoned and an error returned along with a channel that is closed once
+// the call eventually returns.
 func (a *Agent) gatherOnce(
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
+	interval time.Duration,
 	timeout time.Duration,
-) error {
-	ticker := time.NewTicker(timeout)
+) (<-chan struct{}, error) {
+	return a.runGather(input.Gather, acc, input, interval, timeout)
+}
+
+// tryGatherOnce is gatherOnce returning models.ErrGatherRunning, rather than
+// waiting, if the input is already gathering.
+func (a *Agent) tryGatherOnce(
+	acc telegraf.Accumulator,
+	input *models.RunningInput,
+	interval time.Duration,
+	timeout time.Duration,
+) (<-chan struct{}, error) {
+	return a.runGather(input.TryGather, acc, input, interval, timeout)
+}
+
+func (a *Agent) runGather(
+	gather func(telegraf.Accumulator) error,
+	acc telegraf.Accumulator,
+	input *models.RunningInput,
+	interval time.Duration,
+	timeout time.Duration,
+) (<-chan struct{}, error) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	done := make(chan error)
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	done := make(chan error, 1)
+	finished := make(chan struct{})
 	go func() {
-		done <- input.Gather(acc)
+		defer close(finished)
+		done <- gather(acc)
 	}()
 
 	for {
 		select {
 		case err := <-done:
-			return err
+			return nil, err
 		case <-ticker.C:
 			log.Printf("W! [agent] [%s] did not complete within its interval",
 				input.LogName())
+		case <-deadline:
+			NTimeouts.Incr(1)
+			input.GatherTimeouts.Incr(1)
+			return finished, fmt.Errorf("gather did not complete within %s", timeout)
 		}
 	}
 }
 
-// runProcessors applies processors to metrics.
+// runProcessors applies processors to metrics, and sends the metrics held
+// back by the processors once src is closed.
 func (a *Agent) runProcessors(
 	src <-chan telegraf.Metric,
 	agg chan<- telegraf.Metric,
@@ -356,6 +649,10 @@ func (a *Agent) runProcessors(
 		}
 	}
 
+	for _, metric := range a.Config.Processors.Flush() {
+		agg <- metric
+	}
+
 	return nil
 }
 
@@ -526,6 +823,7 @@ func (a *Agent) runOutputs(
 	}
 
 	for metric := range src {
+		a.tap.publish(metric)
 		for i, output := range a.Config.Outputs {
 			if i == len(a.Config.Outputs)-1 {
 				output.AddMetric(metric)
@@ -535,6 +833,10 @@ func (a *Agent) runOutputs(
 		}
 	}
 
+	for _, output := range a.Config.Outputs {
+		output.FlushProcessors()
+	}
+
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
 	cancel()
 	wg.Wait()
@@ -565,6 +867,7 @@ func (a *Agent) flush(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
+			output.ResetBackoff()
 			logError(a.flushOnce(output, interval, output.Write))
 			return
 		default:
@@ -582,6 +885,7 @@ func (a *Agent) flush(
 				logError(a.flushOnce(output, interval, output.WriteBatch))
 			}
 		case <-ctx.Done():
+			output.ResetBackoff()
 			logError(a.flushOnce(output, interval, output.Write))
 			return
 		}
@@ -617,9 +921,88 @@ func (a *Agent) flushOnce(
 
 }
 
-// initPlugins runs the Init function on plugins.
-func (a *Agent) initPlugins() error {
+// initState loads the state store from the file at path, or creates an
+// in-memory store when path is empty, and hands each input its part of the
+// state.
+func (a *Agent) initState(path string) (*state.Store, error) {
+	store, err := loadState(path)
+	if err != nil {
+		return nil, err
+	}
+	a.setState(store, nil)
+	return store, nil
+}
+
+// loadState loads the state store from the file at path, or creates an
+// in-memory store when path is empty.
+func loadState(path string) (*state.Store, error) {
+	if path == "" {
+		return state.New(), nil
+	}
+	return state.Load(path)
+}
+
+// setState hands each input, except the skipped ones, its part of the
+// state.  Inputs configured more than once without an alias are told apart
+// by their order of appearance.
+func (a *Agent) setState(store *state.Store, skip map[*models.RunningInput]bool) {
+	count := make(map[string]int)
+	for _, input := range a.Config.Inputs {
+		name := input.LogName()
+		count[name]++
+		if n := count[name]; n > 1 {
+			name = fmt.Sprintf("%s#%d", name, n)
+		}
+		if !skip[input] {
+			input.SetState(store.Scope(name))
+		}
+	}
+}
+
+// initCache hands the inputs, except the skipped ones, the cache sharing
+// the results of expensive collections within an interval.
+func (a *Agent) initCache(skip map[*models.RunningInput]bool) {
+	c := cache.New(a.Config.Agent.Interval.Duration / 2)
+	for _, input := range a.Config.Inputs {
+		if skip[input] {
+			continue
+		}
+		interval := a.Config.Agent.Interval.Duration
+		if input.Config.Interval != 0 {
+			interval = input.Config.Interval
+		}
+		input.SetCache(c.WithTTL(interval / 2))
+	}
+}
+
+// saveState saves the state store each interval until the context is done.
+func (a *Agent) saveState(ctx context.Context, store *state.Store, interval time.Duration) {
+	if interval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.Save(); err != nil {
+				log.Printf("E! [agent] Error saving state: %v", err)
+			}
+		}
+	}
+}
+
+// initPlugins runs the Init function on plugins, except the skipped inputs.
+func (a *Agent) initPlugins(skip map[*models.RunningInput]bool) error {
 	for _, input := range a.Config.Inputs {
+		if skip[input] {
+			continue
+		}
 		err := input.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize input %s: %v",
@@ -650,9 +1033,46 @@ func (a *Agent) initPlugins() error {
 	return nil
 }
 
-// connectOutputs connects to all outputs.
-func (a *Agent) connectOutputs(ctx context.Context) error {
+// Validate initializes all the plugins, without gathering nor connecting the
+// outputs, and returns an error listing the plugins that failed.  The state
+// file is read but not written.
+func (a *Agent) Validate() error {
+	if _, err := a.initState(a.Config.Agent.Statefile); err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, input := range a.Config.Inputs {
+		if err := input.Init(); err != nil {
+			errs = append(errs, fmt.Sprintf("input %s: %v", input.LogName(), err))
+		}
+	}
+	for _, processor := range a.Config.Processors {
+		if err := processor.Init(); err != nil {
+			errs = append(errs, fmt.Sprintf("processor %s: %v", processor.Config.Name, err))
+		}
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		if err := aggregator.Init(); err != nil {
+			errs = append(errs, fmt.Sprintf("aggregator %s: %v", aggregator.Config.Name, err))
+		}
+	}
 	for _, output := range a.Config.Outputs {
+		if err := output.Init(); err != nil {
+			errs = append(errs, fmt.Sprintf("output %s: %v", output.Config.Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("could not initialize plugins:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// connectOutputs connects to all outputs.  If an output cannot be connected
+// the outputs connected so far are closed.
+func (a *Agent) connectOutputs(ctx context.Context) error {
+	for i, output := range a.Config.Outputs {
 		log.Printf("D! [agent] Attempting connection to [%s]", output.LogName())
 		err := output.Output.Connect()
 		if err != nil {
@@ -660,12 +1080,13 @@ func (a *Agent) connectOutputs(ctx context.Context) error {
 				"error was '%s'", output.LogName(), err)
 
 			err := internal.SleepContext(ctx, 15*time.Second)
-			if err != nil {
-				return err
+			if err == nil {
+				err = output.Output.Connect()
 			}
-
-			err = output.Output.Connect()
 			if err != nil {
+				for _, connected := range a.Config.Outputs[:i] {
+					connected.Close()
+				}
 				return err
 			}
 		}
@@ -674,6 +1095,13 @@ func (a *Agent) connectOutputs(ctx context.Context) error {
 	return nil
 }
 
+// stopProcessors stops the processors, once no more metrics are applied.
+func (a *Agent) stopProcessors() {
+	for _, processor := range a.Config.Processors {
+		processor.Stop()
+	}
+}
+
 // closeOutputs closes all outputs.
 func (a *Agent) closeOutputs() {
 	for _, output := range a.Config.Outputs {
@@ -681,6 +1109,96 @@ func (a *Agent) closeOutputs() {
 	}
 }
 
+// TakeOver carries over the state of an agent replaced by a configuration
+// reload.  The previous agent must be stopped, and the agent prepared with
+// it.  Metrics still buffered in its outputs are moved to the output with the
+// same name in the new configuration, and dropped if the output was removed.
+func (a *Agent) TakeOver(prev *Agent) {
+	logChanges("input", inputNames(prev.Config.Inputs), inputNames(a.Config.Inputs))
+	logChanges("output", outputNames(prev.Config.Outputs), outputNames(a.Config.Outputs))
+
+	for _, input := range a.Config.Inputs {
+		if a.adopted[input] {
+			log.Printf("I! [agent] Keeping unchanged service input %s running", input.LogName())
+		}
+	}
+
+	// Outputs are matched by name, in order of appearance if the same output
+	// is configured more than once.
+	outputs := make(map[string][]*models.RunningOutput)
+	for _, output := range a.Config.Outputs {
+		outputs[output.LogName()] = append(outputs[output.LogName()], output)
+	}
+
+	for _, old := range prev.Config.Outputs {
+		metrics := old.TakeMetrics()
+		if len(metrics) == 0 {
+			continue
+		}
+
+		candidates := outputs[old.LogName()]
+		if len(candidates) == 0 {
+			log.Printf("W! [agent] Dropping %d buffered metrics of removed output %s",
+				len(metrics), old.LogName())
+			for _, m := range metrics {
+				m.Reject()
+			}
+			continue
+		}
+		outputs[old.LogName()] = candidates[1:]
+
+		dropped := candidates[0].RestoreMetrics(metrics)
+		log.Printf("I! [agent] Restored %d buffered metrics to %s, %d dropped",
+			len(metrics)-dropped, old.LogName(), dropped)
+	}
+}
+
+func inputNames(inputs []*models.RunningInput) []string {
+	names := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		names = append(names, input.LogName())
+	}
+	return names
+}
+
+func outputNames(outputs []*models.RunningOutput) []string {
+	names := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		names = append(names, output.LogName())
+	}
+	return names
+}
+
+// logChanges logs the plugins added and removed by a reload.
+func logChanges(kind string, before, after []string) {
+	count := make(map[string]int)
+	for _, name := range before {
+		count[name]--
+	}
+	for _, name := range after {
+		count[name]++
+	}
+
+	var added, removed []string
+	for name, n := range count {
+		for ; n > 0; n-- {
+			added = append(added, name)
+		}
+		for ; n < 0; n++ {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	if len(added) > 0 {
+		log.Printf("I! [agent] Reload added %ss: %s", kind, strings.Join(added, " "))
+	}
+	if len(removed) > 0 {
+		log.Printf("I! [agent] Reload removed %ss: %s", kind, strings.Join(removed, " "))
+	}
+}
+
 // startServiceInputs starts all service inputs.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
@@ -689,6 +1207,9 @@ func (a *Agent) startServiceInputs(
 	started := []telegraf.ServiceInput{}
 
 	for _, input := range a.Config.Inputs {
+		if a.adopted[input] {
+			continue
+		}
 		if si, ok := input.Input.(telegraf.ServiceInput); ok {
 			// Service input plugins are not subject to timestamp rounding.
 			// This only applies to the accumulator passed to Start(), the
@@ -705,6 +1226,9 @@ func (a *Agent) startServiceInputs(
 				for _, si := range started {
 					si.Stop()
 				}
+				for adopted := range a.adopted {
+					adopted.Input.(telegraf.ServiceInput).Stop()
+				}
 
 				return err
 			}
@@ -716,24 +1240,55 @@ func (a *Agent) startServiceInputs(
 	return nil
 }
 
-// stopServiceInputs stops all service inputs.
+// stopServiceInputs stops all service inputs, except those handed over to
+// the next agent.
 func (a *Agent) stopServiceInputs() {
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
+		if si, ok := input.Input.(telegraf.ServiceInput); ok && !a.isHandedOver(input) {
 			si.Stop()
 		}
 	}
 }
 
+// relayServiceMetrics forwards the metrics of the service inputs to dst
+// until stop is closed.
+func (a *Agent) relayServiceMetrics(stop <-chan struct{}, dst chan<- telegraf.Metric) {
+	for {
+		select {
+		case <-stop:
+			return
+		case m := <-a.serviceC:
+			dst <- m
+		}
+	}
+}
+
+// drainServiceMetrics forwards the metrics of the service inputs waiting in
+// the relay channel to dst.  The service inputs handed over to the next
+// agent may still add metrics, which are forwarded by the next agent.
+func (a *Agent) drainServiceMetrics(dst chan<- telegraf.Metric) {
+	for {
+		select {
+		case m := <-a.serviceC:
+			dst <- m
+		default:
+			return
+		}
+	}
+}
+
 // Returns the rounding precision for metrics.
 func (a *Agent) Precision() time.Duration {
 	precision := a.Config.Agent.Precision.Duration
-	interval := a.Config.Agent.Interval.Duration
-
 	if precision > 0 {
 		return precision
 	}
+	return intervalPrecision(a.Config.Agent.Interval.Duration)
+}
 
+// intervalPrecision returns the default precision of metrics gathered at the
+// interval.
+func intervalPrecision(interval time.Duration) time.Duration {
 	switch {
 	case interval >= time.Second:
 		return time.Second
@@ -746,6 +1301,29 @@ func (a *Agent) Precision() time.Duration {
 	}
 }
 
+// InputPrecision returns the rounding precision for metrics of the input,
+// the agent precision is used unless the input sets its own.  When neither
+// is set, the precision defaults to the one of the input interval.
+func (a *Agent) InputPrecision(input *models.RunningInput) time.Duration {
+	if input.Config.Precision != 0 {
+		return input.Config.Precision
+	}
+	if a.Config.Agent.Precision.Duration == 0 && input.Config.Interval != 0 {
+		return intervalPrecision(input.Config.Interval)
+	}
+	return a.Precision()
+}
+
+// InputRoundInterval returns true if the collection of the input is aligned
+// to its interval, the agent round_interval is used unless the input sets
+// its own.
+func (a *Agent) InputRoundInterval(input *models.RunningInput) bool {
+	if input.Config.RoundInterval != nil {
+		return *input.Config.RoundInterval
+	}
+	return a.Config.Agent.RoundInterval
+}
+
 // panicRecover displays an error if an input panics.
 func panicRecover(input *models.RunningInput) {
 	if err := recover(); err != nil {
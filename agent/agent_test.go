@@ -1,10 +1,20 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/metric"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	"github.com/stretchr/testify/assert"
@@ -166,3 +176,388 @@ func TestWindow(t *testing.T) {
 		})
 	}
 }
+
+func TestAgent_InputPrecision(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.Interval = internal.Duration{Duration: 10 * time.Second}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := &models.RunningInput{Config: &models.InputConfig{Name: "zfs"}}
+	require.Equal(t, time.Second, a.InputPrecision(input))
+
+	input.Config.Precision = time.Minute
+	require.Equal(t, time.Minute, a.InputPrecision(input))
+
+	// Without a precision, the one of the input interval is used.
+	input.Config.Precision = 0
+	input.Config.Interval = 250 * time.Millisecond
+	require.Equal(t, time.Millisecond, a.InputPrecision(input))
+
+	c.Agent.Precision = internal.Duration{Duration: time.Second}
+	require.Equal(t, time.Second, a.InputPrecision(input))
+}
+
+func TestAgent_InputRoundInterval(t *testing.T) {
+	c := config.NewConfig()
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := &models.RunningInput{Config: &models.InputConfig{Name: "diskio"}}
+	require.True(t, a.InputRoundInterval(input))
+
+	roundInterval := false
+	input.Config.RoundInterval = &roundInterval
+	require.False(t, a.InputRoundInterval(input))
+}
+
+type blockingInput struct {
+	release chan struct{}
+}
+
+func (i *blockingInput) SampleConfig() string { return "" }
+func (i *blockingInput) Description() string  { return "" }
+
+func (i *blockingInput) Gather(acc telegraf.Accumulator) error {
+	<-i.release
+	return nil
+}
+
+func TestAgent_GatherOnceTimeout(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	require.NoError(t, err)
+
+	plugin := &blockingInput{release: make(chan struct{})}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "blocking"})
+	acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+
+	pending, err := a.gatherOnce(acc, input, time.Hour, 10*time.Millisecond)
+	require.Error(t, err)
+	require.NotNil(t, pending)
+	require.Equal(t, int64(1), input.GatherTimeouts.Get())
+
+	close(plugin.release)
+	<-pending
+}
+
+func TestAgent_GatherOnceNoTimeout(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	require.NoError(t, err)
+
+	plugin := &blockingInput{release: make(chan struct{})}
+	close(plugin.release)
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "blocking"})
+	acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+
+	pending, err := a.gatherOnce(acc, input, time.Hour, 0)
+	require.NoError(t, err)
+	require.Nil(t, pending)
+}
+
+func TestAgent_TakeOverRestoresBufferedMetrics(t *testing.T) {
+	newAgent := func(names ...string) *Agent {
+		c := config.NewConfig()
+		for _, name := range names {
+			c.Outputs = append(c.Outputs, models.NewRunningOutput(name,
+				&nopOutput{}, &models.OutputConfig{Name: name}, 0, 0))
+		}
+		a, err := NewAgent(c)
+		require.NoError(t, err)
+		return a
+	}
+
+	m, err := metric.New("cpu", map[string]string{},
+		map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	prev := newAgent("file", "removed")
+	prev.Config.Outputs[0].AddMetric(m)
+	prev.Config.Outputs[0].AddMetric(m.Copy())
+	prev.Config.Outputs[1].AddMetric(m.Copy())
+
+	a := newAgent("file", "added")
+	a.TakeOver(prev)
+
+	require.Equal(t, 2, a.Config.Outputs[0].BufferLength())
+	require.Equal(t, 0, a.Config.Outputs[1].BufferLength())
+	require.Equal(t, 0, prev.Config.Outputs[0].BufferLength())
+	require.Equal(t, 0, prev.Config.Outputs[1].BufferLength())
+}
+
+type serviceInput struct {
+	mu                   sync.Mutex
+	inits, starts, stops int
+}
+
+func (i *serviceInput) SampleConfig() string                  { return "" }
+func (i *serviceInput) Description() string                   { return "" }
+func (i *serviceInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func (i *serviceInput) Init() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.inits++
+	return nil
+}
+
+func (i *serviceInput) Start(acc telegraf.Accumulator) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.starts++
+	return nil
+}
+
+func (i *serviceInput) Stop() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.stops++
+}
+
+func (i *serviceInput) counts() (int, int, int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.inits, i.starts, i.stops
+}
+
+func newSourcedInput(input telegraf.Input, config *models.InputConfig, source string) *models.RunningInput {
+	ri := models.NewRunningInput(input, config)
+	ri.SetSource(source)
+	return ri
+}
+
+// waitFor waits up to a second for the condition to hold.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAgent_PrepareKeepsUnchangedServiceInputs(t *testing.T) {
+	newAgent := func(inputs ...*models.RunningInput) *Agent {
+		c := config.NewConfig()
+		c.Agent.Interval = internal.Duration{Duration: 10 * time.Second}
+		c.Agent.FlushInterval = internal.Duration{Duration: 10 * time.Second}
+		c.Inputs = inputs
+		c.Outputs = append(c.Outputs, models.NewRunningOutput("nop",
+			&nopOutput{}, &models.OutputConfig{Name: "nop"}, 0, 0))
+		a, err := NewAgent(c)
+		require.NoError(t, err)
+		return a
+	}
+
+	kept, changed := &serviceInput{}, &serviceInput{}
+	prev := newAgent(
+		newSourcedInput(kept, &models.InputConfig{Name: "stream"}, "port = 1\n"),
+		newSourcedInput(changed, &models.InputConfig{Name: "stream", Alias: "b"}, "port = 2\n"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- prev.Run(ctx) }()
+	waitFor(t, func() bool {
+		_, starts, _ := changed.counts()
+		return starts == 1
+	})
+
+	// A plugin failing to initialize leaves the running agent intact.
+	failed := newAgent(
+		newSourcedInput(&serviceInput{}, &models.InputConfig{Name: "stream"}, "port = 1\n"),
+		models.NewRunningInput(&initInput{errors.New("no servers")}, &models.InputConfig{Name: "a"}),
+	)
+	require.Error(t, failed.Prepare(prev))
+
+	replaced, added := &serviceInput{}, &serviceInput{}
+	a := newAgent(
+		newSourcedInput(replaced, &models.InputConfig{Name: "stream"}, "port = 1\n"),
+		newSourcedInput(added, &models.InputConfig{Name: "stream", Alias: "b"}, "port = 3\n"),
+	)
+	require.NoError(t, a.Prepare(prev))
+
+	cancel()
+	require.NoError(t, <-done)
+	a.TakeOver(prev)
+
+	inits, starts, stops := kept.counts()
+	require.Equal(t, []int{1, 1, 0}, []int{inits, starts, stops})
+	inits, starts, stops = changed.counts()
+	require.Equal(t, []int{1, 1, 1}, []int{inits, starts, stops})
+	inits, _, _ = added.counts()
+	require.Equal(t, 1, inits)
+	inits, _, _ = replaced.counts()
+	require.Equal(t, 0, inits)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() { done <- a.Run(ctx) }()
+	waitFor(t, func() bool {
+		_, starts, _ := added.counts()
+		return starts == 1
+	})
+	cancel()
+	require.NoError(t, <-done)
+
+	inits, starts, stops = kept.counts()
+	require.Equal(t, []int{1, 1, 1}, []int{inits, starts, stops})
+	inits, starts, stops = replaced.counts()
+	require.Equal(t, []int{0, 0, 0}, []int{inits, starts, stops})
+}
+
+func TestAgent_RunFailureStopsAdoptedServiceInputs(t *testing.T) {
+	newAgent := func(healthAddress string, inputs ...*models.RunningInput) *Agent {
+		c := config.NewConfig()
+		c.Agent.Interval = internal.Duration{Duration: 10 * time.Second}
+		c.Agent.FlushInterval = internal.Duration{Duration: 10 * time.Second}
+		c.Agent.HealthAddress = healthAddress
+		c.Inputs = inputs
+		c.Outputs = append(c.Outputs, models.NewRunningOutput("nop",
+			&nopOutput{}, &models.OutputConfig{Name: "nop"}, 0, 0))
+		a, err := NewAgent(c)
+		require.NoError(t, err)
+		return a
+	}
+
+	kept := &serviceInput{}
+	prev := newAgent("",
+		newSourcedInput(kept, &models.InputConfig{Name: "stream"}, "port = 1\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- prev.Run(ctx) }()
+	waitFor(t, func() bool {
+		_, starts, _ := kept.counts()
+		return starts == 1
+	})
+
+	// The health server of the new agent cannot listen.
+	a := newAgent("localhost:-1",
+		newSourcedInput(&serviceInput{}, &models.InputConfig{Name: "stream"}, "port = 1\n"))
+	require.NoError(t, a.Prepare(prev))
+
+	cancel()
+	require.NoError(t, <-done)
+	a.TakeOver(prev)
+
+	require.Error(t, a.Run(context.Background()))
+	_, _, stops := kept.counts()
+	require.Equal(t, 1, stops)
+}
+
+type statefulInput struct {
+	State telegraf.StateStore
+}
+
+func (i *statefulInput) SampleConfig() string                  { return "" }
+func (i *statefulInput) Description() string                   { return "" }
+func (i *statefulInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func TestAgent_InitStatePersists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "telegraf.state")
+
+	newAgent := func() (*Agent, []*statefulInput) {
+		c := config.NewConfig()
+		plugins := []*statefulInput{{}, {}}
+		for _, plugin := range plugins {
+			c.Inputs = append(c.Inputs, models.NewRunningInput(plugin,
+				&models.InputConfig{Name: "stateful"}))
+		}
+		a, err := NewAgent(c)
+		require.NoError(t, err)
+		return a, plugins
+	}
+
+	a, plugins := newAgent()
+	store, err := a.initState(path)
+	require.NoError(t, err)
+	require.NoError(t, plugins[0].State.Set("offset", 1))
+	require.NoError(t, plugins[1].State.Set("offset", 2))
+	require.NoError(t, store.Save())
+
+	a, plugins = newAgent()
+	_, err = a.initState(path)
+	require.NoError(t, err)
+
+	// Instances of the same input keep their own state.
+	for i, plugin := range plugins {
+		var offset int
+		ok, err := plugin.State.Get("offset", &offset)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, i+1, offset)
+	}
+}
+
+type initInput struct {
+	err error
+}
+
+func (i *initInput) SampleConfig() string                  { return "" }
+func (i *initInput) Description() string                   { return "" }
+func (i *initInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *initInput) Init() error                           { return i.err }
+
+func TestAgent_ValidateReportsAllErrors(t *testing.T) {
+	c := config.NewConfig()
+	c.Inputs = append(c.Inputs,
+		models.NewRunningInput(&initInput{errors.New("no servers")}, &models.InputConfig{Name: "a"}),
+		models.NewRunningInput(&initInput{}, &models.InputConfig{Name: "b"}),
+		models.NewRunningInput(&initInput{errors.New("invalid regex")}, &models.InputConfig{Name: "c"}),
+	)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	err = a.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "input inputs.a: no servers")
+	require.Contains(t, err.Error(), "input inputs.c: invalid regex")
+	require.NotContains(t, err.Error(), "inputs.b")
+
+	c.Inputs = c.Inputs[1:2]
+	require.NoError(t, a.Validate())
+}
+
+type streamInput struct {
+	mu       sync.Mutex
+	started  time.Time
+	gathered []time.Duration
+}
+
+func (i *streamInput) SampleConfig() string { return "" }
+func (i *streamInput) Description() string  { return "" }
+func (i *streamInput) Start(acc telegraf.Accumulator) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.started = time.Now()
+	return nil
+}
+func (i *streamInput) Stop() {}
+func (i *streamInput) Gather(acc telegraf.Accumulator) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.gathered = append(i.gathered, time.Since(i.started))
+	return nil
+}
+
+func TestAgent_TestGathersServiceInputsAfterWait(t *testing.T) {
+	// Test fails when any input had an error, including in other tests.
+	NErrors.Set(0)
+
+	c := config.NewConfig()
+	plugin := &streamInput{}
+	c.Inputs = append(c.Inputs, models.NewRunningInput(plugin, &models.InputConfig{Name: "stream"}))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Test(context.Background(), 200*time.Millisecond))
+	require.Len(t, plugin.gathered, 1)
+	require.True(t, plugin.gathered[0] >= 200*time.Millisecond)
+
+	plugin.gathered = nil
+	require.NoError(t, a.Test(context.Background(), 0))
+	require.Len(t, plugin.gathered, 1)
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// DiffMetrics compares the series and fields of two sets of metrics, usually
+// gathered with different configurations, and returns the differences sorted
+// by series.  A series or a field only in the old metrics is prefixed with
+// "-", one only in the new metrics with "+", and a field whose type changed
+// with "~".  Field values are not compared, since they change between gathers.
+func DiffMetrics(old, new []telegraf.Metric) []string {
+	oldSeries := seriesFields(old)
+	newSeries := seriesFields(new)
+
+	keys := make([]string, 0, len(oldSeries)+len(newSeries))
+	for key := range oldSeries {
+		keys = append(keys, key)
+	}
+	for key := range newSeries {
+		if _, ok := oldSeries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var diff []string
+	for _, key := range keys {
+		oldFields, inOld := oldSeries[key]
+		newFields, inNew := newSeries[key]
+		switch {
+		case !inNew:
+			diff = append(diff, "- "+key)
+		case !inOld:
+			diff = append(diff, "+ "+key)
+		default:
+			diff = append(diff, diffFields(key, oldFields, newFields)...)
+		}
+	}
+	return diff
+}
+
+func diffFields(series string, old, new map[string]string) []string {
+	names := make([]string, 0, len(old)+len(new))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diff []string
+	for _, name := range names {
+		oldType, inOld := old[name]
+		newType, inNew := new[name]
+		switch {
+		case !inNew:
+			diff = append(diff, fmt.Sprintf("- %s %s=%s", series, name, oldType))
+		case !inOld:
+			diff = append(diff, fmt.Sprintf("+ %s %s=%s", series, name, newType))
+		case oldType != newType:
+			diff = append(diff, fmt.Sprintf("~ %s %s=%s -> %s", series, name, oldType, newType))
+		}
+	}
+	return diff
+}
+
+// seriesFields returns the type of the fields of each series of the metrics.
+func seriesFields(metrics []telegraf.Metric) map[string]map[string]string {
+	series := make(map[string]map[string]string)
+	for _, m := range metrics {
+		var key strings.Builder
+		key.WriteString(m.Name())
+		for _, tag := range m.TagList() {
+			key.WriteString("," + tag.Key + "=" + tag.Value)
+		}
+
+		fields, ok := series[key.String()]
+		if !ok {
+			fields = make(map[string]string)
+			series[key.String()] = fields
+		}
+		for _, field := range m.FieldList() {
+			fields[field.Key] = fieldType(field.Value)
+		}
+	}
+	return series
+}
+
+func fieldType(value interface{}) string {
+	switch value.(type) {
+	case int64:
+		return "integer"
+	case uint64:
+		return "unsigned"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
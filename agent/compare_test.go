@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffMetrics(t *testing.T) {
+	old := []telegraf.Metric{
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "tank"},
+			map[string]interface{}{"nread": int64(1), "health": "ONLINE", "size": int64(3)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "rpool"},
+			map[string]interface{}{"nread": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("zfs",
+			map[string]string{"pools": "rpool::tank"},
+			map[string]interface{}{"arcstats_hits": int64(1)},
+			time.Unix(0, 0),
+		),
+	}
+	new := []telegraf.Metric{
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "tank"},
+			map[string]interface{}{"read_bytes": int64(2), "health": "ONLINE", "size": uint64(3)},
+			time.Unix(10, 0),
+		),
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "rpool"},
+			map[string]interface{}{"read_bytes": int64(1)},
+			time.Unix(10, 0),
+		),
+		// Values are not compared.
+		testutil.MustMetric("zfs",
+			map[string]string{"pools": "rpool::tank"},
+			map[string]interface{}{"arcstats_hits": int64(42)},
+			time.Unix(10, 0),
+		),
+		testutil.MustMetric("zfs_dataset",
+			map[string]string{"dataset": "tank/home"},
+			map[string]interface{}{"nread": int64(1)},
+			time.Unix(10, 0),
+		),
+	}
+
+	expected := []string{
+		"+ zfs_dataset,dataset=tank/home",
+		"- zfs_pool,pool=rpool nread=integer",
+		"+ zfs_pool,pool=rpool read_bytes=integer",
+		"- zfs_pool,pool=tank nread=integer",
+		"+ zfs_pool,pool=tank read_bytes=integer",
+		"~ zfs_pool,pool=tank size=integer -> unsigned",
+	}
+	require.Equal(t, expected, DiffMetrics(old, new))
+	require.Empty(t, DiffMetrics(old, old))
+}
@@ -0,0 +1,516 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// streamBufferSize is the number of metrics queued for a metrics stream
+// before further metrics are dropped for the slow client.
+const streamBufferSize = 1000
+
+type pluginStatus struct {
+	Name    string `json:"name"`
+	Errors  int64  `json:"errors"`
+	Running bool   `json:"gather_running,omitempty"`
+
+	Interval        string     `json:"interval,omitempty"`
+	MetricsGathered int64      `json:"metrics_gathered,omitempty"`
+	GatherTimeouts  int64      `json:"gather_timeouts,omitempty"`
+	WriteErrors     int64      `json:"write_errors,omitempty"`
+	BufferLength    int        `json:"buffer_length,omitempty"`
+	LastFlush       *time.Time `json:"last_flush,omitempty"`
+}
+
+type pluginsReport struct {
+	Inputs      []pluginStatus `json:"inputs"`
+	Processors  []pluginStatus `json:"processors"`
+	Aggregators []pluginStatus `json:"aggregators"`
+	Outputs     []pluginStatus `json:"outputs"`
+}
+
+type gatherResult struct {
+	Name    string `json:"name"`
+	Metrics int64  `json:"metrics"`
+	Error   string `json:"error,omitempty"`
+}
+
+// controlServer exposes operations on the running agent over HTTP, on a
+// unix socket or a TCP address:
+//
+//   - GET /plugins lists the plugins and their state.
+//   - GET /errors returns the last errors logged by each plugin.
+//   - POST /gather?input=<name> runs the Gather of an input immediately.
+//   - POST /reload reloads the configuration.
+//   - POST /snapshot writes a snapshot of the last gathered and buffered
+//     metrics to the snapshot directory.
+//   - GET /metrics streams the metrics sent to the outputs in line protocol,
+//     optionally only those matching the ?name=<glob> measurement filter.
+//
+// The server does not authenticate clients, access is controlled by the
+// permissions of the socket or the reach of the address.
+type controlServer struct {
+	agent  *Agent
+	dst    chan<- telegraf.Metric
+	server *http.Server
+
+	// metrics receives the metrics of the gathers triggered through the
+	// server, they are forwarded to dst until the server is stopped.  A
+	// gather that does not return does not hold up Stop.
+	metrics chan telegraf.Metric
+
+	// mu is held while a metric is forwarded to dst, so that the input
+	// channel is not closed while a metric is sent.
+	mu      sync.RWMutex
+	stopped bool
+	done    chan struct{}
+}
+
+func newControlServer(a *Agent, dst chan<- telegraf.Metric) *controlServer {
+	c := &controlServer{
+		agent:   a,
+		dst:     dst,
+		metrics: make(chan telegraf.Metric),
+		done:    make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins", c.servePlugins)
+	mux.HandleFunc("/errors", c.serveErrors)
+	mux.HandleFunc("/gather", c.serveGather)
+	mux.HandleFunc("/reload", c.serveReload)
+	mux.HandleFunc("/snapshot", c.serveSnapshot)
+	mux.HandleFunc("/metrics", c.serveMetrics)
+	c.server = &http.Server{
+		Addr:    a.Config.Agent.ControlAddress,
+		Handler: mux,
+	}
+
+	go c.forward()
+	return c
+}
+
+// Start starts listening, the server runs until Stop is called.
+func (c *controlServer) Start() error {
+	network, address := "tcp", c.server.Addr
+	if strings.HasPrefix(address, "unix://") {
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+		// Remove the socket left over by an agent that did not stop cleanly.
+		if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(address)
+		}
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("error starting control server: %v", err)
+	}
+	if network == "unix" {
+		if err := os.Chmod(address, 0660); err != nil {
+			listener.Close()
+			return fmt.Errorf("error starting control server: %v", err)
+		}
+	}
+
+	log.Printf("I! [agent] Control server listening on %s", listener.Addr())
+	go func() {
+		err := c.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving control endpoint: %v", err)
+		}
+	}()
+	return nil
+}
+
+// forward sends the metrics of the triggered gathers to the input channel
+// until the server is stopped.
+func (c *controlServer) forward() {
+	for {
+		select {
+		case m := <-c.metrics:
+			c.mu.RLock()
+			if c.stopped {
+				m.Drop()
+			} else {
+				c.dst <- m
+			}
+			c.mu.RUnlock()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Stop ends the metric streams and the forwarding of the triggered gathers,
+// and shuts the server down, waiting at most 5 seconds for the pending
+// requests.  A gather still running afterwards is abandoned, its metrics
+// are dropped.  It must be called before the input channel is closed.
+func (c *controlServer) Stop() {
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+		return
+	}
+	c.stopped = true
+	close(c.done)
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.server.Shutdown(ctx)
+}
+
+func (c *controlServer) servePlugins(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+
+	agentConfig := c.agent.Config.Agent
+	report := &pluginsReport{
+		Inputs:      []pluginStatus{},
+		Processors:  []pluginStatus{},
+		Aggregators: []pluginStatus{},
+		Outputs:     []pluginStatus{},
+	}
+
+	for _, input := range c.agent.Config.Inputs {
+		interval := agentConfig.Interval.Duration
+		if input.Config.Interval != 0 {
+			interval = input.Config.Interval
+		}
+		report.Inputs = append(report.Inputs, pluginStatus{
+			Name:            input.LogName(),
+			Errors:          logErrors(input.Log()),
+			Running:         !input.GatherStarted().IsZero(),
+			Interval:        interval.String(),
+			MetricsGathered: input.MetricsGathered.Get(),
+			GatherTimeouts:  input.GatherTimeouts.Get(),
+		})
+	}
+
+	for _, processor := range c.agent.Config.Processors {
+		report.Processors = append(report.Processors, pluginStatus{
+			Name:   processor.LogName(),
+			Errors: logErrors(processor.Log()),
+		})
+	}
+
+	for _, aggregator := range c.agent.Config.Aggregators {
+		report.Aggregators = append(report.Aggregators, pluginStatus{
+			Name:     aggregator.LogName(),
+			Errors:   logErrors(aggregator.Log()),
+			Interval: aggregator.Period().String(),
+		})
+	}
+
+	for _, output := range c.agent.Config.Outputs {
+		interval := agentConfig.FlushInterval.Duration
+		if output.Config.FlushInterval != 0 {
+			interval = output.Config.FlushInterval
+		}
+		status := pluginStatus{
+			Name:         output.LogName(),
+			Errors:       logErrors(output.Log()),
+			Interval:     interval.String(),
+			WriteErrors:  output.WriteErrors.Get(),
+			BufferLength: output.BufferLength(),
+		}
+		if lastFlush := output.LastFlush(); !lastFlush.IsZero() {
+			status.LastFlush = &lastFlush
+		}
+		report.Outputs = append(report.Outputs, status)
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (c *controlServer) serveErrors(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+
+	errors := make(map[string][]models.LogEntry)
+	add := func(name string, logger telegraf.Logger) {
+		if l, ok := logger.(*models.Logger); ok {
+			if recent := l.RecentErrors(); len(recent) > 0 {
+				errors[name] = append(errors[name], recent...)
+			}
+		}
+	}
+	for _, input := range c.agent.Config.Inputs {
+		add(input.LogName(), input.Log())
+	}
+	for _, processor := range c.agent.Config.Processors {
+		add(processor.LogName(), processor.Log())
+	}
+	for _, aggregator := range c.agent.Config.Aggregators {
+		add(aggregator.LogName(), aggregator.Log())
+	}
+	for _, output := range c.agent.Config.Outputs {
+		add(output.LogName(), output.Log())
+	}
+
+	writeJSON(w, http.StatusOK, errors)
+}
+
+// serveGather runs the Gather of the inputs with the given name, which is
+// either the name of the plugin or its log name such as "inputs.zfs::pool".
+// All the instances of the plugin matching the name are gathered.
+func (c *controlServer) serveGather(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+
+	name := r.URL.Query().Get("input")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing input parameter")
+		return
+	}
+	if !strings.HasPrefix(name, "inputs.") {
+		name = "inputs." + name
+	}
+
+	var inputs []*models.RunningInput
+	for _, input := range c.agent.Config.Inputs {
+		if input.LogName() == name {
+			inputs = append(inputs, input)
+		}
+	}
+	if len(inputs) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("input %q not found", name))
+		return
+	}
+
+	c.mu.RLock()
+	stopped := c.stopped
+	c.mu.RUnlock()
+	if stopped {
+		writeError(w, http.StatusServiceUnavailable, "agent is stopping")
+		return
+	}
+
+	results := []gatherResult{}
+	busy := 0
+	for _, input := range inputs {
+		result, err := c.gather(input)
+		if err == models.ErrGatherRunning {
+			busy++
+		}
+		results = append(results, result)
+	}
+	if busy == len(inputs) {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s is already gathering", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// gather gathers the input unless it is already gathering, in which case
+// models.ErrGatherRunning is returned.
+func (c *controlServer) gather(input *models.RunningInput) (gatherResult, error) {
+	interval := c.agent.Config.Agent.Interval.Duration
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+	timeout := c.agent.Config.Agent.GatherTimeout.Duration
+	if input.Config.GatherTimeout != 0 {
+		timeout = input.Config.GatherTimeout
+	}
+
+	// The metrics are dropped once the server is stopped, so that a gather
+	// still running then returns and releases the input.
+	acc := newAccumulator(input, c.metrics)
+	acc.done = c.done
+	acc.SetPrecision(c.agent.InputPrecision(input))
+
+	log.Printf("I! [agent] [%s] Gather triggered through the control server", input.LogName())
+	before := input.MetricsGathered.Get()
+	_, err := c.agent.tryGatherOnce(acc, input, interval, timeout)
+
+	result := gatherResult{
+		Name:    input.LogName(),
+		Metrics: input.MetricsGathered.Get() - before,
+	}
+	if err != nil {
+		if err != models.ErrGatherRunning {
+			acc.AddError(err)
+		}
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+func (c *controlServer) serveReload(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+
+	select {
+	case c.agent.reloadC <- struct{}{}:
+		log.Printf("I! [agent] Reload requested through the control server")
+	default:
+		// A reload is already pending.
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (c *controlServer) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "POST") {
+		return
+	}
+
+	if c.agent.Config.Agent.SnapshotDir == "" {
+		writeError(w, http.StatusNotFound, "snapshot_dir is not configured")
+		return
+	}
+
+	path, err := c.agent.writeSnapshot(time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("I! [agent] Snapshot written to %s", path)
+	writeJSON(w, http.StatusOK, map[string]string{"file": path})
+}
+
+// serveMetrics streams the metrics sent to the outputs until the client
+// disconnects.  Metrics are dropped when the client does not keep up.
+func (c *controlServer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, "GET") {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	var names []string
+	if name := r.URL.Query().Get("name"); name != "" {
+		names = strings.Split(name, ",")
+	}
+	nameFilter, err := filter.Compile(names)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid name filter: %v", err))
+		return
+	}
+
+	metrics := c.agent.tap.subscribe(streamBufferSize)
+	defer c.agent.tap.unsubscribe(metrics)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	s := influx.NewSerializer()
+	s.SetFieldSortOrder(influx.SortFields)
+	for {
+		select {
+		case m := <-metrics:
+			if nameFilter != nil && !nameFilter.Match(m.Name()) {
+				m.Drop()
+				continue
+			}
+			octets, err := s.Serialize(m)
+			m.Drop()
+			if err != nil {
+				continue
+			}
+			if _, err := w.Write(octets); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// metricTap hands copies of the metrics sent to the outputs to its
+// subscribers.
+type metricTap struct {
+	mu   sync.Mutex
+	subs map[chan telegraf.Metric]struct{}
+}
+
+func newMetricTap() *metricTap {
+	return &metricTap{
+		subs: make(map[chan telegraf.Metric]struct{}),
+	}
+}
+
+func (t *metricTap) subscribe(size int) chan telegraf.Metric {
+	ch := make(chan telegraf.Metric, size)
+	t.mu.Lock()
+	t.subs[ch] = struct{}{}
+	t.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes the subscriber and drops its queued metrics.
+func (t *metricTap) unsubscribe(ch chan telegraf.Metric) {
+	t.mu.Lock()
+	delete(t.subs, ch)
+	t.mu.Unlock()
+
+	for {
+		select {
+		case m := <-ch:
+			m.Drop()
+		default:
+			return
+		}
+	}
+}
+
+// publish sends a copy of the metric to the subscribers with room in their
+// buffer.  It is safe to call on a nil tap.
+func (t *metricTap) publish(m telegraf.Metric) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.subs {
+		// Only the tap sends on the channels, so there is room for the copy.
+		if len(ch) < cap(ch) {
+			ch <- m.Copy()
+		}
+	}
+}
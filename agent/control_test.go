@@ -0,0 +1,265 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type countInput struct {
+	Log telegraf.Logger `toml:"-"`
+	err error
+}
+
+func (i *countInput) SampleConfig() string { return "" }
+func (i *countInput) Description() string  { return "" }
+func (i *countInput) Gather(acc telegraf.Accumulator) error {
+	if i.err != nil {
+		i.Log.Error(i.err)
+		return i.err
+	}
+	acc.AddFields("count", map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+func newControlTestAgent(t *testing.T, inputs ...*countInput) (*Agent, chan telegraf.Metric, *controlServer) {
+	c := config.NewConfig()
+	c.Agent.Interval = internal.Duration{Duration: 10 * time.Second}
+	c.Agent.FlushInterval = internal.Duration{Duration: 10 * time.Second}
+	for i, input := range inputs {
+		alias := ""
+		if i > 0 {
+			alias = "other"
+		}
+		c.Inputs = append(c.Inputs, models.NewRunningInput(input,
+			&models.InputConfig{Name: "count", Alias: alias}))
+	}
+	c.Outputs = append(c.Outputs, models.NewRunningOutput("nop", &nopOutput{},
+		&models.OutputConfig{Name: "nop"}, 0, 0))
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	a.tap = newMetricTap()
+
+	metricC := make(chan telegraf.Metric, 10)
+	return a, metricC, newControlServer(a, metricC)
+}
+
+func request(h http.HandlerFunc, method, target string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(method, target, nil))
+	if v != nil {
+		json.NewDecoder(rec.Body).Decode(v)
+	}
+	return rec.Code
+}
+
+func TestControl_Plugins(t *testing.T) {
+	_, _, c := newControlTestAgent(t, &countInput{}, &countInput{})
+
+	var report pluginsReport
+	require.Equal(t, http.StatusOK, request(c.servePlugins, "GET", "/plugins", &report))
+	require.Len(t, report.Inputs, 2)
+	require.Equal(t, "inputs.count", report.Inputs[0].Name)
+	require.Equal(t, "inputs.count::other", report.Inputs[1].Name)
+	require.Equal(t, "10s", report.Inputs[0].Interval)
+	require.Len(t, report.Outputs, 1)
+	require.Equal(t, "outputs.nop", report.Outputs[0].Name)
+
+	require.Equal(t, http.StatusMethodNotAllowed, request(c.servePlugins, "POST", "/plugins", nil))
+}
+
+func TestControl_Gather(t *testing.T) {
+	_, metricC, c := newControlTestAgent(t, &countInput{}, &countInput{})
+
+	var results []gatherResult
+	require.Equal(t, http.StatusOK,
+		request(c.serveGather, "POST", "/gather?input=count::other", &results))
+	require.Equal(t, []gatherResult{{Name: "inputs.count::other", Metrics: 1}}, results)
+
+	m := <-metricC
+	require.Equal(t, "count", m.Name())
+	require.Equal(t, map[string]interface{}{"value": int64(42)}, m.Fields())
+
+	require.Equal(t, http.StatusNotFound,
+		request(c.serveGather, "POST", "/gather?input=missing", nil))
+	require.Equal(t, http.StatusBadRequest,
+		request(c.serveGather, "POST", "/gather", nil))
+	require.Equal(t, http.StatusMethodNotAllowed,
+		request(c.serveGather, "GET", "/gather?input=count", nil))
+
+	c.Stop()
+	require.Equal(t, http.StatusServiceUnavailable,
+		request(c.serveGather, "POST", "/gather?input=count", nil))
+}
+
+func TestControl_GatherRunning(t *testing.T) {
+	a, _, c := newControlTestAgent(t, &countInput{})
+	plugin := &blockingInput{release: make(chan struct{})}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "count"})
+	a.Config.Inputs[0] = input
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+		a.gatherOnce(acc, input, time.Hour, 0)
+	}()
+	waitFor(t, func() bool { return !input.GatherStarted().IsZero() })
+
+	require.Equal(t, http.StatusConflict,
+		request(c.serveGather, "POST", "/gather?input=count", nil))
+
+	close(plugin.release)
+	<-done
+	require.Equal(t, http.StatusOK,
+		request(c.serveGather, "POST", "/gather?input=count", nil))
+}
+
+func TestControl_StopGatherHung(t *testing.T) {
+	a, _, c := newControlTestAgent(t, &countInput{})
+	plugin := &blockingInput{release: make(chan struct{})}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "count"})
+	a.Config.Inputs[0] = input
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		request(c.serveGather, "POST", "/gather?input=count", nil)
+	}()
+	waitFor(t, func() bool { return !input.GatherStarted().IsZero() })
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		c.Stop()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("stop blocked by the gather")
+	}
+
+	close(plugin.release)
+	<-done
+}
+
+type lateInput struct {
+	release chan struct{}
+}
+
+func (i *lateInput) SampleConfig() string { return "" }
+func (i *lateInput) Description() string  { return "" }
+func (i *lateInput) Gather(acc telegraf.Accumulator) error {
+	<-i.release
+	acc.AddFields("count", map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+func TestControl_StopDropsLateMetrics(t *testing.T) {
+	a, _, c := newControlTestAgent(t, &countInput{})
+	plugin := &lateInput{release: make(chan struct{})}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "count"})
+	a.Config.Inputs[0] = input
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		request(c.serveGather, "POST", "/gather?input=count", nil)
+	}()
+	waitFor(t, func() bool { return !input.GatherStarted().IsZero() })
+
+	c.Stop()
+	close(plugin.release)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("gather blocked after stop")
+	}
+	require.True(t, input.GatherStarted().IsZero())
+}
+
+func TestControl_GatherError(t *testing.T) {
+	_, _, c := newControlTestAgent(t, &countInput{err: errors.New("no pools")})
+
+	var results []gatherResult
+	require.Equal(t, http.StatusOK,
+		request(c.serveGather, "POST", "/gather?input=inputs.count", &results))
+	require.Len(t, results, 1)
+	require.Equal(t, "no pools", results[0].Error)
+
+	var recent map[string][]models.LogEntry
+	require.Equal(t, http.StatusOK, request(c.serveErrors, "GET", "/errors", &recent))
+	require.Contains(t, recent, "inputs.count")
+	require.Equal(t, "no pools", recent["inputs.count"][0].Message)
+}
+
+func TestControl_Reload(t *testing.T) {
+	a, _, c := newControlTestAgent(t)
+
+	require.Equal(t, http.StatusAccepted, request(c.serveReload, "POST", "/reload", nil))
+	// A second request is merged with the pending one.
+	require.Equal(t, http.StatusAccepted, request(c.serveReload, "POST", "/reload", nil))
+
+	select {
+	case <-a.ReloadRequested():
+	default:
+		t.Fatal("reload not requested")
+	}
+	select {
+	case <-a.ReloadRequested():
+		t.Fatal("reload requested twice")
+	default:
+	}
+}
+
+func TestControl_StreamMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "control")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a, _, _ := newControlTestAgent(t)
+	a.Config.Agent.ControlAddress = "unix://" + filepath.Join(dir, "control.sock")
+	c := newControlServer(a, nil)
+	require.NoError(t, c.Start())
+	defer c.Stop()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", a.Config.Agent.ControlAddress[len("unix://"):])
+			},
+		},
+	}
+	resp, err := client.Get("http://localhost/metrics?name=cpu*")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The subscription is registered before the headers are sent.
+	now := time.Unix(0, 42)
+	a.tap.publish(testutil.MustMetric("mem", nil, map[string]interface{}{"used": 1}, now))
+	a.tap.publish(testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"idle": 99.5}, now))
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "cpu,cpu=cpu0 idle=99.5 42\n", line)
+}
@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// healthMaxAgeFactor is the number of plugin intervals used as the maximum
+// age when no health_max_age is configured.
+const healthMaxAgeFactor = 5
+
+type inputHealth struct {
+	Name           string  `json:"name"`
+	Errors         int64   `json:"errors"`
+	GatherTimeouts int64   `json:"gather_timeouts"`
+	GatherRunning  float64 `json:"gather_running_seconds"`
+}
+
+type outputHealth struct {
+	Name          string     `json:"name"`
+	Errors        int64      `json:"errors"`
+	WriteErrors   int64      `json:"write_errors"`
+	BufferLength  int        `json:"buffer_length"`
+	LastFlush     *time.Time `json:"last_flush,omitempty"`
+	LastFlushAge  float64    `json:"last_flush_age_seconds"`
+	FlushInterval string     `json:"flush_interval"`
+}
+
+type healthReport struct {
+	Status   string         `json:"status"`
+	Ready    bool           `json:"ready"`
+	Failures []string       `json:"failures,omitempty"`
+	Inputs   []inputHealth  `json:"inputs"`
+	Outputs  []outputHealth `json:"outputs"`
+}
+
+// healthServer exposes the state of the agent over HTTP:
+//
+//   - /readyz succeeds once all outputs are connected and the service inputs
+//     are started.
+//   - /healthz succeeds as long as every output has flushed, and no input has
+//     been stuck in a gather, within the maximum age.
+//
+// Both endpoints reply with a JSON report of the per-plugin state.
+type healthServer struct {
+	agent   *Agent
+	started time.Time
+	ready   int32
+	server  *http.Server
+}
+
+func newHealthServer(a *Agent) *healthServer {
+	h := &healthServer{
+		agent:   a,
+		started: time.Now(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.serveHealth)
+	mux.HandleFunc("/readyz", h.serveReady)
+	h.server = &http.Server{
+		Addr:    a.Config.Agent.HealthAddress,
+		Handler: mux,
+	}
+	return h
+}
+
+// Start starts listening, the server runs until Stop is called.
+func (h *healthServer) Start() error {
+	listener, err := net.Listen("tcp", h.server.Addr)
+	if err != nil {
+		return fmt.Errorf("error starting health server: %v", err)
+	}
+
+	log.Printf("I! [agent] Health server listening on %s", listener.Addr())
+	go func() {
+		err := h.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving health endpoint: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (h *healthServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h.server.Shutdown(ctx)
+}
+
+// SetReady marks whether the agent is ready to process metrics.
+func (h *healthServer) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&h.ready, v)
+}
+
+func (h *healthServer) serveHealth(w http.ResponseWriter, r *http.Request) {
+	report := h.report(time.Now())
+	h.write(w, report, len(report.Failures) == 0)
+}
+
+func (h *healthServer) serveReady(w http.ResponseWriter, r *http.Request) {
+	report := h.report(time.Now())
+	h.write(w, report, report.Ready)
+}
+
+func (h *healthServer) write(w http.ResponseWriter, report *healthReport, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// report builds the health report at the given time.
+func (h *healthServer) report(now time.Time) *healthReport {
+	agentConfig := h.agent.Config.Agent
+	report := &healthReport{
+		Ready:   atomic.LoadInt32(&h.ready) == 1,
+		Inputs:  []inputHealth{},
+		Outputs: []outputHealth{},
+	}
+
+	for _, input := range h.agent.Config.Inputs {
+		status := inputHealth{
+			Name:           input.LogName(),
+			Errors:         logErrors(input.Log()),
+			GatherTimeouts: input.GatherTimeouts.Get(),
+		}
+
+		if started := input.GatherStarted(); !started.IsZero() {
+			running := now.Sub(started)
+			status.GatherRunning = running.Seconds()
+
+			interval := agentConfig.Interval.Duration
+			if input.Config.Interval != 0 {
+				interval = input.Config.Interval
+			}
+			if maxAge := h.maxAge(interval); running > maxAge {
+				report.Failures = append(report.Failures,
+					fmt.Sprintf("%s: gather running for %s", input.LogName(), running.Round(time.Second)))
+			}
+		}
+		report.Inputs = append(report.Inputs, status)
+	}
+
+	for _, output := range h.agent.Config.Outputs {
+		interval := agentConfig.FlushInterval.Duration
+		if output.Config.FlushInterval != 0 {
+			interval = output.Config.FlushInterval
+		}
+
+		status := outputHealth{
+			Name:          output.LogName(),
+			Errors:        logErrors(output.Log()),
+			WriteErrors:   output.WriteErrors.Get(),
+			BufferLength:  output.BufferLength(),
+			FlushInterval: interval.String(),
+		}
+
+		// Outputs that never flushed are aged from the start of the agent.
+		lastFlush := output.LastFlush()
+		since := h.started
+		if !lastFlush.IsZero() {
+			status.LastFlush = &lastFlush
+			since = lastFlush
+		}
+		age := now.Sub(since)
+		status.LastFlushAge = age.Seconds()
+
+		if maxAge := h.maxAge(interval); report.Ready && age > maxAge {
+			report.Failures = append(report.Failures,
+				fmt.Sprintf("%s: no successful flush for %s", output.LogName(), age.Round(time.Second)))
+		}
+		report.Outputs = append(report.Outputs, status)
+	}
+
+	report.Status = "ok"
+	if len(report.Failures) > 0 {
+		report.Status = "fail"
+	}
+	return report
+}
+
+func (h *healthServer) maxAge(interval time.Duration) time.Duration {
+	if maxAge := h.agent.Config.Agent.HealthMaxAge.Duration; maxAge > 0 {
+		return maxAge
+	}
+	return healthMaxAgeFactor * interval
+}
+
+// logErrors returns the number of errors logged by a plugin.
+func logErrors(logger telegraf.Logger) int64 {
+	if l, ok := logger.(*models.Logger); ok {
+		return l.Errs.Get()
+	}
+	return 0
+}
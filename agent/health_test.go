@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+type nopOutput struct{}
+
+func (o *nopOutput) SampleConfig() string                  { return "" }
+func (o *nopOutput) Description() string                   { return "" }
+func (o *nopOutput) Connect() error                        { return nil }
+func (o *nopOutput) Close() error                          { return nil }
+func (o *nopOutput) Write(metrics []telegraf.Metric) error { return nil }
+
+func newHealthTestAgent(t *testing.T) (*Agent, *models.RunningOutput) {
+	c := config.NewConfig()
+	c.Agent.Interval = internal.Duration{Duration: 10 * time.Second}
+	c.Agent.FlushInterval = internal.Duration{Duration: 10 * time.Second}
+
+	output := models.NewRunningOutput("nop", &nopOutput{},
+		&models.OutputConfig{Name: "nop"}, 0, 0)
+	c.Outputs = append(c.Outputs, output)
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	return a, output
+}
+
+func serve(h http.HandlerFunc) int {
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/", nil))
+	return rec.Code
+}
+
+func TestHealth_Ready(t *testing.T) {
+	a, _ := newHealthTestAgent(t)
+	h := newHealthServer(a)
+
+	require.Equal(t, http.StatusServiceUnavailable, serve(h.serveReady))
+	h.SetReady(true)
+	require.Equal(t, http.StatusOK, serve(h.serveReady))
+}
+
+func TestHealth_OutputFlushAge(t *testing.T) {
+	a, output := newHealthTestAgent(t)
+	h := newHealthServer(a)
+	h.SetReady(true)
+
+	require.Equal(t, http.StatusOK, serve(h.serveHealth))
+
+	// Without a flush the age is measured from the start of the agent.
+	report := h.report(h.started.Add(time.Minute))
+	require.Equal(t, "fail", report.Status)
+	require.Len(t, report.Failures, 1)
+
+	require.NoError(t, output.Write())
+	report = h.report(time.Now().Add(time.Minute))
+	require.Equal(t, "fail", report.Status)
+	report = h.report(time.Now().Add(30 * time.Second))
+	require.Equal(t, "ok", report.Status)
+	require.NotNil(t, report.Outputs[0].LastFlush)
+
+	a.Config.Agent.HealthMaxAge = internal.Duration{Duration: 10 * time.Second}
+	report = h.report(time.Now().Add(30 * time.Second))
+	require.Equal(t, "fail", report.Status)
+}
+
+func TestHealth_InputStuck(t *testing.T) {
+	a, _ := newHealthTestAgent(t)
+	plugin := &blockingInput{release: make(chan struct{})}
+	input := models.NewRunningInput(plugin, &models.InputConfig{Name: "blocking"})
+	a.Config.Inputs = append(a.Config.Inputs, input)
+	h := newHealthServer(a)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		input.Gather(NewAccumulator(input, make(chan telegraf.Metric, 10)))
+	}()
+	for input.GatherStarted().IsZero() {
+		time.Sleep(time.Millisecond)
+	}
+
+	report := h.report(time.Now())
+	require.Equal(t, "ok", report.Status)
+	report = h.report(time.Now().Add(time.Minute))
+	require.Equal(t, "fail", report.Status)
+	require.Equal(t, "inputs.blocking", report.Inputs[0].Name)
+
+	close(plugin.release)
+	<-done
+	require.True(t, input.GatherStarted().IsZero())
+	require.Equal(t, "ok", h.report(time.Now().Add(time.Minute)).Status)
+}
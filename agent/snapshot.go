@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// snapshotTimeFormat is the time format used in the name of the snapshot
+// files.
+const snapshotTimeFormat = "20060102T150405Z"
+
+// writeSnapshot writes the metrics of the last gather of each input and the
+// metrics buffered by each output to a new file in the snapshot directory,
+// in line protocol with comment lines naming the plugins.  Returns the path
+// of the file.
+func (a *Agent) writeSnapshot(now time.Time) (string, error) {
+	dir := a.Config.Agent.SnapshotDir
+	path := filepath.Join(dir, "telegraf-snapshot-"+now.UTC().Format(snapshotTimeFormat)+".txt")
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return "", fmt.Errorf("error creating snapshot: %v", err)
+	}
+
+	w := bufio.NewWriter(f)
+	s := influx.NewSerializer()
+	s.SetFieldSortOrder(influx.SortFields)
+
+	fmt.Fprintf(w, "# Telegraf snapshot taken at %s\n", now.UTC().Format(time.RFC3339))
+	for _, input := range a.Config.Inputs {
+		gathered, metrics := input.LastBatch()
+		if gathered.IsZero() {
+			fmt.Fprintf(w, "\n# %s: no completed gather\n", input.LogName())
+			continue
+		}
+		fmt.Fprintf(w, "\n# %s: %d metrics gathered at %s\n",
+			input.LogName(), len(metrics), gathered.UTC().Format(time.RFC3339))
+		writeMetrics(w, s, metrics)
+	}
+	for _, output := range a.Config.Outputs {
+		metrics := output.BufferedMetrics()
+		fmt.Fprintf(w, "\n# %s: %d metrics buffered\n", output.LogName(), len(metrics))
+		writeMetrics(w, s, metrics)
+	}
+
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("error writing snapshot: %v", err)
+	}
+	return path, nil
+}
+
+// writeMetrics writes the metrics in line protocol, metrics that cannot be
+// serialized are written as comments.
+func writeMetrics(w *bufio.Writer, s *influx.Serializer, metrics []telegraf.Metric) {
+	for _, m := range metrics {
+		octets, err := s.Serialize(m)
+		if err != nil {
+			fmt.Fprintf(w, "# %s: %v\n", m.Name(), err)
+			continue
+		}
+		w.Write(octets)
+	}
+}
+
+// snapshotOnSignal writes a snapshot each time one of the snapshot signals
+// is received, until the context is done.
+func (a *Agent) snapshotOnSignal(ctx context.Context) {
+	if len(snapshotSignals) == 0 {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, snapshotSignals...)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-signals:
+			path, err := a.writeSnapshot(time.Now())
+			if err != nil {
+				log.Printf("E! [agent] %v", err)
+				continue
+			}
+			log.Printf("I! [agent] Snapshot written to %s", path)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// +build !windows
+
+package agent
+
+import (
+	"os"
+	"syscall"
+)
+
+// snapshotSignals are the signals triggering a snapshot.
+var snapshotSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent_WriteSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a, _, _ := newControlTestAgent(t, &countInput{}, &countInput{})
+	a.Config.Agent.SnapshotDir = dir
+
+	input := a.Config.Inputs[0]
+	input.RecordBatches()
+	acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+	require.NoError(t, input.Gather(acc))
+
+	a.Config.Outputs[0].AddMetric(testutil.MustMetric("mem",
+		map[string]string{"host": "nas"},
+		map[string]interface{}{"used": 1},
+		time.Unix(0, 42)))
+
+	now := time.Date(2020, 1, 12, 10, 0, 0, 0, time.UTC)
+	path, err := a.writeSnapshot(now)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "telegraf-snapshot-20200112T100000Z.txt"), path)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Regexp(t, `^# Telegraf snapshot taken at 2020-01-12T10:00:00Z
+
+# inputs.count: 1 metrics gathered at \S+
+count value=42i \d+
+
+# inputs.count::other: no completed gather
+
+# outputs.nop: 1 metrics buffered
+mem,host=nas used=1i 42
+$`, string(content))
+
+	// Snapshots are never overwritten.
+	_, err = a.writeSnapshot(now)
+	require.Error(t, err)
+}
+
+func TestControl_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a, _, c := newControlTestAgent(t)
+	require.Equal(t, http.StatusNotFound, request(c.serveSnapshot, "POST", "/snapshot", nil))
+
+	a.Config.Agent.SnapshotDir = dir
+	var result map[string]string
+	require.Equal(t, http.StatusOK, request(c.serveSnapshot, "POST", "/snapshot", &result))
+	_, err = os.Stat(result["file"])
+	require.NoError(t, err)
+}
@@ -0,0 +1,9 @@
+// +build windows
+
+package agent
+
+import "os"
+
+// snapshotSignals are the signals triggering a snapshot, snapshots are only
+// available through the control server on Windows.
+var snapshotSignals []os.Signal
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/internal/config"
+)
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// compareConfigs gathers the inputs of the old and new configuration once, as
+// in test mode, and prints the series and fields emitted by only one of them
+// or with a different type.  Returns true if there are differences.
+func compareConfigs(
+	ctx context.Context,
+	paths []string,
+	waitDuration time.Duration,
+	inputFilters []string,
+) (bool, error) {
+	if len(paths) != 2 {
+		return false, fmt.Errorf("compare requires two --config files, the old and the new one")
+	}
+
+	var gathered [2][]telegraf.Metric
+	for i, path := range paths {
+		c := config.NewConfig()
+		c.InputFilters = inputFilters
+		if err := c.LoadConfig(path); err != nil {
+			return false, err
+		}
+		if len(c.Inputs) == 0 {
+			return false, fmt.Errorf("no inputs found in %s", path)
+		}
+
+		ag, err := agent.NewAgent(c)
+		if err != nil {
+			return false, err
+		}
+		gathered[i], err = ag.TestMetrics(ctx, waitDuration)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	diff := agent.DiffMetrics(gathered[0], gathered[1])
+	for _, line := range diff {
+		fmt.Println(line)
+	}
+	return len(diff) > 0, nil
+}
@@ -36,7 +36,7 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "enable test mode: gather metrics, print them out, and exit")
-var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test mode")
+var fTestWait = flag.Int("test-wait", 0, "in test mode, run the service inputs for this many seconds before gathering them")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
@@ -67,6 +67,8 @@ var fServiceDisplayName = flag.String("service-display-name", "Telegraf Data Col
 var fRunAsConsole = flag.Bool("console", false, "run as console application (windows only)")
 var fPlugins = flag.String("plugin-directory", "",
 	"path to directory containing external plugins")
+var fWatchConfig = flag.Bool("watch-config", false,
+	"reload the configuration when the config files change")
 
 var (
 	version string
@@ -83,79 +85,123 @@ func reloadLoop(
 	aggregatorFilters []string,
 	processorFilters []string,
 ) {
-	reload := make(chan bool, 1)
-	reload <- true
-	for <-reload {
-		reload <- false
+	ag, err := loadAgent(inputFilters, outputFilters)
+	if err != nil {
+		log.Fatalf("E! [telegraf] Error running agent: %v", err)
+	}
 
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
+		syscall.SIGTERM, syscall.SIGINT)
+
+	for {
 		ctx, cancel := context.WithCancel(context.Background())
 
-		signals := make(chan os.Signal)
-		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
-			syscall.SIGTERM, syscall.SIGINT)
+		var changed <-chan struct{}
+		if *fWatchConfig {
+			changed = watchConfig(ctx, configPaths(), watchInterval)
+		}
+
+		// The new configuration is loaded and its plugins initialized while
+		// the running agent is still active, so that an invalid
+		// configuration does not stop Telegraf.  The service inputs whose
+		// configuration did not change keep running, the other plugins of
+		// the new configuration are started once the running agent stopped.
+		next := make(chan *agent.Agent, 1)
+		requested := ag.ReloadRequested()
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+			for {
+				select {
+				case sig := <-signals:
+					if sig != syscall.SIGHUP {
+						cancel()
+						return
+					}
+				case <-changed:
+					log.Printf("I! Config file changed")
+				case <-requested:
+				case <-stop:
+					cancel()
+					return
+				case <-ctx.Done():
+					return
 				}
+
+				log.Printf("I! Reloading Telegraf config")
+				reloaded, err := loadAgent(inputFilters, outputFilters)
+				if err == nil {
+					err = reloaded.Prepare(ag)
+				}
+				if err != nil {
+					log.Printf("E! [telegraf] Error reloading config, keeping the running config: %v", err)
+					continue
+				}
+				next <- reloaded
 				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		err := runAgent(ctx, ag)
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
+		cancel()
+
+		select {
+		case reloaded := <-next:
+			reloaded.TakeOver(ag)
+			ag = reloaded
+		default:
+			return
+		}
 	}
 }
 
-func runAgent(ctx context.Context,
+// loadAgent loads and validates the configuration, and returns an agent
+// ready to run it.
+func loadAgent(
 	inputFilters []string,
 	outputFilters []string,
-) error {
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+) (*agent.Agent, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
 	err := c.LoadConfig(*fConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
 		err = c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if *fPlugins == "" && len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
 			c.Agent.Interval.Duration)
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
 
-	ag, err := agent.NewAgent(c)
-	if err != nil {
-		return err
-	}
+	return agent.NewAgent(c)
+}
+
+func runAgent(ctx context.Context, ag *agent.Agent) error {
+	log.Printf("I! Starting Telegraf %s", version)
+
+	c := ag.Config
 
 	// Setup logging as configured.
 	logConfig := logger.LogConfig{
@@ -166,6 +212,7 @@ func runAgent(ctx context.Context,
 		RotationInterval:    ag.Config.Agent.LogfileRotationInterval,
 		RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize,
 		RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+		LogFormat:           ag.Config.Agent.LogFormat,
 	}
 
 	logger.SetupLogging(logConfig)
@@ -312,14 +359,56 @@ func main() {
 		case "version":
 			fmt.Println(formatFullVersion())
 			return
+		case "compare":
+			compareFlags := flag.NewFlagSet("compare", flag.ExitOnError)
+			compareFlags.Usage = func() { usageExit(0) }
+			var fConfigs stringList
+			compareFlags.Var(&fConfigs, "config", "configuration file to compare, set twice")
+			fWait := compareFlags.Int("test-wait", 0,
+				"run the service inputs for this many seconds before gathering them")
+			compareFlags.Parse(args[1:])
+
+			changed, err := compareConfigs(context.Background(), fConfigs,
+				time.Duration(*fWait)*time.Second, inputFilters)
+			if err != nil {
+				log.Fatal("E! " + err.Error())
+			}
+			if changed {
+				os.Exit(1)
+			}
+			return
 		case "config":
-			config.PrintSampleConfig(
-				sectionFilters,
-				inputFilters,
-				outputFilters,
-				aggregatorFilters,
-				processorFilters,
-			)
+			configFlags := flag.NewFlagSet("config", flag.ExitOnError)
+			configFlags.Usage = func() { usageExit(0) }
+			fSection := configFlags.String("section", "",
+				"print the sample configuration of these sections only, separator is ,")
+			fValidate := configFlags.Bool("validate", false,
+				"load the configuration and initialize the plugins, then exit")
+			configFlags.Parse(args[1:])
+
+			switch {
+			case *fValidate:
+				ag, err := loadAgent(inputFilters, outputFilters)
+				if err != nil {
+					log.Fatal("E! " + err.Error())
+				}
+				if err := ag.Validate(); err != nil {
+					log.Fatal("E! " + err.Error())
+				}
+				fmt.Println("Configuration is valid")
+			case *fSection != "":
+				if err := config.PrintSections(strings.Split(*fSection, ",")); err != nil {
+					log.Fatal("E! " + err.Error())
+				}
+			default:
+				config.PrintSampleConfig(
+					sectionFilters,
+					inputFilters,
+					outputFilters,
+					aggregatorFilters,
+					processorFilters,
+				)
+			}
 			return
 		}
 	}
@@ -368,6 +457,7 @@ func main() {
 	if err := internal.SetVersion(shortVersion); err != nil {
 		log.Println("Telegraf version already configured to: " + internal.Version())
 	}
+	internal.SetBuild(branch, commit)
 
 	if runtime.GOOS == "windows" && windowsRunAsService() {
 		programFiles := os.Getenv("ProgramFiles")
@@ -401,6 +491,9 @@ func main() {
 			if *fConfigDirectory != "" {
 				svcConfig.Arguments = append(svcConfig.Arguments, "--config-directory", *fConfigDirectory)
 			}
+			if *fPlugins != "" {
+				svcConfig.Arguments = append(svcConfig.Arguments, "--plugin-directory", *fPlugins)
+			}
 			//set servicename to service cmd line, to have a custom name after relaunch as a service
 			svcConfig.Arguments = append(svcConfig.Arguments, "--service-name", *fServiceName)
 
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// watchInterval is the interval at which the config files are checked for
+// changes by --watch-config.
+const watchInterval = 5 * time.Second
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// configPaths returns the local files and directories holding the
+// configuration.  Configurations loaded over HTTP are not watched.
+func configPaths() []string {
+	var paths []string
+	if *fConfig != "" {
+		if u, err := url.Parse(*fConfig); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			paths = append(paths, *fConfig)
+		}
+	}
+	if *fConfigDirectory != "" {
+		paths = append(paths, *fConfigDirectory)
+	}
+	return paths
+}
+
+// watchConfig polls the config files and sends on the returned channel each
+// time one of them is created, modified or removed, until ctx is done.
+// Directories are watched for *.conf files.
+func watchConfig(ctx context.Context, paths []string, interval time.Duration) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := configState(paths)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			state := configState(paths)
+			if reflect.DeepEqual(state, last) {
+				continue
+			}
+			last = state
+
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changed
+}
+
+func configState(paths []string) map[string]fileState {
+	state := make(map[string]fileState)
+	add := func(path string, info os.FileInfo) {
+		state[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			add(path, info)
+			continue
+		}
+
+		filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() && filepath.Ext(path) == ".conf" {
+				add(path, info)
+			}
+			return nil
+		})
+	}
+	return state
+}
@@ -1,6 +1,8 @@
 package filter
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/gobwas/glob"
@@ -19,12 +21,77 @@ type Filter interface {
 //   f.Match("network") // true
 //   f.Match("memory")  // false
 //
+// Filters prefixed with "re:" are regular expressions, which must match the
+// whole string:
+//
+//   f, _ := Compile([]string{"re:l2_(hits|misses)"})
+//   f.Match("l2_hits")      // true
+//   f.Match("l2_hits_size") // false
+//
 func Compile(filters []string) (Filter, error) {
 	// return if there is nothing to compile
 	if len(filters) == 0 {
 		return nil, nil
 	}
 
+	var patterns, regexes []string
+	for _, filter := range filters {
+		if isRegex(filter) {
+			regexes = append(regexes, strings.TrimPrefix(filter, regexPrefix))
+		} else {
+			patterns = append(patterns, filter)
+		}
+	}
+	if len(regexes) == 0 {
+		return compileGlob(filters)
+	}
+
+	re, err := regexp.Compile("^(?:" + strings.Join(regexes, ")$|^(?:") + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %v", err)
+	}
+	if len(patterns) == 0 {
+		return &regexFilter{re: re}, nil
+	}
+
+	glob, err := compileGlob(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return &anyFilter{filters: []Filter{glob, &regexFilter{re: re}}}, nil
+}
+
+// regexPrefix marks a filter as a regular expression.
+const regexPrefix = "re:"
+
+// isRegex reports whether the filter is a regular expression.
+func isRegex(s string) bool {
+	return strings.HasPrefix(s, regexPrefix)
+}
+
+type regexFilter struct {
+	re *regexp.Regexp
+}
+
+func (f *regexFilter) Match(s string) bool {
+	return f.re.MatchString(s)
+}
+
+// anyFilter matches if any of its filters match.
+type anyFilter struct {
+	filters []Filter
+}
+
+func (f *anyFilter) Match(s string) bool {
+	for _, filter := range f.filters {
+		if filter.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileGlob(filters []string) (Filter, error) {
 	// check if we can compile a non-glob filter
 	noGlob := true
 	for _, filter := range filters {
@@ -37,6 +37,26 @@ func TestCompile(t *testing.T) {
 	assert.True(t, f.Match("network"))
 }
 
+func TestCompileRegex(t *testing.T) {
+	f, err := Compile([]string{"re:l2_(hits|misses)"})
+	assert.NoError(t, err)
+	assert.True(t, f.Match("l2_hits"))
+	assert.True(t, f.Match("l2_misses"))
+	assert.False(t, f.Match("l2_hits_size"))
+	assert.False(t, f.Match("arc_l2_hits"))
+
+	f, err = Compile([]string{"re:arcstats_l2_.*", "re:arcstats_mfu_.*", "hits", "mru*"})
+	assert.NoError(t, err)
+	assert.True(t, f.Match("arcstats_l2_size"))
+	assert.True(t, f.Match("arcstats_mfu_hits"))
+	assert.True(t, f.Match("hits"))
+	assert.True(t, f.Match("mru_size"))
+	assert.False(t, f.Match("arcstats_hits"))
+
+	_, err = Compile([]string{"re:l2_(hits"})
+	assert.Error(t, err)
+}
+
 func TestIncludeExclude(t *testing.T) {
 	tags := []string{}
 	labels := []string{"best", "com_influxdata", "timeseries", "com_influxdata_telegraf", "ever"}
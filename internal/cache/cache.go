@@ -0,0 +1,97 @@
+// Package cache implements the cache sharing the results of expensive
+// collections between the inputs gathering in the same interval.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache holds the results loaded by the inputs for a fixed time.  Failed
+// loads are not cached, so the next input to ask tries again.
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*entry
+
+	// now is replaced by the tests.
+	now func() time.Time
+}
+
+type entry struct {
+	done   chan struct{}
+	value  interface{}
+	err    error
+	loaded time.Time
+}
+
+// New returns a cache keeping the results for ttl.
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached under the key, calling load when the value is
+// missing or expired.  Concurrent calls for the same key wait for a single
+// load and share its result.
+func (c *Cache) Get(key string, load func() (interface{}, error)) (interface{}, error) {
+	return c.get(key, c.ttl, load)
+}
+
+// WithTTL returns a view of the cache accepting the results loaded within
+// ttl.  The agent hands each input a view with half of its interval, so that
+// the inputs gathering at the same time share the results while each
+// interval still loads them again.
+func (c *Cache) WithTTL(ttl time.Duration) *View {
+	return &View{cache: c, ttl: ttl}
+}
+
+// View is a view of a Cache with its own time to live.
+type View struct {
+	cache *Cache
+	ttl   time.Duration
+}
+
+// Get is Cache.Get with the time to live of the view.
+func (v *View) Get(key string, load func() (interface{}, error)) (interface{}, error) {
+	return v.cache.get(key, v.ttl, load)
+}
+
+func (c *Cache) get(key string, ttl time.Duration, load func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		select {
+		case <-e.done:
+			if c.now().Before(e.loaded.Add(ttl)) {
+				c.mu.Unlock()
+				return e.value, nil
+			}
+			ok = false
+		default:
+			// Another input is loading the value.
+		}
+	}
+	if !ok {
+		e = &entry{done: make(chan struct{})}
+		c.entries[key] = e
+		c.mu.Unlock()
+
+		e.value, e.err = load()
+		c.mu.Lock()
+		e.loaded = c.now()
+		if e.err != nil && c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		close(e.done)
+		c.mu.Unlock()
+		return e.value, e.err
+	}
+	c.mu.Unlock()
+
+	<-e.done
+	return e.value, e.err
+}
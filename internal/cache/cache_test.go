@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLoadsOncePerTTL(t *testing.T) {
+	now := time.Unix(1580000000, 0)
+	c := New(5 * time.Second)
+	c.now = func() time.Time { return now }
+
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return loads, nil
+	}
+
+	v, err := c.Get("disks", load)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	now = now.Add(4 * time.Second)
+	v, err = c.Get("disks", load)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	// Keys are loaded independently.
+	v, err = c.Get("pools", load)
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+
+	now = now.Add(2 * time.Second)
+	v, err = c.Get("disks", load)
+	require.NoError(t, err)
+	require.Equal(t, 3, v)
+}
+
+func TestViewTTL(t *testing.T) {
+	now := time.Unix(1580000000, 0)
+	c := New(time.Minute)
+	c.now = func() time.Time { return now }
+	fast := c.WithTTL(5 * time.Second)
+	slow := c.WithTTL(30 * time.Second)
+
+	loads := 0
+	load := func() (interface{}, error) {
+		loads++
+		return loads, nil
+	}
+
+	v, err := slow.Get("disks", load)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	// Each view only accepts the results loaded within its own ttl.
+	now = now.Add(10 * time.Second)
+	v, err = slow.Get("disks", load)
+	require.NoError(t, err)
+	require.Equal(t, 1, v)
+
+	v, err = fast.Get("disks", load)
+	require.NoError(t, err)
+	require.Equal(t, 2, v)
+}
+
+func TestGetDoesNotCacheErrors(t *testing.T) {
+	c := New(time.Minute)
+
+	_, err := c.Get("disks", func() (interface{}, error) {
+		return nil, errors.New("smartctl not found")
+	})
+	require.Error(t, err)
+
+	v, err := c.Get("disks", func() (interface{}, error) {
+		return "sda", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "sda", v)
+}
+
+func TestGetConcurrentLoad(t *testing.T) {
+	c := New(time.Minute)
+
+	var mu sync.Mutex
+	loads := 0
+	release := make(chan struct{})
+	load := func() (interface{}, error) {
+		mu.Lock()
+		loads++
+		mu.Unlock()
+		<-release
+		return "sda", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Get("disks", load)
+			require.NoError(t, err)
+			require.Equal(t, "sda", v)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, 1, loads)
+}
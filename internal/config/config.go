@@ -21,6 +21,8 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/secretstore"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -43,8 +45,12 @@ var (
 	// Default output plugins
 	outputDefaults = []string{"influxdb"}
 
-	// envVarRe is a regex to find environment variables in the config file
-	envVarRe = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+	// envVarRe is a regex to find environment variables in the config file,
+	// with an optional default value or error message
+	envVarRe = regexp.MustCompile(`\$\{(\w+)(?:(:?[-?])([^}]*))?\}|\$(\w+)`)
+
+	// secretRe is a regex to find secret store references in the config file
+	secretRe = regexp.MustCompile(`@\{(\w+):([^}]+)\}`)
 
 	envVarEscaper = strings.NewReplacer(
 		`"`, `\"`,
@@ -66,6 +72,18 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// definitions maps the plugin definitions and aliases, and the agent
+	// and global tags settings, to the file they were loaded from.
+	definitions map[string]string
+	settings    map[string]setting
+}
+
+// setting is the value of an agent or global tags setting and the file it was
+// loaded from.
+type setting struct {
+	value string
+	path  string
 }
 
 func NewConfig() *Config {
@@ -111,6 +129,17 @@ type AgentConfig struct {
 	// same time, which can have a measurable effect on the system.
 	CollectionJitter internal.Duration
 
+	// GatherTimeout is the maximum time an input may take to gather before
+	// the call is abandoned and counted as a timeout.  Until the abandoned
+	// call returns, later collections of the input are skipped.  Disabled
+	// when zero.
+	GatherTimeout internal.Duration
+
+	// MaxSeries is the maximum number of distinct series each input may emit
+	// per interval.  Metrics of further series are dropped and counted in
+	// the series_dropped internal stat.  Disabled when zero.
+	MaxSeries int
+
 	// FlushInterval is the Interval at which to flush data
 	FlushInterval internal.Duration
 
@@ -131,6 +160,10 @@ type AgentConfig struct {
 	// not be less than 2 times MetricBatchSize.
 	MetricBufferLimit int
 
+	// FloatPrecision is the number of significant digits float fields are
+	// rounded to before being written to the outputs.  Disabled when zero.
+	FloatPrecision int
+
 	// FlushBufferWhenFull tells Telegraf to flush the metric buffer whenever
 	// it fills up, regardless of FlushInterval. Setting this option to true
 	// does _not_ deactivate FlushInterval.
@@ -168,8 +201,34 @@ type AgentConfig struct {
 	// If set to -1, no archives are removed.
 	LogfileRotationMaxArchives int `toml:"logfile_rotation_max_archives"`
 
+	// Log format controls the format of log messages and can be "text" or
+	// "json".  JSON messages hold the time, level, plugin and message.
+	LogFormat string `toml:"logformat"`
+
 	Hostname     string
 	OmitHostname bool
+
+	// HealthAddress is the address of the HTTP server exposing the /healthz
+	// and /readyz endpoints, disabled when empty.
+	HealthAddress string `toml:"health_address"`
+
+	// HealthMaxAge is how long an output may go without a successful flush,
+	// or an input may spend in a single gather, before the agent is reported
+	// as unhealthy.  When zero, five times the interval of the plugin is used.
+	HealthMaxAge internal.Duration `toml:"health_max_age"`
+
+	// ControlAddress is the address of the control server, either a TCP
+	// address or a unix socket as "unix:///path", disabled when empty.
+	ControlAddress string `toml:"control_address"`
+
+	// SnapshotDir is the directory the snapshots of the last gathered and
+	// buffered metrics are written to.  Snapshots are disabled when empty.
+	SnapshotDir string `toml:"snapshot_dir"`
+
+	// Statefile is the file persisting the state of the inputs, such as
+	// cursors and previous counter values, across restarts.  When empty the
+	// state is kept in memory only.
+	Statefile string `toml:"statefile"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -245,6 +304,10 @@ var globalTagsConfig = `
   # rack = "1a"
   ## Environment variables can be used as tags, and throughout the config file
   # user = "$USER"
+  ## Tags can be computed from host metadata when the config is loaded, using
+  ## the hostname, fqdn, file, instance_id and zone functions.
+  # fqdn = "{{ fqdn }}"
+  # chassis = '{{ file "/etc/chassis" }}'
 
 `
 var agentConfig = `
@@ -266,12 +329,29 @@ var agentConfig = `
   ## cost of higher maximum memory usage.
   metric_buffer_limit = 10000
 
+  ## Number of significant digits float fields are rounded to before being
+  ## written to the outputs, reducing the size of the payloads.  The default
+  ## of 0 disables rounding.
+  # float_precision = 0
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
   ## same time, which can have a measurable effect on the system.
   collection_jitter = "0s"
 
+  ## Maximum time an input may take to gather.  When exceeded the gather is
+  ## abandoned, reported as an error and counted in the internal plugin, and
+  ## collections are skipped until the abandoned call returns.  The default
+  ## of "0s" disables the timeout.
+  # gather_timeout = "0s"
+
+  ## Maximum number of distinct series each input may emit per interval.
+  ## Metrics of further series are dropped and counted in the internal
+  ## plugin, protecting the outputs from runaway cardinality.  The default of
+  ## 0 disables the limit.
+  # max_series = 0
+
   ## Default flushing interval for all outputs. Maximum flush_interval will be
   ## flush_interval + flush_jitter
   flush_interval = "10s"
@@ -316,11 +396,39 @@ var agentConfig = `
   ## If set to -1, no archives are removed.
   # logfile_rotation_max_archives = 5
 
+  ## Log format controls the format of log messages and can be "text" or
+  ## "json".  JSON messages hold the time, level, plugin and message.
+  # logformat = "text"
+
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the HTTP server exposing the /healthz and /readyz endpoints,
+  ## ie ":8089".  The server is disabled when empty.
+  # health_address = ""
+  ## Maximum time an output may go without a successful flush, or an input
+  ## may spend in a single gather, before /healthz reports a failure.  The
+  ## default of "0s" uses five times the interval of each plugin.
+  # health_max_age = "0s"
+
+  ## Address of the control server used to list the plugins, trigger a
+  ## gather, reload the config and stream the metrics, either a unix socket
+  ## as "unix:///path" or a TCP address.  The server has no authentication,
+  ## do not expose it beyond the local host.  Disabled when empty.
+  # control_address = "unix:///var/run/telegraf/control.sock"
+
+  ## Directory receiving the snapshots of the metrics of the last gather of
+  ## each input and of the metrics buffered by each output, written on
+  ## SIGUSR1 or through the control server.  Disabled when empty.
+  # snapshot_dir = "/var/lib/telegraf/snapshots"
+
+  ## File persisting the state of the inputs, such as cursors and previous
+  ## counter values, across restarts.  When empty the state is kept in
+  ## memory only and lost on restart.
+  # statefile = "/var/lib/telegraf/telegraf.state"
+
 `
 
 var outputHeader = `
@@ -561,6 +669,51 @@ func printFilteredGlobalSections(sectionFilters []string) {
 	}
 }
 
+// PrintSections prints the sample configuration of the given sections only,
+// ie "agent" or "inputs.zfs", in order.
+func PrintSections(sections []string) error {
+	for _, section := range sections {
+		section = strings.TrimSpace(section)
+		if section == "agent" || section == "global_tags" {
+			printFilteredGlobalSections([]string{section})
+			continue
+		}
+
+		parts := strings.SplitN(section, ".", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid section %q, expected ie 'agent' or 'inputs.cpu'", section)
+		}
+		kind, name := parts[0], parts[1]
+
+		var p printer
+		switch kind {
+		case "inputs":
+			if creator, ok := inputs.Inputs[name]; ok {
+				p = creator()
+			}
+		case "outputs":
+			if creator, ok := outputs.Outputs[name]; ok {
+				p = creator()
+			}
+		case "processors":
+			if creator, ok := processors.Processors[name]; ok {
+				p = creator()
+			}
+		case "aggregators":
+			if creator, ok := aggregators.Aggregators[name]; ok {
+				p = creator()
+			}
+		default:
+			return fmt.Errorf("invalid section %q, unknown plugin type %q", section, kind)
+		}
+		if p == nil {
+			return fmt.Errorf("invalid section %q, plugin %s not found", section, name)
+		}
+		printConfig(name, p, kind, false)
+	}
+	return nil
+}
+
 type printer interface {
 	Description() string
 	SampleConfig() string
@@ -589,6 +742,31 @@ func printConfig(name string, p printer, op string, commented bool) {
 	}
 }
 
+// tableSource returns the source of the fields of the table, sorted by key,
+// which differs when the configuration of the plugin changed.
+func tableSource(tbl *ast.Table) string {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		switch field := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(&b, "%s = %s\n", key, field.Value.Source())
+		case *ast.Table:
+			fmt.Fprintf(&b, "[%s]\n%s", key, tableSource(field))
+		case []*ast.Table:
+			for _, t := range field {
+				fmt.Fprintf(&b, "[[%s]]\n%s", key, tableSource(t))
+			}
+		}
+	}
+	return b.String()
+}
+
 func sliceContains(name string, list []string) bool {
 	for _, b := range list {
 		if b == name {
@@ -699,10 +877,14 @@ func (c *Config) LoadConfig(path string) error {
 			if !ok {
 				return fmt.Errorf("%s: invalid configuration", path)
 			}
+			c.checkSettings(path, "global_tags", subTable)
 			if err = toml.UnmarshalTable(subTable, c.Tags); err != nil {
 				log.Printf("E! Could not parse [global_tags] config\n")
 				return fmt.Errorf("Error parsing %s, %s", path, err)
 			}
+			if err = expandTags(c.Tags); err != nil {
+				return fmt.Errorf("Error parsing %s, [global_tags]: %s", path, err)
+			}
 		}
 	}
 
@@ -712,6 +894,7 @@ func (c *Config) LoadConfig(path string) error {
 		if !ok {
 			return fmt.Errorf("%s: invalid configuration", path)
 		}
+		c.checkSettings(path, "agent", subTable)
 		if err = toml.UnmarshalTable(subTable, c.Agent); err != nil {
 			log.Printf("E! Could not parse [agent] config\n")
 			return fmt.Errorf("Error parsing %s, %s", path, err)
@@ -731,6 +914,14 @@ func (c *Config) LoadConfig(path string) error {
 		c.Tags["host"] = c.Agent.Hostname
 	}
 
+	// add checks that the plugin is not already defined before adding it.
+	add := func(kind, name string, table *ast.Table, fn func(string, *ast.Table) error) error {
+		if err := c.checkDefinition(path, kind, name, table); err != nil {
+			return err
+		}
+		return fn(name, table)
+	}
+
 	// Parse all the rest of the plugins:
 	for name, val := range tbl.Fields {
 		subTable, ok := val.(*ast.Table)
@@ -745,12 +936,12 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [outputs.influxdb] support
 				case *ast.Table:
-					if err = c.addOutput(pluginName, pluginSubTable); err != nil {
+					if err = add("outputs", pluginName, pluginSubTable, c.addOutput); err != nil {
 						return fmt.Errorf("Error parsing %s, %s", path, err)
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addOutput(pluginName, t); err != nil {
+						if err = add("outputs", pluginName, t, c.addOutput); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", path, err)
 						}
 					}
@@ -764,12 +955,12 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [inputs.cpu] support
 				case *ast.Table:
-					if err = c.addInput(pluginName, pluginSubTable); err != nil {
+					if err = add("inputs", pluginName, pluginSubTable, c.addInput); err != nil {
 						return fmt.Errorf("Error parsing %s, %s", path, err)
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addInput(pluginName, t); err != nil {
+						if err = add("inputs", pluginName, t, c.addInput); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", path, err)
 						}
 					}
@@ -783,7 +974,7 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addProcessor(pluginName, t); err != nil {
+						if err = add("processors", pluginName, t, c.addProcessor); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", path, err)
 						}
 					}
@@ -797,7 +988,7 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addAggregator(pluginName, t); err != nil {
+						if err = add("aggregators", pluginName, t, c.addAggregator); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", path, err)
 						}
 					}
@@ -809,7 +1000,7 @@ func (c *Config) LoadConfig(path string) error {
 		// Assume it's an input input for legacy config file support if no other
 		// identifiers are present
 		default:
-			if err = c.addInput(name, subTable); err != nil {
+			if err = add("inputs", name, subTable, c.addInput); err != nil {
 				return fmt.Errorf("Error parsing %s, %s", path, err)
 			}
 		}
@@ -822,6 +1013,91 @@ func (c *Config) LoadConfig(path string) error {
 	return nil
 }
 
+// checkDefinition returns an error if the plugin is defined identically in
+// another file, or with the same alias in any loaded table.  Such a plugin is
+// usually defined both in the configuration file and in a file of the
+// configuration directory, and would emit every metric twice.
+func (c *Config) checkDefinition(path, kind, name string, tbl *ast.Table) error {
+	if c.definitions == nil {
+		c.definitions = make(map[string]string)
+	}
+
+	plugin := kind + "." + name
+	key := plugin + " " + canonicalTable(tbl)
+	if prev, ok := c.definitions[key]; ok && prev != path {
+		return fmt.Errorf("%s is defined identically in %s", plugin, prev)
+	}
+	c.definitions[key] = path
+
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok && str.Value != "" {
+				key := plugin + " alias=" + str.Value
+				if prev, ok := c.definitions[key]; ok {
+					return fmt.Errorf("%s with alias %q is already defined in %s", plugin, str.Value, prev)
+				}
+				c.definitions[key] = path
+			}
+		}
+	}
+	return nil
+}
+
+// checkSettings warns about the settings of the agent or global tags table
+// set to a different value in a previously loaded file.  The value of the
+// last loaded file is used.
+func (c *Config) checkSettings(path, tableName string, tbl *ast.Table) {
+	if c.settings == nil {
+		c.settings = make(map[string]setting)
+	}
+
+	for key, node := range tbl.Fields {
+		kv, ok := node.(*ast.KeyValue)
+		if !ok {
+			continue
+		}
+		name := tableName + "." + key
+		value := kv.Value.Source()
+		if prev, ok := c.settings[name]; ok && prev.path != path && prev.value != value {
+			log.Printf("W! [%s] %s is set in %s and %s, using the value of %s",
+				tableName, key, prev.path, path, path)
+		}
+		c.settings[name] = setting{value: value, path: path}
+	}
+}
+
+// canonicalTable returns the settings of the table sorted by key, ignoring the
+// comments and formatting.
+func canonicalTable(tbl *ast.Table) string {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for _, key := range keys {
+		buf.WriteString(key)
+		buf.WriteString("=")
+		switch node := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			buf.WriteString(node.Value.Source())
+		case *ast.Table:
+			buf.WriteString(canonicalTable(node))
+		case []*ast.Table:
+			buf.WriteString("[")
+			for _, t := range node {
+				buf.WriteString(canonicalTable(t))
+			}
+			buf.WriteString("]")
+		}
+		buf.WriteString(";")
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
 // trimBOM trims the Byte-Order-Marks from the beginning of the file.
 // this is for Windows compatibility only.
 // see https://github.com/influxdata/telegraf/issues/1378
@@ -879,29 +1155,92 @@ func fetchConfig(u *url.URL) ([]byte, error) {
 func parseConfig(contents []byte) (*ast.Table, error) {
 	contents = trimBOM(contents)
 
-	parameters := envVarRe.FindAllSubmatch(contents, -1)
-	for _, parameter := range parameters {
-		if len(parameter) != 3 {
-			continue
-		}
+	contents, err := expandEnv(contents)
+	if err != nil {
+		return nil, err
+	}
 
-		var env_var []byte
-		if parameter[1] != nil {
-			env_var = parameter[1]
-		} else if parameter[2] != nil {
-			env_var = parameter[2]
-		} else {
+	contents, err = resolveSecrets(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	return toml.Parse(contents)
+}
+
+// expandEnv replaces the environment variables.  As in the shell,
+// ${VAR:-default} is replaced with the default if the variable is unset or
+// empty, ${VAR-default} only if it is unset, and ${VAR:?message} and
+// ${VAR?message} fail with the message.  Unset variables without a default are
+// left untouched, and variables in comments never fail.
+func expandEnv(contents []byte) ([]byte, error) {
+	if !envVarRe.Match(contents) {
+		return contents, nil
+	}
+
+	var err error
+	lines := bytes.Split(contents, []byte("\n"))
+	for i, line := range lines {
+		comment := bytes.HasPrefix(bytes.TrimSpace(line), []byte("#"))
+		lines[i] = envVarRe.ReplaceAllFunc(line, func(ref []byte) []byte {
+			parameter := envVarRe.FindSubmatch(ref)
+			name, op, word := string(parameter[1]), string(parameter[2]), string(parameter[3])
+			if name == "" {
+				name = string(parameter[4])
+			}
+
+			value, ok := os.LookupEnv(name)
+			empty := !ok || (value == "" && strings.HasPrefix(op, ":"))
+			switch {
+			case !empty:
+				return []byte(escapeEnv(value))
+			case strings.HasSuffix(op, "-"):
+				return []byte(word)
+			case strings.HasSuffix(op, "?") && !comment && err == nil:
+				if word == "" {
+					word = "not set"
+				}
+				err = fmt.Errorf("environment variable %s: %s", name, word)
+			case ok:
+				return []byte(escapeEnv(value))
+			}
+			return ref
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// resolveSecrets replaces the @{store:key} references with the secret read
+// from the store.  References in comments are left untouched.
+func resolveSecrets(contents []byte) ([]byte, error) {
+	if !secretRe.Match(contents) {
+		return contents, nil
+	}
+
+	lines := bytes.Split(contents, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
 			continue
 		}
 
-		env_val, ok := os.LookupEnv(strings.TrimPrefix(string(env_var), "$"))
-		if ok {
-			env_val = escapeEnv(env_val)
-			contents = bytes.Replace(contents, parameter[0], []byte(env_val), 1)
+		var err error
+		lines[i] = secretRe.ReplaceAllFunc(line, func(ref []byte) []byte {
+			if err != nil {
+				return ref
+			}
+			m := secretRe.FindSubmatch(ref)
+			var secret string
+			secret, err = secretstore.Get(string(m[1]), string(m[2]))
+			return []byte(escapeEnv(secret))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
 		}
 	}
-
-	return toml.Parse(contents)
+	return bytes.Join(lines, []byte("\n")), nil
 }
 
 func (c *Config) addAggregator(name string, table *ast.Table) error {
@@ -925,25 +1264,65 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 }
 
 func (c *Config) addProcessor(name string, table *ast.Table) error {
+	rf, err := newRunningProcessor(name, table)
+	if err != nil {
+		return err
+	}
+
+	c.Processors = append(c.Processors, rf)
+	return nil
+}
+
+func newRunningProcessor(name string, table *ast.Table) (*models.RunningProcessor, error) {
 	creator, ok := processors.Processors[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested processor: %s", name)
+		return nil, fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := toml.UnmarshalTable(table, processor); err != nil {
-		return err
+		return nil, err
 	}
 
-	rf := models.NewRunningProcessor(processor, processorConfig)
+	return models.NewRunningProcessor(processor, processorConfig), nil
+}
 
-	c.Processors = append(c.Processors, rf)
-	return nil
+// buildOutputProcessors parses the processors applied to the metrics of an
+// output only, defined as [[outputs.<name>.processors.<processor>]].
+func buildOutputProcessors(name string, tbl *ast.Table) (models.RunningProcessors, error) {
+	node, ok := tbl.Fields["processors"]
+	if !ok {
+		return nil, nil
+	}
+	delete(tbl.Fields, "processors")
+
+	subTable, ok := node.(*ast.Table)
+	if !ok {
+		return nil, fmt.Errorf("invalid processors of output %s", name)
+	}
+
+	var rps models.RunningProcessors
+	for pluginName, pluginVal := range subTable.Fields {
+		pluginSubTable, ok := pluginVal.([]*ast.Table)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported config format: %s, output %s",
+				pluginName, name)
+		}
+		for _, t := range pluginSubTable {
+			rp, err := newRunningProcessor(pluginName, t)
+			if err != nil {
+				return nil, err
+			}
+			rps = append(rps, rp)
+		}
+	}
+	sort.Sort(rps)
+	return rps, nil
 }
 
 func (c *Config) addOutput(name string, table *ast.Table) error {
@@ -956,6 +1335,11 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	}
 	output := creator()
 
+	outputProcessors, err := buildOutputProcessors(name, table)
+	if err != nil {
+		return err
+	}
+
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
 	switch t := output.(type) {
@@ -976,8 +1360,13 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if outputConfig.FloatPrecision == 0 {
+		outputConfig.FloatPrecision = c.Agent.FloatPrecision
+	}
+
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	ro.Processors = outputProcessors
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -997,6 +1386,9 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	}
 	input := creator()
 
+	// The fields of the table are removed as they are parsed.
+	source := tableSource(table)
+
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
 	switch t := input.(type) {
@@ -1028,8 +1420,13 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return err
 	}
 
+	if pluginConfig.MaxSeries == 0 {
+		pluginConfig.MaxSeries = c.Agent.MaxSeries
+	}
+
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
+	rp.SetSource(source)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
@@ -1127,6 +1524,12 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	var err error
+	conf.LogLevel, err = buildLogLevel(tbl)
+	if err != nil {
+		return conf, err
+	}
+
 	conf.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1135,6 +1538,9 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 			}
 		}
 	}
+	if err := expandTags(conf.Tags); err != nil {
+		return conf, err
+	}
 
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
@@ -1144,8 +1550,8 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "tags")
-	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
 		return conf, err
@@ -1179,9 +1585,15 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	var err error
+	conf.LogLevel, err = buildLogLevel(tbl)
+	if err != nil {
+		return conf, err
+	}
+
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "order")
-	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
 		return conf, err
@@ -1189,6 +1601,25 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 	return conf, nil
 }
 
+// buildLogLevel parses the log_level of a plugin, an empty level means the
+// plugin uses the log level of the agent.
+func buildLogLevel(tbl *ast.Table) (string, error) {
+	node, ok := tbl.Fields["log_level"]
+	if !ok {
+		return "", nil
+	}
+
+	if kv, ok := node.(*ast.KeyValue); ok {
+		if str, ok := kv.Value.(*ast.String); ok {
+			if _, err := logger.ParseLevel(str.Value); err != nil {
+				return "", err
+			}
+			return str.Value, nil
+		}
+	}
+	return "", nil
+}
+
 // buildFilter builds a Filter
 // (tagpass/tagdrop/namepass/namedrop/fieldpass/fielddrop) to
 // be inserted into the models.OutputConfig/models.InputConfig
@@ -1344,6 +1775,73 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["collection_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionJitter = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["precision"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.Precision = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["round_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				roundInterval, err := strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.RoundInterval = &roundInterval
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["gather_timeout"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.GatherTimeout = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_series"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				if v < 0 {
+					return nil, fmt.Errorf("max_series must not be negative")
+				}
+				cp.MaxSeries = int(v)
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1376,6 +1874,12 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	var err error
+	cp.LogLevel, err = buildLogLevel(tbl)
+	if err != nil {
+		return cp, err
+	}
+
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1384,14 +1888,22 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 			}
 		}
 	}
+	if err := expandTags(cp.Tags); err != nil {
+		return cp, err
+	}
 
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "collection_jitter")
+	delete(tbl.Fields, "precision")
+	delete(tbl.Fields, "round_interval")
+	delete(tbl.Fields, "gather_timeout")
+	delete(tbl.Fields, "max_series")
 	delete(tbl.Fields, "tags")
-	var err error
 	cp.Filter, err = buildFilter(tbl)
 	if err != nil {
 		return cp, err
@@ -1796,6 +2308,73 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	//for jsonpath parser
+	if node, ok := tbl.Fields["jsonpath_query"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONPathQuery = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_measurement"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONPathMeasurement = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_time"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONPathTime = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_time_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONPathTimeFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["jsonpath_timezone"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONPathTimezone = str.Value
+			}
+		}
+	}
+
+	c.JSONPathTags = make(map[string]string)
+	if node, ok := tbl.Fields["jsonpath_tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.JSONPathTags[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	c.JSONPathFields = make(map[string]string)
+	if node, ok := tbl.Fields["jsonpath_fields"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.JSONPathFields[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1838,6 +2417,13 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "csv_trim_space")
 	delete(tbl.Fields, "form_urlencoded_tag_keys")
+	delete(tbl.Fields, "jsonpath_query")
+	delete(tbl.Fields, "jsonpath_measurement")
+	delete(tbl.Fields, "jsonpath_time")
+	delete(tbl.Fields, "jsonpath_time_format")
+	delete(tbl.Fields, "jsonpath_timezone")
+	delete(tbl.Fields, "jsonpath_tags")
+	delete(tbl.Fields, "jsonpath_fields")
 
 	return c, nil
 }
@@ -1876,6 +2462,46 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["templates"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.Templates = append(c.Templates, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_header"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVHeader, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_delimiter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVDelimiter = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampFormat = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["influx_max_line_bytes"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
@@ -1988,6 +2614,9 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	delete(tbl.Fields, "csv_header")
+	delete(tbl.Fields, "csv_delimiter")
+	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
@@ -1995,6 +2624,7 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
+	delete(tbl.Fields, "templates")
 	delete(tbl.Fields, "json_timestamp_units")
 	delete(tbl.Fields, "splunkmetric_hec_routing")
 	delete(tbl.Fields, "splunkmetric_multimetric")
@@ -2017,13 +2647,20 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		Filter: filter,
 	}
 
-	// TODO
-	// Outputs don't support FieldDrop/FieldPass, so set to NameDrop/NamePass
+	// Outputs used to select the measurement names with FieldPass/FieldDrop,
+	// keep doing so until these options are removed from outputs.
 	if len(oc.Filter.FieldDrop) > 0 {
+		log.Printf("W! [outputs.%s] fielddrop is deprecated on outputs, use namedrop", name)
 		oc.Filter.NameDrop = oc.Filter.FieldDrop
+		oc.Filter.FieldDrop = nil
 	}
 	if len(oc.Filter.FieldPass) > 0 {
+		log.Printf("W! [outputs.%s] fieldpass is deprecated on outputs, use namepass", name)
 		oc.Filter.NamePass = oc.Filter.FieldPass
+		oc.Filter.FieldPass = nil
+	}
+	if err := oc.Filter.Compile(); err != nil {
+		return nil, err
 	}
 
 	if node, ok := tbl.Fields["flush_interval"]; ok {
@@ -2076,6 +2713,76 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["max_concurrent_writes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.MaxConcurrentWrites = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_write_rate"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			switch value := kv.Value.(type) {
+			case *ast.Integer:
+				v, err := value.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.MaxWriteRate = float64(v)
+			case *ast.Float:
+				v, err := value.Float()
+				if err != nil {
+					return nil, err
+				}
+				oc.MaxWriteRate = v
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_backoff"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryBackoff = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_backoff_max"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryBackoffMax = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["float_precision"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				if v < 0 {
+					return nil, fmt.Errorf("float_precision must not be negative")
+				}
+				oc.FloatPrecision = int(v)
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["alias"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -2084,11 +2791,22 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	oc.LogLevel, err = buildLogLevel(tbl)
+	if err != nil {
+		return nil, err
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "flush_jitter")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "max_concurrent_writes")
+	delete(tbl.Fields, "max_write_rate")
+	delete(tbl.Fields, "retry_backoff")
+	delete(tbl.Fields, "retry_backoff_max")
+	delete(tbl.Fields, "float_precision")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 
 	return oc, nil
 }
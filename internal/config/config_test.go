@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -12,8 +13,11 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs/http_listener_v2"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
+	"github.com/influxdata/telegraf/plugins/outputs"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors/downsample"
+	"github.com/influxdata/toml"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -61,6 +65,45 @@ func TestConfig_LoadSingleInputWithEnvVars(t *testing.T) {
 		"Testdata did not produce correct memcached metadata.")
 }
 
+func TestExpandEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("TEST_POOL", "tank"))
+	require.NoError(t, os.Setenv("TEST_EMPTY", ""))
+	require.NoError(t, os.Unsetenv("TEST_UNSET"))
+
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: `pools = ["${TEST_POOL}"]`, expected: `pools = ["tank"]`},
+		{input: `pools = ["$TEST_POOL"]`, expected: `pools = ["tank"]`},
+		{input: `pools = ["${TEST_UNSET}"]`, expected: `pools = ["${TEST_UNSET}"]`},
+		{input: `pools = ["${TEST_EMPTY}"]`, expected: `pools = [""]`},
+		{input: `pools = ["${TEST_POOL:-rpool}"]`, expected: `pools = ["tank"]`},
+		{input: `pools = ["${TEST_UNSET:-rpool}"]`, expected: `pools = ["rpool"]`},
+		{input: `pools = ["${TEST_EMPTY:-rpool}"]`, expected: `pools = ["rpool"]`},
+		{input: `pools = ["${TEST_EMPTY-rpool}"]`, expected: `pools = [""]`},
+		{input: `pools = ["${TEST_UNSET-rpool}"]`, expected: `pools = ["rpool"]`},
+		{input: `url = "http://${TEST_UNSET:-localhost}:8086"`, expected: `url = "http://localhost:8086"`},
+		{input: `pools = ["${TEST_POOL:?pool required}"]`, expected: `pools = ["tank"]`},
+		{input: `pools = ["${TEST_UNSET:?pool required}"]`, err: "environment variable TEST_UNSET: pool required"},
+		{input: `pools = ["${TEST_EMPTY:?}"]`, err: "environment variable TEST_EMPTY: not set"},
+		{input: `pools = ["${TEST_EMPTY?}"]`, expected: `pools = [""]`},
+		{input: `# pools = ["${TEST_UNSET:?pool required}"]`, expected: `# pools = ["${TEST_UNSET:?pool required}"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := expandEnv([]byte(tt.input))
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
 func TestConfig_LoadSingleInput(t *testing.T) {
 	c := NewConfig()
 	c.LoadConfig("./testdata/single_plugin.toml")
@@ -100,6 +143,31 @@ func TestConfig_LoadSingleInput(t *testing.T) {
 		"Testdata did not produce correct memcached metadata.")
 }
 
+func TestConfig_LoadSingleInputIntervals(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/single_plugin_intervals.toml")
+	require.NoError(t, err)
+
+	memcached := inputs.Inputs["memcached"]().(*memcached.Memcached)
+	memcached.Servers = []string{"localhost"}
+
+	roundInterval := false
+	mConfig := &models.InputConfig{
+		Name:             "memcached",
+		Interval:         time.Hour,
+		CollectionJitter: 5 * time.Minute,
+		Precision:        time.Minute,
+		GatherTimeout:    30 * time.Second,
+		RoundInterval:    &roundInterval,
+		Tags:             make(map[string]string),
+	}
+
+	assert.Equal(t, memcached, c.Inputs[0].Input,
+		"Testdata did not produce a correct memcached struct.")
+	assert.Equal(t, mConfig, c.Inputs[0].Config,
+		"Testdata did not produce correct memcached metadata.")
+}
+
 func TestConfig_LoadDirectory(t *testing.T) {
 	c := NewConfig()
 	err := c.LoadConfig("./testdata/single_plugin.toml")
@@ -186,6 +254,22 @@ func TestConfig_LoadDirectory(t *testing.T) {
 		"Merged Testdata did not produce correct procstat metadata.")
 }
 
+func TestConfig_LoadDirectoryDuplicate(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/single_plugin.toml"))
+
+	err := c.LoadDirectory("./testdata/duplicate")
+	require.Error(t, err)
+	require.Equal(t, "Error parsing testdata/duplicate/memcached.conf, inputs.memcached is defined identically in ./testdata/single_plugin.toml", err.Error())
+}
+
+func TestConfig_LoadDirectoryDuplicateAlias(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadDirectory("./testdata/duplicate_alias")
+	require.Error(t, err)
+	require.Equal(t, `Error parsing testdata/duplicate_alias/b.conf, inputs.exec with alias "collector" is already defined in testdata/duplicate_alias/a.conf`, err.Error())
+}
+
 func TestConfig_LoadSpecialTypes(t *testing.T) {
 	c := NewConfig()
 	err := c.LoadConfig("./testdata/special_types.toml")
@@ -235,6 +319,91 @@ func TestConfig_InlineTables(t *testing.T) {
 	assert.Equal(t, []string{"org_id"}, c.Outputs[0].Config.Filter.TagInclude)
 }
 
+func TestConfig_FloatPrecision(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/float_precision.toml"))
+	require.Len(t, c.Outputs, 2)
+
+	require.Equal(t, 4, c.Outputs[0].Config.FloatPrecision)
+	require.Equal(t, 2, c.Outputs[1].Config.FloatPrecision)
+	// fieldpass is deprecated on outputs and selects the measurement names.
+	require.Equal(t, []string{"usage_*"}, c.Outputs[1].Config.Filter.NamePass)
+	require.Empty(t, c.Outputs[1].Config.Filter.FieldPass)
+}
+
+func TestConfig_OutputWrites(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/output_writes.toml"))
+	require.Len(t, c.Outputs, 2)
+
+	conf := c.Outputs[0].Config
+	require.Equal(t, 4, conf.MaxConcurrentWrites)
+	require.Equal(t, 2.5, conf.MaxWriteRate)
+	require.Equal(t, 10*time.Second, conf.RetryBackoff)
+	require.Equal(t, 5*time.Minute, conf.RetryBackoffMax)
+
+	conf = c.Outputs[1].Config
+	require.Equal(t, 1, conf.MaxConcurrentWrites)
+	require.Equal(t, 10.0, conf.MaxWriteRate)
+	require.Equal(t, time.Duration(0), conf.RetryBackoff)
+}
+
+func TestConfig_OutputProcessors(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/output_processors.toml"))
+	require.Len(t, c.Outputs, 2)
+	require.Len(t, c.Processors, 0)
+
+	require.Len(t, c.Outputs[0].Processors, 0)
+	require.Len(t, c.Outputs[1].Processors, 2)
+
+	// The processors of an output are sorted by order.
+	first, ok := c.Outputs[1].Processors[0].Processor.(*downsample.Downsample)
+	require.True(t, ok)
+	require.Equal(t, 10, first.Points)
+	require.Equal(t, "", first.Tag)
+	require.Equal(t, "downsample", c.Outputs[1].Processors[1].Config.Name)
+}
+
+// noSerializerOutput is an output writing its own format.
+type noSerializerOutput struct{}
+
+func (*noSerializerOutput) Connect() error                { return nil }
+func (*noSerializerOutput) Close() error                  { return nil }
+func (*noSerializerOutput) Description() string           { return "" }
+func (*noSerializerOutput) SampleConfig() string          { return "" }
+func (*noSerializerOutput) Write([]telegraf.Metric) error { return nil }
+
+func TestConfig_OutputDataFormat(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/output_data_format.toml"))
+	require.Len(t, c.Outputs, 2)
+
+	// The data_format of an output without a serializer is an unknown
+	// option.
+	outputs.Add("no_serializer", func() telegraf.Output {
+		return &noSerializerOutput{}
+	})
+	defer delete(outputs.Outputs, "no_serializer")
+	c = NewConfig()
+	err := c.LoadConfig("./testdata/output_no_serializer.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "data_format")
+}
+
+func TestConfig_MaxSeries(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/max_series.toml"))
+	require.Len(t, c.Inputs, 2)
+
+	require.Equal(t, 1000, c.Inputs[0].Config.MaxSeries)
+	require.Equal(t, 50, c.Inputs[1].Config.MaxSeries)
+
+	input, ok := c.Inputs[1].Input.(*memcached.Memcached)
+	require.True(t, ok)
+	require.Equal(t, []string{"192.168.1.1"}, input.Servers)
+}
+
 func TestConfig_SliceComment(t *testing.T) {
 	t.Skipf("Skipping until #3642 is resolved")
 
@@ -256,3 +425,41 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error parsing ./testdata/non_slice_slice.toml, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+func TestConfig_LoadSingleInputWithSecrets(t *testing.T) {
+	os.Setenv("MY_TEST_SECRET_SERVER", `192.168.1.1"`)
+	defer os.Unsetenv("MY_TEST_SECRET_SERVER")
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/single_plugin_secrets.toml"))
+	require.Len(t, c.Inputs, 1)
+
+	memcached := c.Inputs[0].Input.(*memcached.Memcached)
+	require.Equal(t, []string{`192.168.1.1"`}, memcached.Servers)
+}
+
+func TestConfig_ResolveSecretsError(t *testing.T) {
+	_, err := resolveSecrets([]byte("password = \"@{env:MY_TEST_MISSING_SECRET}\"\n"))
+	require.Error(t, err)
+
+	_, err = resolveSecrets([]byte("password = \"@{keychain:password}\"\n"))
+	require.Error(t, err)
+}
+
+func TestPrintSections_Invalid(t *testing.T) {
+	require.Error(t, PrintSections([]string{"zfs"}))
+	require.Error(t, PrintSections([]string{"plugins.zfs"}))
+	require.Error(t, PrintSections([]string{"inputs.does_not_exist"}))
+}
+
+func TestTableSource(t *testing.T) {
+	parse := func(data string) string {
+		tbl, err := toml.Parse([]byte(data))
+		require.NoError(t, err)
+		return tableSource(tbl)
+	}
+
+	source := parse("port = 1\nservers = [\"a\", \"b\"]\n")
+	require.Equal(t, source, parse("servers = [\"a\", \"b\"]\nport = 1\n"))
+	require.NotEqual(t, source, parse("port = 2\nservers = [\"a\", \"b\"]\n"))
+}
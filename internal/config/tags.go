@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Tag values containing "{{" are Go templates evaluated when the
+// configuration is loaded, with these functions available:
+//
+//   - hostname: the hostname reported by the kernel.
+//   - fqdn: the fully qualified domain name of the host.
+//   - file "path": the contents of the file, without trailing whitespace.
+//   - instance_id: the instance ID from the cloud metadata service.
+//   - zone: the availability zone from the cloud metadata service.
+var tagFuncs = template.FuncMap{
+	"hostname":    os.Hostname,
+	"fqdn":        fqdn,
+	"file":        readTagFile,
+	"instance_id": func() (string, error) { return cloudMetadata("instance_id") },
+	"zone":        func() (string, error) { return cloudMetadata("zone") },
+}
+
+// expandTags evaluates the templates in the tag values.
+func expandTags(tags map[string]string) error {
+	for key, value := range tags {
+		if !strings.Contains(value, "{{") {
+			continue
+		}
+
+		tmpl, err := template.New(key).Funcs(tagFuncs).Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid template for tag %q: %v", key, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			return fmt.Errorf("could not evaluate tag %q: %v", key, err)
+		}
+		tags[key] = buf.String()
+	}
+	return nil
+}
+
+func readTagFile(path string) (string, error) {
+	octets, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(octets)), nil
+}
+
+// fqdn returns the canonical name of the host, falling back to the hostname
+// if it cannot be resolved.
+func fqdn() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+
+	addrs, err := net.LookupIP(hostname)
+	if err != nil {
+		return hostname, nil
+	}
+	for _, addr := range addrs {
+		names, err := net.LookupAddr(addr.String())
+		if err != nil || len(names) == 0 {
+			continue
+		}
+		return strings.TrimSuffix(names[0], "."), nil
+	}
+	return hostname, nil
+}
+
+// metadataRequest describes how to read an item from the metadata service of
+// a cloud provider.
+type metadataRequest struct {
+	url    string
+	header map[string]string
+
+	// tokenURL, if set, is used to request a session token sent with the
+	// request, as required by version 2 of the AWS metadata service.
+	tokenURL string
+}
+
+// metadataProvider is the metadata service of a cloud provider.
+type metadataProvider struct {
+	name  string
+	items map[string]metadataRequest
+}
+
+// metadataProviders lists the metadata services tried in order, the first
+// one answering is used.
+var metadataProviders = []metadataProvider{
+	{
+		name: "aws",
+		items: map[string]metadataRequest{
+			"instance_id": {
+				url:      "http://169.254.169.254/latest/meta-data/instance-id",
+				tokenURL: "http://169.254.169.254/latest/api/token",
+			},
+			"zone": {
+				url:      "http://169.254.169.254/latest/meta-data/placement/availability-zone",
+				tokenURL: "http://169.254.169.254/latest/api/token",
+			},
+		},
+	},
+	{
+		name: "gcp",
+		items: map[string]metadataRequest{
+			"instance_id": {
+				url:    "http://metadata.google.internal/computeMetadata/v1/instance/id",
+				header: map[string]string{"Metadata-Flavor": "Google"},
+			},
+			"zone": {
+				url:    "http://metadata.google.internal/computeMetadata/v1/instance/zone",
+				header: map[string]string{"Metadata-Flavor": "Google"},
+			},
+		},
+	},
+	{
+		name: "azure",
+		items: map[string]metadataRequest{
+			"instance_id": {
+				url:    "http://169.254.169.254/metadata/instance/compute/vmId?api-version=2019-06-01&format=text",
+				header: map[string]string{"Metadata": "true"},
+			},
+			"zone": {
+				url:    "http://169.254.169.254/metadata/instance/compute/location?api-version=2019-06-01&format=text",
+				header: map[string]string{"Metadata": "true"},
+			},
+		},
+	},
+}
+
+var (
+	metadataMu    sync.Mutex
+	metadataCache = make(map[string]string)
+
+	metadataClient = &http.Client{Timeout: 2 * time.Second}
+)
+
+// cloudMetadata returns an item from the metadata service of the cloud the
+// host is running in.  Values are cached for the life of the process.
+func cloudMetadata(item string) (string, error) {
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+
+	if value, ok := metadataCache[item]; ok {
+		return value, nil
+	}
+
+	for _, provider := range metadataProviders {
+		req, ok := provider.items[item]
+		if !ok {
+			continue
+		}
+
+		value, err := fetchMetadata(req)
+		if err != nil {
+			continue
+		}
+
+		// GCP returns the zone as projects/<number>/zones/<zone>.
+		if i := strings.LastIndex(value, "/"); i >= 0 {
+			value = value[i+1:]
+		}
+		metadataCache[item] = value
+		return value, nil
+	}
+	return "", fmt.Errorf("no cloud metadata service available for %s", item)
+}
+
+func fetchMetadata(r metadataRequest) (string, error) {
+	req, err := http.NewRequest("GET", r.url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range r.header {
+		req.Header.Set(k, v)
+	}
+	if r.tokenURL != "" {
+		// Fall back to version 1 of the service if no token is issued.
+		if token, err := fetchMetadataToken(r.tokenURL); err == nil {
+			req.Header.Set("X-aws-ec2-metadata-token", token)
+		}
+	}
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned HTTP status %s", r.url, resp.Status)
+	}
+
+	octets, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(octets)), nil
+}
+
+func fetchMetadataToken(url string) (string, error) {
+	req, err := http.NewRequest("PUT", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned HTTP status %s", url, resp.Status)
+	}
+
+	octets, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(octets), nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTags(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "rack")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("r12\n")
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"dc":   "us-east-1",
+		"rack": `{{ file "` + tmpfile.Name() + `" }}`,
+		"node": "{{ hostname }}-{{ file \"" + tmpfile.Name() + "\" }}",
+	}
+	require.NoError(t, expandTags(tags))
+	require.Equal(t, map[string]string{
+		"dc":   "us-east-1",
+		"rack": "r12",
+		"node": hostname + "-r12",
+	}, tags)
+}
+
+func TestExpandTagsErrors(t *testing.T) {
+	require.Error(t, expandTags(map[string]string{"rack": `{{ file "/nonexistent/rack" }}`}))
+	require.Error(t, expandTags(map[string]string{"rack": `{{ unknown }}`}))
+}
+
+func TestCloudMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/id":
+			w.Write([]byte("4520031799277581759"))
+		case "/zone":
+			w.Write([]byte("projects/123456/zones/europe-west1-b\n"))
+		}
+	}))
+	defer ts.Close()
+
+	providers := metadataProviders
+	defer func() {
+		metadataProviders = providers
+		metadataCache = make(map[string]string)
+	}()
+	metadataProviders = []metadataProvider{
+		{
+			name: "aws",
+			items: map[string]metadataRequest{
+				"instance_id": {url: ts.URL + "/latest/meta-data/instance-id"},
+			},
+		},
+		{
+			name: "gcp",
+			items: map[string]metadataRequest{
+				"instance_id": {url: ts.URL + "/id", header: map[string]string{"Metadata-Flavor": "Google"}},
+				"zone":        {url: ts.URL + "/zone", header: map[string]string{"Metadata-Flavor": "Google"}},
+			},
+		},
+	}
+
+	tags := map[string]string{
+		"instance": "{{ instance_id }}",
+		"zone":     "{{ zone }}",
+	}
+	require.NoError(t, expandTags(tags))
+	require.Equal(t, "4520031799277581759", tags["instance"])
+	require.Equal(t, "europe-west1-b", tags["zone"])
+}
@@ -4,15 +4,25 @@ import (
 	"bytes"
 	"compress/gzip"
 	"errors"
+	"fmt"
 	"io"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 // NewContentEncoder returns a ContentEncoder for the encoding type.
 func NewContentEncoder(encoding string) (ContentEncoder, error) {
+	return NewContentEncoderLevel(encoding, 0)
+}
+
+// NewContentEncoderLevel returns a ContentEncoder for the encoding type
+// compressing at the given level, or at the default level when zero.
+func NewContentEncoderLevel(encoding string, level int) (ContentEncoder, error) {
 	switch encoding {
 	case "gzip":
-		return NewGzipEncoder()
-
+		return NewGzipEncoderLevel(level)
+	case "zstd":
+		return NewZstdEncoder(level)
 	case "identity", "":
 		return NewIdentityEncoder(), nil
 	default:
@@ -20,11 +30,61 @@ func NewContentEncoder(encoding string) (ContentEncoder, error) {
 	}
 }
 
+// newCompressWriter returns a writer compressing to w with the encoding type.
+func newCompressWriter(w io.Writer, encoding string, level int) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		gzipLevel, err := gzipLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		return gzip.NewWriterLevel(w, gzipLevel)
+	case "zstd":
+		zstdLevel, err := zstdLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstdLevel))
+	default:
+		return nil, errors.New("invalid value for content_encoding")
+	}
+}
+
+func gzipLevel(level int) (int, error) {
+	if level == 0 {
+		return gzip.DefaultCompression, nil
+	}
+	if level < gzip.BestSpeed || level > gzip.BestCompression {
+		return 0, fmt.Errorf("invalid gzip compression level %d, must be between %d and %d",
+			level, gzip.BestSpeed, gzip.BestCompression)
+	}
+	return level, nil
+}
+
+// zstdLevel maps the zstd compression levels to the available encoders:
+// levels 1 and 2 use the fastest encoder, higher levels the default one.
+// The vendored zstd package has no stronger encoders, so levels above 3
+// compress no better than 3.
+func zstdLevel(level int) (zstd.EncoderLevel, error) {
+	switch {
+	case level == 0:
+		return zstd.SpeedDefault, nil
+	case level < 1 || level > 22:
+		return 0, fmt.Errorf("invalid zstd compression level %d, must be between 1 and 22", level)
+	case level < 3:
+		return zstd.SpeedFastest, nil
+	default:
+		return zstd.SpeedDefault, nil
+	}
+}
+
 // NewContentDecoder returns a ContentDecoder for the encoding type.
 func NewContentDecoder(encoding string) (ContentDecoder, error) {
 	switch encoding {
 	case "gzip":
 		return NewGzipDecoder()
+	case "zstd":
+		return NewZstdDecoder()
 	case "identity", "":
 		return NewIdentityDecoder(), nil
 	default:
@@ -37,16 +97,32 @@ type ContentEncoder interface {
 	Encode([]byte) ([]byte, error)
 }
 
-// GzipEncoder compresses the buffer using gzip at the default level.
+// GzipEncoder compresses the buffer using gzip.
 type GzipEncoder struct {
 	writer *gzip.Writer
 	buf    *bytes.Buffer
 }
 
+// NewGzipEncoder returns an encoder compressing at the default level.
 func NewGzipEncoder() (*GzipEncoder, error) {
+	return NewGzipEncoderLevel(0)
+}
+
+// NewGzipEncoderLevel returns an encoder compressing at the given level, or
+// at the default level when zero.
+func NewGzipEncoderLevel(level int) (*GzipEncoder, error) {
+	level, err := gzipLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
 	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
 	return &GzipEncoder{
-		writer: gzip.NewWriter(&buf),
+		writer: writer,
 		buf:    &buf,
 	}, nil
 }
@@ -66,6 +142,32 @@ func (e *GzipEncoder) Encode(data []byte) ([]byte, error) {
 	return e.buf.Bytes(), nil
 }
 
+// ZstdEncoder compresses the buffer using zstd.
+type ZstdEncoder struct {
+	encoder *zstd.Encoder
+}
+
+// NewZstdEncoder returns an encoder compressing at the given level, or at the
+// default level when zero.
+func NewZstdEncoder(level int) (*ZstdEncoder, error) {
+	zstdLevel, err := zstdLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel))
+	if err != nil {
+		return nil, err
+	}
+	return &ZstdEncoder{
+		encoder: encoder,
+	}, nil
+}
+
+func (e *ZstdEncoder) Encode(data []byte) ([]byte, error) {
+	return e.encoder.EncodeAll(data, nil), nil
+}
+
 // IdentityEncoder is a null encoder that applies no transformation.
 type IdentityEncoder struct{}
 
@@ -110,6 +212,25 @@ func (d *GzipDecoder) Decode(data []byte) ([]byte, error) {
 	return d.buf.Bytes(), nil
 }
 
+// ZstdDecoder decompresses buffers with zstd compression.
+type ZstdDecoder struct {
+	decoder *zstd.Decoder
+}
+
+func NewZstdDecoder() (*ZstdDecoder, error) {
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ZstdDecoder{
+		decoder: decoder,
+	}, nil
+}
+
+func (d *ZstdDecoder) Decode(data []byte) ([]byte, error) {
+	return d.decoder.DecodeAll(data, nil)
+}
+
 // IdentityDecoder is a null decoder that returns the input.
 type IdentityDecoder struct{}
 
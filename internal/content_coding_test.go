@@ -44,6 +44,42 @@ func TestGzipReuse(t *testing.T) {
 	require.Equal(t, "doody", string(actual))
 }
 
+func TestGzipEncodeLevel(t *testing.T) {
+	enc, err := NewContentEncoderLevel("gzip", 1)
+	require.NoError(t, err)
+	dec, err := NewGzipDecoder()
+	require.NoError(t, err)
+
+	payload, err := enc.Encode([]byte("howdy"))
+	require.NoError(t, err)
+
+	actual, err := dec.Decode(payload)
+	require.NoError(t, err)
+
+	require.Equal(t, "howdy", string(actual))
+
+	_, err = NewContentEncoderLevel("gzip", 10)
+	require.Error(t, err)
+}
+
+func TestZstdEncodeDecode(t *testing.T) {
+	enc, err := NewContentEncoderLevel("zstd", 0)
+	require.NoError(t, err)
+	dec, err := NewContentDecoder("zstd")
+	require.NoError(t, err)
+
+	payload, err := enc.Encode([]byte("howdy"))
+	require.NoError(t, err)
+
+	actual, err := dec.Decode(payload)
+	require.NoError(t, err)
+
+	require.Equal(t, "howdy", string(actual))
+
+	_, err = NewContentEncoderLevel("zstd", 23)
+	require.Error(t, err)
+}
+
 func TestIdentityEncodeDecode(t *testing.T) {
 	enc := NewIdentityEncoder()
 	dec := NewIdentityDecoder()
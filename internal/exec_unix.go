@@ -5,6 +5,7 @@ package internal
 import (
 	"log"
 	"os/exec"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -17,6 +18,8 @@ const KillGrace = 5 * time.Second
 // It assumes the command has already been started.
 // If the command times out, it attempts to kill the process.
 func WaitTimeout(c *exec.Cmd, timeout time.Duration) error {
+	// mu guards kill, set by the term timer.
+	var mu sync.Mutex
 	var kill *time.Timer
 	term := time.AfterFunc(timeout, func() {
 		err := c.Process.Signal(syscall.SIGTERM)
@@ -25,6 +28,8 @@ func WaitTimeout(c *exec.Cmd, timeout time.Duration) error {
 			return
 		}
 
+		mu.Lock()
+		defer mu.Unlock()
 		kill = time.AfterFunc(KillGrace, func() {
 			err := c.Process.Kill()
 			if err != nil {
@@ -37,10 +42,12 @@ func WaitTimeout(c *exec.Cmd, timeout time.Duration) error {
 	err := c.Wait()
 
 	// Shutdown all timers
+	termSent := !term.Stop()
+	mu.Lock()
 	if kill != nil {
 		kill.Stop()
 	}
-	termSent := !term.Stop()
+	mu.Unlock()
 
 	// If the process exited without error treat it as success.  This allows a
 	// process to do a clean shutdown on signal.
@@ -4,14 +4,16 @@ package goplugin
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path"
 	"path/filepath"
 	"plugin"
+	"sort"
 	"strings"
 )
 
-// loadExternalPlugins loads external plugins from shared libraries (.so, .dll, etc.)
+// LoadExternalPlugins loads external plugins from shared libraries (.so, .dll, etc.)
 // in the specified directory.
 func LoadExternalPlugins(rootDir string) error {
 	return filepath.Walk(rootDir, func(pth string, info os.FileInfo, err error) error {
@@ -31,12 +33,27 @@ func LoadExternalPlugins(rootDir string) error {
 			return nil
 		}
 
-		// Load plugin.
+		// Load plugin, the plugins it provides register themselves when the
+		// shared library is initialized.
+		before := registered()
 		_, err = plugin.Open(pth)
 		if err != nil {
 			return fmt.Errorf("error loading %s: %s", pth, err)
 		}
 
+		added, replaced := changes(before, registered())
+		sort.Strings(added)
+		sort.Strings(replaced)
+		for _, name := range added {
+			log.Printf("I! Loaded plugin %s from %s", name, pth)
+		}
+		for _, name := range replaced {
+			log.Printf("W! Plugin %s was replaced by %s", name, pth)
+		}
+		if len(added) == 0 && len(replaced) == 0 {
+			log.Printf("W! No plugins registered by %s", pth)
+		}
+
 		return nil
 	})
 }
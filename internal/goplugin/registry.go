@@ -0,0 +1,44 @@
+package goplugin
+
+import (
+	"reflect"
+
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// registered returns the creator of every registered plugin keyed by its
+// namespaced name, such as "inputs.zfs".
+func registered() map[string]uintptr {
+	creators := make(map[string]uintptr)
+	for name, creator := range inputs.Inputs {
+		creators["inputs."+name] = reflect.ValueOf(creator).Pointer()
+	}
+	for name, creator := range outputs.Outputs {
+		creators["outputs."+name] = reflect.ValueOf(creator).Pointer()
+	}
+	for name, creator := range processors.Processors {
+		creators["processors."+name] = reflect.ValueOf(creator).Pointer()
+	}
+	for name, creator := range aggregators.Aggregators {
+		creators["aggregators."+name] = reflect.ValueOf(creator).Pointer()
+	}
+	return creators
+}
+
+// changes returns the plugins that were added or replaced between the
+// before and after registry snapshots.
+func changes(before, after map[string]uintptr) (added, replaced []string) {
+	for name, creator := range after {
+		previous, ok := before[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case previous != creator:
+			replaced = append(replaced, name)
+		}
+	}
+	return added, replaced
+}
@@ -0,0 +1,25 @@
+package goplugin
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	inputs.Add("goplugin_replaced", func() telegraf.Input { return nil })
+	before := registered()
+
+	inputs.Add("goplugin_added", func() telegraf.Input { return nil })
+	inputs.Add("goplugin_replaced", func() telegraf.Input { return nil })
+	defer delete(inputs.Inputs, "goplugin_added")
+	defer delete(inputs.Inputs, "goplugin_replaced")
+
+	added, replaced := changes(before, registered())
+	sort.Strings(added)
+	require.Equal(t, []string{"inputs.goplugin_added"}, added)
+	require.Equal(t, []string{"inputs.goplugin_replaced"}, replaced)
+}
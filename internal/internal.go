@@ -36,6 +36,7 @@ var (
 
 // Set via the main module
 var version string
+var branch, commit string
 
 // Duration just wraps time.Duration
 type Duration struct {
@@ -51,6 +52,23 @@ type Number struct {
 	Value float64
 }
 
+// PartialWriteError is returned by the Write function of an output that
+// wrote only part of the metrics.  The metrics not listed in MetricsAccept
+// are kept in the buffer and retried on the next write.
+type PartialWriteError struct {
+	Err error
+	// MetricsAccept holds the indices, in the written slice, of the metrics
+	// that were written.
+	MetricsAccept []int
+}
+
+func (e *PartialWriteError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("partial write: %d metrics written", len(e.MetricsAccept))
+	}
+	return e.Err.Error()
+}
+
 type ReadWaitCloser struct {
 	pipeReader *io.PipeReader
 	wg         sync.WaitGroup
@@ -70,6 +88,18 @@ func Version() string {
 	return version
 }
 
+// SetBuild sets the git branch and commit the agent was built from
+func SetBuild(b, c string) {
+	branch = b
+	commit = c
+}
+
+// Build returns the git branch and commit the agent was built from, empty if
+// unknown
+func Build() (string, string) {
+	return branch, commit
+}
+
 // ProductToken returns a tag for Telegraf that can be used in user agents.
 func ProductToken() string {
 	return fmt.Sprintf("Telegraf/%s Go/%s",
@@ -319,6 +349,29 @@ func CompressWithGzip(data io.Reader) (io.ReadCloser, error) {
 	return pipeReader, err
 }
 
+// CompressWithEncoding takes an io.Reader as input and pipes it through the
+// compression of the content encoding, "gzip" or "zstd", at the given level
+// or at the default level when zero.
+func CompressWithEncoding(data io.Reader, encoding string, level int) (io.ReadCloser, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	writer, err := newCompressWriter(pipeWriter, encoding, level)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_, err := io.Copy(writer, data)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		// subsequent reads from the read half of the pipe will
+		// return no bytes and the error err, or EOF if err is nil.
+		pipeWriter.CloseWithError(err)
+	}()
+
+	return pipeReader, nil
+}
+
 // ParseTimestamp parses a Time according to the standard Telegraf options.
 // These are generally displayed in the toml similar to:
 //   json_time_key= "timestamp"
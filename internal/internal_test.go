@@ -234,6 +234,37 @@ func TestCompressWithGzip(t *testing.T) {
 	assert.Equal(t, testData, string(output))
 }
 
+func TestCompressWithEncoding(t *testing.T) {
+	testData := "the quick brown fox jumps over the lazy dog"
+
+	rc, err := CompressWithEncoding(bytes.NewBufferString(testData), "gzip", 9)
+	require.NoError(t, err)
+
+	gzipReader, err := gzip.NewReader(rc)
+	require.NoError(t, err)
+	defer gzipReader.Close()
+
+	output, err := ioutil.ReadAll(gzipReader)
+	require.NoError(t, err)
+	require.Equal(t, testData, string(output))
+
+	rc, err = CompressWithEncoding(bytes.NewBufferString(testData), "zstd", 3)
+	require.NoError(t, err)
+	compressed, err := ioutil.ReadAll(rc)
+	require.NoError(t, err)
+
+	dec, err := NewZstdDecoder()
+	require.NoError(t, err)
+	output, err = dec.Decode(compressed)
+	require.NoError(t, err)
+	require.Equal(t, testData, string(output))
+
+	_, err = CompressWithEncoding(bytes.NewBufferString(testData), "gzip", 10)
+	require.Error(t, err)
+	_, err = CompressWithEncoding(bytes.NewBufferString(testData), "br", 0)
+	require.Error(t, err)
+}
+
 type mockReader struct {
 	readN uint64 // record the number of calls to Read
 }
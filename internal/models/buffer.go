@@ -189,6 +189,29 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	b.Lock()
 	defer b.Unlock()
 
+	b.reject(batch)
+}
+
+// Settle marks the accepted part of a batch, acquired from Batch(), as
+// successfully written and returns the rejected part to the buffer.  Both
+// parts must keep the newest to oldest order of the batch.
+func (b *Buffer) Settle(accepted, rejected []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range accepted {
+		b.metricWritten(m)
+	}
+
+	if len(rejected) == 0 {
+		b.resetBatch()
+		b.BufferSize.Set(int64(b.length()))
+		return
+	}
+	b.reject(rejected)
+}
+
+func (b *Buffer) reject(batch []telegraf.Metric) {
 	if len(batch) == 0 {
 		return
 	}
@@ -232,6 +255,46 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	b.BufferSize.Set(int64(b.length()))
 }
 
+// Drain removes all metrics from the buffer and returns them ordered from
+// oldest to newest.  The metrics are neither accepted nor rejected, ownership
+// is passed to the caller.  Drain must not be called while a batch is
+// outstanding.
+func (b *Buffer) Drain() []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	out := make([]telegraf.Metric, 0, b.size)
+	index := b.first
+	for i := 0; i < b.size; i++ {
+		out = append(out, b.buf[index])
+		b.buf[index] = nil
+		index = b.next(index)
+	}
+
+	b.first = 0
+	b.last = 0
+	b.size = 0
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+	return out
+}
+
+// Snapshot returns the metrics in the buffer ordered from oldest to newest,
+// without removing them.  The metrics of an outstanding batch are not
+// included.  The metrics must not be modified by the caller.
+func (b *Buffer) Snapshot() []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	out := make([]telegraf.Metric, 0, b.size)
+	index := b.first
+	for i := 0; i < b.size; i++ {
+		out = append(out, b.buf[index])
+		index = b.next(index)
+	}
+	return out
+}
+
 // dist returns the distance between two indexes.  Because this data structure
 // uses a half open range the arguments must both either left side or right
 // side pairs.
@@ -231,6 +231,40 @@ func TestBuffer_RejectWithRoom(t *testing.T) {
 		}, batch)
 }
 
+func TestBuffer_Settle(t *testing.T) {
+	b := setup(NewBuffer("test", "", 5))
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	b.Add(MetricTime(3))
+	b.Add(MetricTime(4))
+	batch := b.Batch(4)
+	b.Add(MetricTime(5))
+	b.Settle(batch[:2], batch[2:])
+
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+	require.Equal(t, 3, b.Len())
+
+	batch = b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(5),
+			MetricTime(2),
+			MetricTime(1),
+		}, batch)
+}
+
+func TestBuffer_SettleAllAccepted(t *testing.T) {
+	b := setup(NewBuffer("test", "", 5))
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	batch := b.Batch(2)
+	b.Settle(batch, nil)
+
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	require.Equal(t, 0, b.Len())
+}
+
 func TestBuffer_RejectNothingNewFull(t *testing.T) {
 	b := setup(NewBuffer("test", "", 5))
 	b.Add(MetricTime(1))
@@ -726,3 +760,39 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func TestBuffer_Drain(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+
+	drained := b.Drain()
+	require.Len(t, drained, 3)
+	for i, m := range drained {
+		require.Equal(t, time.Unix(int64(i+2), 0), m.Time())
+	}
+	require.Equal(t, 0, b.Len())
+	require.Equal(t, int64(0), b.MetricsWritten.Get())
+
+	b.Add(MetricTime(5))
+	batch := b.Batch(3)
+	require.Len(t, batch, 1)
+	require.Equal(t, time.Unix(5, 0), batch[0].Time())
+}
+
+func TestBuffer_Snapshot(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+
+	snapshot := b.Snapshot()
+	require.Len(t, snapshot, 3)
+	for i, m := range snapshot {
+		require.Equal(t, time.Unix(int64(i+2), 0), m.Time())
+	}
+	require.Equal(t, 3, b.Len())
+
+	// The metrics of an outstanding batch are not in the buffer.
+	batch := b.Batch(2)
+	require.Len(t, b.Snapshot(), 1)
+	b.Reject(batch)
+	require.Len(t, b.Snapshot(), 3)
+}
@@ -1,10 +1,14 @@
 package models
 
 import (
+	"fmt"
 	"log"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -12,48 +16,107 @@ import (
 type Logger struct {
 	Errs selfstat.Stat
 	Name string // Name is the plugin name, will be printed in the `[]`.
+
+	// Level overrides the log level of the agent for the plugin, if set.
+	Level *logger.Level
+
+	mu     sync.Mutex
+	recent []LogEntry
+}
+
+// maxRecentErrors is the number of errors kept by a Logger.
+const maxRecentErrors = 10
+
+// LogEntry is an error logged by a plugin.
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
 }
 
 // Errorf logs an error message, patterned after log.Printf.
 func (l *Logger) Errorf(format string, args ...interface{}) {
-	l.Errs.Incr(1)
-	log.Printf("E! ["+l.Name+"] "+format, args...)
+	l.error(fmt.Sprintf(format, args...))
 }
 
 // Error logs an error message, patterned after log.Print.
 func (l *Logger) Error(args ...interface{}) {
-	l.Errs.Incr(1)
-	log.Print(append([]interface{}{"E! [" + l.Name + "] "}, args...)...)
+	l.error(fmt.Sprint(args...))
 }
 
 // Debugf logs a debug message, patterned after log.Printf.
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	log.Printf("D! ["+l.Name+"] "+format, args...)
+	l.print(logger.LevelDebug, fmt.Sprintf(format, args...))
 }
 
 // Debug logs a debug message, patterned after log.Print.
 func (l *Logger) Debug(args ...interface{}) {
-	log.Print(append([]interface{}{"D! [" + l.Name + "] "}, args...)...)
+	l.print(logger.LevelDebug, fmt.Sprint(args...))
 }
 
 // Warnf logs a warning message, patterned after log.Printf.
 func (l *Logger) Warnf(format string, args ...interface{}) {
-	log.Printf("W! ["+l.Name+"] "+format, args...)
+	l.print(logger.LevelWarn, fmt.Sprintf(format, args...))
 }
 
 // Warn logs a warning message, patterned after log.Print.
 func (l *Logger) Warn(args ...interface{}) {
-	log.Print(append([]interface{}{"W! [" + l.Name + "] "}, args...)...)
+	l.print(logger.LevelWarn, fmt.Sprint(args...))
 }
 
 // Infof logs an information message, patterned after log.Printf.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	log.Printf("I! ["+l.Name+"] "+format, args...)
+	l.print(logger.LevelInfo, fmt.Sprintf(format, args...))
 }
 
 // Info logs an information message, patterned after log.Print.
 func (l *Logger) Info(args ...interface{}) {
-	log.Print(append([]interface{}{"I! [" + l.Name + "] "}, args...)...)
+	l.print(logger.LevelInfo, fmt.Sprint(args...))
+}
+
+// RecentErrors returns the last errors logged, oldest first.
+func (l *Logger) RecentErrors() []LogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]LogEntry(nil), l.recent...)
+}
+
+func (l *Logger) error(msg string) {
+	l.Errs.Incr(1)
+
+	l.mu.Lock()
+	if len(l.recent) == maxRecentErrors {
+		l.recent = append(l.recent[:0], l.recent[1:]...)
+	}
+	l.recent = append(l.recent, LogEntry{Time: time.Now(), Message: msg})
+	l.mu.Unlock()
+
+	l.print(logger.LevelError, msg)
+}
+
+func (l *Logger) print(level logger.Level, msg string) {
+	if l.Level == nil {
+		log.Print(level.Prefix() + " [" + l.Name + "] " + msg)
+		return
+	}
+
+	if level < *l.Level {
+		return
+	}
+	logger.Output(level, l.Name, msg)
+}
+
+// logLevel returns the parsed log level of a plugin, or nil if the plugin
+// uses the level of the agent.  The level is validated when loading the
+// config.
+func logLevel(name string) *logger.Level {
+	if name == "" {
+		return nil
+	}
+	level, err := logger.ParseLevel(name)
+	if err != nil {
+		return nil
+	}
+	return &level
 }
 
 // logName returns the log-friendly name/type.
@@ -85,3 +148,9 @@ func setLogIfExist(i interface{}, log telegraf.Logger) {
 
 	return
 }
+
+// SetLoggerOnPlugin injects the logger into the plugin if it defines a Log
+// field of type telegraf.Logger.
+func SetLoggerOnPlugin(i interface{}, log telegraf.Logger) {
+	setLogIfExist(i, log)
+}
@@ -1,8 +1,10 @@
 package models
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/selfstat"
 	"github.com/stretchr/testify/require"
 )
@@ -68,3 +70,32 @@ func TestLogging(t *testing.T) {
 	log.Error("something happened")
 	require.Equal(t, int64(2), log.Errs.Get())
 }
+
+func TestLogLevel(t *testing.T) {
+	require.Nil(t, logLevel(""))
+	require.Nil(t, logLevel("verbose"))
+	require.Equal(t, logger.LevelDebug, *logLevel("debug"))
+
+	input := NewRunningInput(&testInput{}, &InputConfig{Name: "test", LogLevel: "error"})
+	require.Equal(t, logger.LevelError, *input.Log().(*Logger).Level)
+}
+
+func TestRecentErrors(t *testing.T) {
+	log := Logger{Name: "inputs.test", Errs: selfstat.Register(
+		"gather",
+		"errors",
+		map[string]string{"input": "test"},
+	)}
+
+	log.Warn("not an error")
+	require.Empty(t, log.RecentErrors())
+
+	for i := 0; i < maxRecentErrors+2; i++ {
+		log.Errorf("error %d", i)
+	}
+
+	recent := log.RecentErrors()
+	require.Len(t, recent, maxRecentErrors)
+	require.Equal(t, "error 2", recent[0].Message)
+	require.Equal(t, fmt.Sprintf("error %d", maxRecentErrors+1), recent[len(recent)-1].Message)
+}
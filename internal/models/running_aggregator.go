@@ -30,8 +30,9 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 	}
 
 	logger := &Logger{
-		Name: logName("aggregators", config.Name, config.Alias),
-		Errs: selfstat.Register("aggregate", "errors", tags),
+		Name:  logName("aggregators", config.Name, config.Alias),
+		Errs:  selfstat.Register("aggregate", "errors", tags),
+		Level: logLevel(config.LogLevel),
 	}
 
 	setLogIfExist(aggregator, logger)
@@ -67,6 +68,7 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 type AggregatorConfig struct {
 	Name         string
 	Alias        string
+	LogLevel     string
 	DropOriginal bool
 	Period       time.Duration
 	Delay        time.Duration
@@ -83,6 +85,10 @@ func (r *RunningAggregator) LogName() string {
 	return logName("aggregators", r.Config.Name, r.Config.Alias)
 }
 
+func (r *RunningAggregator) Log() telegraf.Logger {
+	return r.log
+}
+
 func (r *RunningAggregator) Init() error {
 	if p, ok := r.Aggregator.(telegraf.Initializer); ok {
 		err := p.Init()
@@ -1,23 +1,64 @@
 package models
 
 import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
-var GlobalMetricsGathered = selfstat.Register("agent", "metrics_gathered", map[string]string{})
+// maxRecordedMetrics is the maximum number of metrics recorded per gather
+// for snapshots.
+const maxRecordedMetrics = 10000
+
+// ErrGatherRunning is returned by TryGather when a Gather call is running.
+var ErrGatherRunning = errors.New("already gathering")
+
+var (
+	GlobalMetricsGathered = selfstat.Register("agent", "metrics_gathered", map[string]string{})
+	GlobalSeriesDropped   = selfstat.Register("agent", "series_dropped", map[string]string{})
+)
 
 type RunningInput struct {
+	// Must be 64-bit aligned
+	gatherStart int64
+
 	Input  telegraf.Input
 	Config *InputConfig
 
 	log         telegraf.Logger
 	defaultTags map[string]string
+	// source is the source of the plugin table in the configuration file,
+	// to detect the inputs changed by a reload.
+	source string
+
+	// series holds the series seen during the current interval, it is only
+	// used when MaxSeries is set.
+	seriesMu      sync.Mutex
+	series        map[uint64]struct{}
+	seriesDropped int
+
+	// gathering serializes the Gather calls, which may be triggered outside
+	// of the interval through the control API.  It holds a value while a
+	// call is running.
+	gathering chan struct{}
+
+	// batch holds copies of the metrics of the running gather, and lastBatch
+	// those of the previous gather, when recording is enabled for snapshots.
+	batchMu       sync.Mutex
+	recording     bool
+	batch         []telegraf.Metric
+	lastBatch     []telegraf.Metric
+	lastBatchTime time.Time
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+	GatherTimeouts  selfstat.Stat
+	SeriesDropped   selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -27,8 +68,9 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 	}
 
 	logger := &Logger{
-		Name: logName("inputs", config.Name, config.Alias),
-		Errs: selfstat.Register("gather", "errors", tags),
+		Name:  logName("inputs", config.Name, config.Alias),
+		Errs:  selfstat.Register("gather", "errors", tags),
+		Level: logLevel(config.LogLevel),
 	}
 	setLogIfExist(input, logger)
 
@@ -45,15 +87,39 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"gather_time_ns",
 			tags,
 		),
-		log: logger,
+		GatherTimeouts: selfstat.Register(
+			"gather",
+			"gather_timeouts",
+			tags,
+		),
+		SeriesDropped: selfstat.Register(
+			"gather",
+			"series_dropped",
+			tags,
+		),
+		series:    make(map[uint64]struct{}),
+		gathering: make(chan struct{}, 1),
+		log:       logger,
 	}
 }
 
 // InputConfig is the common config for all inputs.
 type InputConfig struct {
-	Name     string
-	Alias    string
-	Interval time.Duration
+	Name             string
+	Alias            string
+	LogLevel         string
+	Interval         time.Duration
+	CollectionJitter time.Duration
+	Precision        time.Duration
+	GatherTimeout    time.Duration
+
+	// RoundInterval overrides the agent round_interval when not nil.
+	RoundInterval *bool
+
+	// MaxSeries is the maximum number of distinct series the input may emit
+	// per interval, metrics of further series are dropped.  Disabled when
+	// zero.
+	MaxSeries int
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -62,6 +128,16 @@ type InputConfig struct {
 	Filter            Filter
 }
 
+// SameConfig returns whether the input is configured like other, so that a
+// reload can keep it running in place of other.
+func (r *RunningInput) SameConfig(other *RunningInput) bool {
+	return r.Config.Name == other.Config.Name &&
+		r.Config.Alias == other.Config.Alias &&
+		r.source == other.source &&
+		r.Config.MaxSeries == other.Config.MaxSeries &&
+		reflect.DeepEqual(r.defaultTags, other.defaultTags)
+}
+
 func (r *RunningInput) metricFiltered(metric telegraf.Metric) {
 	metric.Drop()
 }
@@ -70,6 +146,10 @@ func (r *RunningInput) LogName() string {
 	return logName("inputs", r.Config.Name, r.Config.Alias)
 }
 
+func (r *RunningInput) Log() telegraf.Logger {
+	return r.log
+}
+
 func (r *RunningInput) Init() error {
 	if p, ok := r.Input.(telegraf.Initializer); ok {
 		err := p.Init()
@@ -100,19 +180,192 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	if !r.admitSeries(m) {
+		r.metricFiltered(m)
+		return nil
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
+	r.recordMetric(m)
 	return m
 }
 
+// RecordBatches enables keeping a copy of the metrics of the last gather,
+// returned by LastBatch.
+func (r *RunningInput) RecordBatches() {
+	r.batchMu.Lock()
+	r.recording = true
+	r.batchMu.Unlock()
+}
+
+// LastBatch returns the time and the metrics of the last completed gather
+// when recording is enabled.  For service inputs, the batch holds the
+// metrics added between the last two gathers.  The metrics must not be
+// modified.
+func (r *RunningInput) LastBatch() (time.Time, []telegraf.Metric) {
+	r.batchMu.Lock()
+	defer r.batchMu.Unlock()
+	return r.lastBatchTime, append([]telegraf.Metric(nil), r.lastBatch...)
+}
+
+func (r *RunningInput) recordMetric(m telegraf.Metric) {
+	r.batchMu.Lock()
+	defer r.batchMu.Unlock()
+	if r.recording && len(r.batch) < maxRecordedMetrics {
+		r.batch = append(r.batch, m.Copy())
+	}
+}
+
+// rotateBatch completes the batch of the gather started at the given time.
+func (r *RunningInput) rotateBatch(start time.Time) {
+	r.batchMu.Lock()
+	defer r.batchMu.Unlock()
+	if !r.recording {
+		return
+	}
+
+	for _, m := range r.lastBatch {
+		m.Drop()
+	}
+	r.lastBatch = r.batch
+	r.lastBatchTime = start
+	r.batch = nil
+}
+
+// admitSeries reports if the series of the metric is within the MaxSeries
+// limit for the current interval.
+func (r *RunningInput) admitSeries(metric telegraf.Metric) bool {
+	if r.Config.MaxSeries <= 0 {
+		return true
+	}
+
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+
+	id := metric.HashID()
+	if _, ok := r.series[id]; ok {
+		return true
+	}
+	if len(r.series) < r.Config.MaxSeries {
+		r.series[id] = struct{}{}
+		return true
+	}
+
+	r.seriesDropped++
+	r.SeriesDropped.Incr(1)
+	GlobalSeriesDropped.Incr(1)
+	return false
+}
+
+// resetSeries starts a new interval for the MaxSeries limit.
+func (r *RunningInput) resetSeries() {
+	if r.Config.MaxSeries <= 0 {
+		return
+	}
+
+	r.seriesMu.Lock()
+	defer r.seriesMu.Unlock()
+
+	if r.seriesDropped > 0 {
+		r.log.Warnf("Dropped %d metrics exceeding the series limit of %d",
+			r.seriesDropped, r.Config.MaxSeries)
+	}
+	r.series = make(map[uint64]struct{}, len(r.series))
+	r.seriesDropped = 0
+}
+
+// Gather runs the Gather function of the input, once the running call, if
+// any, returns.
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	r.gathering <- struct{}{}
+	defer func() { <-r.gathering }()
+	return r.gather(acc)
+}
+
+// TryGather runs the Gather function of the input, or returns
+// ErrGatherRunning without waiting if a call is running.
+func (r *RunningInput) TryGather(acc telegraf.Accumulator) error {
+	select {
+	case r.gathering <- struct{}{}:
+	default:
+		return ErrGatherRunning
+	}
+	defer func() { <-r.gathering }()
+	return r.gather(acc)
+}
+
+func (r *RunningInput) gather(acc telegraf.Accumulator) error {
+	r.resetSeries()
+
 	start := time.Now()
+	atomic.StoreInt64(&r.gatherStart, start.UnixNano())
 	err := r.Input.Gather(acc)
+	atomic.StoreInt64(&r.gatherStart, 0)
+	r.rotateBatch(start)
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
 	return err
 }
 
+// GatherStarted returns the start time of the running Gather call, or the
+// zero time if the input is not gathering.
+func (r *RunningInput) GatherStarted() time.Time {
+	ts := atomic.LoadInt64(&r.gatherStart)
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ts)
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
+
+// SetSource sets the source of the configuration of the input, see
+// SameConfig.
+func (r *RunningInput) SetSource(source string) {
+	r.source = source
+}
+
+// SetState hands the state store to the input if it defines a State field of
+// type telegraf.StateStore.
+func (r *RunningInput) SetState(store telegraf.StateStore) {
+	valI := reflect.ValueOf(r.Input)
+	if valI.Type().Kind() != reflect.Ptr {
+		return
+	}
+
+	field := valI.Elem().FieldByName("State")
+	if !field.IsValid() {
+		return
+	}
+
+	switch field.Type().String() {
+	case "telegraf.StateStore":
+		if field.CanSet() {
+			field.Set(reflect.ValueOf(store))
+		}
+	}
+}
+
+// SetCache hands the cache to the input if it defines a Cache field of type
+// telegraf.Cache.
+func (r *RunningInput) SetCache(cache telegraf.Cache) {
+	valI := reflect.ValueOf(r.Input)
+	if valI.Type().Kind() != reflect.Ptr {
+		return
+	}
+
+	field := valI.Elem().FieldByName("Cache")
+	if !field.IsValid() {
+		return
+	}
+
+	switch field.Type().String() {
+	case "telegraf.Cache":
+		if field.CanSet() {
+			field.Set(reflect.ValueOf(cache))
+		}
+	}
+}
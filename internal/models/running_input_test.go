@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/cache"
+	"github.com/influxdata/telegraf/internal/state"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
@@ -256,8 +258,105 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricMaxSeries(t *testing.T) {
+	now := time.Now()
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:      "TestRunningInput",
+		MaxSeries: 2,
+	})
+	ri.log = testutil.Logger{}
+
+	newMetric := func(host string) telegraf.Metric {
+		m, err := metric.New("cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": 42},
+			now)
+		require.NoError(t, err)
+		return m
+	}
+
+	require.NotNil(t, ri.MakeMetric(newMetric("a")))
+	require.NotNil(t, ri.MakeMetric(newMetric("b")))
+	require.Nil(t, ri.MakeMetric(newMetric("c")))
+	require.NotNil(t, ri.MakeMetric(newMetric("a")))
+	require.Equal(t, int64(1), ri.SeriesDropped.Get())
+
+	// A new interval starts counting the series again.
+	require.NoError(t, ri.Gather(&testutil.Accumulator{}))
+	require.NotNil(t, ri.MakeMetric(newMetric("c")))
+	require.NotNil(t, ri.MakeMetric(newMetric("d")))
+	require.Nil(t, ri.MakeMetric(newMetric("a")))
+	require.Equal(t, int64(2), ri.SeriesDropped.Get())
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
 func (t *testInput) SampleConfig() string                  { return "" }
 func (t *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type statefulInput struct {
+	State telegraf.StateStore
+}
+
+func (t *statefulInput) Description() string                   { return "" }
+func (t *statefulInput) SampleConfig() string                  { return "" }
+func (t *statefulInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func TestSetState(t *testing.T) {
+	input := &statefulInput{}
+	ri := NewRunningInput(input, &InputConfig{Name: "TestRunningInput"})
+
+	store := state.New().Scope(ri.LogName())
+	ri.SetState(store)
+	require.Equal(t, store, input.State)
+
+	// Inputs without a State field are left alone.
+	NewRunningInput(&testInput{}, &InputConfig{}).SetState(store)
+}
+
+type cachingInput struct {
+	Cache telegraf.Cache
+}
+
+func (t *cachingInput) Description() string                   { return "" }
+func (t *cachingInput) SampleConfig() string                  { return "" }
+func (t *cachingInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func TestSetCache(t *testing.T) {
+	input := &cachingInput{}
+	ri := NewRunningInput(input, &InputConfig{Name: "TestRunningInput"})
+
+	c := cache.New(time.Second)
+	ri.SetCache(c)
+	require.Equal(t, c, input.Cache)
+
+	// Inputs without a Cache field are left alone.
+	NewRunningInput(&testInput{}, &InputConfig{}).SetCache(c)
+}
+
+func TestLastBatch(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{Name: "TestRunningInput"})
+	m := testutil.MustMetric("cpu", nil, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+
+	// Nothing is recorded unless enabled.
+	ri.MakeMetric(m.Copy())
+	require.NoError(t, ri.Gather(nil))
+	_, batch := ri.LastBatch()
+	require.Empty(t, batch)
+
+	ri.RecordBatches()
+	ri.MakeMetric(m.Copy())
+	before := time.Now()
+	require.NoError(t, ri.Gather(nil))
+	ts, batch := ri.LastBatch()
+	require.False(t, ts.Before(before))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, batch)
+
+	// The metrics are copies, modifying the gathered metric has no effect.
+	gathered := ri.MakeMetric(m.Copy())
+	gathered.AddField("value", 0)
+	require.NoError(t, ri.Gather(nil))
+	_, batch = ri.LastBatch()
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, batch)
+}
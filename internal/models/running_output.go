@@ -1,11 +1,15 @@
 package models
 
 import (
+	"fmt"
+	"math/rand"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -15,18 +19,38 @@ const (
 
 	// Default number of metrics kept. It should be a multiple of batch size.
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
+
+	// Default maximum retry backoff, as a multiple of the initial backoff.
+	defaultRetryBackoffFactor = 10
 )
 
 // OutputConfig containing name and filter
 type OutputConfig struct {
-	Name   string
-	Alias  string
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel string
+	Filter   Filter
 
 	FlushInterval     time.Duration
 	FlushJitter       *time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	// FloatPrecision is the number of significant digits float fields are
+	// rounded to, disabled when zero.
+	FloatPrecision int
+
+	// MaxConcurrentWrites is the maximum number of batches written at the
+	// same time, the output must support concurrent calls to Write.
+	MaxConcurrentWrites int
+	// MaxWriteRate is the maximum number of writes per second, unlimited when
+	// zero.
+	MaxWriteRate float64
+	// RetryBackoff is the delay before retrying after a failed write, doubled
+	// on each consecutive failure up to RetryBackoffMax.  When zero failed
+	// writes are retried on the next flush.
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
 }
 
 // RunningOutput contains the output configuration
@@ -34,21 +58,32 @@ type RunningOutput struct {
 	// Must be 64-bit aligned
 	newMetricsCount int64
 	droppedMetrics  int64
+	lastFlush       int64
 
 	Output            telegraf.Output
 	Config            *OutputConfig
 	MetricBufferLimit int
 	MetricBatchSize   int
 
+	// Processors are applied to the metrics of this output only, before its
+	// filters.
+	Processors RunningProcessors
+
 	MetricsFiltered selfstat.Stat
 	WriteTime       selfstat.Stat
+	WriteErrors     selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
-	log    telegraf.Logger
+	buffer  *Buffer
+	log     telegraf.Logger
+	limiter *rateLimiter
 
 	aggMutex sync.Mutex
+
+	backoffMutex sync.Mutex
+	failures     int
+	retryAt      time.Time
 }
 
 func NewRunningOutput(
@@ -64,8 +99,9 @@ func NewRunningOutput(
 	}
 
 	logger := &Logger{
-		Name: logName("outputs", config.Name, config.Alias),
-		Errs: selfstat.Register("write", "errors", tags),
+		Name:  logName("outputs", config.Name, config.Alias),
+		Errs:  selfstat.Register("write", "errors", tags),
+		Level: logLevel(config.LogLevel),
 	}
 	setLogIfExist(output, logger)
 
@@ -81,6 +117,15 @@ func NewRunningOutput(
 	if batchSize == 0 {
 		batchSize = DEFAULT_METRIC_BATCH_SIZE
 	}
+	if config.MaxConcurrentWrites < 1 {
+		config.MaxConcurrentWrites = 1
+	}
+	if config.RetryBackoffMax == 0 {
+		config.RetryBackoffMax = defaultRetryBackoffFactor * config.RetryBackoff
+	}
+	if config.RetryBackoffMax < config.RetryBackoff {
+		config.RetryBackoffMax = config.RetryBackoff
+	}
 
 	ro := &RunningOutput{
 		buffer:            NewBuffer(config.Name, config.Alias, bufferLimit),
@@ -99,8 +144,16 @@ func NewRunningOutput(
 			"write_time_ns",
 			tags,
 		),
+		WriteErrors: selfstat.Register(
+			"write",
+			"write_errors",
+			tags,
+		),
 		log: logger,
 	}
+	if config.MaxWriteRate > 0 {
+		ro.limiter = newRateLimiter(config.MaxWriteRate)
+	}
 
 	return ro
 }
@@ -109,12 +162,22 @@ func (r *RunningOutput) LogName() string {
 	return logName("outputs", r.Config.Name, r.Config.Alias)
 }
 
+func (r *RunningOutput) Log() telegraf.Logger {
+	return r.log
+}
+
 func (ro *RunningOutput) metricFiltered(metric telegraf.Metric) {
 	ro.MetricsFiltered.Incr(1)
 	metric.Drop()
 }
 
 func (r *RunningOutput) Init() error {
+	for _, processor := range r.Processors {
+		err := processor.Init()
+		if err != nil {
+			return fmt.Errorf("processor %s: %v", processor.Config.Name, err)
+		}
+	}
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -125,10 +188,35 @@ func (r *RunningOutput) Init() error {
 	return nil
 }
 
-// AddMetric adds a metric to the output.
+// AddMetric adds a metric to the output, once applied the processors of the
+// output.
 //
 // Takes ownership of metric
 func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
+	if len(ro.Processors) == 0 {
+		ro.addMetric(metric)
+		return
+	}
+
+	metrics := []telegraf.Metric{metric}
+	for _, processor := range ro.Processors {
+		metrics = processor.Apply(metrics...)
+	}
+	for _, m := range metrics {
+		ro.addMetric(m)
+	}
+}
+
+// FlushProcessors adds the metrics held back by the processors of the
+// output.  It is called once no more metrics are added, before the last
+// write.
+func (ro *RunningOutput) FlushProcessors() {
+	for _, m := range ro.Processors.Flush() {
+		ro.addMetric(m)
+	}
+}
+
+func (ro *RunningOutput) addMetric(metric telegraf.Metric) {
 	if ok := ro.Config.Filter.Select(metric); !ok {
 		ro.metricFiltered(metric)
 		return
@@ -140,6 +228,10 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
+	if ro.Config.FloatPrecision > 0 {
+		roundFloats(metric, ro.Config.FloatPrecision)
+	}
+
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		output.Add(metric)
@@ -173,48 +265,205 @@ func (ro *RunningOutput) Write() error {
 
 	atomic.StoreInt64(&ro.newMetricsCount, 0)
 
+	if ro.backingOff() {
+		return nil
+	}
+
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
+	concurrency := ro.Config.MaxConcurrentWrites
 	nBuffer := ro.buffer.Len()
 	nBatches := nBuffer/ro.MetricBatchSize + 1
-	for i := 0; i < nBatches; i++ {
-		batch := ro.buffer.Batch(ro.MetricBatchSize)
+	for i := 0; i < nBatches; i += concurrency {
+		batch := ro.buffer.Batch(ro.MetricBatchSize * concurrency)
 		if len(batch) == 0 {
 			break
 		}
 
-		err := ro.write(batch)
+		err := ro.writeBatch(batch)
 		if err != nil {
-			ro.buffer.Reject(batch)
 			return err
 		}
-		ro.buffer.Accept(batch)
 	}
+
+	atomic.StoreInt64(&ro.lastFlush, time.Now().UnixNano())
 	return nil
 }
 
+// LastFlush returns the time of the last flush that completed without error,
+// or the zero time if there was none.
+func (ro *RunningOutput) LastFlush() time.Time {
+	ts := atomic.LoadInt64(&ro.lastFlush)
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ts)
+}
+
 // WriteBatch writes a single batch of metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
+	if ro.backingOff() {
+		return nil
+	}
+
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
 	}
 
-	err := ro.write(batch)
+	return ro.writeBatch(batch)
+}
+
+// writeBatch writes a batch taken from the buffer, split in up to
+// MaxConcurrentWrites batches of MetricBatchSize written concurrently.  Only
+// the metrics that were not written are returned to the buffer.
+func (ro *RunningOutput) writeBatch(batch []telegraf.Metric) error {
+	if len(batch) <= ro.MetricBatchSize {
+		err := ro.write(batch)
+		if err != nil {
+			accepted, rejected := splitWritten(batch, err)
+			ro.buffer.Settle(accepted, rejected)
+			if len(rejected) == 0 {
+				ro.writeSucceeded()
+				return nil
+			}
+			ro.writeFailed()
+			return err
+		}
+		ro.buffer.Accept(batch)
+		ro.writeSucceeded()
+		return nil
+	}
+
+	var chunks [][]telegraf.Metric
+	for len(batch) > 0 {
+		n := min(len(batch), ro.MetricBatchSize)
+		chunks = append(chunks, batch[:n])
+		batch = batch[n:]
+	}
+
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk []telegraf.Metric) {
+			defer wg.Done()
+			errs[i] = ro.write(chunk)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	var accepted, rejected []telegraf.Metric
+	var err error
+	for i, chunk := range chunks {
+		if errs[i] == nil {
+			accepted = append(accepted, chunk...)
+			continue
+		}
+		a, r := splitWritten(chunk, errs[i])
+		accepted = append(accepted, a...)
+		rejected = append(rejected, r...)
+		if len(r) > 0 && err == nil {
+			err = errs[i]
+		}
+	}
+	ro.buffer.Settle(accepted, rejected)
+
 	if err != nil {
-		ro.buffer.Reject(batch)
+		ro.writeFailed()
 		return err
 	}
-	ro.buffer.Accept(batch)
-
+	ro.writeSucceeded()
 	return nil
 }
 
+// splitWritten splits the metrics of a failed write in the ones the output
+// acknowledged with a PartialWriteError and the ones to retry, keeping their
+// order.
+func splitWritten(metrics []telegraf.Metric, err error) (accepted, rejected []telegraf.Metric) {
+	partial, ok := err.(*internal.PartialWriteError)
+	if !ok {
+		return nil, metrics
+	}
+
+	written := make([]bool, len(metrics))
+	for _, i := range partial.MetricsAccept {
+		if i >= 0 && i < len(metrics) {
+			written[i] = true
+		}
+	}
+	for i, m := range metrics {
+		if written[i] {
+			accepted = append(accepted, m)
+		} else {
+			rejected = append(rejected, m)
+		}
+	}
+	return accepted, rejected
+}
+
+// backingOff returns true if writes are delayed after a failed write.
+func (ro *RunningOutput) backingOff() bool {
+	ro.backoffMutex.Lock()
+	defer ro.backoffMutex.Unlock()
+
+	wait := time.Until(ro.retryAt)
+	if wait <= 0 {
+		return false
+	}
+	ro.log.Debugf("Write failed %d times, retrying in %s", ro.failures, wait.Round(time.Millisecond))
+	return true
+}
+
+// ResetBackoff allows writing immediately, even after a failed write.
+func (ro *RunningOutput) ResetBackoff() {
+	ro.backoffMutex.Lock()
+	defer ro.backoffMutex.Unlock()
+
+	ro.retryAt = time.Time{}
+}
+
+func (ro *RunningOutput) writeSucceeded() {
+	ro.backoffMutex.Lock()
+	defer ro.backoffMutex.Unlock()
+
+	ro.failures = 0
+	ro.retryAt = time.Time{}
+}
+
+// writeFailed delays the next write by the exponential backoff with jitter,
+// so that outputs recovering from an outage are not written to all at once.
+func (ro *RunningOutput) writeFailed() {
+	if ro.Config.RetryBackoff <= 0 {
+		return
+	}
+
+	ro.backoffMutex.Lock()
+	defer ro.backoffMutex.Unlock()
+
+	ro.failures++
+	delay := ro.Config.RetryBackoff
+	for i := 1; i < ro.failures && delay < ro.Config.RetryBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > ro.Config.RetryBackoffMax {
+		delay = ro.Config.RetryBackoffMax
+	}
+
+	// Wait between half and all of the delay.
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	ro.retryAt = time.Now().Add(delay)
+}
+
+// Close closes the output and stops its processors.
 func (r *RunningOutput) Close() {
 	err := r.Output.Close()
 	if err != nil {
 		r.log.Errorf("Error closing output: %v", err)
 	}
+	for _, processor := range r.Processors {
+		processor.Stop()
+	}
 }
 
 func (r *RunningOutput) write(metrics []telegraf.Metric) error {
@@ -224,18 +473,103 @@ func (r *RunningOutput) write(metrics []telegraf.Metric) error {
 		atomic.StoreInt64(&r.droppedMetrics, 0)
 	}
 
+	if r.limiter != nil {
+		r.limiter.Wait()
+	}
+
 	start := time.Now()
 	err := r.Output.Write(metrics)
 	elapsed := time.Since(start)
 	r.WriteTime.Incr(elapsed.Nanoseconds())
 
-	if err == nil {
-		r.log.Debugf("Wrote batch of %d metrics in %s", len(metrics), elapsed)
+	if err != nil {
+		r.WriteErrors.Incr(1)
+		if partial, ok := err.(*internal.PartialWriteError); ok {
+			r.log.Debugf("Wrote %d of %d metrics in %s", len(partial.MetricsAccept), len(metrics), elapsed)
+		}
+		return err
 	}
-	return err
+
+	r.log.Debugf("Wrote batch of %d metrics in %s", len(metrics), elapsed)
+	return nil
 }
 
 func (r *RunningOutput) LogBufferStatus() {
 	nBuffer := r.buffer.Len()
 	r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
 }
+
+// BufferLength returns the number of metrics waiting in the buffer.
+func (r *RunningOutput) BufferLength() int {
+	return r.buffer.Len()
+}
+
+// BufferedMetrics returns the metrics waiting in the buffer, oldest first,
+// without removing them.  The metrics must not be modified.
+func (r *RunningOutput) BufferedMetrics() []telegraf.Metric {
+	return r.buffer.Snapshot()
+}
+
+// TakeMetrics removes and returns the metrics waiting in the buffer, oldest
+// first.  It is used to carry unsent metrics over to a new output when the
+// configuration is reloaded, and must only be called once the output is no
+// longer written to.
+func (r *RunningOutput) TakeMetrics() []telegraf.Metric {
+	return r.buffer.Drain()
+}
+
+// RestoreMetrics adds metrics taken from a previous output to the buffer,
+// bypassing the filters and aggregation already applied to them.  Returns the
+// number of metrics dropped because the buffer is full.
+func (r *RunningOutput) RestoreMetrics(metrics []telegraf.Metric) int {
+	dropped := r.buffer.Add(metrics...)
+	if r.buffer.Len() >= r.MetricBatchSize {
+		select {
+		case r.BatchReady <- time.Now():
+		default:
+		}
+	}
+	return dropped
+}
+
+// roundFloats rounds the float fields of the metric to the number of
+// significant digits.
+func roundFloats(metric telegraf.Metric, digits int) {
+	for _, field := range metric.FieldList() {
+		v, ok := field.Value.(float64)
+		if !ok {
+			continue
+		}
+		rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', digits, 64), 64)
+		if err == nil {
+			field.Value = rounded
+		}
+	}
+}
+
+// rateLimiter spaces calls to Wait to not exceed a rate per second.
+type rateLimiter struct {
+	sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / rate),
+	}
+}
+
+// Wait blocks until the next call is allowed.
+func (r *rateLimiter) Wait() {
+	r.Lock()
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	wait := r.next.Sub(now)
+	r.next = r.next.Add(r.interval)
+	r.Unlock()
+
+	time.Sleep(wait)
+}
@@ -3,9 +3,12 @@ package models
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -216,6 +219,102 @@ func TestRunningOutput_TagIncludeMatch(t *testing.T) {
 	assert.Len(t, m.Metrics()[0].Tags(), 1)
 }
 
+// Test that the processors of the output are applied before its filters
+func TestRunningOutput_Processors(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			TagInclude: []string{"route"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	ro.Processors = RunningProcessors{
+		NewRunningProcessor(TagProcessor("route", "local"), &ProcessorConfig{Name: "tag"}),
+	}
+	assert.NoError(t, ro.Init())
+
+	ro.AddMetric(testutil.TestMetric(101, "metric1"))
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	assert.Len(t, m.Metrics(), 1)
+	assert.Equal(t, map[string]string{"route": "local"}, m.Metrics()[0].Tags())
+}
+
+// Test that fields are properly passed
+func TestRunningOutput_FieldPassMatch(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			FieldPass: []string{"re:val.*"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	ro.AddMetric(testutil.TestMetric(101, "metric1"))
+	assert.Len(t, m.Metrics(), 0)
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	assert.Len(t, m.Metrics(), 1)
+	assert.Len(t, m.Metrics()[0].FieldList(), 1)
+}
+
+// Test that metrics without fields are dropped
+func TestRunningOutput_FieldDropMatch(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			FieldDrop: []string{"value"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	ro.AddMetric(testutil.TestMetric(101, "metric1"))
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	assert.Len(t, m.Metrics(), 0)
+}
+
+// Test that float fields are rounded
+func TestRunningOutput_FloatPrecision(t *testing.T) {
+	conf := &OutputConfig{
+		FloatPrecision: 3,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	ro.AddMetric(testutil.MustMetric("iostat",
+		map[string]string{},
+		map[string]interface{}{
+			"util":   12.3456789,
+			"await":  0.000123456,
+			"reads":  int64(123456),
+			"large":  123456.789,
+			"device": "sda",
+		},
+		time.Unix(0, 0)))
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	require.Len(t, m.Metrics(), 1)
+	assert.Equal(t, map[string]interface{}{
+		"util":   12.3,
+		"await":  0.000123,
+		"reads":  int64(123456),
+		"large":  123000.0,
+		"device": "sda",
+	}, m.Metrics()[0].Fields())
+}
+
 // Test that we can write metrics with simple default setup.
 func TestRunningOutputDefault(t *testing.T) {
 	conf := &OutputConfig{
@@ -270,6 +369,26 @@ func TestRunningOutputWriteFail(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
+func TestRunningOutputWriteErrors(t *testing.T) {
+	conf := &OutputConfig{
+		Name:   "write_errors",
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+
+	ro.AddMetric(first5[0])
+	require.Error(t, ro.Write())
+	require.Error(t, ro.Write())
+	assert.Equal(t, int64(2), ro.WriteErrors.Get())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Equal(t, int64(2), ro.WriteErrors.Get())
+}
+
 // Verify that the order of points is preserved during a write failure.
 func TestRunningOutputWriteFailOrder(t *testing.T) {
 	conf := &OutputConfig{
@@ -412,6 +531,196 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Test that batches are written concurrently up to the limit.
+func TestRunningOutputConcurrentWrites(t *testing.T) {
+	conf := &OutputConfig{
+		MaxConcurrentWrites: 3,
+	}
+
+	m := &concurrentOutput{}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+
+	require.NoError(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+	require.Equal(t, int64(5), atomic.LoadInt64(&m.writes))
+	require.Equal(t, int64(10), atomic.LoadInt64(&m.written))
+	require.True(t, atomic.LoadInt64(&m.maxActive) <= 3)
+}
+
+// Test that only the failed batches of concurrent writes are kept.
+func TestRunningOutputConcurrentWritesFail(t *testing.T) {
+	conf := &OutputConfig{
+		MaxConcurrentWrites: 5,
+	}
+
+	m := &concurrentOutput{failName: "metric3"}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// metric3 is written in the batch with metric2
+	require.Error(t, ro.Write())
+	require.Equal(t, int64(3), atomic.LoadInt64(&m.written))
+	require.Equal(t, 2, ro.BufferLength())
+
+	m.failName = ""
+	require.NoError(t, ro.Write())
+	require.Equal(t, int64(5), atomic.LoadInt64(&m.written))
+	require.Equal(t, 0, ro.BufferLength())
+}
+
+// Test that writes are delayed after a failure.
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		RetryBackoff: time.Hour,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	require.Equal(t, 10*time.Hour, conf.RetryBackoffMax)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.NoError(t, ro.WriteBatch())
+	require.Len(t, m.Metrics(), 0)
+	require.Equal(t, 5, ro.BufferLength())
+
+	ro.ResetBackoff()
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 5)
+}
+
+// Test that the backoff delay grows exponentially up to the maximum.
+func TestRunningOutputRetryBackoffMax(t *testing.T) {
+	conf := &OutputConfig{
+		RetryBackoff:    time.Minute,
+		RetryBackoffMax: 3 * time.Minute,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		ro.writeFailed()
+		wait := time.Until(ro.retryAt)
+		require.True(t, wait <= expected)
+		require.True(t, wait >= expected/2-time.Second)
+	}
+
+	ro.writeSucceeded()
+	require.False(t, ro.backingOff())
+}
+
+// Test that writes are limited to the maximum rate.
+func TestRunningOutputMaxWriteRate(t *testing.T) {
+	conf := &OutputConfig{
+		MaxWriteRate: 20,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1, 10000)
+
+	for _, metric := range first5[:3] {
+		ro.AddMetric(metric)
+	}
+
+	start := time.Now()
+	require.NoError(t, ro.Write())
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+	require.Len(t, m.Metrics(), 3)
+}
+
+// Test that only the metrics not acknowledged by a partial write are retried.
+func TestRunningOutputPartialWrite(t *testing.T) {
+	conf := &OutputConfig{}
+
+	m := &partialOutput{failNames: map[string]bool{"metric2": true, "metric4": true}}
+	ro := NewRunningOutput("test", m, conf, 10, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	err := ro.Write()
+	require.Error(t, err)
+	require.Len(t, m.Metrics(), 3)
+	require.Equal(t, 2, ro.BufferLength())
+
+	m.failNames = nil
+	require.NoError(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+
+	var names []string
+	for _, metric := range m.Metrics() {
+		names = append(names, metric.Name())
+	}
+	require.Equal(t, []string{"metric5", "metric3", "metric1", "metric4", "metric2"}, names)
+}
+
+// Test that a partial write acknowledging every metric is a success.
+func TestRunningOutputPartialWriteAll(t *testing.T) {
+	conf := &OutputConfig{
+		RetryBackoff: time.Hour,
+	}
+
+	m := &partialOutput{failNames: map[string]bool{}}
+	ro := NewRunningOutput("test", m, conf, 10, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.NoError(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+	require.False(t, ro.backingOff())
+}
+
+// Test that partial writes of concurrent batches keep the order of the
+// retried metrics.
+func TestRunningOutputPartialWriteConcurrent(t *testing.T) {
+	conf := &OutputConfig{
+		MaxConcurrentWrites: 5,
+	}
+
+	m := &partialOutput{failNames: map[string]bool{"metric3": true, "metric8": true}}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Len(t, m.Metrics(), 8)
+	require.Equal(t, 2, ro.BufferLength())
+
+	m.failNames = nil
+	require.NoError(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+
+	metrics := m.Metrics()
+	require.Len(t, metrics, 10)
+	require.Equal(t, "metric8", metrics[8].Name())
+	require.Equal(t, "metric3", metrics[9].Name())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -487,3 +796,104 @@ func (m *perfOutput) Write(metrics []telegraf.Metric) error {
 	}
 	return nil
 }
+
+// concurrentOutput records the number of concurrent writes, and fails the
+// writes of batches containing a metric named failName.
+type concurrentOutput struct {
+	writes    int64
+	written   int64
+	active    int64
+	maxActive int64
+
+	failName string
+}
+
+func (m *concurrentOutput) Connect() error {
+	return nil
+}
+
+func (m *concurrentOutput) Close() error {
+	return nil
+}
+
+func (m *concurrentOutput) Description() string {
+	return ""
+}
+
+func (m *concurrentOutput) SampleConfig() string {
+	return ""
+}
+
+func (m *concurrentOutput) Write(metrics []telegraf.Metric) error {
+	active := atomic.AddInt64(&m.active, 1)
+	defer atomic.AddInt64(&m.active, -1)
+	for {
+		max := atomic.LoadInt64(&m.maxActive)
+		if active <= max || atomic.CompareAndSwapInt64(&m.maxActive, max, active) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	atomic.AddInt64(&m.writes, 1)
+	for _, metric := range metrics {
+		if m.failName != "" && metric.Name() == m.failName {
+			return fmt.Errorf("failed write")
+		}
+	}
+	atomic.AddInt64(&m.written, int64(len(metrics)))
+	return nil
+}
+
+// partialOutput writes all metrics except the ones named in failNames, and
+// reports the written ones with a PartialWriteError.
+type partialOutput struct {
+	sync.Mutex
+
+	metrics []telegraf.Metric
+
+	failNames map[string]bool
+}
+
+func (m *partialOutput) Connect() error {
+	return nil
+}
+
+func (m *partialOutput) Close() error {
+	return nil
+}
+
+func (m *partialOutput) Description() string {
+	return ""
+}
+
+func (m *partialOutput) SampleConfig() string {
+	return ""
+}
+
+func (m *partialOutput) Write(metrics []telegraf.Metric) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failNames == nil {
+		m.metrics = append(m.metrics, metrics...)
+		return nil
+	}
+
+	partial := &internal.PartialWriteError{}
+	for i, metric := range metrics {
+		if m.failNames[metric.Name()] {
+			partial.Err = fmt.Errorf("failed to write %s", metric.Name())
+			continue
+		}
+		m.metrics = append(m.metrics, metric)
+		partial.MetricsAccept = append(partial.MetricsAccept, i)
+	}
+	return partial
+}
+
+func (m *partialOutput) Metrics() []telegraf.Metric {
+	m.Lock()
+	defer m.Unlock()
+	return m.metrics
+}
@@ -22,10 +22,11 @@ func (rp RunningProcessors) Less(i, j int) bool { return rp[i].Config.Order < rp
 
 // FilterConfig containing a name and filter
 type ProcessorConfig struct {
-	Name   string
-	Alias  string
-	Order  int64
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel string
+	Order    int64
+	Filter   Filter
 }
 
 func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig) *RunningProcessor {
@@ -35,8 +36,9 @@ func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig)
 	}
 
 	logger := &Logger{
-		Name: logName("processors", config.Name, config.Alias),
-		Errs: selfstat.Register("process", "errors", tags),
+		Name:  logName("processors", config.Name, config.Alias),
+		Errs:  selfstat.Register("process", "errors", tags),
+		Level: logLevel(config.LogLevel),
 	}
 	setLogIfExist(processor, logger)
 
@@ -47,6 +49,14 @@ func NewRunningProcessor(processor telegraf.Processor, config *ProcessorConfig)
 	}
 }
 
+func (rp *RunningProcessor) LogName() string {
+	return logName("processors", rp.Config.Name, rp.Config.Alias)
+}
+
+func (rp *RunningProcessor) Log() telegraf.Logger {
+	return rp.log
+}
+
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
 	metric.Drop()
 }
@@ -97,3 +107,39 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 
 	return ret
 }
+
+// Flush returns the metrics held back by the processor, if any.
+func (rp *RunningProcessor) Flush() []telegraf.Metric {
+	if p, ok := rp.Processor.(telegraf.FlushingProcessor); ok {
+		rp.Lock()
+		defer rp.Unlock()
+		return p.Flush()
+	}
+	return nil
+}
+
+// Flush returns the metrics held back by the processors, each applied the
+// processors following the one flushing it.
+func (rp RunningProcessors) Flush() []telegraf.Metric {
+	var flushed []telegraf.Metric
+	for i, processor := range rp {
+		metrics := processor.Flush()
+		for _, next := range rp[i+1:] {
+			if len(metrics) == 0 {
+				break
+			}
+			metrics = next.Apply(metrics...)
+		}
+		flushed = append(flushed, metrics...)
+	}
+	return flushed
+}
+
+// Stop stops the processor if it holds resources to release.
+func (rp *RunningProcessor) Stop() {
+	if p, ok := rp.Processor.(telegraf.StoppableProcessor); ok {
+		rp.Lock()
+		defer rp.Unlock()
+		p.Stop()
+	}
+}
@@ -28,6 +28,24 @@ func (p *MockProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	return p.ApplyF(in...)
 }
 
+// FlushingMockProcessor is a MockProcessor holding back the metrics
+// applied until it is flushed.
+type FlushingMockProcessor struct {
+	MockProcessor
+	held []telegraf.Metric
+}
+
+func (p *FlushingMockProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	p.held = append(p.held, in...)
+	return nil
+}
+
+func (p *FlushingMockProcessor) Flush() []telegraf.Metric {
+	held := p.held
+	p.held = nil
+	return held
+}
+
 // TagProcessor returns a Processor whose Apply function adds the tag and
 // value.
 func TagProcessor(key, value string) *MockProcessor {
@@ -187,3 +205,25 @@ func TestRunningProcessor_Order(t *testing.T) {
 		RunningProcessors{rp1, rp2, rp3},
 		procs)
 }
+
+func TestRunningProcessors_Flush(t *testing.T) {
+	processors := RunningProcessors{
+		NewRunningProcessor(TagProcessor("first", "true"), &ProcessorConfig{Name: "first"}),
+		NewRunningProcessor(&FlushingMockProcessor{}, &ProcessorConfig{Name: "flushing"}),
+		NewRunningProcessor(TagProcessor("last", "true"), &ProcessorConfig{Name: "last"}),
+	}
+
+	m := testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	metrics := []telegraf.Metric{m}
+	for _, processor := range processors {
+		metrics = processor.Apply(metrics...)
+	}
+	require.Len(t, metrics, 0)
+
+	// The flushed metrics are applied only the processors following the
+	// flushing one.
+	flushed := processors.Flush()
+	require.Len(t, flushed, 1)
+	require.Equal(t, map[string]string{"first": "true", "last": "true"}, flushed[0].Tags())
+	require.Len(t, processors.Flush(), 0)
+}
@@ -0,0 +1,243 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Process is a long-running command that is restarted with an increasing
+// delay whenever it exits until it is stopped.
+type Process struct {
+	// ReadStdoutFn and ReadStderrFn are called with the output streams of
+	// each run of the command and must consume them until EOF.
+	ReadStdoutFn func(io.Reader)
+	ReadStderrFn func(io.Reader)
+
+	// OnExitFn, if set, is called with the result every time the command
+	// exits unexpectedly.
+	OnExitFn func(err error)
+
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+	Log             telegraf.Logger
+
+	name string
+	args []string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	readWg sync.WaitGroup
+}
+
+// New creates a Process for the given command, the first element is the
+// program to run and the remaining ones its arguments.
+func New(command []string) (*Process, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command specified")
+	}
+
+	return &Process{
+		RestartDelay:    10 * time.Second,
+		MaxRestartDelay: 5 * time.Minute,
+		name:            command[0],
+		args:            command[1:],
+	}, nil
+}
+
+// Start runs the command and keeps restarting it in the background.  An
+// error is returned if the first run of the command could not be started.
+func (p *Process) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	if err := p.cmdStart(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.cmdLoop(ctx)
+	}()
+
+	return nil
+}
+
+// Stop kills the command and waits until the output streams are drained.
+func (p *Process) Stop() {
+	if p.cancel == nil {
+		return
+	}
+
+	p.cancel()
+	p.wg.Wait()
+}
+
+// Write writes b to the standard input of the running command.
+func (p *Process) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stdin == nil {
+		return 0, errors.New("process is not running")
+	}
+	return p.stdin.Write(b)
+}
+
+// Signal sends sig to the running command.
+func (p *Process) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil || p.cmd.Process == nil {
+		return errors.New("process is not running")
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// cmdStart starts the command and the goroutines reading its output.
+func (p *Process) cmdStart(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, p.name, p.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdin pipe: %s", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdout pipe: %s", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stderr pipe: %s", err)
+	}
+
+	if p.Log != nil {
+		p.Log.Debugf("Starting process: %s %s", p.name, p.args)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting process %s: %s", p.name, err)
+	}
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.stdin = stdin
+	p.mu.Unlock()
+
+	p.readWg.Add(2)
+	go func() {
+		defer p.readWg.Done()
+		p.readStream(stdout, p.ReadStdoutFn)
+	}()
+	go func() {
+		defer p.readWg.Done()
+		p.readStream(stderr, p.ReadStderrFn)
+	}()
+
+	return nil
+}
+
+// cmdLoop waits for the command to exit and restarts it until the process
+// is stopped.  The delay is doubled after each consecutive failure up to
+// MaxRestartDelay, and reset once the command stays up for longer than
+// MaxRestartDelay.
+func (p *Process) cmdLoop(ctx context.Context) {
+	delay := p.RestartDelay
+	for {
+		started := time.Now()
+
+		// All reads must be completed before calling Wait, which closes the
+		// pipes, unless the process is stopped; a child of the command may
+		// still hold the pipes open after the command is killed.
+		done := make(chan struct{})
+		go func() {
+			p.readWg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+
+		err := p.cmd.Wait()
+
+		p.mu.Lock()
+		p.stdin = nil
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			<-done
+			return
+		default:
+		}
+
+		if err == nil {
+			err = fmt.Errorf("process %s terminated", p.name)
+		} else {
+			err = fmt.Errorf("process %s terminated: %s", p.name, err)
+		}
+		if p.OnExitFn != nil {
+			p.OnExitFn(err)
+		}
+
+		if time.Since(started) > p.MaxRestartDelay {
+			delay = p.RestartDelay
+		}
+
+		if p.Log != nil {
+			p.Log.Infof("Restarting in %s...", delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > p.MaxRestartDelay {
+			delay = p.MaxRestartDelay
+		}
+
+		for {
+			err := p.cmdStart(ctx)
+			if err == nil {
+				break
+			}
+			if p.OnExitFn != nil {
+				p.OnExitFn(err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+func (p *Process) readStream(r io.Reader, fn func(io.Reader)) {
+	if fn == nil {
+		io.Copy(ioutil.Discard, r)
+		return
+	}
+	fn(r)
+}
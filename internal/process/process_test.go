@@ -0,0 +1,77 @@
+// +build !windows
+
+package process
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessWriteAndRead(t *testing.T) {
+	p, err := New([]string{"/bin/cat"})
+	require.NoError(t, err)
+	p.Log = testutil.Logger{}
+
+	lines := make(chan string, 1)
+	p.ReadStdoutFn = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}
+
+	require.NoError(t, p.Start())
+	defer p.Stop()
+
+	_, err = p.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", <-lines)
+}
+
+func TestProcessRestarts(t *testing.T) {
+	p, err := New([]string{"/bin/sh", "-c", "exit 1"})
+	require.NoError(t, err)
+	p.Log = testutil.Logger{}
+	p.RestartDelay = time.Millisecond
+	p.MaxRestartDelay = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	var errs []error
+	done := make(chan struct{})
+	p.OnExitFn = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+		if len(errs) == 3 {
+			close(done)
+		}
+	}
+
+	require.NoError(t, p.Start())
+	<-done
+	p.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, err := range errs {
+		require.Error(t, err)
+	}
+}
+
+func TestProcessStartInvalidCommand(t *testing.T) {
+	p, err := New([]string{"/nonexistent/command"})
+	require.NoError(t, err)
+	require.Error(t, p.Start())
+	p.Stop()
+}
+
+func TestNewWithoutCommand(t *testing.T) {
+	_, err := New(nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+// Package secretstore resolves the secrets referenced from the configuration
+// as @{store:key}, so that credentials do not need to be written in plain text
+// in the configuration files.
+package secretstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store retrieves secrets by key.
+type Store interface {
+	Get(key string) (string, error)
+}
+
+var (
+	mu     sync.Mutex
+	stores = map[string]Store{
+		"env":     envStore{},
+		"file":    fileStore{},
+		"systemd": systemdStore{},
+		"vault":   &vaultStore{},
+	}
+)
+
+// Add registers a store under the given name, replacing any store with the
+// same name.
+func Add(name string, store Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	stores[name] = store
+}
+
+// Get returns the secret stored under key in the named store.
+func Get(store, key string) (string, error) {
+	mu.Lock()
+	s, ok := stores[store]
+	mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("unknown secret store %q", store)
+	}
+
+	secret, err := s.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("error reading secret %q from %s store: %v", key, store, err)
+	}
+	return secret, nil
+}
+
+// envStore reads secrets from environment variables.
+type envStore struct{}
+
+func (envStore) Get(key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("environment variable not set")
+	}
+	return value, nil
+}
+
+// fileStore reads a secret from the file named by the key, a trailing
+// newline is removed.
+type fileStore struct{}
+
+func (fileStore) Get(key string) (string, error) {
+	return readSecretFile(key)
+}
+
+// systemdStore reads credentials passed by systemd with LoadCredential= or
+// SetCredential=, or decrypted by systemd-creds.
+type systemdStore struct{}
+
+func (systemdStore) Get(key string) (string, error) {
+	dir, ok := os.LookupEnv("CREDENTIALS_DIRECTORY")
+	if !ok {
+		return "", fmt.Errorf("CREDENTIALS_DIRECTORY not set, is the credential configured in the unit?")
+	}
+	if strings.ContainsRune(key, filepath.Separator) {
+		return "", fmt.Errorf("invalid credential name")
+	}
+	return readSecretFile(filepath.Join(dir, key))
+}
+
+func readSecretFile(path string) (string, error) {
+	octets, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(octets), "\r\n"), nil
+}
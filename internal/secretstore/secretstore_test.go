@@ -0,0 +1,92 @@
+package secretstore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnv(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_SECRET", "hunter2")
+	defer os.Unsetenv("TELEGRAF_TEST_SECRET")
+
+	secret, err := Get("env", "TELEGRAF_TEST_SECRET")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", secret)
+
+	_, err = Get("env", "TELEGRAF_TEST_MISSING")
+	require.Error(t, err)
+}
+
+func TestFileAndSystemd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secretstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "ceph_key")
+	require.NoError(t, ioutil.WriteFile(path, []byte("s3cret\n"), 0600))
+
+	secret, err := Get("file", path)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", secret)
+
+	_, err = Get("systemd", "ceph_key")
+	require.Error(t, err)
+
+	os.Setenv("CREDENTIALS_DIRECTORY", dir)
+	defer os.Unsetenv("CREDENTIALS_DIRECTORY")
+
+	secret, err = Get("systemd", "ceph_key")
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", secret)
+
+	_, err = Get("systemd", "../ceph_key")
+	require.Error(t, err)
+}
+
+func TestVault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/secret/data/kafka":
+			w.Write([]byte(`{"data": {"data": {"password": "kv2"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/kafka":
+			w.Write([]byte(`{"data": {"password": "kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	os.Setenv("VAULT_ADDR", ts.URL)
+	os.Setenv("VAULT_TOKEN", "token")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	secret, err := Get("vault", "secret/data/kafka#password")
+	require.NoError(t, err)
+	require.Equal(t, "kv2", secret)
+
+	secret, err = Get("vault", "kv/kafka#password")
+	require.NoError(t, err)
+	require.Equal(t, "kv1", secret)
+
+	_, err = Get("vault", "kv/kafka#username")
+	require.Error(t, err)
+
+	_, err = Get("vault", "kv/missing#password")
+	require.Error(t, err)
+
+	_, err = Get("vault", "kv/kafka")
+	require.Error(t, err)
+}
+
+func TestUnknownStore(t *testing.T) {
+	_, err := Get("keychain", "password")
+	require.Error(t, err)
+}
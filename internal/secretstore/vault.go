@@ -0,0 +1,82 @@
+package secretstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultStore reads secrets from HashiCorp Vault.  The server and token are
+// taken from the standard VAULT_ADDR and VAULT_TOKEN environment variables.
+//
+// Keys are in the form "path#field", for example "secret/data/kafka#password".
+// Both version 1 and version 2 of the key/value secrets engine are supported.
+type vaultStore struct {
+	client *http.Client
+}
+
+func (s *vaultStore) Get(key string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR not set")
+	}
+
+	i := strings.LastIndex(key, "#")
+	if i < 0 {
+		return "", fmt.Errorf("key must be in the form path#field")
+	}
+	path, field := strings.Trim(key[:i], "/"), key[i+1:]
+
+	req, err := http.NewRequest("GET", strings.TrimRight(addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	client := s.client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned HTTP status %s", req.URL, resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", err
+	}
+
+	// Version 2 of the key/value engine nests the secret in data.data.
+	data := secret.Data
+	if nested, ok := data["data"]; ok {
+		if _, ok := data["metadata"]; ok {
+			data = nil
+			if err := json.Unmarshal(nested, &data); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	raw, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found", field)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("field %q is not a string", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,152 @@
+// Package state implements the store persisting the state of plugins across
+// restarts of the agent.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Store holds the state of all plugins, keyed by plugin instance.  A store
+// with a path is loaded from and saved to that file as JSON, otherwise the
+// state only lives in memory.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	data  map[string]map[string]json.RawMessage
+	dirty bool
+}
+
+// New returns an empty store that is kept in memory only.
+func New() *Store {
+	return &Store{
+		data: make(map[string]map[string]json.RawMessage),
+	}
+}
+
+// Load returns the store saved to the file at path.  A missing file results
+// in an empty store that is created on the first save.
+func Load(path string) (*Store, error) {
+	s := New()
+	s.path = path
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buf) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(buf, &s.data); err != nil {
+		return nil, fmt.Errorf("error parsing state file %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Scope returns the view of the store for the named plugin instance.
+func (s *Store) Scope(name string) telegraf.StateStore {
+	return &scope{store: s, name: name}
+}
+
+// Save writes the store to its file if it changed since the last save.  The
+// file is replaced atomically so a crash never leaves a partial state.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" || !s.dirty {
+		return nil
+	}
+
+	buf, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.dirty = false
+	return nil
+}
+
+type scope struct {
+	store *Store
+	name  string
+}
+
+func (c *scope) Get(key string, v interface{}) (bool, error) {
+	c.store.mu.Lock()
+	raw, ok := c.store.data[c.name][key]
+	c.store.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("error decoding state %q: %v", key, err)
+	}
+	return true, nil
+}
+
+func (c *scope) Set(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding state %q: %v", key, err)
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	values, ok := c.store.data[c.name]
+	if !ok {
+		values = make(map[string]json.RawMessage)
+		c.store.data[c.name] = values
+	}
+	values[key] = raw
+	c.store.dirty = true
+	return nil
+}
+
+func (c *scope) Delete(key string) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	values, ok := c.store.data[c.name]
+	if !ok {
+		return
+	}
+	if _, ok := values[key]; !ok {
+		return
+	}
+
+	delete(values, key)
+	if len(values) == 0 {
+		delete(c.store.data, c.name)
+	}
+	c.store.dirty = true
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type cursor struct {
+	Offset int64  `json:"offset"`
+	Last   string `json:"last"`
+}
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.state")
+	s, err := Load(path)
+	require.NoError(t, err)
+
+	zfs := s.Scope("inputs.zfs")
+	require.NoError(t, zfs.Set("history", cursor{Offset: 42, Last: "scrub"}))
+	require.NoError(t, zfs.Set("scrub_time", int64(1577836800)))
+	require.NoError(t, s.Scope("inputs.zfs::backup").Set("history", cursor{Offset: 7}))
+	require.NoError(t, s.Save())
+
+	s, err = Load(path)
+	require.NoError(t, err)
+
+	var c cursor
+	ok, err := s.Scope("inputs.zfs").Get("history", &c)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, cursor{Offset: 42, Last: "scrub"}, c)
+
+	var scrub int64
+	ok, err = s.Scope("inputs.zfs").Get("scrub_time", &scrub)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(1577836800), scrub)
+
+	ok, err = s.Scope("inputs.zfs::backup").Get("history", &c)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(7), c.Offset)
+
+	ok, err = s.Scope("inputs.disk").Get("history", &c)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestStoreDelete(t *testing.T) {
+	s := New()
+	scope := s.Scope("inputs.zfs")
+	require.NoError(t, scope.Set("history", 1))
+	scope.Delete("history")
+	scope.Delete("missing")
+
+	var v int
+	ok, err := scope.Get("history", &v)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestStoreMissingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.state")
+	s, err := Load(path)
+	require.NoError(t, err)
+
+	// Nothing is written until the state changes.
+	require.NoError(t, s.Save())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestStoreInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.state")
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+
+	_, err = Load(path)
+	require.Error(t, err)
+}
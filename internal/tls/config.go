@@ -15,6 +15,12 @@ type ClientConfig struct {
 	TLSKey             string `toml:"tls_key"`
 	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
 
+	// Advanced settings, not included in sample configurations.
+	TLSServerName   string   `toml:"tls_server_name"`
+	TLSCipherSuites []string `toml:"tls_cipher_suites"`
+	TLSMinVersion   string   `toml:"tls_min_version"`
+	TLSMaxVersion   string   `toml:"tls_max_version"`
+
 	// Deprecated in 1.7; use TLS variables above
 	SSLCA   string `toml:"ssl_ca"`
 	SSLCert string `toml:"ssl_cert"`
@@ -49,13 +55,16 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
 	// the dedicated option `TLSEnable`.
-	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify {
+	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify &&
+		c.TLSServerName == "" && len(c.TLSCipherSuites) == 0 &&
+		c.TLSMinVersion == "" && c.TLSMaxVersion == "" {
 		return nil, nil
 	}
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: c.InsecureSkipVerify,
 		Renegotiation:      tls.RenegotiateNever,
+		ServerName:         c.TLSServerName,
 	}
 
 	if c.TLSCA != "" {
@@ -73,6 +82,11 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		}
 	}
 
+	err := setVersionAndCiphers(tlsConfig, c.TLSCipherSuites, c.TLSMinVersion, c.TLSMaxVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	return tlsConfig, nil
 }
 
@@ -101,39 +115,50 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 		}
 	}
 
-	if len(c.TLSCipherSuites) != 0 {
-		cipherSuites, err := ParseCiphers(c.TLSCipherSuites)
+	err := setVersionAndCiphers(tlsConfig, c.TLSCipherSuites, c.TLSMinVersion, c.TLSMaxVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsConfig, nil
+}
+
+// setVersionAndCiphers applies the cipher suites and TLS versions shared by
+// the client and server configurations.
+func setVersionAndCiphers(tlsConfig *tls.Config, cipherSuites []string, minVersion, maxVersion string) error {
+	if len(cipherSuites) != 0 {
+		ciphers, err := ParseCiphers(cipherSuites)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"could not parse server cipher suites %s: %v", strings.Join(c.TLSCipherSuites, ","), err)
+			return fmt.Errorf(
+				"could not parse cipher suites %s: %v", strings.Join(cipherSuites, ","), err)
 		}
-		tlsConfig.CipherSuites = cipherSuites
+		tlsConfig.CipherSuites = ciphers
 	}
 
-	if c.TLSMaxVersion != "" {
-		version, err := ParseTLSVersion(c.TLSMaxVersion)
+	if maxVersion != "" {
+		version, err := ParseTLSVersion(maxVersion)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"could not parse tls max version %q: %v", c.TLSMaxVersion, err)
+			return fmt.Errorf(
+				"could not parse tls max version %q: %v", maxVersion, err)
 		}
 		tlsConfig.MaxVersion = version
 	}
 
-	if c.TLSMinVersion != "" {
-		version, err := ParseTLSVersion(c.TLSMinVersion)
+	if minVersion != "" {
+		version, err := ParseTLSVersion(minVersion)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"could not parse tls min version %q: %v", c.TLSMinVersion, err)
+			return fmt.Errorf(
+				"could not parse tls min version %q: %v", minVersion, err)
 		}
 		tlsConfig.MinVersion = version
 	}
 
 	if tlsConfig.MinVersion != 0 && tlsConfig.MaxVersion != 0 && tlsConfig.MinVersion > tlsConfig.MaxVersion {
-		return nil, fmt.Errorf(
-			"tls min version %q can't be greater then tls max version %q", tlsConfig.MinVersion, tlsConfig.MaxVersion)
+		return fmt.Errorf(
+			"tls min version %q can't be greater then tls max version %q", minVersion, maxVersion)
 	}
 
-	return tlsConfig, nil
+	return nil
 }
 
 func makeCertPool(certFiles []string) (*x509.CertPool, error) {
@@ -78,6 +78,37 @@ func TestClientConfig(t *testing.T) {
 			expNil: false,
 			expErr: false,
 		},
+		{
+			name: "min version only",
+			client: tls.ClientConfig{
+				TLSMinVersion: "TLS12",
+			},
+		},
+		{
+			name: "invalid min version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "SSL3",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "min version greater than max version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "TLS12",
+				TLSMaxVersion: "TLS11",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "invalid cipher suites",
+			client: tls.ClientConfig{
+				TLSCipherSuites: []string{"TLS_RSA_WITH_NULL"},
+			},
+			expNil: true,
+			expErr: true,
+		},
 		{
 			name: "support deprecated ssl field names",
 			client: tls.ClientConfig{
@@ -11,6 +11,15 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+    --section <sections>         print only these sections, separator is ,
+                                 ie 'agent,inputs.zfs,outputs.influxdb'
+    --validate                   load the configuration and initialize the
+                                 plugins without gathering, then exit
+  compare             gather the inputs of two configurations once and print
+                      the series and fields emitted by only one of them
+    --config <file>              the old, then the new configuration file
+    --test-wait <seconds>        run the service inputs for this many seconds
+                                 before gathering them
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
@@ -31,10 +40,11 @@ The commands & flags are:
                                  'processors', 'aggregators' and 'inputs'
   --test                         gather metrics, print them out, and exit;
                                  processors, aggregators, and outputs are not run
-  --test-wait                    wait up to this many seconds for service
-                                 inputs to complete in test mode
+  --test-wait <seconds>          in test mode, run the service inputs for this
+                                 many seconds before gathering them
   --usage <plugin>               print usage for a plugin, ie, 'telegraf --usage mysql'
   --version                      display the version and exit
+  --watch-config                 reload the configuration when the config files change
 
   --console                      run as console application (windows only)
   --service <service>            operate on the service (windows only)
@@ -49,9 +59,21 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # print the sample configuration of the zfs input only
+  telegraf config --section inputs.zfs
+
+  # check a configuration file before deploying it
+  telegraf --config telegraf.conf config --validate
+
+  # check the series and fields changed by a new configuration
+  telegraf compare --config telegraf.conf --config telegraf.conf.new
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # also report what the service inputs received during 10 seconds
+  telegraf --config telegraf.conf --test --test-wait 10
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
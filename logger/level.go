@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/influxdata/wlog"
+)
+
+// Level is the severity of a log message.
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// ParseLevel parses the name of a log level: debug, info, warn or error.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("invalid log level %q", name)
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "info"
+}
+
+// Prefix returns the prefix marking messages of this level, such as "E!".
+func (l Level) Prefix() string {
+	switch l {
+	case LevelDebug:
+		return "D!"
+	case LevelWarn:
+		return "W!"
+	case LevelError:
+		return "E!"
+	}
+	return "I!"
+}
+
+func levelFromPrefix(c byte) Level {
+	switch c {
+	case 'D':
+		return LevelDebug
+	case 'W':
+		return LevelWarn
+	case 'E':
+		return LevelError
+	}
+	return LevelInfo
+}
+
+// agentLevel is the minimum level of messages logged, unless overridden per
+// plugin.
+var agentLevel = int32(LevelInfo)
+
+// AgentLevel returns the log level of the agent.
+func AgentLevel() Level {
+	return Level(atomic.LoadInt32(&agentLevel))
+}
+
+func setAgentLevel(level Level) {
+	atomic.StoreInt32(&agentLevel, int32(level))
+
+	// Keep wlog in sync for plugins querying it.
+	switch level {
+	case LevelDebug:
+		wlog.SetLevel(wlog.DEBUG)
+	case LevelInfo:
+		wlog.SetLevel(wlog.INFO)
+	case LevelWarn:
+		wlog.SetLevel(wlog.WARN)
+	case LevelError:
+		wlog.SetLevel(wlog.ERROR)
+	}
+}
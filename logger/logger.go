@@ -1,25 +1,36 @@
 package logger
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/rotate"
-	"github.com/influxdata/wlog"
 )
 
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+// pluginRegex matches the plugin name following the level prefix.
+var pluginRegex = regexp.MustCompile(`^\[([^\]]+)\] `)
+
 const (
 	LogTargetFile   = "file"
 	LogTargetStderr = "stderr"
 )
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 // LogConfig contains the log configuration settings
 type LogConfig struct {
 	// will set the log level to DEBUG
@@ -38,6 +49,8 @@ type LogConfig struct {
 	RotationMaxSize internal.Size
 	// maximum rotated files to keep (older ones will be deleted)
 	RotationMaxArchives int
+	// text or json
+	LogFormat string
 }
 
 type LoggerCreator interface {
@@ -54,17 +67,67 @@ func registerLogger(name string, loggerCreator LoggerCreator) {
 }
 
 type telegrafLog struct {
+	mu             sync.Mutex
+	format         string
 	writer         io.Writer
 	internalWriter io.Writer
 }
 
+// entry is a single parsed log message.
+type entry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Plugin string    `json:"plugin,omitempty"`
+	Msg    string    `json:"msg"`
+
+	level Level
+}
+
+// parseEntry splits a message in the "E! [plugin] message" form used
+// throughout Telegraf.  Messages without a prefix are logged as info.
+func parseEntry(b []byte) *entry {
+	e := &entry{Time: time.Now().UTC(), level: LevelInfo}
+	if loc := prefixRegex.FindIndex(b); loc != nil {
+		e.level = levelFromPrefix(b[0])
+		b = b[loc[1]:]
+		if len(b) > 0 && b[0] == ' ' {
+			b = b[1:]
+		}
+	}
+	if m := pluginRegex.FindSubmatch(b); m != nil {
+		e.Plugin = string(m[1])
+	}
+	e.Level = e.level.String()
+	e.Msg = strings.TrimRight(string(b), "\n")
+	return e
+}
+
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
+	e := parseEntry(b)
+	if e.level < AgentLevel() {
+		return len(b), nil
+	}
+	return t.write(e)
+}
+
+func (t *telegrafLog) write(e *entry) (int, error) {
 	var line []byte
-	if !prefixRegex.Match(b) {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
-	} else {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" "), b...)
+	switch t.format {
+	case LogFormatJSON:
+		if e.Plugin != "" {
+			e.Msg = strings.TrimPrefix(e.Msg, "["+e.Plugin+"] ")
+		}
+		octets, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		line = append(octets, '\n')
+	default:
+		line = []byte(e.Time.Format(time.RFC3339) + " " + e.level.Prefix() + " " + e.Msg + "\n")
 	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.writer.Write(line)
 }
 
@@ -83,13 +146,32 @@ func (t *telegrafLog) Close() error {
 }
 
 // newTelegrafWriter returns a logging-wrapped writer.
-func newTelegrafWriter(w io.Writer) io.Writer {
+func newTelegrafWriter(w io.Writer) *telegrafLog {
 	return &telegrafLog{
-		writer:         wlog.NewWriter(w),
+		format:         LogFormatText,
+		writer:         w,
 		internalWriter: w,
 	}
 }
 
+// Output logs a message of a plugin at the given level, regardless of the
+// level of the agent.  It is used by plugins with their own log level.
+func Output(level Level, plugin string, msg string) {
+	if t, ok := actualLogger.(*telegrafLog); ok {
+		t.write(&entry{
+			Time:   time.Now().UTC(),
+			Level:  level.String(),
+			Plugin: plugin,
+			Msg:    "[" + plugin + "] " + msg,
+			level:  level,
+		})
+		return
+	}
+
+	// Other targets only receive messages allowed by the agent level.
+	log.Print(level.Prefix() + " [" + plugin + "] " + msg)
+}
+
 // SetupLogging configures the logging output.
 func SetupLogging(config LogConfig) {
 	newLogWriter(config)
@@ -120,7 +202,17 @@ func (t *telegrafLogCreator) CreateLogger(config LogConfig) (io.Writer, error) {
 		writer = defaultWriter
 	}
 
-	return newTelegrafWriter(writer), nil
+	switch config.LogFormat {
+	case LogFormatText, LogFormatJSON, "":
+	default:
+		return nil, fmt.Errorf("unsupported logformat: %s", config.LogFormat)
+	}
+
+	w := newTelegrafWriter(writer)
+	if config.LogFormat != "" {
+		w.format = config.LogFormat
+	}
+	return w, nil
 }
 
 // Keep track what is actually set as a log output, because log package doesn't provide a getter.
@@ -129,18 +221,24 @@ var actualLogger io.Writer
 
 func newLogWriter(config LogConfig) io.Writer {
 	log.SetFlags(0)
-	if config.Debug {
-		wlog.SetLevel(wlog.DEBUG)
-	}
-	if config.Quiet {
-		wlog.SetLevel(wlog.ERROR)
-	}
-	if !config.Debug && !config.Quiet {
-		wlog.SetLevel(wlog.INFO)
+	switch {
+	case config.Debug:
+		setAgentLevel(LevelDebug)
+	case config.Quiet:
+		setAgentLevel(LevelError)
+	default:
+		setAgentLevel(LevelInfo)
 	}
+
 	var logWriter io.Writer
+	var err error
 	if logCreator, ok := loggerRegistry[config.LogTarget]; ok {
-		logWriter, _ = logCreator.CreateLogger(config)
+		logWriter, err = logCreator.CreateLogger(config)
+	}
+	if err != nil {
+		config.LogFormat = ""
+		logWriter, _ = (&telegrafLogCreator{}).CreateLogger(config)
+		logWriter.Write([]byte("E! " + err.Error() + ", using text"))
 	}
 	if logWriter == nil {
 		logWriter, _ = (&telegrafLogCreator{}).CreateLogger(config)
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"log"
@@ -151,3 +152,42 @@ func createBasicLogConfig(filename string) LogConfig {
 		RotationMaxArchives: -1,
 	}
 }
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTelegrafWriter(&buf)
+	w.format = LogFormatJSON
+	setAgentLevel(LevelInfo)
+
+	w.Write([]byte("W! [inputs.zfs] pool tank degraded\n"))
+
+	var e map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &e))
+	require.Equal(t, "warn", e["level"])
+	require.Equal(t, "inputs.zfs", e["plugin"])
+	require.Equal(t, "pool tank degraded", e["msg"])
+	require.Contains(t, e, "time")
+}
+
+func TestPluginLevelOverridesAgentLevel(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	SetupLogging(createBasicLogConfig(tmpfile.Name()))
+	log.Printf("D! [inputs.cpu] ignored")
+	Output(LevelDebug, "inputs.zfs", "TEST")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	require.Equal(t, []byte("Z D! [inputs.zfs] TEST\n"), f[19:])
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("Warning")
+	require.NoError(t, err)
+	require.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	require.Error(t, err)
+}
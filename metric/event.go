@@ -0,0 +1,61 @@
+package metric
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Events are metrics recording that something happened, such as a pool
+// changing health or a scrub completing, rather than a sampled value.  They
+// are regular metrics with a string "title" field and an optional string
+// "text" field, so they can be filtered, processed and written like any other
+// metric; outputs supporting annotations can tell them apart with IsEvent.
+const (
+	EventTitleField = "title"
+	EventTextField  = "text"
+)
+
+// NewEvent creates an event metric, the text is omitted when empty.
+func NewEvent(
+	name string,
+	tags map[string]string,
+	title string,
+	text string,
+	tm time.Time,
+) (telegraf.Metric, error) {
+	fields := map[string]interface{}{
+		EventTitleField: title,
+	}
+	if text != "" {
+		fields[EventTextField] = text
+	}
+	return New(name, tags, fields, tm)
+}
+
+// IsEvent returns true if the metric follows the event conventions.
+func IsEvent(m telegraf.Metric) bool {
+	_, ok := EventTitle(m)
+	return ok
+}
+
+// EventTitle returns the title of an event metric.
+func EventTitle(m telegraf.Metric) (string, bool) {
+	return stringField(m, EventTitleField)
+}
+
+// EventText returns the text of an event metric, or an empty string if the
+// event has no text.
+func EventText(m telegraf.Metric) string {
+	text, _ := stringField(m, EventTextField)
+	return text
+}
+
+func stringField(m telegraf.Metric, key string) (string, bool) {
+	v, ok := m.GetField(key)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
@@ -0,0 +1,43 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEvent(t *testing.T) {
+	now := time.Now()
+
+	m, err := NewEvent("zfs_events", map[string]string{"pool": "tank"},
+		"scrub finished", "0 errors", now)
+	require.NoError(t, err)
+
+	require.True(t, IsEvent(m))
+	title, ok := EventTitle(m)
+	require.True(t, ok)
+	require.Equal(t, "scrub finished", title)
+	require.Equal(t, "0 errors", EventText(m))
+	require.Equal(t, map[string]string{"pool": "tank"}, m.Tags())
+	require.Equal(t, now, m.Time())
+}
+
+func TestNewEventWithoutText(t *testing.T) {
+	m, err := NewEvent("zfs_events", nil, "pool online", "", time.Now())
+	require.NoError(t, err)
+
+	require.True(t, IsEvent(m))
+	require.Equal(t, "", EventText(m))
+	require.Len(t, m.FieldList(), 1)
+}
+
+func TestIsEvent(t *testing.T) {
+	m, err := New("cpu", nil, map[string]interface{}{"title": 42}, time.Now())
+	require.NoError(t, err)
+	require.False(t, IsEvent(m))
+
+	m, err = New("cpu", nil, map[string]interface{}{"usage": 42.0}, time.Now())
+	require.NoError(t, err)
+	require.False(t, IsEvent(m))
+}
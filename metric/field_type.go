@@ -0,0 +1,64 @@
+package metric
+
+import (
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// The value type of a metric applies to all of its fields, but many inputs
+// emit counters and gauges side by side in a single measurement.  Inputs can
+// declare the value type of individual fields with RegisterFieldType, so that
+// outputs with typed data models, such as Prometheus, export them correctly.
+// The declarations are keyed by the measurement name as emitted by the
+// input, they do not follow measurements renamed with name_override,
+// name_prefix or name_suffix.
+
+type fieldTypeRule struct {
+	fields filter.Filter
+	tp     telegraf.ValueType
+}
+
+var (
+	fieldTypesMu sync.RWMutex
+	fieldTypes   = make(map[string][]fieldTypeRule)
+)
+
+// RegisterFieldType declares the value type, usually telegraf.Counter or
+// telegraf.Gauge, of the fields of a measurement.  The fields support glob
+// patterns; when a field matches several declarations the first one
+// registered wins.  It is usually called from the init function of the
+// plugin.
+func RegisterFieldType(measurement string, tp telegraf.ValueType, fields ...string) {
+	f, err := filter.Compile(fields)
+	if err != nil {
+		panic("metric: invalid field pattern for " + measurement + ": " + err.Error())
+	}
+	if f == nil {
+		return
+	}
+
+	fieldTypesMu.Lock()
+	defer fieldTypesMu.Unlock()
+	fieldTypes[measurement] = append(fieldTypes[measurement],
+		fieldTypeRule{fields: f, tp: tp})
+}
+
+// FieldType returns the value type of a field of the metric.  The type of
+// the metric takes precedence, for untyped metrics the declared type of the
+// field is returned, or telegraf.Untyped if there is none.
+func FieldType(m telegraf.Metric, field string) telegraf.ValueType {
+	if tp := m.Type(); tp != telegraf.Untyped {
+		return tp
+	}
+
+	fieldTypesMu.RLock()
+	defer fieldTypesMu.RUnlock()
+	for _, rule := range fieldTypes[m.Name()] {
+		if rule.fields.Match(field) {
+			return rule.tp
+		}
+	}
+	return telegraf.Untyped
+}
@@ -0,0 +1,38 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldType(t *testing.T) {
+	RegisterFieldType("field_type_test", telegraf.Gauge, "size", "arc_*_size")
+	RegisterFieldType("field_type_test", telegraf.Counter, "arc_*")
+
+	m, err := New("field_type_test", nil, map[string]interface{}{
+		"size":          int64(1),
+		"arc_hits":      int64(2),
+		"arc_data_size": int64(3),
+		"other":         int64(4),
+	}, time.Now())
+	require.NoError(t, err)
+
+	require.Equal(t, telegraf.Gauge, FieldType(m, "size"))
+	require.Equal(t, telegraf.Counter, FieldType(m, "arc_hits"))
+	require.Equal(t, telegraf.Gauge, FieldType(m, "arc_data_size"))
+	require.Equal(t, telegraf.Untyped, FieldType(m, "other"))
+}
+
+func TestFieldTypeMetricTypeWins(t *testing.T) {
+	RegisterFieldType("field_type_test_typed", telegraf.Counter, "value")
+
+	m, err := New("field_type_test_typed", nil, map[string]interface{}{
+		"value": int64(1),
+	}, time.Now(), telegraf.Gauge)
+	require.NoError(t, err)
+
+	require.Equal(t, telegraf.Gauge, FieldType(m, "value"))
+}
@@ -28,3 +28,27 @@ type Logger interface {
 	// Info logs an information message, patterned after log.Print.
 	Info(args ...interface{})
 }
+
+// StateStore persists small amounts of plugin state, such as cursors or
+// previous counter values, across restarts of the agent.  Plugins receive
+// a store scoped to the plugin instance by defining a State field of type
+// telegraf.StateStore.
+type StateStore interface {
+	// Get decodes the value stored under the key into v and reports if
+	// the key was found.
+	Get(key string, v interface{}) (bool, error)
+	// Set stores the JSON encoding of the value under the key.
+	Set(key string, v interface{}) error
+	// Delete removes the key.
+	Delete(key string)
+}
+
+// Cache shares the results of expensive collections, such as the inventory
+// of the disks, between the inputs gathering in the same interval.  Inputs
+// receive the cache by defining a Cache field of type telegraf.Cache.
+type Cache interface {
+	// Get returns the value cached under the key, calling load to compute
+	// it when it is missing or was loaded in a previous interval.  The
+	// inputs sharing a key must agree on the type of its value.
+	Get(key string, load func() (interface{}, error)) (interface{}, error)
+}
@@ -6,5 +6,6 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/aggregators/histogram"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/merge"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/minmax"
+	_ "github.com/influxdata/telegraf/plugins/aggregators/storage_health"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/valuecounter"
 )
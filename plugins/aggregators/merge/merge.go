@@ -4,22 +4,41 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 )
 
 const (
 	description  = "Merge metrics into multifield metrics by series key"
-	sampleConfig = ""
+	sampleConfig = `
+  ## Merge the metrics of these measurements with the same tags and
+  ## timestamp into metrics named after the first of them.  By default only
+  ## the metrics of the same measurement are merged.  A field found in
+  ## several of them keeps the value of the last metric merged.
+  # measurements = []
+
+  ## Round the timestamps to this duration before merging, so that the
+  ## metrics gathered a little apart are merged.
+  # round_timestamp_to = "0s"
+`
 )
 
 type Merge struct {
+	Measurements     []string          `toml:"measurements"`
+	RoundTimestampTo internal.Duration `toml:"round_timestamp_to"`
+
 	grouper *metric.SeriesGrouper
+	merged  map[string]bool
 	log     telegraf.Logger
 }
 
 func (a *Merge) Init() error {
 	a.grouper = metric.NewSeriesGrouper()
+	a.merged = make(map[string]bool, len(a.Measurements))
+	for _, name := range a.Measurements {
+		a.merged[name] = true
+	}
 	return nil
 }
 
@@ -32,9 +51,18 @@ func (a *Merge) SampleConfig() string {
 }
 
 func (a *Merge) Add(m telegraf.Metric) {
+	name := m.Name()
+	if a.merged[name] {
+		name = a.Measurements[0]
+	}
+	tm := m.Time()
+	if a.RoundTimestampTo.Duration > 0 {
+		tm = tm.Round(a.RoundTimestampTo.Duration)
+	}
+
 	tags := m.Tags()
 	for _, field := range m.FieldList() {
-		err := a.grouper.Add(m.Name(), tags, m.Time(), field.Key, field.Value)
+		err := a.grouper.Add(name, tags, tm, field.Key, field.Value)
 		if err != nil {
 			a.log.Errorf("Error adding metric: %v", err)
 		}
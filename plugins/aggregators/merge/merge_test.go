@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -184,3 +185,79 @@ func TestReset(t *testing.T) {
 
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
 }
+
+func TestMeasurements(t *testing.T) {
+	plugin := &Merge{
+		Measurements:     []string{"zfs_pool", "zfs_pool_forecast"},
+		RoundTimestampTo: internal.Duration{Duration: time.Second},
+	}
+
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	plugin.Add(
+		testutil.MustMetric(
+			"zfs_pool",
+			map[string]string{
+				"pool": "tank",
+			},
+			map[string]interface{}{
+				"read_bytes": 1884160,
+			},
+			time.Unix(0, 0),
+		),
+	)
+	plugin.Add(
+		testutil.MustMetric(
+			"zfs_pool_forecast",
+			map[string]string{
+				"pool": "tank",
+			},
+			map[string]interface{}{
+				"days_until_full": 38.0,
+			},
+			time.Unix(0, 200*int64(time.Millisecond)),
+		),
+	)
+	plugin.Add(
+		testutil.MustMetric(
+			"zfs",
+			map[string]string{
+				"pools": "tank",
+			},
+			map[string]interface{}{
+				"arcstats_hits": 42,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"zfs_pool",
+			map[string]string{
+				"pool": "tank",
+			},
+			map[string]interface{}{
+				"read_bytes":      1884160,
+				"days_until_full": 38.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"zfs",
+			map[string]string{
+				"pools": "tank",
+			},
+			map[string]interface{}{
+				"arcstats_hits": 42,
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
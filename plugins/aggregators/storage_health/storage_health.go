@@ -0,0 +1,256 @@
+package storage_health
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Tags of the hosts, a storage_health metric is emitted for each value
+  ## of these tags.
+  # group_by = ["host"]
+
+  ## Checks giving the health of the metrics, compared to the value of a
+  ## tag or of a field: equal to one of the warning or critical values, or
+  ## above the *_gt or below the *_lt thresholds.  By default the health of
+  ## the ZFS pools and the SMART health of the disks are checked.
+  # [[aggregators.storage_health.check]]
+  #   name = "pool"
+  #   measurement = "zfs_pool"
+  #   tag = "health"
+  #   warning = ["DEGRADED"]
+  #   critical = ["FAULTED", "OFFLINE", "REMOVED", "UNAVAIL", "SUSPENDED"]
+  # [[aggregators.storage_health.check]]
+  #   name = "smart"
+  #   measurement = "smart_device"
+  #   field = "health_ok"
+  #   critical = ["false"]
+  # [[aggregators.storage_health.check]]
+  #   name = "temperature"
+  #   measurement = "smart_device"
+  #   field = "temp_c"
+  #   warning_gt = 50.0
+  #   critical_gt = 60.0
+`
+
+const (
+	levelOK = iota
+	levelWarning
+	levelCritical
+)
+
+var statuses = []string{"ok", "warning", "critical"}
+
+var defaultChecks = []*Check{
+	{
+		Name:        "pool",
+		Measurement: "zfs_pool",
+		Tag:         "health",
+		Warning:     []string{"DEGRADED"},
+		Critical:    []string{"FAULTED", "OFFLINE", "REMOVED", "UNAVAIL", "SUSPENDED"},
+	},
+	{
+		Name:        "smart",
+		Measurement: "smart_device",
+		Field:       "health_ok",
+		Critical:    []string{"false"},
+	},
+}
+
+type Check struct {
+	Name        string   `toml:"name"`
+	Measurement string   `toml:"measurement"`
+	Tag         string   `toml:"tag"`
+	Field       string   `toml:"field"`
+	Warning     []string `toml:"warning"`
+	Critical    []string `toml:"critical"`
+	WarningGT   *float64 `toml:"warning_gt"`
+	WarningLT   *float64 `toml:"warning_lt"`
+	CriticalGT  *float64 `toml:"critical_gt"`
+	CriticalLT  *float64 `toml:"critical_lt"`
+}
+
+// level returns the health of the metric, false when the check does not
+// apply to it.
+func (c *Check) level(m telegraf.Metric) (int, bool) {
+	if m.Name() != c.Measurement {
+		return levelOK, false
+	}
+
+	var value string
+	if c.Tag != "" {
+		v, found := m.GetTag(c.Tag)
+		if !found {
+			return levelOK, false
+		}
+		value = v
+	} else {
+		v, found := m.GetField(c.Field)
+		if !found {
+			return levelOK, false
+		}
+		value = fmt.Sprint(v)
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	isNumber := err == nil
+	exceeds := func(gt, lt *float64) bool {
+		return isNumber && ((gt != nil && number > *gt) || (lt != nil && number < *lt))
+	}
+
+	if exceeds(c.CriticalGT, c.CriticalLT) {
+		return levelCritical, true
+	}
+	for _, v := range c.Critical {
+		if v == value {
+			return levelCritical, true
+		}
+	}
+	if exceeds(c.WarningGT, c.WarningLT) {
+		return levelWarning, true
+	}
+	for _, v := range c.Warning {
+		if v == value {
+			return levelWarning, true
+		}
+	}
+	return levelOK, true
+}
+
+// seriesID identifies the series of the metric, without the tag of the
+// check, as a pool changing health is still the same pool.
+func (c *Check) seriesID(m telegraf.Metric) string {
+	var id strings.Builder
+	id.WriteString(m.Name())
+	for _, tag := range m.TagList() {
+		if tag.Key == c.Tag {
+			continue
+		}
+		id.WriteByte(0)
+		id.WriteString(tag.Key)
+		id.WriteByte('=')
+		id.WriteString(tag.Value)
+	}
+	return id.String()
+}
+
+// group is the health of the metrics of a host, by check.
+type group struct {
+	tags   map[string]string
+	series map[int]map[string]int
+}
+
+type StorageHealth struct {
+	GroupBy []string `toml:"group_by"`
+	Checks  []*Check `toml:"check"`
+
+	groups map[string]*group
+}
+
+func (s *StorageHealth) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *StorageHealth) Description() string {
+	return "Roll up the health of the storage into a score per host."
+}
+
+func (s *StorageHealth) Init() error {
+	if len(s.Checks) == 0 {
+		s.Checks = defaultChecks
+	}
+	names := make(map[string]bool)
+	for i, check := range s.Checks {
+		if check.Name == "" || check.Measurement == "" {
+			return fmt.Errorf("check %d: name and measurement must be set", i+1)
+		}
+		if (check.Tag == "") == (check.Field == "") {
+			return fmt.Errorf("check %s: exactly one of tag and field must be set", check.Name)
+		}
+		if names[check.Name] {
+			return fmt.Errorf("check %s: duplicate name", check.Name)
+		}
+		names[check.Name] = true
+	}
+	s.Reset()
+	return nil
+}
+
+func (s *StorageHealth) Add(in telegraf.Metric) {
+	for i, check := range s.Checks {
+		level, applies := check.level(in)
+		if !applies {
+			continue
+		}
+
+		tags := make(map[string]string, len(s.GroupBy))
+		var id strings.Builder
+		for _, key := range s.GroupBy {
+			if value, found := in.GetTag(key); found {
+				tags[key] = value
+				id.WriteString(value)
+			}
+			id.WriteByte(0)
+		}
+		g, found := s.groups[id.String()]
+		if !found {
+			g = &group{tags: tags, series: make(map[int]map[string]int)}
+			s.groups[id.String()] = g
+		}
+		if g.series[i] == nil {
+			g.series[i] = make(map[string]int)
+		}
+		// The last health of each series counts.
+		g.series[i][check.seriesID(in)] = level
+	}
+}
+
+func (s *StorageHealth) Push(acc telegraf.Accumulator) {
+	for _, g := range s.groups {
+		score := levelOK
+		failing := 0
+		fields := make(map[string]interface{})
+		for i, series := range g.series {
+			worst := levelOK
+			for _, level := range series {
+				if level > worst {
+					worst = level
+				}
+				if level > levelOK {
+					failing++
+				}
+			}
+			fields[s.Checks[i].Name] = worst
+			if worst > score {
+				score = worst
+			}
+		}
+		fields["score"] = score
+		fields["status"] = statuses[score]
+		fields["failing"] = failing
+		acc.AddFields("storage_health", fields, g.tags)
+	}
+}
+
+func (s *StorageHealth) Reset() {
+	s.groups = make(map[string]*group)
+}
+
+func init() {
+	aggregators.Add("storage_health", func() telegraf.Aggregator {
+		return &StorageHealth{
+			GroupBy: []string{"host"},
+		}
+	})
+}
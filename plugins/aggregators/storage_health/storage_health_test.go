@@ -0,0 +1,168 @@
+package storage_health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func pool(host, name, health string) telegraf.Metric {
+	return testutil.MustMetric("zfs_pool",
+		map[string]string{"host": host, "pool": name, "health": health},
+		map[string]interface{}{"allocated": int64(1)},
+		time.Unix(0, 0),
+	)
+}
+
+func disk(host, device string, healthOK bool) telegraf.Metric {
+	return testutil.MustMetric("smart_device",
+		map[string]string{"host": host, "device": device},
+		map[string]interface{}{"health_ok": healthOK},
+		time.Unix(0, 0),
+	)
+}
+
+func TestStorageHealth(t *testing.T) {
+	s := &StorageHealth{GroupBy: []string{"host"}}
+	require.NoError(t, s.Init())
+
+	s.Add(pool("nas1", "tank", "ONLINE"))
+	s.Add(pool("nas1", "backup", "DEGRADED"))
+	s.Add(disk("nas1", "sda", true))
+	s.Add(pool("nas2", "tank", "ONLINE"))
+	s.Add(disk("nas2", "sda", true))
+	s.Add(disk("nas2", "sdb", false))
+	s.Add(pool("nas3", "tank", "DEGRADED"))
+	// The last health of a series counts.
+	s.Add(pool("nas3", "tank", "ONLINE"))
+	// Not checked.
+	s.Add(testutil.MustMetric("cpu", map[string]string{"host": "nas4"},
+		map[string]interface{}{"usage_idle": 100.0}, time.Unix(0, 0)))
+
+	var acc testutil.Accumulator
+	s.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("storage_health",
+			map[string]string{"host": "nas1"},
+			map[string]interface{}{"pool": 1, "smart": 0, "score": 1, "status": "warning", "failing": 1},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storage_health",
+			map[string]string{"host": "nas2"},
+			map[string]interface{}{"pool": 0, "smart": 2, "score": 2, "status": "critical", "failing": 1},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storage_health",
+			map[string]string{"host": "nas3"},
+			map[string]interface{}{"pool": 0, "score": 0, "status": "ok", "failing": 0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+
+	s.Reset()
+	acc = testutil.Accumulator{}
+	s.Push(&acc)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestStorageHealthChecks(t *testing.T) {
+	s := &StorageHealth{
+		Checks: []*Check{{
+			Name:        "bond",
+			Measurement: "bond",
+			Field:       "status",
+			Critical:    []string{"0"},
+		}},
+	}
+	require.NoError(t, s.Init())
+
+	s.Add(testutil.MustMetric("bond", map[string]string{"bond": "bond0"},
+		map[string]interface{}{"status": 0}, time.Unix(0, 0)))
+	s.Add(pool("nas1", "tank", "FAULTED"))
+
+	var acc testutil.Accumulator
+	s.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("storage_health",
+			map[string]string{},
+			map[string]interface{}{"bond": 2, "score": 2, "status": "critical", "failing": 1},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestStorageHealthThresholds(t *testing.T) {
+	warning, critical, below := 0.0, 1.0, 2.0
+	s := &StorageHealth{
+		GroupBy: []string{"host"},
+		Checks: []*Check{
+			{
+				Name:        "vdev",
+				Measurement: "zfs_vdev",
+				Field:       "errors",
+				WarningGT:   &warning,
+				CriticalGT:  &critical,
+			},
+			{
+				Name:        "multipath",
+				Measurement: "multipath",
+				Field:       "paths_active",
+				CriticalLT:  &below,
+			},
+		},
+	}
+	require.NoError(t, s.Init())
+
+	vdev := func(host, name string, errors int64) telegraf.Metric {
+		return testutil.MustMetric("zfs_vdev",
+			map[string]string{"host": host, "vdev": name},
+			map[string]interface{}{"errors": errors},
+			time.Unix(0, 0),
+		)
+	}
+	s.Add(vdev("nas1", "sda", 0))
+	s.Add(vdev("nas1", "sdb", 1))
+	s.Add(vdev("nas2", "sda", 3))
+	s.Add(testutil.MustMetric("multipath", map[string]string{"host": "nas3", "map": "mpatha"},
+		map[string]interface{}{"paths_active": int64(1)}, time.Unix(0, 0)))
+
+	var acc testutil.Accumulator
+	s.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("storage_health",
+			map[string]string{"host": "nas1"},
+			map[string]interface{}{"vdev": 1, "score": 1, "status": "warning", "failing": 1},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storage_health",
+			map[string]string{"host": "nas2"},
+			map[string]interface{}{"vdev": 2, "score": 2, "status": "critical", "failing": 1},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storage_health",
+			map[string]string{"host": "nas3"},
+			map[string]interface{}{"multipath": 2, "score": 2, "status": "critical", "failing": 1},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestStorageHealthInit(t *testing.T) {
+	s := &StorageHealth{Checks: []*Check{{Name: "pool", Measurement: "zfs_pool"}}}
+	require.Error(t, s.Init())
+
+	s = &StorageHealth{Checks: []*Check{
+		{Name: "pool", Measurement: "zfs_pool", Tag: "health"},
+		{Name: "pool", Measurement: "zfs_pool", Field: "capacity"},
+	}}
+	require.Error(t, s.Init())
+}
@@ -0,0 +1,121 @@
+package shim
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// accumulator sends the metrics added by an input to a channel.  It is a
+// trimmed down version of the agent accumulator, so that external plugins
+// do not depend on the agent and all of its plugins.
+type accumulator struct {
+	name      string
+	metrics   chan<- telegraf.Metric
+	precision time.Duration
+}
+
+func newAccumulator(name string, metrics chan<- telegraf.Metric) *accumulator {
+	return &accumulator{
+		name:      name,
+		metrics:   metrics,
+		precision: time.Nanosecond,
+	}
+}
+
+func (ac *accumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.addFields(measurement, tags, fields, telegraf.Untyped, t...)
+}
+
+func (ac *accumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.addFields(measurement, tags, fields, telegraf.Gauge, t...)
+}
+
+func (ac *accumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.addFields(measurement, tags, fields, telegraf.Counter, t...)
+}
+
+func (ac *accumulator) AddSummary(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.addFields(measurement, tags, fields, telegraf.Summary, t...)
+}
+
+func (ac *accumulator) AddHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.addFields(measurement, tags, fields, telegraf.Histogram, t...)
+}
+
+func (ac *accumulator) AddMetric(m telegraf.Metric) {
+	m.SetTime(m.Time().Round(ac.precision))
+	ac.metrics <- m
+}
+
+func (ac *accumulator) addFields(
+	measurement string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tp telegraf.ValueType,
+	t ...time.Time,
+) {
+	timestamp := time.Now()
+	if len(t) > 0 {
+		timestamp = t[0]
+	}
+
+	m, err := metric.New(measurement, tags, fields, timestamp.Round(ac.precision), tp)
+	if err != nil {
+		return
+	}
+	ac.metrics <- m
+}
+
+func (ac *accumulator) AddError(err error) {
+	if err == nil {
+		return
+	}
+	log.Printf("E! [%s] Error in plugin: %v", ac.name, err)
+}
+
+func (ac *accumulator) SetPrecision(precision time.Duration) {
+	ac.precision = precision
+}
+
+func (ac *accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	return &trackingAccumulator{
+		Accumulator: ac,
+		delivered:   make(chan telegraf.DeliveryInfo, maxTracked),
+	}
+}
+
+type trackingAccumulator struct {
+	telegraf.Accumulator
+	delivered chan telegraf.DeliveryInfo
+}
+
+func (a *trackingAccumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
+	dm, id := metric.WithTracking(m, a.onDelivery)
+	a.AddMetric(dm)
+	return id
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	db, id := metric.WithGroupTracking(group, a.onDelivery)
+	for _, m := range db {
+		a.AddMetric(m)
+	}
+	return id
+}
+
+func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+func (a *trackingAccumulator) onDelivery(info telegraf.DeliveryInfo) {
+	select {
+	case a.delivered <- info:
+	default:
+		// This is a programming error in the input.  More items were sent for
+		// tracking than space requested.
+		panic("channel is full")
+	}
+}
@@ -0,0 +1,248 @@
+package shim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Shim runs a single Telegraf plugin as an external program communicating
+// with the execd input, processor or output over STDIN and STDOUT:
+//
+//   - Inputs write their metrics to STDOUT in line protocol.  The plugin is
+//     gathered on every newline received on STDIN and, if set, on every poll
+//     interval.
+//   - Processors read a batch of metrics from STDIN terminated by an empty
+//     line, and write the processed metrics to STDOUT followed by an empty
+//     line.
+//   - Outputs read a batch of metrics from STDIN terminated by an empty line,
+//     and reply on STDOUT with an empty line on success or with a single line
+//     holding the error message.
+//
+// Log messages are written to STDERR.
+type Shim struct {
+	Input     telegraf.Input
+	Processor telegraf.Processor
+	Output    telegraf.Output
+
+	stdin  io.Reader
+	stdout io.Writer
+
+	serializer *serializer.Serializer
+	parser     *influx.Parser
+}
+
+// New creates a Shim using the standard streams of the program.
+func New() *Shim {
+	s := &Shim{
+		stdin:      os.Stdin,
+		stdout:     os.Stdout,
+		serializer: serializer.NewSerializer(),
+		parser:     influx.NewParser(influx.NewMetricHandler()),
+	}
+	s.serializer.SetFieldTypeSupport(serializer.UintSupport)
+	return s
+}
+
+// AddInput adds the input to the shim, the plugin is initialized if it
+// implements telegraf.Initializer.
+func (s *Shim) AddInput(input telegraf.Input) error {
+	if err := s.setup(input, "inputs"); err != nil {
+		return err
+	}
+	s.Input = input
+	return nil
+}
+
+// AddProcessor adds the processor to the shim, the plugin is initialized if
+// it implements telegraf.Initializer.
+func (s *Shim) AddProcessor(processor telegraf.Processor) error {
+	if err := s.setup(processor, "processors"); err != nil {
+		return err
+	}
+	s.Processor = processor
+	return nil
+}
+
+// AddOutput adds the output to the shim, the plugin is initialized if it
+// implements telegraf.Initializer.
+func (s *Shim) AddOutput(output telegraf.Output) error {
+	if err := s.setup(output, "outputs"); err != nil {
+		return err
+	}
+	s.Output = output
+	return nil
+}
+
+func (s *Shim) setup(plugin interface{}, pluginType string) error {
+	name := fmt.Sprintf("%s.%T", pluginType, plugin)
+	models.SetLoggerOnPlugin(plugin, &models.Logger{
+		Name: name,
+		Errs: selfstat.Register("shim", "errors", map[string]string{}),
+	})
+
+	if p, ok := plugin.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return fmt.Errorf("could not initialize %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// Run runs the plugin until STDIN is closed.  The pollInterval is only used
+// by inputs, a value of zero disables polling so that the plugin is only
+// gathered when requested over STDIN.
+func (s *Shim) Run(pollInterval time.Duration) error {
+	switch {
+	case s.Input != nil:
+		return s.runInput(pollInterval)
+	case s.Processor != nil:
+		return s.runProcessor()
+	case s.Output != nil:
+		return s.runOutput()
+	}
+	return errors.New("no plugin added to the shim")
+}
+
+func (s *Shim) runInput(pollInterval time.Duration) error {
+	metrics := make(chan telegraf.Metric, 100)
+	acc := newAccumulator(fmt.Sprintf("inputs.%T", s.Input), metrics)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.writeMetrics(metrics)
+	}()
+
+	if service, ok := s.Input.(telegraf.ServiceInput); ok {
+		if err := service.Start(acc); err != nil {
+			close(metrics)
+			wg.Wait()
+			return err
+		}
+		defer service.Stop()
+	}
+
+	gather := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(s.stdin)
+		for scanner.Scan() {
+			gather <- struct{}{}
+		}
+	}()
+
+	var tick <-chan time.Time
+	if pollInterval > 0 {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	defer func() {
+		close(metrics)
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-gather:
+		case <-tick:
+		case <-done:
+			return nil
+		}
+
+		if err := s.Input.Gather(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+}
+
+func (s *Shim) writeMetrics(metrics <-chan telegraf.Metric) {
+	for m := range metrics {
+		octets, err := s.serializer.Serialize(m)
+		if err != nil {
+			m.Drop()
+			continue
+		}
+		if _, err := s.stdout.Write(octets); err != nil {
+			m.Drop()
+			continue
+		}
+		m.Accept()
+	}
+}
+
+func (s *Shim) runProcessor() error {
+	return s.readBatches(func(batch []telegraf.Metric) error {
+		for _, m := range s.Processor.Apply(batch...) {
+			octets, err := s.serializer.Serialize(m)
+			if err != nil {
+				continue
+			}
+			if _, err := s.stdout.Write(octets); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(s.stdout, "\n")
+		return err
+	})
+}
+
+func (s *Shim) runOutput() error {
+	if err := s.Output.Connect(); err != nil {
+		return err
+	}
+	defer s.Output.Close()
+
+	return s.readBatches(func(batch []telegraf.Metric) error {
+		var reply string
+		if len(batch) > 0 {
+			if err := s.Output.Write(batch); err != nil {
+				reply = strings.Replace(err.Error(), "\n", " ", -1)
+			}
+		}
+		_, err := io.WriteString(s.stdout, reply+"\n")
+		return err
+	})
+}
+
+// readBatches reads metrics from STDIN and calls fn with each batch of
+// metrics terminated by an empty line.
+func (s *Shim) readBatches(fn func([]telegraf.Metric) error) error {
+	var batch []telegraf.Metric
+
+	scanner := bufio.NewScanner(s.stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			m, err := s.parser.ParseLine(line)
+			if err != nil {
+				log.Printf("E! [shim] Parse error: %s", err)
+				continue
+			}
+			batch = append(batch, m)
+			continue
+		}
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+		batch = nil
+	}
+	return scanner.Err()
+}
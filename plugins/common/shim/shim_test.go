@@ -0,0 +1,115 @@
+package shim
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+type testInput struct {
+	counter int64
+}
+
+func (i *testInput) SampleConfig() string { return "" }
+func (i *testInput) Description() string  { return "" }
+
+func (i *testInput) Gather(acc telegraf.Accumulator) error {
+	i.counter++
+	acc.AddFields("counter",
+		map[string]interface{}{"count": i.counter},
+		map[string]string{"pool": "tank"},
+		time.Unix(42, 0))
+	return nil
+}
+
+type testProcessor struct {
+	Log telegraf.Logger
+}
+
+func (p *testProcessor) SampleConfig() string { return "" }
+func (p *testProcessor) Description() string  { return "" }
+
+func (p *testProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.AddTag("processed", "true")
+	}
+	return in
+}
+
+type testOutput struct {
+	err     error
+	metrics []telegraf.Metric
+}
+
+func (o *testOutput) SampleConfig() string { return "" }
+func (o *testOutput) Description() string  { return "" }
+func (o *testOutput) Connect() error       { return nil }
+func (o *testOutput) Close() error         { return nil }
+
+func (o *testOutput) Write(metrics []telegraf.Metric) error {
+	o.metrics = append(o.metrics, metrics...)
+	return o.err
+}
+
+func newShim(stdin string) (*Shim, *bytes.Buffer) {
+	var stdout bytes.Buffer
+	s := New()
+	s.stdin = strings.NewReader(stdin)
+	s.stdout = &stdout
+	return s, &stdout
+}
+
+func TestInputGatherOnStdin(t *testing.T) {
+	s, stdout := newShim("\n\n")
+	require.NoError(t, s.AddInput(&testInput{}))
+	require.NoError(t, s.Run(0))
+
+	require.Equal(t,
+		"counter,pool=tank count=1i 42000000000\n"+
+			"counter,pool=tank count=2i 42000000000\n",
+		stdout.String())
+}
+
+func TestProcessorBatches(t *testing.T) {
+	s, stdout := newShim("cpu value=1 42\ncpu value=2 42\n\nmem value=3 42\n\n")
+	p := &testProcessor{}
+	require.NoError(t, s.AddProcessor(p))
+	require.NotNil(t, p.Log)
+	require.NoError(t, s.Run(0))
+
+	require.Equal(t,
+		"cpu,processed=true value=1 42\n"+
+			"cpu,processed=true value=2 42\n"+
+			"\n"+
+			"mem,processed=true value=3 42\n"+
+			"\n",
+		stdout.String())
+}
+
+func TestOutputReplies(t *testing.T) {
+	s, stdout := newShim("cpu value=1 42\n\n")
+	o := &testOutput{}
+	require.NoError(t, s.AddOutput(o))
+	require.NoError(t, s.Run(0))
+
+	require.Len(t, o.metrics, 1)
+	require.Equal(t, "\n", stdout.String())
+}
+
+func TestOutputWriteError(t *testing.T) {
+	s, stdout := newShim("cpu value=1 42\n\n")
+	require.NoError(t, s.AddOutput(&testOutput{err: errors.New("pool is full\nretry later")}))
+	require.NoError(t, s.Run(0))
+
+	require.Equal(t, "pool is full retry later\n", stdout.String())
+}
+
+func TestRunWithoutPlugin(t *testing.T) {
+	s, _ := newShim("")
+	require.Error(t, s.Run(0))
+}
@@ -75,18 +75,25 @@ func (bond *Bond) gatherBondInterface(bondName string, rawFile string, acc teleg
 	bondPart := rawFile[:splitIndex]
 	slavePart := rawFile[splitIndex:]
 
-	err := bond.gatherBondPart(bondName, bondPart, acc)
+	slaves, err := bond.parseSlavePart(slavePart)
 	if err != nil {
 		return err
 	}
-	err = bond.gatherSlavePart(bondName, slavePart, acc)
+	err = bond.gatherBondPart(bondName, bondPart, slaves, acc)
 	if err != nil {
 		return err
 	}
+	for _, slave := range slaves {
+		tags := map[string]string{
+			"bond":      bondName,
+			"interface": slave.name,
+		}
+		acc.AddFields("bond_slave", slave.fields, tags)
+	}
 	return nil
 }
 
-func (bond *Bond) gatherBondPart(bondName string, rawFile string, acc telegraf.Accumulator) error {
+func (bond *Bond) gatherBondPart(bondName string, rawFile string, slaves []slaveInfo, acc telegraf.Accumulator) error {
 	fields := make(map[string]interface{})
 	tags := map[string]string{
 		"bond": bondName,
@@ -95,7 +102,7 @@ func (bond *Bond) gatherBondPart(bondName string, rawFile string, acc telegraf.A
 	scanner := bufio.NewScanner(strings.NewReader(rawFile))
 	for scanner.Scan() {
 		line := scanner.Text()
-		stats := strings.Split(line, ":")
+		stats := strings.SplitN(line, ":", 2)
 		if len(stats) < 2 {
 			continue
 		}
@@ -109,58 +116,99 @@ func (bond *Bond) gatherBondPart(bondName string, rawFile string, acc telegraf.A
 			if value == "up" {
 				fields["status"] = 1
 			}
-			acc.AddFields("bond", fields, tags)
-			return nil
+		}
+		// The active aggregator of the 802.3ad mode.
+		if name == "Aggregator ID" {
+			if id, err := strconv.Atoi(value); err == nil {
+				fields["aggregator_id"] = id
+			}
+		}
+		if name == "Number of ports" {
+			if ports, err := strconv.Atoi(value); err == nil {
+				fields["aggregator_ports"] = ports
+			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
 		return err
 	}
-	return fmt.Errorf("Couldn't find status info for '%s' ", bondName)
+	if _, ok := fields["status"]; !ok {
+		return fmt.Errorf("Couldn't find status info for '%s' ", bondName)
+	}
+
+	slavesUp := 0
+	for _, slave := range slaves {
+		if slave.fields["status"] == 1 {
+			slavesUp++
+		}
+	}
+	fields["slaves"] = len(slaves)
+	fields["slaves_up"] = slavesUp
+	acc.AddFields("bond", fields, tags)
+	return nil
 }
 
-func (bond *Bond) gatherSlavePart(bondName string, rawFile string, acc telegraf.Accumulator) error {
-	var slave string
-	var status int
+type slaveInfo struct {
+	name   string
+	fields map[string]interface{}
+}
+
+func (bond *Bond) parseSlavePart(rawFile string) ([]slaveInfo, error) {
+	var slaves []slaveInfo
+	var slave *slaveInfo
 
 	scanner := bufio.NewScanner(strings.NewReader(rawFile))
 	for scanner.Scan() {
 		line := scanner.Text()
-		stats := strings.Split(line, ":")
+		stats := strings.SplitN(line, ":", 2)
 		if len(stats) < 2 {
 			continue
 		}
 		name := strings.TrimSpace(stats[0])
 		value := strings.TrimSpace(stats[1])
 		if strings.Contains(name, "Slave Interface") {
-			slave = value
+			slaves = append(slaves, slaveInfo{name: value, fields: map[string]interface{}{}})
+			slave = &slaves[len(slaves)-1]
+			continue
 		}
-		if strings.Contains(name, "MII Status") {
-			status = 0
+		if slave == nil {
+			continue
+		}
+		switch name {
+		case "MII Status":
+			slave.fields["status"] = 0
 			if value == "up" {
-				status = 1
+				slave.fields["status"] = 1
 			}
-		}
-		if strings.Contains(name, "Link Failure Count") {
+		case "Link Failure Count":
 			count, err := strconv.Atoi(value)
 			if err != nil {
-				return err
+				return nil, err
+			}
+			slave.fields["failures"] = count
+		case "Speed":
+			// Unknown when the link is down.
+			if speed, err := strconv.Atoi(strings.TrimSuffix(value, " Mbps")); err == nil {
+				slave.fields["speed"] = speed
 			}
-			fields := map[string]interface{}{
-				"status":   status,
-				"failures": count,
+		case "Duplex":
+			switch value {
+			case "full":
+				slave.fields["duplex"] = 1
+			case "half":
+				slave.fields["duplex"] = 0
 			}
-			tags := map[string]string{
-				"bond":      bondName,
-				"interface": slave,
+		case "Aggregator ID":
+			// N/A when the bond has no active aggregator.
+			if id, err := strconv.Atoi(value); err == nil {
+				slave.fields["aggregator_id"] = id
 			}
-			acc.AddFields("bond_slave", fields, tags)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return slaves, nil
 }
 
 // loadPath can be used to read path firstly from config
@@ -61,17 +61,70 @@ Link Failure Count: 0
 Permanent HW addr:
 `
 
+var sampleTestLACP = `
+Ethernet Channel Bonding Driver: v3.7.1 (April 27, 2011)
+
+Bonding Mode: IEEE 802.3ad Dynamic link aggregation
+Transmit Hash Policy: layer3+4 (1)
+MII Status: up
+MII Polling Interval (ms): 100
+Up Delay (ms): 0
+Down Delay (ms): 0
+
+802.3ad info
+LACP rate: fast
+Min links: 0
+Aggregator selection policy (ad_select): stable
+System priority: 65535
+System MAC address: 3c:fd:fe:9e:a1:20
+Active Aggregator Info:
+	Aggregator ID: 1
+	Number of ports: 1
+	Actor Key: 21
+	Partner Key: 32791
+	Partner Mac Address: 00:23:04:ee:be:64
+
+Slave Interface: enp3s0f0
+MII Status: up
+Speed: 10000 Mbps
+Duplex: full
+Link Failure Count: 0
+Permanent HW addr: 3c:fd:fe:9e:a1:20
+Slave queue ID: 0
+Aggregator ID: 1
+Actor Churn State: none
+Partner Churn State: none
+
+Slave Interface: enp3s0f1
+MII Status: up
+Speed: 1000 Mbps
+Duplex: full
+Link Failure Count: 1
+Permanent HW addr: 3c:fd:fe:9e:a1:21
+Slave queue ID: 0
+Aggregator ID: 2
+Actor Churn State: churned
+Partner Churn State: churned
+`
+
 func TestGatherBondInterface(t *testing.T) {
 	var acc testutil.Accumulator
 	bond := &Bond{}
 
 	bond.gatherBondInterface("bond802", sampleTest802, &acc)
-	acc.AssertContainsTaggedFields(t, "bond", map[string]interface{}{"status": 1}, map[string]string{"bond": "bond802"})
+	acc.AssertContainsTaggedFields(t, "bond", map[string]interface{}{"status": 1, "slaves": 2, "slaves_up": 2}, map[string]string{"bond": "bond802"})
 	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 0, "status": 1}, map[string]string{"bond": "bond802", "interface": "eth1"})
 	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 3, "status": 1}, map[string]string{"bond": "bond802", "interface": "eth2"})
 
 	bond.gatherBondInterface("bondAB", sampleTestAB, &acc)
-	acc.AssertContainsTaggedFields(t, "bond", map[string]interface{}{"active_slave": "eth2", "status": 1}, map[string]string{"bond": "bondAB"})
-	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 2, "status": 0}, map[string]string{"bond": "bondAB", "interface": "eth3"})
-	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 0, "status": 1}, map[string]string{"bond": "bondAB", "interface": "eth2"})
+	acc.AssertContainsTaggedFields(t, "bond", map[string]interface{}{"active_slave": "eth2", "status": 1, "slaves": 2, "slaves_up": 1}, map[string]string{"bond": "bondAB"})
+	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 2, "status": 0, "speed": 1000, "duplex": 1}, map[string]string{"bond": "bondAB", "interface": "eth3"})
+	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 0, "status": 1, "speed": 100, "duplex": 1}, map[string]string{"bond": "bondAB", "interface": "eth2"})
+
+	// The second slave negotiated a lower speed and is not in the active
+	// aggregator: the bond is degraded.
+	bond.gatherBondInterface("bondLACP", sampleTestLACP, &acc)
+	acc.AssertContainsTaggedFields(t, "bond", map[string]interface{}{"status": 1, "slaves": 2, "slaves_up": 2, "aggregator_id": 1, "aggregator_ports": 1}, map[string]string{"bond": "bondLACP"})
+	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 0, "status": 1, "speed": 10000, "duplex": 1, "aggregator_id": 1}, map[string]string{"bond": "bondLACP", "interface": "enp3s0f0"})
+	acc.AssertContainsTaggedFields(t, "bond_slave", map[string]interface{}{"failures": 1, "status": 1, "speed": 1000, "duplex": 1, "aggregator_id": 2}, map[string]string{"bond": "bondLACP", "interface": "enp3s0f1"})
 }
@@ -1,10 +1,16 @@
 package disk
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/system"
 )
@@ -15,8 +21,11 @@ type DiskStats struct {
 	// Legacy support
 	Mountpoints []string `toml:"mountpoints"`
 
-	MountPoints []string `toml:"mount_points"`
-	IgnoreFS    []string `toml:"ignore_fs"`
+	MountPoints     []string `toml:"mount_points"`
+	IgnoreFS        []string `toml:"ignore_fs"`
+	ZFSDatasetUsage bool     `toml:"zfs_dataset_usage"`
+
+	zfsList func() ([]byte, error)
 }
 
 func (_ *DiskStats) Description() string {
@@ -30,6 +39,11 @@ var diskSampleConfig = `
 
   ## Ignore mount points by filesystem type.
   ignore_fs = ["tmpfs", "devtmpfs", "devfs", "iso9660", "overlay", "aufs", "squashfs"]
+
+  ## Report the space used and available of the ZFS datasets as ZFS accounts
+  ## them, including snapshots, descendants, quotas and reservations, rather
+  ## than the statfs numbers, and tag them with their pool.  Runs zfs list.
+  # zfs_dataset_usage = false
 `
 
 func (_ *DiskStats) SampleConfig() string {
@@ -47,6 +61,14 @@ func (s *DiskStats) Gather(acc telegraf.Accumulator) error {
 		return fmt.Errorf("error getting disk usage info: %s", err)
 	}
 
+	var datasets map[string]zfsUsage
+	if s.ZFSDatasetUsage {
+		datasets, err = s.zfsDatasets()
+		if err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	for i, du := range disks {
 		if du.Total == 0 {
 			// Skip dummy filesystem (procfs, cgroupfs, ...)
@@ -59,6 +81,14 @@ func (s *DiskStats) Gather(acc telegraf.Accumulator) error {
 			"fstype": du.Fstype,
 			"mode":   mountOpts.Mode(),
 		}
+		if du.Fstype == "zfs" {
+			if usage, ok := datasets[partitions[i].Device]; ok {
+				tags["pool"] = strings.SplitN(partitions[i].Device, "/", 2)[0]
+				du.Used = usage.used
+				du.Free = usage.available
+				du.Total = usage.used + usage.available
+			}
+		}
 		var used_percent float64
 		if du.Used+du.Free > 0 {
 			used_percent = float64(du.Used) /
@@ -80,6 +110,49 @@ func (s *DiskStats) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+type zfsUsage struct {
+	used      uint64
+	available uint64
+}
+
+// zfsDatasets returns the usage of the ZFS filesystems by dataset name, the
+// device of their mounts.
+func (s *DiskStats) zfsDatasets() (map[string]zfsUsage, error) {
+	out, err := s.zfsList()
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := make(map[string]zfsUsage)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// name, used and available separated by tabs.
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) != 3 {
+			continue
+		}
+		used, err := strconv.ParseUint(cols[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		available, err := strconv.ParseUint(cols[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		datasets[cols[0]] = zfsUsage{used: used, available: available}
+	}
+	return datasets, scanner.Err()
+}
+
+func runZfsList() ([]byte, error) {
+	cmd := exec.Command("zfs", "list", "-Hp", "-o", "name,used,available", "-t", "filesystem")
+	out, err := internal.CombinedOutputTimeout(cmd, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("error running zfs list: %s: %s", err, bytes.TrimSpace(out))
+	}
+	return out, nil
+}
+
 type MountOptions []string
 
 func (opts MountOptions) Mode() string {
@@ -108,6 +181,6 @@ func parseOptions(opts string) MountOptions {
 func init() {
 	ps := system.NewSystemPS()
 	inputs.Add("disk", func() telegraf.Input {
-		return &DiskStats{ps: ps}
+		return &DiskStats{ps: ps, zfsList: runZfsList}
 	})
 }
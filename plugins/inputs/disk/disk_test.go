@@ -118,6 +118,91 @@ func TestDiskUsage(t *testing.T) {
 	assert.Equal(t, 2*expectedAllDiskMetrics+7, acc.NFields())
 }
 
+func TestDiskUsageZFS(t *testing.T) {
+	mck := &mock.Mock{}
+	mps := system.MockPSDisk{SystemPS: &system.SystemPS{PSDiskDeps: &system.MockDiskUsage{Mock: mck}}, Mock: mck}
+	defer mps.AssertExpectations(t)
+
+	psAll := []disk.PartitionStat{
+		{
+			Device:     "tank/home",
+			Mountpoint: "/home",
+			Fstype:     "zfs",
+			Opts:       "rw,xattr,noacl",
+		},
+		{
+			Device:     "tank/new",
+			Mountpoint: "/new",
+			Fstype:     "zfs",
+			Opts:       "rw,xattr,noacl",
+		},
+	}
+	duAll := []disk.UsageStat{
+		{
+			Path:   "/home",
+			Fstype: "zfs",
+			Total:  1000,
+			Free:   600,
+			Used:   400,
+		},
+		{
+			Path:   "/new",
+			Fstype: "zfs",
+			Total:  1000,
+			Free:   1000,
+			Used:   0,
+		},
+	}
+
+	mps.On("Partitions", true).Return(psAll, nil)
+	mps.On("OSGetenv", "HOST_MOUNT_PREFIX").Return("")
+	mps.On("PSDiskUsage", "/home").Return(&duAll[0], nil)
+	mps.On("PSDiskUsage", "/new").Return(&duAll[1], nil)
+
+	var acc testutil.Accumulator
+	err := (&DiskStats{
+		ps:              mps,
+		ZFSDatasetUsage: true,
+		zfsList: func() ([]byte, error) {
+			// The snapshots of tank/home use 300 bytes, and its quota leaves
+			// 100 bytes available.
+			return []byte("tank\t1200\t100\ntank/home\t700\t100\n"), nil
+		},
+	}).Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "disk", map[string]interface{}{
+		"total":        uint64(800),
+		"used":         uint64(700),
+		"free":         uint64(100),
+		"inodes_total": uint64(0),
+		"inodes_free":  uint64(0),
+		"inodes_used":  uint64(0),
+		"used_percent": float64(87.5),
+	}, map[string]string{
+		"path":   "/home",
+		"fstype": "zfs",
+		"device": "tank/home",
+		"mode":   "rw",
+		"pool":   "tank",
+	})
+	// A dataset unknown to zfs list keeps the statfs numbers.
+	acc.AssertContainsTaggedFields(t, "disk", map[string]interface{}{
+		"total":        uint64(1000),
+		"used":         uint64(0),
+		"free":         uint64(1000),
+		"inodes_total": uint64(0),
+		"inodes_free":  uint64(0),
+		"inodes_used":  uint64(0),
+		"used_percent": float64(0),
+	}, map[string]string{
+		"path":   "/new",
+		"fstype": "zfs",
+		"device": "tank/new",
+		"mode":   "rw",
+	})
+}
+
 func TestDiskUsageHostMountPrefix(t *testing.T) {
 	tests := []struct {
 		name            string
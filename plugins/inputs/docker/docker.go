@@ -46,6 +46,8 @@ type Docker struct {
 
 	IncludeSourceTag bool `toml:"source_tag"`
 
+	StorageObjects []string `toml:"storage_objects"`
+
 	Log telegraf.Logger
 
 	tlsint.ClientConfig
@@ -77,6 +79,7 @@ const (
 var (
 	sizeRegex       = regexp.MustCompile(`^(\d+(\.\d+)*) ?([kKmMgGtTpP])?[bB]?$`)
 	containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+	storageObjects  = []string{"container", "image", "volume"}
 	now             = time.Now
 )
 
@@ -117,6 +120,11 @@ var sampleConfig = `
   ## Whether to report for each container total blkio and network stats or not
   total = false
 
+  ## Objects to report the disk usage of in the docker_disk_usage
+  ## measurement, any of "container", "image" and "volume".  Computing the
+  ## disk usage can be slow with many containers or large volumes.
+  # storage_objects = []
+
   ## Which environment variables should we use as a tag
   ##tag_env = ["JAVA_HOME", "HEAP_SIZE"]
 
@@ -165,6 +173,11 @@ func (d *Docker) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+		for _, object := range d.StorageObjects {
+			if !sliceContains(object, storageObjects) {
+				return fmt.Errorf("unknown storage object %q", object)
+			}
+		}
 		d.filtersCreated = true
 	}
 
@@ -181,6 +194,13 @@ func (d *Docker) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 
+	if len(d.StorageObjects) > 0 {
+		err := d.gatherDiskUsage(acc)
+		if err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	filterArgs := filters.NewArgs()
 	for _, state := range containerStates {
 		if d.stateFilter.Match(state) {
@@ -417,6 +437,87 @@ func (d *Docker) gatherInfo(acc telegraf.Accumulator) error {
 	return nil
 }
 
+func (d *Docker) gatherDiskUsage(acc telegraf.Accumulator) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration)
+	defer cancel()
+
+	du, err := d.client.DiskUsage(ctx)
+	if err == context.DeadlineExceeded {
+		return errDiskUsageTimeout
+	}
+	if err != nil {
+		return err
+	}
+
+	tm := time.Now()
+	tags := map[string]string{
+		"engine_host":    d.engineHost,
+		"server_version": d.serverVersion,
+	}
+	acc.AddFields("docker_disk_usage",
+		map[string]interface{}{"layers_size": du.LayersSize}, tags, tm)
+
+	if sliceContains("container", d.StorageObjects) {
+		for _, container := range du.Containers {
+			var cname string
+			for _, name := range container.Names {
+				trimmedName := strings.TrimPrefix(name, "/")
+				if d.containerFilter.Match(trimmedName) {
+					cname = trimmedName
+					break
+				}
+			}
+			if cname == "" {
+				continue
+			}
+
+			imageName, imageVersion := docker.ParseImage(container.Image)
+			ctags := copyTags(tags)
+			ctags["container_name"] = cname
+			ctags["container_image"] = imageName
+			ctags["container_version"] = imageVersion
+			fields := map[string]interface{}{
+				"size_rw":      container.SizeRw,
+				"size_root_fs": container.SizeRootFs,
+			}
+			acc.AddFields("docker_disk_usage", fields, ctags, tm)
+		}
+	}
+
+	if sliceContains("image", d.StorageObjects) {
+		for _, image := range du.Images {
+			itags := copyTags(tags)
+			itags["image_id"] = hostnameFromID(strings.TrimPrefix(image.ID, "sha256:"))
+			if len(image.RepoTags) > 0 {
+				itags["image_name"], itags["image_version"] = docker.ParseImage(image.RepoTags[0])
+			}
+			fields := map[string]interface{}{
+				"size":        image.Size,
+				"shared_size": image.SharedSize,
+			}
+			acc.AddFields("docker_disk_usage", fields, itags, tm)
+		}
+	}
+
+	if sliceContains("volume", d.StorageObjects) {
+		for _, volume := range du.Volumes {
+			// The size is -1 when it is not computed by the volume driver.
+			if volume.UsageData == nil || volume.UsageData.Size < 0 {
+				continue
+			}
+			vtags := copyTags(tags)
+			vtags["volume_name"] = volume.Name
+			fields := map[string]interface{}{
+				"size":      volume.UsageData.Size,
+				"ref_count": volume.UsageData.RefCount,
+			}
+			acc.AddFields("docker_disk_usage", fields, vtags, tm)
+		}
+	}
+
+	return nil
+}
+
 func hostnameFromID(id string) string {
 	if len(id) > 12 {
 		return id[0:12]
@@ -24,6 +24,7 @@ type MockClient struct {
 	ServiceListF      func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
 	TaskListF         func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
 	NodeListF         func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
+	DiskUsageF        func(ctx context.Context) (types.DiskUsage, error)
 }
 
 func (c *MockClient) Info(ctx context.Context) (types.Info, error) {
@@ -73,6 +74,10 @@ func (c *MockClient) NodeList(
 	return c.NodeListF(ctx, options)
 }
 
+func (c *MockClient) DiskUsage(ctx context.Context) (types.DiskUsage, error) {
+	return c.DiskUsageF(ctx)
+}
+
 var baseClient = MockClient{
 	InfoF: func(context.Context) (types.Info, error) {
 		return info, nil
@@ -95,6 +100,9 @@ var baseClient = MockClient{
 	NodeListF: func(context.Context, types.NodeListOptions) ([]swarm.Node, error) {
 		return NodeList, nil
 	},
+	DiskUsageF: func(context.Context) (types.DiskUsage, error) {
+		return diskUsage, nil
+	},
 }
 
 func newClient(host string, tlsConfig *tls.Config) (Client, error) {
@@ -1117,3 +1125,93 @@ func TestHostnameFromID(t *testing.T) {
 	}
 
 }
+
+func TestDockerGatherDiskUsage(t *testing.T) {
+	var acc testutil.Accumulator
+	d := Docker{
+		Log:              testutil.Logger{},
+		newClient:        newClient,
+		StorageObjects:   []string{"container", "image", "volume"},
+		ContainerInclude: []string{"etcd"},
+		ContainerStateExclude: []string{
+			"created", "restarting", "running", "removing", "paused", "exited", "dead",
+		},
+	}
+
+	require.NoError(t, acc.GatherError(d.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"docker_disk_usage",
+			map[string]string{
+				"engine_host":    "absol",
+				"server_version": "17.09.0-ce",
+			},
+			map[string]interface{}{
+				"layers_size": int64(1234567890),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"docker_disk_usage",
+			map[string]string{
+				"engine_host":       "absol",
+				"server_version":    "17.09.0-ce",
+				"container_name":    "etcd",
+				"container_image":   "quay.io/coreos/etcd",
+				"container_version": "v2.2.2",
+			},
+			map[string]interface{}{
+				"size_rw":      int64(4096),
+				"size_root_fs": int64(123456),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"docker_disk_usage",
+			map[string]string{
+				"engine_host":    "absol",
+				"server_version": "17.09.0-ce",
+				"image_id":       "e2173b9478a6",
+				"image_name":     "quay.io/coreos/etcd",
+				"image_version":  "v2.2.2",
+			},
+			map[string]interface{}{
+				"size":        int64(123456),
+				"shared_size": int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"docker_disk_usage",
+			map[string]string{
+				"engine_host":    "absol",
+				"server_version": "17.09.0-ce",
+				"volume_name":    "etcd-data",
+			},
+			map[string]interface{}{
+				"size":      int64(52428800),
+				"ref_count": int64(1),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	var actual []telegraf.Metric
+	for _, m := range acc.GetTelegrafMetrics() {
+		if m.Name() == "docker_disk_usage" {
+			actual = append(actual, m)
+		}
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestDockerUnknownStorageObject(t *testing.T) {
+	var acc testutil.Accumulator
+	d := Docker{
+		Log:            testutil.Logger{},
+		newClient:      newClient,
+		StorageObjects: []string{"network"},
+	}
+	require.Error(t, acc.GatherError(d.Gather))
+}
@@ -148,6 +148,40 @@ var containerList = []types.Container{
 	},
 }
 
+var diskUsage = types.DiskUsage{
+	LayersSize: 1234567890,
+	Containers: []*types.Container{
+		{
+			ID:         "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
+			Names:      []string{"/etcd"},
+			Image:      "quay.io/coreos/etcd:v2.2.2",
+			SizeRw:     4096,
+			SizeRootFs: 123456,
+		},
+		{
+			ID:    "e8a713dd90604f5a257b97c15945e047ab60ed5b2c4397c5a6b5bf40e1bd2791",
+			Names: []string{"/acme"},
+		},
+	},
+	Images: []*types.ImageSummary{
+		{
+			ID:       "sha256:e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
+			RepoTags: []string{"quay.io/coreos/etcd:v2.2.2"},
+			Size:     123456,
+		},
+	},
+	Volumes: []*types.Volume{
+		{
+			Name:      "etcd-data",
+			UsageData: &types.VolumeUsageData{RefCount: 1, Size: 52428800},
+		},
+		{
+			Name:      "remote",
+			UsageData: &types.VolumeUsageData{RefCount: 0, Size: -1},
+		},
+	},
+}
+
 var two = uint64(2)
 var ServiceList = []swarm.Service{
 	{
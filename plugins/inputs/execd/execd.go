@@ -0,0 +1,153 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon
+  command = ["telegraf-smartctl", "-d", "/dev/sda"]
+
+  ## Define how the process is signaled on each collection interval.
+  ## Valid values are:
+  ##   "none"   : Do not signal anything.
+  ##              The process must output metrics by itself.
+  ##   "STDIN"  : Send a newline on STDIN.
+  ##   "SIGHUP" : Send a HUP signal. Not available on Windows.
+  ##   "SIGUSR1" : Send a USR1 signal. Not available on Windows.
+  ##   "SIGUSR2" : Send a USR2 signal. Not available on Windows.
+  signal = "none"
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay is doubled after each consecutive failure up to
+  ## max_restart_delay, and reset once the process stays up for longer than
+  ## max_restart_delay.
+  restart_delay = "10s"
+  # max_restart_delay = "5m"
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command         []string
+	Signal          string
+	RestartDelay    internal.Duration
+	MaxRestartDelay internal.Duration
+
+	Log telegraf.Logger
+
+	process *process.Process
+	acc     telegraf.Accumulator
+	parser  parsers.Parser
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running input plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return err
+	}
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.MaxRestartDelay = e.MaxRestartDelay.Duration
+	e.process.Log = e.Log
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+	e.process.OnExitFn = acc.AddError
+
+	return e.process.Start()
+}
+
+func (e *Execd) Stop() {
+	if e.process == nil {
+		return
+	}
+
+	e.process.Stop()
+}
+
+func (e *Execd) Gather(acc telegraf.Accumulator) error {
+	if e.process == nil {
+		return nil
+	}
+
+	switch e.Signal {
+	case "STDIN":
+		if _, err := io.WriteString(e.process, "\n"); err != nil {
+			return fmt.Errorf("error writing to stdin: %s", err)
+		}
+	case "none", "":
+	default:
+		return e.signal()
+	}
+
+	return nil
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		metrics, err := e.parser.Parse(scanner.Bytes())
+		if err != nil {
+			e.acc.AddError(fmt.Errorf("parse error: %s", err))
+			continue
+		}
+
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.acc.AddError(fmt.Errorf("error reading stdout: %s", err))
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.acc.AddError(fmt.Errorf("error reading stderr: %s", err))
+	}
+}
+
+func init() {
+	inputs.Add("execd", func() telegraf.Input {
+		return &Execd{
+			Signal:          "none",
+			RestartDelay:    internal.Duration{Duration: 10 * time.Second},
+			MaxRestartDelay: internal.Duration{Duration: 5 * time.Minute},
+		}
+	})
+}
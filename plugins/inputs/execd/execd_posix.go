@@ -0,0 +1,21 @@
+// +build !windows
+
+package execd
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func (e *Execd) signal() error {
+	switch e.Signal {
+	case "SIGHUP":
+		return e.process.Signal(syscall.SIGHUP)
+	case "SIGUSR1":
+		return e.process.Signal(syscall.SIGUSR1)
+	case "SIGUSR2":
+		return e.process.Signal(syscall.SIGUSR2)
+	}
+
+	return fmt.Errorf("invalid signal: %s", e.Signal)
+}
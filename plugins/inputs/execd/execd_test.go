@@ -0,0 +1,97 @@
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newExecd(command ...string) *Execd {
+	parser, _ := parsers.NewInfluxParser()
+	return &Execd{
+		Command:         command,
+		Signal:          "none",
+		RestartDelay:    internal.Duration{Duration: 10 * time.Millisecond},
+		MaxRestartDelay: internal.Duration{Duration: 50 * time.Millisecond},
+		Log:             testutil.Logger{},
+		parser:          parser,
+	}
+}
+
+func TestExecdReadsMetrics(t *testing.T) {
+	e := newExecd("/bin/sh", "-c", "echo 'zpool,pool=tank alloc=42i'; sleep 10")
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "zpool",
+		map[string]interface{}{"alloc": int64(42)},
+		map[string]string{"pool": "tank"})
+}
+
+func TestExecdSignalStdin(t *testing.T) {
+	e := newExecd("/bin/sh", "-c", "while read line; do echo 'counter value=1i'; done")
+	e.Signal = "STDIN"
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	require.NoError(t, e.Gather(acc))
+	acc.Wait(1)
+	require.NoError(t, e.Gather(acc))
+	acc.Wait(2)
+}
+
+func TestExecdRestartsProcess(t *testing.T) {
+	e := newExecd("/bin/sh", "-c", "echo 'restarted value=1i'")
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	// Each run of the process emits one metric, receiving more than one
+	// proves the process was restarted.
+	acc.Wait(3)
+	require.Error(t, acc.FirstError())
+}
+
+func TestExecdReadsOutputBeforeExit(t *testing.T) {
+	e := newExecd("/bin/sh", "-c",
+		`i=0; while [ $i -lt 1000 ]; do echo "line value=${i}i"; i=$((i+1)); done`)
+	e.RestartDelay = internal.Duration{Duration: time.Hour}
+	e.MaxRestartDelay = internal.Duration{Duration: time.Hour}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+	defer e.Stop()
+
+	// The output is read completely before the exit of the process is
+	// reported, rather than cut off by the pipes being closed.
+	acc.WaitError(1)
+	acc.Lock()
+	defer acc.Unlock()
+	require.Equal(t, uint64(1000), acc.NMetrics())
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "terminated")
+}
+
+func TestExecdStartInvalidCommand(t *testing.T) {
+	e := newExecd("/nonexistent/command")
+
+	acc := &testutil.Accumulator{}
+	require.Error(t, e.Start(acc))
+}
+
+func TestExecdStopWithoutStart(t *testing.T) {
+	e := newExecd("/bin/true")
+	e.Stop()
+}
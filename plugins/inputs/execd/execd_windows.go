@@ -0,0 +1,11 @@
+// +build windows
+
+package execd
+
+import (
+	"fmt"
+)
+
+func (e *Execd) signal() error {
+	return fmt.Errorf("invalid signal: %s", e.Signal)
+}
@@ -1,6 +1,7 @@
 package filecount
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -45,6 +46,13 @@ const sampleConfig = `
   ## duration. If mtime is negative, only count files that have been
   ## touched in this duration. Defaults to "0s".
   mtime = "0s"
+
+  ## Maximum number of files and directories visited, and maximum duration of
+  ## the walk of each directory.  A walk reaching either limit is stopped with
+  ## an error, and the directories not completely walked are not reported.
+  ## Unlimited when zero.
+  # max_files = 0
+  # timeout = "0s"
 `
 
 type FileCount struct {
@@ -55,10 +63,14 @@ type FileCount struct {
 	RegularOnly bool
 	Size        internal.Size
 	MTime       internal.Duration `toml:"mtime"`
+	MaxFiles    int               `toml:"max_files"`
+	Timeout     internal.Duration `toml:"timeout"`
 	fileFilters []fileFilterFunc
 	globPaths   []globpath.GlobPath
 	Fs          fileSystem
 	Log         telegraf.Logger
+
+	now func() time.Time
 }
 
 func (_ *FileCount) Description() string {
@@ -154,12 +166,28 @@ func (fc *FileCount) initFileFilters() {
 func (fc *FileCount) count(acc telegraf.Accumulator, basedir string, glob globpath.GlobPath) {
 	childCount := make(map[string]int64)
 	childSize := make(map[string]int64)
+	childOldest := make(map[string]time.Time)
+	childNewest := make(map[string]time.Time)
+
+	now := fc.now()
+	var deadline time.Time
+	if fc.Timeout.Duration > 0 {
+		deadline = time.Now().Add(fc.Timeout.Duration)
+	}
+	visited := 0
 
 	walkFn := func(path string, de *godirwalk.Dirent) error {
 		rel, err := filepath.Rel(basedir, path)
 		if err == nil && rel == "." {
 			return nil
 		}
+		visited++
+		if fc.MaxFiles > 0 && visited > fc.MaxFiles {
+			return fmt.Errorf("walk of %s stopped after %d files", basedir, fc.MaxFiles)
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("walk of %s timed out after %s", basedir, fc.Timeout.Duration)
+		}
 		file, err := fc.Fs.Stat(path)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -176,6 +204,7 @@ func (fc *FileCount) count(acc telegraf.Accumulator, basedir string, glob globpa
 			parent := filepath.Dir(path)
 			childCount[parent]++
 			childSize[parent] += file.Size()
+			updateAge(childOldest, childNewest, parent, file.ModTime(), file.ModTime())
 		}
 		if file.IsDir() && !fc.Recursive && !glob.HasSuperMeta {
 			return filepath.SkipDir
@@ -189,6 +218,11 @@ func (fc *FileCount) count(acc telegraf.Accumulator, basedir string, glob globpa
 				"count":      childCount[path],
 				"size_bytes": childSize[path],
 			}
+			if oldest, ok := childOldest[path]; ok {
+				gauge["oldest_file_timestamp"] = oldest.UnixNano()
+				gauge["newest_file_timestamp"] = childNewest[path].UnixNano()
+				gauge["oldest_file_age_seconds"] = int64(now.Sub(oldest).Seconds())
+			}
 			acc.AddGauge("filecount", gauge,
 				map[string]string{
 					"directory": path,
@@ -198,9 +232,14 @@ func (fc *FileCount) count(acc telegraf.Accumulator, basedir string, glob globpa
 		if fc.Recursive {
 			childCount[parent] += childCount[path]
 			childSize[parent] += childSize[path]
+			if oldest, ok := childOldest[path]; ok {
+				updateAge(childOldest, childNewest, parent, oldest, childNewest[path])
+			}
 		}
 		delete(childCount, path)
 		delete(childSize, path)
+		delete(childOldest, path)
+		delete(childNewest, path)
 		return nil
 	}
 
@@ -221,6 +260,17 @@ func (fc *FileCount) count(acc telegraf.Accumulator, basedir string, glob globpa
 	}
 }
 
+// updateAge extends the range of modification times of the files counted in
+// the directory.
+func updateAge(oldestByDir, newestByDir map[string]time.Time, dir string, oldest, newest time.Time) {
+	if t, ok := oldestByDir[dir]; !ok || oldest.Before(t) {
+		oldestByDir[dir] = oldest
+	}
+	if t, ok := newestByDir[dir]; !ok || newest.After(t) {
+		newestByDir[dir] = newest
+	}
+}
+
 func (fc *FileCount) filter(file os.FileInfo) (bool, error) {
 	if fc.fileFilters == nil {
 		fc.initFileFilters()
@@ -243,6 +293,9 @@ func (fc *FileCount) Gather(acc telegraf.Accumulator) error {
 	if fc.globPaths == nil {
 		fc.initGlobPaths(acc)
 	}
+	if fc.now == nil {
+		fc.now = time.Now
+	}
 
 	for _, glob := range fc.globPaths {
 		for _, dir := range fc.onlyDirectories(glob.GetRoots()) {
@@ -301,6 +354,7 @@ func NewFileCount() *FileCount {
 		MTime:       internal.Duration{Duration: 0},
 		fileFilters: nil,
 		Fs:          osFS{},
+		now:         time.Now,
 	}
 }
 
@@ -127,6 +127,9 @@ func TestDirectoryWithTrailingSlash(t *testing.T) {
 		Name:        "*",
 		Recursive:   true,
 		Fs:          getFakeFileSystem(getTestdataDir()),
+		now: func() time.Time {
+			return time.Date(2010, time.December, 15, 18, 25, 5, 0, time.UTC)
+		},
 	}
 
 	var acc testutil.Accumulator
@@ -140,8 +143,11 @@ func TestDirectoryWithTrailingSlash(t *testing.T) {
 				"directory": getTestdataDir(),
 			},
 			map[string]interface{}{
-				"count":      9,
-				"size_bytes": 5096,
+				"count":                   9,
+				"size_bytes":              5096,
+				"oldest_file_timestamp":   time.Date(2010, time.December, 14, 18, 25, 5, 0, time.UTC).UnixNano(),
+				"newest_file_timestamp":   time.Date(2015, time.December, 14, 18, 25, 5, 0, time.UTC).UnixNano(),
+				"oldest_file_age_seconds": int64(86400),
 			},
 			time.Unix(0, 0),
 			telegraf.Gauge,
@@ -151,6 +157,41 @@ func TestDirectoryWithTrailingSlash(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
 }
 
+func TestOldestFile(t *testing.T) {
+	fc := getNoFilterFileCount()
+	fc.Directories = []string{getTestdataDir(), getTestdataDir() + "/**"}
+	fc.now = func() time.Time {
+		return time.Date(2015, time.December, 14, 18, 26, 5, 0, time.UTC)
+	}
+
+	acc := testutil.Accumulator{}
+	acc.GatherError(fc.Gather)
+
+	mtime := time.Date(2015, time.December, 14, 18, 25, 5, 0, time.UTC)
+	olderMtime := time.Date(2010, time.December, 14, 18, 25, 5, 0, time.UTC)
+
+	tags := map[string]string{"directory": getTestdataDir()}
+	require.True(t, acc.HasPoint("filecount", tags, "oldest_file_timestamp", olderMtime.UnixNano()))
+	require.True(t, acc.HasPoint("filecount", tags, "newest_file_timestamp", mtime.UnixNano()))
+
+	// baz is not in subdir
+	tags = map[string]string{"directory": getTestdataDir() + "/subdir"}
+	require.True(t, acc.HasPoint("filecount", tags, "oldest_file_timestamp", mtime.UnixNano()))
+	require.True(t, acc.HasPoint("filecount", tags, "oldest_file_age_seconds", int64(60)))
+}
+
+func TestMaxFiles(t *testing.T) {
+	fc := getNoFilterFileCount()
+	fc.MaxFiles = 3
+
+	acc := testutil.Accumulator{}
+	require.Error(t, acc.GatherError(fc.Gather))
+	require.False(t, acc.HasTag("filecount", "directory"))
+
+	fc.MaxFiles = 9
+	fileCountEquals(t, fc, 9, 5096)
+}
+
 func getNoFilterFileCount() FileCount {
 	return FileCount{
 		Log:         testutil.Logger{},
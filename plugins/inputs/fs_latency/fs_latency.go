@@ -0,0 +1,226 @@
+package fs_latency
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type ResultType uint64
+
+const (
+	Success ResultType = 0
+	Timeout            = 1
+	Failed             = 2
+)
+
+const sampleConfig = `
+  ## Directories in which a file is created, written, synced, read and
+  ## removed, typically one per dataset or mount point.
+  directories = ["/var/spool", "/tank/backup"]
+
+  ## Size of the file written.
+  # file_size = "4KiB"
+
+  ## Maximum duration of the cycle, a directory whose cycle did not complete
+  ## is not probed again until it does.
+  # timeout = "5s"
+`
+
+// phase is a step of the probe cycle and its duration.
+type phase struct {
+	name     string
+	duration time.Duration
+}
+
+type FSLatency struct {
+	Directories []string          `toml:"directories"`
+	FileSize    internal.Size     `toml:"file_size"`
+	Timeout     internal.Duration `toml:"timeout"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	mu      sync.Mutex
+	running map[string]bool
+
+	probe func(dir string, size int64) ([]phase, error)
+}
+
+func (f *FSLatency) Description() string {
+	return "Time the creation, write, sync, read and removal of a file in directories"
+}
+
+func (f *FSLatency) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *FSLatency) Init() error {
+	if f.FileSize.Size <= 0 {
+		return fmt.Errorf("file_size must be positive")
+	}
+	if f.Timeout.Duration <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	f.running = make(map[string]bool)
+	return nil
+}
+
+func (f *FSLatency) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, dir := range f.Directories {
+		wg.Add(1)
+		go func(dir string) {
+			defer wg.Done()
+			f.gatherDirectory(acc, dir)
+		}(dir)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (f *FSLatency) gatherDirectory(acc telegraf.Accumulator, dir string) {
+	tags := map[string]string{"directory": dir}
+	fields := map[string]interface{}{}
+
+	f.mu.Lock()
+	if f.running[dir] {
+		f.mu.Unlock()
+		f.Log.Debugf("Previous cycle in %s did not complete", dir)
+		setResult(Timeout, fields, tags)
+		acc.AddFields("fs_latency", fields, tags)
+		return
+	}
+	f.running[dir] = true
+	f.mu.Unlock()
+
+	type result struct {
+		phases []phase
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		phases, err := f.probe(dir, f.FileSize.Size)
+
+		f.mu.Lock()
+		delete(f.running, dir)
+		f.mu.Unlock()
+
+		done <- result{phases, err}
+	}()
+
+	timer := time.NewTimer(f.Timeout.Duration)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		var total time.Duration
+		for _, p := range r.phases {
+			fields[p.name+"_time"] = p.duration.Seconds()
+			total += p.duration
+		}
+		if r.err != nil {
+			acc.AddError(fmt.Errorf("%s: %v", dir, r.err))
+			setResult(Failed, fields, tags)
+		} else {
+			fields["total_time"] = total.Seconds()
+			setResult(Success, fields, tags)
+		}
+	case <-timer.C:
+		setResult(Timeout, fields, tags)
+	}
+	acc.AddFields("fs_latency", fields, tags)
+}
+
+// probe creates, writes, syncs, reads back and removes a file in the
+// directory, and returns the duration of the completed phases.
+func probe(dir string, size int64) ([]phase, error) {
+	var phases []phase
+	step := func(name string, fn func() error) error {
+		start := time.Now()
+		err := fn()
+		if err == nil {
+			phases = append(phases, phase{name, time.Since(start)})
+		}
+		return err
+	}
+
+	// Random data, so that it is not compressed or deduplicated away.
+	data := make([]byte, size)
+	rand.Read(data)
+
+	var file *os.File
+	err := step("create", func() error {
+		var err error
+		file, err = ioutil.TempFile(dir, ".telegraf-fs_latency-")
+		return err
+	})
+	if err != nil {
+		return phases, err
+	}
+	name := file.Name()
+	defer os.Remove(name)
+
+	err = step("write", func() error {
+		_, err := file.Write(data)
+		return err
+	})
+	if err == nil {
+		err = step("fsync", file.Sync)
+	}
+	if err == nil {
+		err = step("read", func() error {
+			buf := make([]byte, size)
+			if _, err := file.ReadAt(buf, 0); err != nil {
+				return err
+			}
+			if !bytes.Equal(buf, data) {
+				return fmt.Errorf("data read from %s differs from the data written", name)
+			}
+			return nil
+		})
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return phases, err
+	}
+
+	err = step("unlink", func() error {
+		return os.Remove(name)
+	})
+	return phases, err
+}
+
+func setResult(result ResultType, fields map[string]interface{}, tags map[string]string) {
+	var tag string
+	switch result {
+	case Success:
+		tag = "success"
+	case Timeout:
+		tag = "timeout"
+	case Failed:
+		tag = "failed"
+	}
+
+	tags["result"] = tag
+	fields["result_code"] = uint64(result)
+}
+
+func init() {
+	inputs.Add("fs_latency", func() telegraf.Input {
+		return &FSLatency{
+			FileSize: internal.Size{Size: 4096},
+			Timeout:  internal.Duration{Duration: 5 * time.Second},
+			probe:    probe,
+		}
+	})
+}
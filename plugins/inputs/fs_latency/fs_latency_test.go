@@ -0,0 +1,92 @@
+package fs_latency
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newFSLatency(dirs ...string) *FSLatency {
+	f := &FSLatency{
+		Directories: dirs,
+		FileSize:    internal.Size{Size: 4096},
+		Timeout:     internal.Duration{Duration: 5 * time.Second},
+		Log:         testutil.Logger{},
+		probe:       probe,
+	}
+	return f
+}
+
+func TestFSLatency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_latency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	f := newFSLatency(dir)
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(f.Gather))
+
+	require.Len(t, acc.Metrics, 1)
+	m := acc.Metrics[0]
+	require.Equal(t, map[string]string{"directory": dir, "result": "success"}, m.Tags)
+	require.Equal(t, uint64(0), m.Fields["result_code"])
+	for _, field := range []string{"create_time", "write_time", "fsync_time", "read_time", "unlink_time", "total_time"} {
+		require.IsType(t, float64(0), m.Fields[field], field)
+	}
+
+	// The file is removed.
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 0)
+}
+
+func TestFSLatencyFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fs_latency")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	missing := filepath.Join(dir, "missing")
+
+	f := newFSLatency(missing)
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	acc.AssertContainsTaggedFields(t, "fs_latency",
+		map[string]interface{}{"result_code": uint64(2)},
+		map[string]string{"directory": missing, "result": "failed"})
+}
+
+func TestFSLatencyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	f := newFSLatency("/tank/backup")
+	f.Timeout = internal.Duration{Duration: 10 * time.Millisecond}
+	f.probe = func(dir string, size int64) ([]phase, error) {
+		<-release
+		return nil, nil
+	}
+	require.NoError(t, f.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, f.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "fs_latency",
+		map[string]interface{}{"result_code": uint64(1)},
+		map[string]string{"directory": "/tank/backup", "result": "timeout"})
+
+	// The directory is not probed again while the cycle is blocked.
+	acc.ClearMetrics()
+	require.NoError(t, f.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "fs_latency",
+		map[string]interface{}{"result_code": uint64(1)},
+		map[string]string{"directory": "/tank/backup", "result": "timeout"})
+
+	close(release)
+}
@@ -1,12 +1,14 @@
 package http
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -22,30 +24,51 @@ type HTTP struct {
 	Body            string   `toml:"body"`
 	ContentEncoding string   `toml:"content_encoding"`
 
+	// Hosts the URLs and body templates are expanded for.
+	Hosts []string `toml:"hosts"`
+
 	Headers map[string]string `toml:"headers"`
 
 	// HTTP Basic Auth Credentials
 	Username string `toml:"username"`
 	Password string `toml:"password"`
+
+	// Bearer token authentication, the file is read on each request so that
+	// rotated tokens are picked up.
+	BearerToken       string `toml:"bearer_token"`
+	BearerTokenString string `toml:"bearer_token_string"`
+
 	tls.ClientConfig
 
 	SuccessStatusCodes []int `toml:"success_status_codes"`
 
 	Timeout internal.Duration `toml:"timeout"`
 
-	client *http.Client
+	client  *http.Client
+	targets []target
 
 	// The parser will automatically be set by Telegraf core code because
 	// this plugin implements the ParserInput interface (i.e. the SetParser method)
 	parser parsers.Parser
 }
 
+// target is a request made on each gather.
+type target struct {
+	url  string
+	body string
+}
+
 var sampleConfig = `
   ## One or more URLs from which to read formatted metrics
   urls = [
     "http://localhost/metrics"
   ]
 
+  ## Optional list of hosts, when set the urls and the body are Go templates
+  ## expanded for each host, with the host available as {{.Host}}.
+  # urls = ["https://{{.Host}}/api/v2.0/pool"]
+  # hosts = ["nas1.example.org", "nas2.example.org"]
+
   ## HTTP method
   # method = "GET"
 
@@ -56,6 +79,12 @@ var sampleConfig = `
   # username = "username"
   # password = "pa$$word"
 
+  ## Optional bearer token authentication, the token is read from the file
+  ## on each request.  ('bearer_token' takes priority)
+  # bearer_token = "/path/to/bearer/token"
+  ## OR
+  # bearer_token_string = "abc_123"
+
   ## HTTP entity-body to send with POST/PUT requests.
   # body = ""
 
@@ -111,21 +140,61 @@ func (h *HTTP) Init() error {
 	if len(h.SuccessStatusCodes) == 0 {
 		h.SuccessStatusCodes = []int{200}
 	}
-	return nil
+
+	h.targets, err = expandTargets(h.URLs, h.Body, h.Hosts)
+	return err
+}
+
+// expandTargets returns the requests made on each gather, the urls and the
+// body are expanded as templates for each host when hosts are given.
+func expandTargets(urls []string, body string, hosts []string) ([]target, error) {
+	if len(hosts) == 0 {
+		targets := make([]target, 0, len(urls))
+		for _, u := range urls {
+			targets = append(targets, target{url: u, body: body})
+		}
+		return targets, nil
+	}
+
+	bodyTmpl, err := template.New("body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing body template: %v", err)
+	}
+
+	var targets []target
+	for _, u := range urls {
+		urlTmpl, err := template.New("url").Option("missingkey=error").Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing url template %q: %v", u, err)
+		}
+		for _, host := range hosts {
+			data := struct{ Host string }{Host: host}
+
+			var url, body bytes.Buffer
+			if err := urlTmpl.Execute(&url, data); err != nil {
+				return nil, fmt.Errorf("error expanding url template %q: %v", u, err)
+			}
+			if err := bodyTmpl.Execute(&body, data); err != nil {
+				return nil, fmt.Errorf("error expanding body template: %v", err)
+			}
+			targets = append(targets, target{url: url.String(), body: body.String()})
+		}
+	}
+	return targets, nil
 }
 
 // Gather takes in an accumulator and adds the metrics that the Input
 // gathers. This is called every "interval"
 func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
-	for _, u := range h.URLs {
+	for _, t := range h.targets {
 		wg.Add(1)
-		go func(url string) {
+		go func(t target) {
 			defer wg.Done()
-			if err := h.gatherURL(acc, url); err != nil {
-				acc.AddError(fmt.Errorf("[url=%s]: %s", url, err))
+			if err := h.gatherURL(acc, t.url, t.body); err != nil {
+				acc.AddError(fmt.Errorf("[url=%s]: %s", t.url, err))
 			}
-		}(u)
+		}(t)
 	}
 
 	wg.Wait()
@@ -142,14 +211,16 @@ func (h *HTTP) SetParser(parser parsers.Parser) {
 // Parameters:
 //     acc    : The telegraf Accumulator to use
 //     url    : endpoint to send request to
+//     body   : entity-body to send with the request
 //
 // Returns:
 //     error: Any error that may have occurred
 func (h *HTTP) gatherURL(
 	acc telegraf.Accumulator,
 	url string,
+	requestBody string,
 ) error {
-	body, err := makeRequestBodyReader(h.ContentEncoding, h.Body)
+	body, err := makeRequestBodyReader(h.ContentEncoding, requestBody)
 	if err != nil {
 		return err
 	}
@@ -176,6 +247,18 @@ func (h *HTTP) gatherURL(
 		request.SetBasicAuth(h.Username, h.Password)
 	}
 
+	token := h.BearerTokenString
+	if h.BearerToken != "" {
+		b, err := ioutil.ReadFile(h.BearerToken)
+		if err != nil {
+			return err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
 	resp, err := h.client.Do(request)
 	if err != nil {
 		return err
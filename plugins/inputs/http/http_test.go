@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	plugin "github.com/influxdata/telegraf/plugins/inputs/http"
@@ -252,3 +253,78 @@ func TestBodyAndContentEncoding(t *testing.T) {
 		})
 	}
 }
+
+func TestHostTemplates(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		_, _ = fmt.Fprintf(w, `{"host": %q, "query": %q}`, r.URL.Path, body)
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs:   []string{fakeServer.URL + "/pools/{{.Host}}"},
+		Hosts:  []string{"nas1", "nas2"},
+		Method: "POST",
+		Body:   `pool.query {{.Host}}`,
+	}
+	p, _ := parsers.NewParser(&parsers.Config{
+		DataFormat:       "json",
+		MetricName:       "pool",
+		JSONStringFields: []string{"host", "query"},
+	})
+	plugin.SetParser(p)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	require.Len(t, acc.Metrics, 2)
+	for _, host := range []string{"nas1", "nas2"} {
+		require.True(t, acc.HasPoint("pool",
+			map[string]string{"url": fakeServer.URL + "/pools/" + host},
+			"query", "pool.query "+host))
+	}
+}
+
+func TestInvalidHostTemplate(t *testing.T) {
+	plugin := &plugin.HTTP{
+		URLs:  []string{"http://{{.Hostname}}/metrics"},
+		Hosts: []string{"nas1"},
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestBearerToken(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer abc_123" {
+			_, _ = w.Write([]byte(simpleJSON))
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer fakeServer.Close()
+
+	token, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(token.Name())
+	_, err = token.WriteString("abc_123\n")
+	require.NoError(t, err)
+	require.NoError(t, token.Close())
+
+	for _, plugin := range []*plugin.HTTP{
+		{URLs: []string{fakeServer.URL}, BearerTokenString: "abc_123"},
+		{URLs: []string{fakeServer.URL}, BearerToken: token.Name(), BearerTokenString: "wrong"},
+	} {
+		p, _ := parsers.NewParser(&parsers.Config{
+			DataFormat: "json",
+			MetricName: "metricName",
+		})
+		plugin.SetParser(p)
+
+		var acc testutil.Accumulator
+		require.NoError(t, plugin.Init())
+		require.NoError(t, acc.GatherError(plugin.Gather))
+		require.Len(t, acc.Metrics, 1)
+	}
+}
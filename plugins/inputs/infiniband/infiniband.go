@@ -0,0 +1,33 @@
+package infiniband
+
+// Infiniband reads the counters of the RDMA devices, InfiniBand and RoCE,
+// from sysfs.
+type Infiniband struct {
+	// Path of the infiniband class in sysfs
+	SysPath string `toml:"sys_path"`
+
+	// Devices to gather, all if empty
+	Devices []string `toml:"devices"`
+}
+
+const (
+	pluginName = "infiniband"
+
+	sampleConfig = `
+  ## Path of the infiniband class in sysfs, by default
+  ## $HOST_SYS/class/infiniband.
+  # sys_path = "/sys/class/infiniband"
+
+  ## RDMA devices to gather, by default all.
+  # devices = ["mlx5_0"]
+`
+)
+
+func (i *Infiniband) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a one-sentence description on the Input
+func (i *Infiniband) Description() string {
+	return "Gets the counters of the InfiniBand and RoCE ports"
+}
@@ -0,0 +1,135 @@
+// +build linux
+
+package infiniband
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (i *Infiniband) Gather(acc telegraf.Accumulator) error {
+	devices := i.Devices
+	if len(devices) == 0 {
+		entries, err := ioutil.ReadDir(i.SysPath)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			devices = append(devices, entry.Name())
+		}
+	}
+
+	for _, device := range devices {
+		ports, err := ioutil.ReadDir(filepath.Join(i.SysPath, device, "ports"))
+		if err != nil {
+			acc.AddError(fmt.Errorf("device %s: %v", device, err))
+			continue
+		}
+		for _, port := range ports {
+			i.gatherPort(acc, device, port.Name())
+		}
+	}
+	return nil
+}
+
+func (i *Infiniband) gatherPort(acc telegraf.Accumulator, device, port string) {
+	dir := filepath.Join(i.SysPath, device, "ports", port)
+	tags := map[string]string{
+		"device": device,
+		"port":   port,
+	}
+	if linkLayer, err := readString(filepath.Join(dir, "link_layer")); err == nil {
+		tags["link_layer"] = linkLayer
+	}
+
+	fields := make(map[string]interface{})
+	// The counters of the InfiniBand specification, and the counters of the
+	// driver, like the congestion counters of RoCE.
+	for _, counters := range []string{"counters", "hw_counters"} {
+		if err := readCounters(filepath.Join(dir, counters), fields); err != nil && !os.IsNotExist(err) {
+			acc.AddError(fmt.Errorf("device %s port %s: %v", device, port, err))
+		}
+	}
+
+	// port_rcv_data and port_xmit_data count 4 byte words.
+	for _, name := range []string{"rcv", "xmit"} {
+		if data, ok := fields["port_"+name+"_data"].(uint64); ok {
+			fields["port_"+name+"_bytes"] = data * 4
+		}
+	}
+
+	// The state is given as "4: ACTIVE" and the rate as
+	// "100 Gb/sec (4X EDR)".
+	if state, err := readString(filepath.Join(dir, "state")); err == nil {
+		if code, err := strconv.Atoi(strings.SplitN(state, ":", 2)[0]); err == nil {
+			fields["state"] = code
+		}
+	}
+	if rate, err := readString(filepath.Join(dir, "rate")); err == nil {
+		if gbps, err := strconv.ParseFloat(strings.Fields(rate)[0], 64); err == nil {
+			fields["rate_gbps"] = gbps
+		}
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields(pluginName, fields, tags)
+	}
+}
+
+// readCounters adds the counters of the files of dir, the counters that
+// cannot be read, like the ones not supported by the device, are skipped.
+func readCounters(dir string, fields map[string]interface{}) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		value, err := readString(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		counter, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		fields[file.Name()] = counter
+	}
+	return nil
+}
+
+func readString(filename string) (string, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(contents))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", filename)
+	}
+	return value, nil
+}
+
+func hostSys() string {
+	if sys := os.Getenv("HOST_SYS"); sys != "" {
+		return sys
+	}
+	return "/sys"
+}
+
+func init() {
+	inputs.Add(pluginName, func() telegraf.Input {
+		return &Infiniband{
+			SysPath: filepath.Join(hostSys(), "class/infiniband"),
+		}
+	})
+}
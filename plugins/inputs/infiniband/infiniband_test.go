@@ -0,0 +1,91 @@
+// +build linux
+
+package infiniband
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func makeFakeSys(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "infiniband")
+	require.NoError(t, err)
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	return dir
+}
+
+func TestGather(t *testing.T) {
+	dir := makeFakeSys(t, map[string]string{
+		"mlx5_0/ports/1/link_layer":                   "Ethernet\n",
+		"mlx5_0/ports/1/state":                        "4: ACTIVE\n",
+		"mlx5_0/ports/1/rate":                         "100 Gb/sec (4X EDR)\n",
+		"mlx5_0/ports/1/counters/port_rcv_data":       "1000\n",
+		"mlx5_0/ports/1/counters/port_xmit_data":      "2000\n",
+		"mlx5_0/ports/1/counters/port_rcv_errors":     "3\n",
+		"mlx5_0/ports/1/counters/symbol_error":        "0\n",
+		"mlx5_0/ports/1/hw_counters/np_cnp_sent":      "12\n",
+		"mlx5_0/ports/1/hw_counters/rp_cnp_handled":   "34\n",
+		"mlx5_0/ports/1/hw_counters/out_of_sequence":  "5\n",
+		"mlx5_0/ports/1/hw_counters/unsupported_file": "N/A\n",
+		"mlx4_0/ports/1/link_layer":                   "InfiniBand\n",
+		"mlx4_0/ports/1/state":                        "1: DOWN\n",
+		"mlx4_0/ports/1/rate":                         "10 Gb/sec (4X)\n",
+		"mlx4_0/ports/1/counters/port_rcv_errors":     "0\n",
+	})
+	defer os.RemoveAll(dir)
+
+	var acc testutil.Accumulator
+	plugin := &Infiniband{SysPath: dir}
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "infiniband",
+		map[string]interface{}{
+			"port_rcv_data":   uint64(1000),
+			"port_xmit_data":  uint64(2000),
+			"port_rcv_bytes":  uint64(4000),
+			"port_xmit_bytes": uint64(8000),
+			"port_rcv_errors": uint64(3),
+			"symbol_error":    uint64(0),
+			"np_cnp_sent":     uint64(12),
+			"rp_cnp_handled":  uint64(34),
+			"out_of_sequence": uint64(5),
+			"state":           4,
+			"rate_gbps":       100.0,
+		},
+		map[string]string{"device": "mlx5_0", "port": "1", "link_layer": "Ethernet"},
+	)
+	acc.AssertContainsTaggedFields(t, "infiniband",
+		map[string]interface{}{
+			"port_rcv_errors": uint64(0),
+			"state":           1,
+			"rate_gbps":       10.0,
+		},
+		map[string]string{"device": "mlx4_0", "port": "1", "link_layer": "InfiniBand"},
+	)
+}
+
+func TestGatherDevices(t *testing.T) {
+	dir := makeFakeSys(t, map[string]string{
+		"mlx5_0/ports/1/counters/port_rcv_errors": "3\n",
+		"mlx5_1/ports/1/counters/port_rcv_errors": "0\n",
+	})
+	defer os.RemoveAll(dir)
+
+	var acc testutil.Accumulator
+	plugin := &Infiniband{SysPath: dir, Devices: []string{"mlx5_1", "mlx5_9"}}
+	require.NoError(t, plugin.Gather(&acc))
+
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "mlx5_1", acc.Metrics[0].Tags["device"])
+	require.Len(t, acc.Errors, 1)
+}
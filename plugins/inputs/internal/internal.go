@@ -2,6 +2,7 @@ package internal
 
 import (
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/influxdata/telegraf"
@@ -12,19 +13,32 @@ import (
 
 type Self struct {
 	CollectMemstats bool
+	CollectBuild    bool
 }
 
 func NewSelf() telegraf.Input {
 	return &Self{
 		CollectMemstats: true,
+		CollectBuild:    true,
 	}
 }
 
 var sampleConfig = `
   ## If true, collect telegraf memory stats.
   # collect_memstats = true
+
+  ## If true, collect the telegraf version, build and plugins.
+  # collect_build = true
 `
 
+// pluginTags are the tags naming the plugin of the internal measurements.
+var pluginTags = map[string]string{
+	"internal_gather":    "input",
+	"internal_process":   "processor",
+	"internal_aggregate": "aggregator",
+	"internal_write":     "output",
+}
+
 func (s *Self) Description() string {
 	return "Collect statistics about itself"
 }
@@ -52,6 +66,7 @@ func (s *Self) Gather(acc telegraf.Accumulator) error {
 			"heap_released_bytes": m.HeapReleased, // bytes released to the OS
 			"heap_objects":        m.HeapObjects,  // total number of allocated objects
 			"num_gc":              m.NumGC,
+			"num_goroutines":      runtime.NumGoroutine(),
 		}
 		acc.AddFields("internal_memstats", fields, map[string]string{})
 	}
@@ -59,14 +74,50 @@ func (s *Self) Gather(acc telegraf.Accumulator) error {
 	telegrafVersion := inter.Version()
 	goVersion := strings.TrimPrefix(runtime.Version(), "go")
 
+	plugins := make(map[string]map[string]bool)
 	for _, m := range selfstat.Metrics() {
 		if m.Name() == "internal_agent" {
 			m.AddTag("go_version", goVersion)
 		}
+		if key, ok := pluginTags[m.Name()]; ok {
+			if name, ok := m.GetTag(key); ok {
+				if plugins[key] == nil {
+					plugins[key] = make(map[string]bool)
+				}
+				plugins[key][name] = true
+			}
+		}
 		m.AddTag("version", telegrafVersion)
 		acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 	}
 
+	if s.CollectBuild {
+		branch, commit := inter.Build()
+		tags := map[string]string{
+			"version":    telegrafVersion,
+			"go_version": goVersion,
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+		}
+		if branch != "" {
+			tags["branch"] = branch
+		}
+		if commit != "" {
+			tags["commit"] = commit
+		}
+
+		fields := map[string]interface{}{}
+		for _, key := range pluginTags {
+			var names []string
+			for name := range plugins[key] {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fields[key+"s"] = strings.Join(names, ",")
+		}
+		acc.AddFields("internal_build", fields, tags)
+	}
+
 	return nil
 }
 
@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/influxdata/telegraf/selfstat"
@@ -63,3 +65,34 @@ func TestSelfPlugin(t *testing.T) {
 		},
 	)
 }
+
+func TestSelfPluginBuild(t *testing.T) {
+	s := NewSelf()
+	acc := &testutil.Accumulator{}
+
+	selfstat.Register("gather", "errors", map[string]string{"input": "zfs"})
+	selfstat.Register("gather", "errors", map[string]string{"input": "diskio"})
+	selfstat.Register("gather", "errors", map[string]string{"input": "zfs", "alias": "tank"})
+	selfstat.Register("write", "errors", map[string]string{"output": "influxdb"})
+
+	s.Gather(acc)
+	acc.AssertContainsTaggedFields(t, "internal_build",
+		map[string]interface{}{
+			"inputs":      "diskio,zfs",
+			"processors":  "",
+			"aggregators": "",
+			"outputs":     "influxdb",
+		},
+		map[string]string{
+			"version":    "",
+			"go_version": strings.TrimPrefix(runtime.Version(), "go"),
+			"os":         runtime.GOOS,
+			"arch":       runtime.GOARCH,
+		},
+	)
+
+	acc.ClearMetrics()
+	s.(*Self).CollectBuild = false
+	s.Gather(acc)
+	assert.False(t, acc.HasMeasurement("internal_build"))
+}
@@ -9,11 +9,17 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 type Interrupts struct {
-	CpuAsTag bool `toml:"cpu_as_tag"`
+	CpuAsTag bool     `toml:"cpu_as_tag"`
+	IRQs     []string `toml:"irqs"`
+	Devices  []string `toml:"devices"`
+
+	irqFilter    filter.Filter
+	deviceFilter filter.Filter
 }
 
 type IRQ struct {
@@ -37,6 +43,16 @@ const sampleConfig = `
   ## deployments.
   # cpu_as_tag = false
 
+  ## IRQs to collect, glob patterns are supported.  The filter applies to
+  ## both interrupts and soft interrupts.
+  # irqs = ["BLOCK", "LOC"]
+
+  ## Devices of the hardware interrupts to collect, glob patterns are
+  ## supported.  An interrupt is collected if one of its device names
+  ## matches, ie "nvme0q1" in "IR-PCI-MSI 524289-edge nvme0q1".  Soft
+  ## interrupts are not filtered.
+  # devices = ["nvme*", "mpt3sas*", "ahci*"]
+
   ## To filter which IRQs to collect, make use of tagpass / tagdrop, i.e.
   # [inputs.interrupts.tagdrop]
   #   irq = [ "NET_RX", "TASKLET" ]
@@ -50,6 +66,17 @@ func (s *Interrupts) SampleConfig() string {
 	return sampleConfig
 }
 
+func (s *Interrupts) Init() error {
+	var err error
+	if s.irqFilter, err = filter.Compile(s.IRQs); err != nil {
+		return fmt.Errorf("error compiling irqs: %v", err)
+	}
+	if s.deviceFilter, err = filter.Compile(s.Devices); err != nil {
+		return fmt.Errorf("error compiling devices: %v", err)
+	}
+	return nil
+}
+
 func parseInterrupts(r io.Reader) ([]IRQ, error) {
 	var irqs []IRQ
 	var cpucount int
@@ -121,11 +148,44 @@ func (s *Interrupts) Gather(acc telegraf.Accumulator) error {
 			acc.AddError(fmt.Errorf("Parsing %s: %s", file, err))
 			continue
 		}
-		reportMetrics(measurement, irqs, acc, s.CpuAsTag)
+		reportMetrics(measurement, s.filterIRQs(measurement, irqs), acc, s.CpuAsTag)
 	}
 	return nil
 }
 
+func (s *Interrupts) filterIRQs(measurement string, irqs []IRQ) []IRQ {
+	if s.irqFilter == nil && s.deviceFilter == nil {
+		return irqs
+	}
+
+	filtered := irqs[:0]
+	for _, irq := range irqs {
+		if s.irqFilter != nil && !s.irqFilter.Match(irq.ID) {
+			continue
+		}
+		if s.deviceFilter != nil && measurement == "interrupts" && !matchDevice(s.deviceFilter, irq.Device) {
+			continue
+		}
+		filtered = append(filtered, irq)
+	}
+	return filtered
+}
+
+// matchDevice returns true if one of the devices sharing the IRQ matches.
+// The device column may start with the hardware IRQ number and holds the
+// devices separated by commas, ie "1-edge i8042, ehci_hcd:usb1".
+func matchDevice(f filter.Filter, device string) bool {
+	names := strings.FieldsFunc(device, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for _, name := range names {
+		if f.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func reportMetrics(measurement string, irqs []IRQ, acc telegraf.Accumulator, cpusAsTags bool) {
 	for _, irq := range irqs {
 		tags, fields := gatherTagsFields(irq)
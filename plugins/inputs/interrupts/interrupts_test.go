@@ -154,3 +154,45 @@ func TestCpuAsFieldsHwIrqs(t *testing.T) {
 		expectCpuAsFields(acc, t, "interrupts", irq)
 	}
 }
+
+// =====================================================================================
+//	Filters
+// =====================================================================================
+
+const storageIrqsString = `            CPU0       CPU1
+  0:         16          0   IO-APIC   2-edge      timer
+  1:          0          9   IO-APIC   1-edge      i8042
+ 16:        101          0   IO-APIC  16-fasteoi   ehci_hcd:usb1, mpt3sas0
+ 40:       1520          0   PCI-MSI 376832-edge      ahci[0000:00:17.0]
+ 41:      98304          7   IR-PCI-MSI 524288-edge      nvme0q0
+ 42:     184320          0   IR-PCI-MSI 524289-edge      nvme0q1
+NMI:          0          0   Non-maskable interrupts
+LOC:    2338608    2334309   Local timer interrupts`
+
+func filteredIRQs(t *testing.T, s *Interrupts, measurement string) []string {
+	require.NoError(t, s.Init())
+	irqs, err := parseInterrupts(bytes.NewBufferString(storageIrqsString))
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, irq := range s.filterIRQs(measurement, irqs) {
+		ids = append(ids, irq.ID)
+	}
+	return ids
+}
+
+func TestFilterDevices(t *testing.T) {
+	s := &Interrupts{Devices: []string{"nvme*", "mpt3sas*", "ahci*"}}
+	require.Equal(t, []string{"16", "40", "41", "42"}, filteredIRQs(t, s, "interrupts"))
+
+	// Soft interrupts have no device.
+	require.Len(t, filteredIRQs(t, s, "soft_interrupts"), 8)
+}
+
+func TestFilterIRQs(t *testing.T) {
+	s := &Interrupts{IRQs: []string{"4*", "LOC"}}
+	require.Equal(t, []string{"40", "41", "42", "LOC"}, filteredIRQs(t, s, "interrupts"))
+
+	s = &Interrupts{IRQs: []string{"4*"}, Devices: []string{"nvme*"}}
+	require.Equal(t, []string{"41", "42"}, filteredIRQs(t, s, "interrupts"))
+}
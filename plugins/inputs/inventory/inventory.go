@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+type Inventory struct {
+	Collect []string          `toml:"collect"`
+	UseSudo bool              `toml:"use_sudo"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	etcPath  string
+	procPath string
+	sysPath  string
+	zpool    func() ([]byte, error)
+}
+
+var sampleConfig = `
+  ## The inventory rarely changes, gather it at a low frequency.
+  interval = "1h"
+
+  ## Data to collect, any of "os", "disks", "controllers", "zfs" and
+  ## "zpools".  By default all of them are collected.
+  # collect = ["os", "disks", "controllers", "zfs", "zpools"]
+
+  ## Setting 'use_sudo' to true will make use of sudo to run zpool.
+  # use_sudo = false
+
+  ## Timeout for the zpool command to complete.
+  # timeout = "5s"
+`
+
+var collectable = []string{"os", "disks", "controllers", "zfs", "zpools"}
+
+func (inv *Inventory) SampleConfig() string {
+	return sampleConfig
+}
+
+func (inv *Inventory) Description() string {
+	return "Report the inventory of the host: OS release, disks, storage controllers and ZFS"
+}
+
+func (inv *Inventory) collects(data string) bool {
+	if len(inv.Collect) == 0 {
+		return true
+	}
+	for _, c := range inv.Collect {
+		if c == data {
+			return true
+		}
+	}
+	return false
+}
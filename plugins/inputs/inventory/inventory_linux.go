@@ -0,0 +1,291 @@
+// +build linux
+
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (inv *Inventory) Init() error {
+	for _, c := range inv.Collect {
+		found := false
+		for _, known := range collectable {
+			if c == known {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown data to collect %q", c)
+		}
+	}
+	return nil
+}
+
+func (inv *Inventory) Gather(acc telegraf.Accumulator) error {
+	if inv.collects("os") {
+		if err := inv.gatherOS(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if inv.collects("disks") {
+		if err := inv.gatherDisks(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if inv.collects("controllers") {
+		if err := inv.gatherControllers(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if inv.collects("zfs") {
+		inv.gatherZFS(acc)
+	}
+	if inv.collects("zpools") {
+		if err := inv.gatherZpools(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+func (inv *Inventory) gatherOS(acc telegraf.Accumulator) error {
+	fields := make(map[string]interface{})
+
+	f, err := os.Open(filepath.Join(inv.etcPath, "os-release"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// os-release is a list of shell variable assignments.
+	release := map[string]string{
+		"ID":          "os_id",
+		"VERSION_ID":  "os_version_id",
+		"PRETTY_NAME": "os_pretty_name",
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if name, ok := release[kv[0]]; ok {
+			fields[name] = strings.Trim(kv[1], `"'`)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if kernel := readValue(filepath.Join(inv.procPath, "sys/kernel/osrelease")); kernel != "" {
+		fields["kernel_release"] = kernel
+	}
+
+	acc.AddFields("inventory_os", fields, nil)
+	return nil
+}
+
+func (inv *Inventory) gatherDisks(acc telegraf.Accumulator) error {
+	devices, err := filepath.Glob(filepath.Join(inv.sysPath, "block/*/device"))
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		block := filepath.Dir(device)
+		fields := make(map[string]interface{})
+		for file, name := range map[string]string{
+			"model":        "model",
+			"vendor":       "vendor",
+			"serial":       "serial",
+			"wwid":         "wwid",
+			"rev":          "firmware",
+			"firmware_rev": "firmware",
+		} {
+			if v := readValue(filepath.Join(device, file)); v != "" {
+				fields[name] = v
+			}
+		}
+		// The serial number of the SCSI and SATA disks is in the unit serial
+		// number page of the vital product data.
+		if _, ok := fields["serial"]; !ok {
+			if page, err := ioutil.ReadFile(filepath.Join(device, "vpd_pg80")); err == nil && len(page) > 4 {
+				if serial := strings.TrimSpace(string(page[4:])); serial != "" {
+					fields["serial"] = serial
+				}
+			}
+		}
+		if sectors, err := strconv.ParseInt(readValue(filepath.Join(block, "size")), 10, 64); err == nil {
+			fields["size_bytes"] = sectors * 512
+		}
+		if rotational := readValue(filepath.Join(block, "queue/rotational")); rotational != "" {
+			fields["rotational"] = rotational == "1"
+		}
+
+		tags := map[string]string{
+			"device": filepath.Base(block),
+		}
+		acc.AddFields("inventory_disk", fields, tags)
+	}
+	return nil
+}
+
+func (inv *Inventory) gatherControllers(acc telegraf.Accumulator) error {
+	devices, err := filepath.Glob(filepath.Join(inv.sysPath, "bus/pci/devices/*"))
+	if err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		// Class 0x01 is the mass storage controllers.
+		class := readValue(filepath.Join(device, "class"))
+		if !strings.HasPrefix(class, "0x01") {
+			continue
+		}
+
+		fields := map[string]interface{}{
+			"class": class,
+		}
+		for _, name := range []string{"vendor", "device", "subsystem_vendor", "subsystem_device"} {
+			if v := readValue(filepath.Join(device, name)); v != "" {
+				fields[name] = v
+			}
+		}
+		if driver, err := os.Readlink(filepath.Join(device, "driver")); err == nil {
+			fields["driver"] = filepath.Base(driver)
+		}
+
+		tags := map[string]string{
+			"pci_address": filepath.Base(device),
+		}
+		acc.AddFields("inventory_controller", fields, tags)
+	}
+	return nil
+}
+
+func (inv *Inventory) gatherZFS(acc telegraf.Accumulator) {
+	fields := make(map[string]interface{})
+	for _, module := range []string{"zfs", "spl"} {
+		if v := readValue(filepath.Join(inv.sysPath, "module", module, "version")); v != "" {
+			fields[module+"_version"] = v
+		}
+	}
+	// ZFS is not loaded.
+	if len(fields) == 0 {
+		return
+	}
+	acc.AddFields("inventory_zfs", fields, nil)
+}
+
+func (inv *Inventory) gatherZpools(acc telegraf.Accumulator) error {
+	if readValue(filepath.Join(inv.sysPath, "module/zfs/version")) == "" {
+		return nil
+	}
+
+	out, err := inv.zpool()
+	if err != nil {
+		return err
+	}
+
+	type poolFeatures struct {
+		counts  map[string]int
+		enabled []string
+	}
+	pools := make(map[string]*poolFeatures)
+	var names []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// name, property and value separated by tabs.
+		cols := strings.Split(scanner.Text(), "\t")
+		if len(cols) < 3 || !strings.HasPrefix(cols[1], "feature@") {
+			continue
+		}
+		p, ok := pools[cols[0]]
+		if !ok {
+			p = &poolFeatures{counts: make(map[string]int)}
+			pools[cols[0]] = p
+			names = append(names, cols[0])
+		}
+		p.counts[cols[2]]++
+		if cols[2] == "active" || cols[2] == "enabled" {
+			p.enabled = append(p.enabled, strings.TrimPrefix(cols[1], "feature@"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		p := pools[name]
+		sort.Strings(p.enabled)
+		fields := map[string]interface{}{
+			"features_active":   p.counts["active"],
+			"features_enabled":  p.counts["enabled"],
+			"features_disabled": p.counts["disabled"],
+			"features":          strings.Join(p.enabled, ","),
+		}
+		tags := map[string]string{
+			"pool": name,
+		}
+		acc.AddFields("inventory_zpool", fields, tags)
+	}
+	return nil
+}
+
+// readValue returns the trimmed content of a sysfs or procfs file, or an
+// empty string if it cannot be read.
+func readValue(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (inv *Inventory) runZpool() ([]byte, error) {
+	args := []string{"get", "-H", "-p", "-o", "name,property,value", "all"}
+	cmd := exec.Command("zpool", args...)
+	if inv.UseSudo {
+		cmd = exec.Command("sudo", append([]string{"-n", "zpool"}, args...)...)
+	}
+	out, err := internal.CombinedOutputTimeout(cmd, inv.Timeout.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("zpool error: %s: %s", err, bytes.TrimSpace(out))
+	}
+	return out, nil
+}
+
+func hostPath(env, path string) string {
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	return path
+}
+
+func init() {
+	inputs.Add("inventory", func() telegraf.Input {
+		inv := &Inventory{
+			Timeout:  internal.Duration{Duration: 5 * time.Second},
+			etcPath:  hostPath("HOST_ETC", "/etc"),
+			procPath: hostPath("HOST_PROC", "/proc"),
+			sysPath:  hostPath("HOST_SYS", "/sys"),
+		}
+		inv.zpool = inv.runZpool
+		return inv
+	})
+}
@@ -0,0 +1,190 @@
+// +build linux
+
+package inventory
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const osRelease = `NAME="Ubuntu"
+VERSION="20.04 LTS (Focal Fossa)"
+ID=ubuntu
+ID_LIKE=debian
+PRETTY_NAME="Ubuntu 20.04 LTS"
+VERSION_ID="20.04"
+`
+
+const zpoolOutput = "tank\tsize\t1000\n" +
+	"tank\tfeature@async_destroy\tenabled\n" +
+	"tank\tfeature@lz4_compress\tactive\n" +
+	"tank\tfeature@encryption\tdisabled\n" +
+	"rpool\tfeature@lz4_compress\tactive\n"
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func newTestInventory(t *testing.T) (*Inventory, string) {
+	root, err := ioutil.TempDir("", "inventory")
+	require.NoError(t, err)
+
+	writeFiles(t, root, map[string]string{
+		"etc/os-release":                          osRelease,
+		"proc/sys/kernel/osrelease":               "5.4.0-42-generic\n",
+		"sys/block/sda/size":                      "7814037168\n",
+		"sys/block/sda/queue/rotational":          "1\n",
+		"sys/block/sda/device/model":              "ST4000NM0035-1V4\n",
+		"sys/block/sda/device/vendor":             "ATA     \n",
+		"sys/block/sda/device/rev":                "TN03\n",
+		"sys/block/sda/device/vpd_pg80":           "\x00\x80\x00\x08ZC1B2XYZ",
+		"sys/block/nvme0n1/size":                  "1000215216\n",
+		"sys/block/nvme0n1/queue/rotational":      "0\n",
+		"sys/block/nvme0n1/device/model":          "Samsung SSD 970 EVO Plus 500GB\n",
+		"sys/block/nvme0n1/device/serial":         "S4EVNF0M123456\n",
+		"sys/block/nvme0n1/device/firmware_rev":   "2B2QEXM7\n",
+		"sys/block/loop0/size":                    "0\n",
+		"sys/bus/pci/devices/0000:00:17.0/class":  "0x010601\n",
+		"sys/bus/pci/devices/0000:00:17.0/vendor": "0x8086\n",
+		"sys/bus/pci/devices/0000:00:17.0/device": "0xa352\n",
+		"sys/bus/pci/devices/0000:00:02.0/class":  "0x030000\n",
+		"sys/module/zfs/version":                  "0.8.3-1ubuntu12\n",
+		"sys/module/spl/version":                  "0.8.3-1ubuntu12\n",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys/bus/pci/drivers/ahci"), 0755))
+	require.NoError(t, os.Symlink("../../../bus/pci/drivers/ahci",
+		filepath.Join(root, "sys/bus/pci/devices/0000:00:17.0/driver")))
+
+	inv := &Inventory{
+		etcPath:  filepath.Join(root, "etc"),
+		procPath: filepath.Join(root, "proc"),
+		sysPath:  filepath.Join(root, "sys"),
+		zpool: func() ([]byte, error) {
+			return []byte(zpoolOutput), nil
+		},
+	}
+	return inv, root
+}
+
+func TestGather(t *testing.T) {
+	inv, root := newTestInventory(t)
+	defer os.RemoveAll(root)
+	require.NoError(t, inv.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(inv.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"inventory_os",
+			map[string]string{},
+			map[string]interface{}{
+				"os_id":          "ubuntu",
+				"os_version_id":  "20.04",
+				"os_pretty_name": "Ubuntu 20.04 LTS",
+				"kernel_release": "5.4.0-42-generic",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"inventory_disk",
+			map[string]string{"device": "nvme0n1"},
+			map[string]interface{}{
+				"model":      "Samsung SSD 970 EVO Plus 500GB",
+				"serial":     "S4EVNF0M123456",
+				"firmware":   "2B2QEXM7",
+				"size_bytes": int64(512110190592),
+				"rotational": false,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"inventory_disk",
+			map[string]string{"device": "sda"},
+			map[string]interface{}{
+				"model":      "ST4000NM0035-1V4",
+				"vendor":     "ATA",
+				"serial":     "ZC1B2XYZ",
+				"firmware":   "TN03",
+				"size_bytes": int64(4000787030016),
+				"rotational": true,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"inventory_controller",
+			map[string]string{"pci_address": "0000:00:17.0"},
+			map[string]interface{}{
+				"class":  "0x010601",
+				"vendor": "0x8086",
+				"device": "0xa352",
+				"driver": "ahci",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"inventory_zfs",
+			map[string]string{},
+			map[string]interface{}{
+				"zfs_version": "0.8.3-1ubuntu12",
+				"spl_version": "0.8.3-1ubuntu12",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"inventory_zpool",
+			map[string]string{"pool": "tank"},
+			map[string]interface{}{
+				"features_active":   1,
+				"features_enabled":  1,
+				"features_disabled": 1,
+				"features":          "async_destroy,lz4_compress",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"inventory_zpool",
+			map[string]string{"pool": "rpool"},
+			map[string]interface{}{
+				"features_active":   1,
+				"features_enabled":  0,
+				"features_disabled": 0,
+				"features":          "lz4_compress",
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherWithoutZFS(t *testing.T) {
+	inv, root := newTestInventory(t)
+	defer os.RemoveAll(root)
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "sys/module")))
+	inv.Collect = []string{"zfs", "zpools"}
+	inv.zpool = func() ([]byte, error) {
+		return nil, errors.New("zpool not found")
+	}
+	require.NoError(t, inv.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(inv.Gather))
+	require.Len(t, acc.Metrics, 0)
+}
+
+func TestInitUnknownCollect(t *testing.T) {
+	inv := &Inventory{Collect: []string{"memory"}}
+	require.Error(t, inv.Init())
+}
@@ -0,0 +1,25 @@
+// +build !linux
+
+package inventory
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (inv *Inventory) Init() error {
+	return fmt.Errorf("the inventory input is not supported on %s", runtime.GOOS)
+}
+
+func (inv *Inventory) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("inventory", func() telegraf.Input {
+		return &Inventory{}
+	})
+}
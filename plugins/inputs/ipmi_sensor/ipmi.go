@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -32,6 +33,14 @@ type Ipmi struct {
 	Servers       []string
 	Timeout       internal.Duration
 	MetricVersion int
+	SEL           bool `toml:"sel"`
+
+	State telegraf.StateStore `toml:"-"`
+	Log   telegraf.Logger     `toml:"-"`
+
+	// Record ID of the last System Event Log entry reported, by server.
+	mu      sync.Mutex
+	lastSEL map[string]int64
 }
 
 var sampleConfig = `
@@ -59,8 +68,13 @@ var sampleConfig = `
 
   ## Schema Version: (Optional, defaults to version 1)
   metric_version = 2
+
+  ## Report the entries added to the System Event Log as ipmi_sel events
+  # sel = false
 `
 
+const selKey = "sel_last_id"
+
 // SampleConfig returns the documentation about the sample configuration
 func (m *Ipmi) SampleConfig() string {
 	return sampleConfig
@@ -71,6 +85,17 @@ func (m *Ipmi) Description() string {
 	return "Read metrics from the bare metal servers via IPMI"
 }
 
+// Init loads the position in the System Event Log of each server
+func (m *Ipmi) Init() error {
+	m.lastSEL = make(map[string]int64)
+	if m.SEL && m.State != nil {
+		if _, err := m.State.Get(selKey, &m.lastSEL); err != nil {
+			m.Log.Warnf("Ignoring saved SEL position: %v", err)
+		}
+	}
+	return nil
+}
+
 // Gather is the main execution function for the plugin
 func (m *Ipmi) Gather(acc telegraf.Accumulator) error {
 	if len(m.Path) == 0 {
@@ -108,20 +133,133 @@ func (m *Ipmi) parse(acc telegraf.Accumulator, server string) error {
 		hostname = conn.Hostname
 		opts = conn.options()
 	}
-	opts = append(opts, "sdr")
+	sdrOpts := append(opts, "sdr")
 	if m.MetricVersion == 2 {
-		opts = append(opts, "elist")
+		sdrOpts = append(sdrOpts, "elist")
 	}
-	cmd := execCommand(m.Path, opts...)
-	out, err := internal.CombinedOutputTimeout(cmd, m.Timeout.Duration)
+	out, err := m.run(sdrOpts...)
 	timestamp := time.Now()
 	if err != nil {
-		return fmt.Errorf("failed to run command %s: %s - %s", strings.Join(cmd.Args, " "), err, string(out))
+		return err
 	}
 	if m.MetricVersion == 2 {
-		return parseV2(acc, hostname, out, timestamp)
+		err = parseV2(acc, hostname, out, timestamp)
+	} else {
+		err = parseV1(acc, hostname, out, timestamp)
+	}
+	if err != nil || !m.SEL {
+		return err
+	}
+
+	out, err = m.run(append(opts, "sel", "elist")...)
+	if err != nil {
+		return err
+	}
+	return m.gatherSEL(acc, hostname, out)
+}
+
+func (m *Ipmi) run(opts ...string) ([]byte, error) {
+	cmd := execCommand(m.Path, opts...)
+	out, err := internal.CombinedOutputTimeout(cmd, m.Timeout.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command %s: %s - %s", strings.Join(cmd.Args, " "), err, string(out))
 	}
-	return parseV1(acc, hostname, out, timestamp)
+	return out, nil
+}
+
+// gatherSEL adds the entries of the System Event Log newer than the last one
+// reported.  On the first run of a server the existing entries are skipped.
+func (m *Ipmi) gatherSEL(acc telegraf.Accumulator, hostname string, cmdOut []byte) error {
+	entries, err := parseSEL(cmdOut)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	last, seen := m.lastSEL[hostname]
+	maxID := int64(0)
+	for _, e := range entries {
+		if e.id > maxID {
+			maxID = e.id
+		}
+	}
+	// Record IDs restart after the log is cleared.
+	if maxID < last {
+		last = 0
+	}
+
+	for _, e := range entries {
+		if !seen || e.id <= last {
+			continue
+		}
+		tags := map[string]string{
+			"sensor":    transform(e.sensor),
+			"direction": transform(e.direction),
+		}
+		if hostname != "" {
+			tags["server"] = hostname
+		}
+		ev, err := metric.NewEvent("ipmi_sel", tags, e.sensor+": "+e.event, e.reading, e.time)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		acc.AddMetric(ev)
+	}
+
+	m.lastSEL[hostname] = maxID
+	if m.State != nil {
+		return m.State.Set(selKey, m.lastSEL)
+	}
+	return nil
+}
+
+type selEntry struct {
+	id        int64
+	time      time.Time
+	sensor    string
+	event     string
+	direction string
+	reading   string
+}
+
+func parseSEL(cmdOut []byte) ([]selEntry, error) {
+	// each line will look something like
+	//    1 | 01/12/2020 | 10:31:14 | Temperature CPU1 Temp | Upper Critical going high | Asserted | Reading 92 > Threshold 90 degrees C
+	//    2 | Pre-Init  |0000000012| System Event #0x83 | Timestamp Clock Sync | Asserted
+	var entries []selEntry
+	scanner := bufio.NewScanner(bytes.NewReader(cmdOut))
+	for scanner.Scan() {
+		cols := strings.Split(scanner.Text(), "|")
+		if len(cols) < 6 {
+			continue
+		}
+		id, err := strconv.ParseInt(trim(cols[0]), 16, 64)
+		if err != nil {
+			continue
+		}
+
+		// Entries logged before the BMC clock was set have no date.
+		tm, err := time.ParseInLocation("01/02/2006 15:04:05", trim(cols[1])+" "+trim(cols[2]), time.Local)
+		if err != nil {
+			tm = time.Now()
+		}
+
+		e := selEntry{
+			id:        id,
+			time:      tm,
+			sensor:    trim(cols[3]),
+			event:     trim(cols[4]),
+			direction: trim(cols[5]),
+		}
+		if len(cols) > 6 {
+			e.reading = trim(cols[6])
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
 }
 
 func parseV1(acc telegraf.Accumulator, hostname string, cmdOut []byte, measured_at time.Time) error {
@@ -272,14 +410,11 @@ func transform(s string) string {
 }
 
 func init() {
-	m := Ipmi{}
 	path, _ := exec.LookPath("ipmitool")
-	if len(path) > 0 {
-		m.Path = path
-	}
-	m.Timeout = internal.Duration{Duration: time.Second * 20}
 	inputs.Add("ipmi_sensor", func() telegraf.Input {
-		m := m
-		return &m
+		return &Ipmi{
+			Path:    path,
+			Timeout: internal.Duration{Duration: time.Second * 20},
+		}
 	})
 }
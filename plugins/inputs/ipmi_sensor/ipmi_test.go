@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/state"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -718,3 +719,57 @@ func Test_parseV2(t *testing.T) {
 		acc.AssertContainsTaggedFields(t, "ipmi_sensor", tt.wantFields, tt.wantTags)
 	}
 }
+
+func TestGatherSEL(t *testing.T) {
+	first := []byte(`   1 | 01/12/2020 | 10:31:14 | Temperature CPU1 Temp | Upper Critical going high | Asserted | Reading 92 > Threshold 90 degrees C
+   2 | 01/12/2020 | 10:35:02 | Temperature CPU1 Temp | Upper Critical going high | Deasserted | Reading 80 < Threshold 90 degrees C
+`)
+	second := append(first, []byte(`   3 | Pre-Init  |0000000012| System Event #0x83 | Timestamp Clock Sync | Asserted
+   a | 01/12/2020 | 11:02:45 | Fan FAN3 | Lower Critical going low | Asserted | Reading 0 < Threshold 600 RPM
+`)...)
+	cleared := []byte(`   1 | 01/13/2020 | 08:00:00 | Event Logging Disabled SEL | Log area reset/cleared | Asserted
+`)
+
+	store := state.New()
+	newPlugin := func() *Ipmi {
+		i := &Ipmi{
+			SEL:   true,
+			State: store.Scope("ipmi_sensor"),
+			Log:   testutil.Logger{},
+		}
+		require.NoError(t, i.Init())
+		return i
+	}
+
+	// The existing entries are skipped on the first run.
+	i := newPlugin()
+	var acc testutil.Accumulator
+	require.NoError(t, i.gatherSEL(&acc, "192.168.1.1", first))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// The position survives a restart.
+	i = newPlugin()
+	require.NoError(t, i.gatherSEL(&acc, "192.168.1.1", second))
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 2)
+
+	expected := testutil.MustMetric("ipmi_sel",
+		map[string]string{
+			"server":    "192.168.1.1",
+			"sensor":    "fan_fan3",
+			"direction": "asserted",
+		},
+		map[string]interface{}{
+			"title": "Fan FAN3: Lower Critical going low",
+			"text":  "Reading 0 < Threshold 600 RPM",
+		},
+		time.Date(2020, 1, 12, 11, 2, 45, 0, time.Local))
+	testutil.RequireMetricEqual(t, expected, metrics[1])
+	require.Equal(t, "System Event #0x83: Timestamp Clock Sync", metrics[0].Fields()["title"])
+
+	acc.ClearMetrics()
+	require.NoError(t, i.gatherSEL(&acc, "192.168.1.1", cleared))
+	metrics = acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "Event Logging Disabled SEL: Log area reset/cleared", metrics[0].Fields()["title"])
+}
@@ -0,0 +1,337 @@
+package kmsg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultPath   = "/dev/kmsg"
+	bootIDPath    = "/proc/sys/kernel/random/boot_id"
+	positionKey   = "position"
+	countsKey     = "counts"
+	maxRecordSize = 8192
+)
+
+// Pattern is a regular expression matched against the kernel messages.
+type Pattern struct {
+	Name  string `toml:"name"`
+	Regex string `toml:"regex"`
+	Event bool   `toml:"event"`
+
+	re *regexp.Regexp
+}
+
+// position identifies the last kernel message read, sequence numbers restart
+// with every boot.
+type position struct {
+	BootID string `json:"boot_id"`
+	Seq    uint64 `json:"seq"`
+}
+
+// Kmsg follows the kernel log buffer and counts the messages matching
+// patterns.
+type Kmsg struct {
+	Path     string     `toml:"path"`
+	Patterns []*Pattern `toml:"pattern"`
+
+	State telegraf.StateStore `toml:"-"`
+	Log   telegraf.Logger     `toml:"-"`
+
+	bootID string
+
+	mu     sync.Mutex
+	pos    position
+	counts map[string]int64
+
+	acc  telegraf.Accumulator
+	file *os.File
+	wg   sync.WaitGroup
+}
+
+var defaultPatterns = []*Pattern{
+	{Name: "io_error", Regex: `I/O error`},
+	{Name: "blk_update_request", Regex: `blk_update_request`},
+	{Name: "xfs", Regex: `XFS \(.*\): .*(error|corrupt|WARN)`},
+	{Name: "zfs", Regex: `(ZFS|SPL): .*(WARN|error|panic)`},
+	{Name: "mce", Regex: `(mce|Machine check): `},
+}
+
+var sampleConfig = `
+  ## Path of the kernel log device.
+  # path = "/dev/kmsg"
+
+  ## Patterns matched against the kernel messages, the number of matches of
+  ## each pattern is reported.  When event is true, an event is also emitted
+  ## for every matching message, named capture groups are added as tags.
+  ## The default patterns below are used when none are configured.
+  # [[inputs.kmsg.pattern]]
+  #   name = "io_error"
+  #   regex = 'I/O error'
+  #   event = false
+  # [[inputs.kmsg.pattern]]
+  #   name = "blk_update_request"
+  #   regex = 'blk_update_request'
+  # [[inputs.kmsg.pattern]]
+  #   name = "xfs"
+  #   regex = 'XFS \(.*\): .*(error|corrupt|WARN)'
+  # [[inputs.kmsg.pattern]]
+  #   name = "zfs"
+  #   regex = '(ZFS|SPL): .*(WARN|error|panic)'
+  # [[inputs.kmsg.pattern]]
+  #   name = "mce"
+  #   regex = '(mce|Machine check): '
+`
+
+func (k *Kmsg) Description() string {
+	return "Count kernel log messages matching patterns"
+}
+
+func (k *Kmsg) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *Kmsg) Init() error {
+	if k.Path == "" {
+		k.Path = defaultPath
+	}
+	if len(k.Patterns) == 0 {
+		for _, p := range defaultPatterns {
+			pattern := *p
+			k.Patterns = append(k.Patterns, &pattern)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, p := range k.Patterns {
+		if p.Name == "" {
+			return fmt.Errorf("pattern %q has no name", p.Regex)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate pattern name %q", p.Name)
+		}
+		names[p.Name] = true
+
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex of pattern %q: %v", p.Name, err)
+		}
+		p.re = re
+	}
+
+	if buf, err := ioutil.ReadFile(bootIDPath); err == nil {
+		k.bootID = strings.TrimSpace(string(buf))
+	}
+
+	k.counts = make(map[string]int64)
+	if k.State != nil {
+		if _, err := k.State.Get(positionKey, &k.pos); err != nil {
+			k.Log.Warnf("Ignoring saved position: %v", err)
+		}
+		if _, err := k.State.Get(countsKey, &k.counts); err != nil {
+			k.Log.Warnf("Ignoring saved counts: %v", err)
+		}
+	}
+	return nil
+}
+
+func (k *Kmsg) Start(acc telegraf.Accumulator) error {
+	k.acc = acc
+
+	f, err := os.Open(k.Path)
+	if err != nil {
+		return err
+	}
+
+	// Without a position from this boot only the new messages are read,
+	// otherwise the messages logged while Telegraf was not running are read
+	// as well.
+	skip := uint64(0)
+	switch {
+	case k.pos.BootID != "" && k.pos.BootID == k.bootID:
+		skip = k.pos.Seq + 1
+	case k.pos.BootID == "":
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	k.pos.BootID = k.bootID
+	k.file = f
+
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		k.read(f, skip)
+	}()
+	return nil
+}
+
+func (k *Kmsg) Stop() {
+	if k.file != nil {
+		k.file.Close()
+	}
+	k.wg.Wait()
+	k.saveState()
+}
+
+func (k *Kmsg) Gather(acc telegraf.Accumulator) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, p := range k.Patterns {
+		acc.AddFields("kmsg",
+			map[string]interface{}{"matches": k.counts[p.Name]},
+			map[string]string{"pattern": p.Name})
+	}
+	return k.saveStateLocked()
+}
+
+// read processes the records until the file is closed or ends.  Records
+// with a sequence number below skip are ignored.
+func (k *Kmsg) read(r io.Reader, skip uint64) {
+	reader := bufio.NewReaderSize(r, maxRecordSize)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// The oldest records were overwritten before they were read,
+			// reading continues with the next available record.
+			if pe, ok := err.(*os.PathError); ok {
+				switch pe.Err {
+				case syscall.EPIPE:
+					k.Log.Debugf("Kernel messages were overwritten before being read")
+					continue
+				case os.ErrClosed:
+					return
+				}
+			}
+			if err != io.EOF {
+				k.acc.AddError(err)
+			}
+			return
+		}
+
+		// Continuation lines hold the device properties of the message.
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		seq, tags, message, err := parseRecord(strings.TrimSuffix(line, "\n"))
+		if err != nil {
+			k.Log.Debugf("Skipping kernel message: %v", err)
+			continue
+		}
+		if seq < skip {
+			continue
+		}
+		k.process(seq, tags, message)
+	}
+}
+
+func (k *Kmsg) process(seq uint64, tags map[string]string, message string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.pos.Seq = seq
+	for _, p := range k.Patterns {
+		match := p.re.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		k.counts[p.Name]++
+
+		if !p.Event {
+			continue
+		}
+
+		eventTags := map[string]string{"pattern": p.Name}
+		for key, value := range tags {
+			eventTags[key] = value
+		}
+		for i, name := range p.re.SubexpNames() {
+			if name != "" && match[i] != "" {
+				eventTags[name] = match[i]
+			}
+		}
+
+		m, err := metric.NewEvent("kmsg_events", eventTags, message, "", time.Now())
+		if err != nil {
+			k.acc.AddError(err)
+			continue
+		}
+		k.acc.AddMetric(m)
+	}
+}
+
+func (k *Kmsg) saveState() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := k.saveStateLocked(); err != nil {
+		k.Log.Errorf("Error saving state: %v", err)
+	}
+}
+
+func (k *Kmsg) saveStateLocked() error {
+	if k.State == nil || k.pos.BootID == "" {
+		return nil
+	}
+	if err := k.State.Set(positionKey, k.pos); err != nil {
+		return err
+	}
+	return k.State.Set(countsKey, k.counts)
+}
+
+var levels = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// parseRecord parses a record of /dev/kmsg:
+//
+//   6,1234,5678901,-;sd 2:0:0:0: [sdb] tag#0 FAILED Result: hostbyte=DID_OK
+//
+// The prefix holds the priority, sequence number, timestamp and flags.
+func parseRecord(line string) (uint64, map[string]string, string, error) {
+	i := strings.IndexByte(line, ';')
+	if i < 0 {
+		return 0, nil, "", fmt.Errorf("missing prefix in %q", line)
+	}
+	prefix := strings.Split(line[:i], ",")
+	if len(prefix) < 3 {
+		return 0, nil, "", fmt.Errorf("invalid prefix in %q", line)
+	}
+
+	prio, err := strconv.Atoi(prefix[0])
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("invalid priority in %q", line)
+	}
+	seq, err := strconv.ParseUint(prefix[1], 10, 64)
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("invalid sequence number in %q", line)
+	}
+
+	tags := map[string]string{
+		"level":    levels[prio&7],
+		"facility": strconv.Itoa(prio >> 3),
+	}
+	return seq, tags, line[i+1:], nil
+}
+
+func init() {
+	metric.RegisterFieldType("kmsg", telegraf.Counter, "matches")
+
+	inputs.Add("kmsg", func() telegraf.Input {
+		return &Kmsg{}
+	})
+}
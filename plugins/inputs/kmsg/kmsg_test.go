@@ -0,0 +1,153 @@
+package kmsg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/state"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const records = `6,100,5000000,-;usb 1-1: new high-speed USB device number 2
+3,101,5100000,-;blk_update_request: I/O error, dev sdb, sector 2048 op 0x0:(READ)
+ SUBSYSTEM=block
+ DEVICE=b8:16
+3,102,5200000,-;Buffer I/O error on dev sdb, logical block 256, async page read
+4,103,5300000,-;WARNING: ZFS: pool tank has encountered an uncorrectable I/O error
+`
+
+func writeRecords(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "kmsg")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "kmsg")
+	require.NoError(t, ioutil.WriteFile(path, []byte(records), 0600))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestParseRecord(t *testing.T) {
+	seq, tags, message, err := parseRecord("3,101,5100000,-;blk_update_request: I/O error")
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), seq)
+	require.Equal(t, map[string]string{"level": "err", "facility": "0"}, tags)
+	require.Equal(t, "blk_update_request: I/O error", message)
+
+	_, _, _, err = parseRecord("no prefix")
+	require.Error(t, err)
+}
+
+func TestKmsg(t *testing.T) {
+	path, cleanup := writeRecords(t)
+	defer cleanup()
+
+	plugin := &Kmsg{
+		Path: path,
+		Patterns: []*Pattern{
+			{Name: "io_error", Regex: `I/O error, dev (?P<device>\w+)`, Event: true},
+			{Name: "buffer_io_error", Regex: `Buffer I/O error`},
+			{Name: "mce", Regex: `mce: `},
+		},
+		State: state.New().Scope("inputs.kmsg"),
+		Log:   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	// The saved position is from a previous boot, so the whole buffer is
+	// read.
+	plugin.bootID = "current"
+	plugin.pos = position{BootID: "previous", Seq: 500}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	plugin.wg.Wait()
+	require.NoError(t, plugin.Gather(&acc))
+	plugin.Stop()
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("kmsg_events",
+			map[string]string{"pattern": "io_error", "device": "sdb", "level": "err", "facility": "0"},
+			map[string]interface{}{"title": "blk_update_request: I/O error, dev sdb, sector 2048 op 0x0:(READ)"},
+			time.Unix(0, 0)),
+		testutil.MustMetric("kmsg",
+			map[string]string{"pattern": "io_error"},
+			map[string]interface{}{"matches": int64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("kmsg",
+			map[string]string{"pattern": "buffer_io_error"},
+			map[string]interface{}{"matches": int64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("kmsg",
+			map[string]string{"pattern": "mce"},
+			map[string]interface{}{"matches": int64(0)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	var pos position
+	ok, err := plugin.State.Get(positionKey, &pos)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, position{BootID: "current", Seq: 103}, pos)
+}
+
+func TestKmsgResumesAfterPosition(t *testing.T) {
+	path, cleanup := writeRecords(t)
+	defer cleanup()
+
+	store := state.New().Scope("inputs.kmsg")
+	require.NoError(t, store.Set(countsKey, map[string]int64{"io_error": 5}))
+
+	plugin := &Kmsg{
+		Path:     path,
+		Patterns: []*Pattern{{Name: "io_error", Regex: `I/O error`}},
+		State:    store,
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	plugin.bootID = "current"
+	plugin.pos = position{BootID: "current", Seq: 101}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	plugin.wg.Wait()
+	require.NoError(t, plugin.Gather(&acc))
+	plugin.Stop()
+
+	// Records up to 101 were counted before the restart.
+	m, ok := acc.Get("kmsg")
+	require.True(t, ok)
+	require.Equal(t, int64(7), m.Fields["matches"])
+}
+
+func TestKmsgStartsAtEndWithoutPosition(t *testing.T) {
+	path, cleanup := writeRecords(t)
+	defer cleanup()
+
+	plugin := &Kmsg{Path: path, Log: testutil.Logger{}}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	plugin.wg.Wait()
+	require.NoError(t, plugin.Gather(&acc))
+	plugin.Stop()
+
+	for _, m := range acc.GetTelegrafMetrics() {
+		require.Equal(t, "kmsg", m.Name())
+		v, _ := m.GetField("matches")
+		require.Equal(t, int64(0), v)
+	}
+	require.Len(t, acc.GetTelegrafMetrics(), len(defaultPatterns))
+}
+
+func TestInitInvalidPattern(t *testing.T) {
+	plugin := &Kmsg{Patterns: []*Pattern{{Name: "bad", Regex: `(`}}}
+	require.Error(t, plugin.Init())
+
+	plugin = &Kmsg{Patterns: []*Pattern{{Regex: `error`}}}
+	require.Error(t, plugin.Init())
+}
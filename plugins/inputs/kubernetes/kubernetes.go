@@ -233,10 +233,16 @@ func buildPodMetrics(summaryMetrics *SummaryMetrics, acc telegraf.Accumulator) {
 				"namespace":   pod.PodRef.Namespace,
 				"volume_name": volume.Name,
 			}
+			if volume.PVCRef != nil {
+				tags["pvc_name"] = volume.PVCRef.Name
+			}
 			fields := make(map[string]interface{})
 			fields["available_bytes"] = volume.AvailableBytes
 			fields["capacity_bytes"] = volume.CapacityBytes
 			fields["used_bytes"] = volume.UsedBytes
+			fields["inodes"] = volume.Inodes
+			fields["inodes_free"] = volume.InodesFree
+			fields["inodes_used"] = volume.InodesUsed
 			acc.AddFields("kubernetes_pod_volume", fields, tags)
 		}
 
@@ -86,8 +86,18 @@ type NetworkMetrics struct {
 
 // VolumeMetrics represents the disk usage data for a given volume
 type VolumeMetrics struct {
-	Name           string `json:"name"`
-	AvailableBytes int64  `json:"availableBytes"`
-	CapacityBytes  int64  `json:"capacityBytes"`
-	UsedBytes      int64  `json:"usedBytes"`
+	Name           string        `json:"name"`
+	AvailableBytes int64         `json:"availableBytes"`
+	CapacityBytes  int64         `json:"capacityBytes"`
+	UsedBytes      int64         `json:"usedBytes"`
+	Inodes         int64         `json:"inodes"`
+	InodesFree     int64         `json:"inodesFree"`
+	InodesUsed     int64         `json:"inodesUsed"`
+	PVCRef         *PVCReference `json:"pvcRef"`
+}
+
+// PVCReference is the persistent volume claim of a volume
+type PVCReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
@@ -119,6 +119,9 @@ func TestKubernetesStats(t *testing.T) {
 		"available_bytes": int64(7903948800),
 		"capacity_bytes":  int64(7903961088),
 		"used_bytes":      int64(12288),
+		"inodes":          int64(0),
+		"inodes_free":     int64(0),
+		"inodes_used":     int64(0),
 	}
 	tags = map[string]string{
 		"node_name":   "node1",
@@ -128,6 +131,23 @@ func TestKubernetesStats(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "kubernetes_pod_volume", fields, tags)
 
+	fields = map[string]interface{}{
+		"available_bytes": int64(10464022528),
+		"capacity_bytes":  int64(10737418240),
+		"used_bytes":      int64(273395712),
+		"inodes":          int64(20420034),
+		"inodes_free":     int64(20419986),
+		"inodes_used":     int64(48),
+	}
+	tags = map[string]string{
+		"node_name":   "node1",
+		"volume_name": "data",
+		"namespace":   "foons",
+		"pod_name":    "foopod",
+		"pvc_name":    "data-foopod",
+	}
+	acc.AssertContainsTaggedFields(t, "kubernetes_pod_volume", fields, tags)
+
 	fields = map[string]interface{}{
 		"rx_bytes":  int64(70749124),
 		"rx_errors": int64(0),
@@ -305,6 +325,19 @@ var response = `
       "capacityBytes": 7903961088,
       "usedBytes": 8192,
       "name": "volume4"
+     },
+     {
+      "availableBytes": 10464022528,
+      "capacityBytes": 10737418240,
+      "usedBytes": 273395712,
+      "inodesFree": 20419986,
+      "inodes": 20420034,
+      "inodesUsed": 48,
+      "name": "data",
+      "pvcRef": {
+       "name": "data-foopod",
+       "namespace": "foons"
+      }
      }
     ]
    },
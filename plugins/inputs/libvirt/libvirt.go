@@ -0,0 +1,207 @@
+package libvirt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var sampleConfig = `
+  ## Path to the virsh executable.
+  # virsh_path = "/usr/bin/virsh"
+
+  ## Libvirt connection URI.
+  # uri = "qemu:///system"
+
+  ## Domains to report, by default all the running domains.  Globs are
+  ## accepted.
+  # domains = []
+
+  ## Setting 'use_sudo' to true will make use of sudo to run virsh.
+  ## Sudo must be configured to allow the telegraf user to run virsh
+  ## without a password.
+  # use_sudo = false
+
+  ## Timeout for the virsh command to complete.
+  # timeout = "5s"
+`
+
+// blockFields maps the block statistics of virsh domstats to field names.
+var blockFields = map[string]string{
+	"rd.reqs":    "read_ops",
+	"rd.bytes":   "read_bytes",
+	"rd.times":   "read_time_ns",
+	"wr.reqs":    "write_ops",
+	"wr.bytes":   "write_bytes",
+	"wr.times":   "write_time_ns",
+	"fl.reqs":    "flush_ops",
+	"fl.times":   "flush_time_ns",
+	"allocation": "allocation",
+	"capacity":   "capacity",
+	"physical":   "physical",
+}
+
+type Libvirt struct {
+	VirshPath string            `toml:"virsh_path"`
+	URI       string            `toml:"uri"`
+	Domains   []string          `toml:"domains"`
+	UseSudo   bool              `toml:"use_sudo"`
+	Timeout   internal.Duration `toml:"timeout"`
+
+	domainFilter filter.Filter
+}
+
+// blockDevice is the statistics of a block device of a domain.
+type blockDevice struct {
+	domain string
+	name   string
+	path   string
+	fields map[string]interface{}
+}
+
+func (l *Libvirt) Description() string {
+	return "Read block device statistics of libvirt domains"
+}
+
+func (l *Libvirt) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Libvirt) Init() error {
+	if l.VirshPath == "" {
+		path, err := exec.LookPath("virsh")
+		if err != nil {
+			return fmt.Errorf("virsh not found: verify that virsh is installed and that virsh is in your PATH")
+		}
+		l.VirshPath = path
+	}
+
+	var err error
+	l.domainFilter, err = filter.Compile(l.Domains)
+	return err
+}
+
+func (l *Libvirt) Gather(acc telegraf.Accumulator) error {
+	args := []string{"--readonly"}
+	if l.URI != "" {
+		args = append(args, "--connect", l.URI)
+	}
+	args = append(args, "domstats", "--block", "--state-running")
+
+	out, err := runCmd(l.Timeout, l.UseSudo, l.VirshPath, args...)
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %s: %s", l.VirshPath, err, bytes.TrimSpace(out))
+	}
+
+	devices, err := parseDomstats(out)
+	if err != nil {
+		return err
+	}
+	for _, dev := range devices {
+		if l.domainFilter != nil && !l.domainFilter.Match(dev.domain) {
+			continue
+		}
+		tags := map[string]string{
+			"domain": dev.domain,
+			"device": dev.name,
+		}
+		if dev.path != "" {
+			tags["path"] = dev.path
+		}
+		acc.AddFields("libvirt_block", dev.fields, tags)
+	}
+	return nil
+}
+
+var runCmd = func(timeout internal.Duration, sudo bool, command string, args ...string) ([]byte, error) {
+	cmd := exec.Command(command, args...)
+	if sudo {
+		cmd = exec.Command("sudo", append([]string{"-n", command}, args...)...)
+	}
+	return internal.CombinedOutputTimeout(cmd, timeout.Duration)
+}
+
+// parseDomstats parses the block statistics printed by virsh domstats:
+//
+//   Domain: 'web'
+//     block.count=1
+//     block.0.name=vda
+//     block.0.path=/dev/zvol/tank/vm-100-disk-0
+//     block.0.rd.reqs=1013
+func parseDomstats(out []byte) ([]*blockDevice, error) {
+	var devices []*blockDevice
+	var domain string
+	var current map[string]*blockDevice
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Domain:") {
+			domain = strings.Trim(strings.TrimSpace(line[len("Domain:"):]), "'")
+			current = make(map[string]*blockDevice)
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], "block.") {
+			continue
+		}
+		// block.<index>.<stat>
+		parts := strings.SplitN(kv[0], ".", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		index, stat := parts[1], parts[2]
+
+		dev, ok := current[index]
+		if !ok {
+			dev = &blockDevice{domain: domain, fields: make(map[string]interface{})}
+			current[index] = dev
+			devices = append(devices, dev)
+		}
+		switch stat {
+		case "name":
+			dev.name = kv[1]
+		case "path":
+			dev.path = kv[1]
+		default:
+			name, ok := blockFields[stat]
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %q: %s", line, err)
+			}
+			dev.fields[name] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func init() {
+	inputs.Add("libvirt", func() telegraf.Input {
+		return &Libvirt{
+			URI:     "qemu:///system",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
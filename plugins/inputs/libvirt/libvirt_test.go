@@ -0,0 +1,121 @@
+package libvirt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const domstatsOutput = `Domain: 'web'
+  block.count=2
+  block.0.name=vda
+  block.0.path=/dev/zvol/tank/vm-100-disk-0
+  block.0.backingIndex=1
+  block.0.rd.reqs=1013
+  block.0.rd.bytes=20123648
+  block.0.rd.times=812345678
+  block.0.wr.reqs=4567
+  block.0.wr.bytes=98304000
+  block.0.wr.times=2345678901
+  block.0.fl.reqs=321
+  block.0.fl.times=123456789
+  block.0.allocation=10737418240
+  block.0.capacity=10737418240
+  block.0.physical=10737418240
+  block.1.name=sda
+  block.1.rd.reqs=12
+  block.1.rd.bytes=49152
+
+Domain: 'db'
+  block.count=1
+  block.0.name=vda
+  block.0.path=/var/lib/libvirt/images/db.qcow2
+  block.0.rd.reqs=5
+  block.0.wr.reqs=6
+
+`
+
+func TestGather(t *testing.T) {
+	var args []string
+	runCmd = func(timeout internal.Duration, sudo bool, command string, a ...string) ([]byte, error) {
+		args = a
+		return []byte(domstatsOutput), nil
+	}
+
+	l := &Libvirt{
+		VirshPath: "virsh",
+		URI:       "qemu:///system",
+		Domains:   []string{"web"},
+	}
+	require.NoError(t, l.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(l.Gather))
+	require.Equal(t, []string{"--readonly", "--connect", "qemu:///system",
+		"domstats", "--block", "--state-running"}, args)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"libvirt_block",
+			map[string]string{
+				"domain": "web",
+				"device": "vda",
+				"path":   "/dev/zvol/tank/vm-100-disk-0",
+			},
+			map[string]interface{}{
+				"read_ops":      uint64(1013),
+				"read_bytes":    uint64(20123648),
+				"read_time_ns":  uint64(812345678),
+				"write_ops":     uint64(4567),
+				"write_bytes":   uint64(98304000),
+				"write_time_ns": uint64(2345678901),
+				"flush_ops":     uint64(321),
+				"flush_time_ns": uint64(123456789),
+				"allocation":    uint64(10737418240),
+				"capacity":      uint64(10737418240),
+				"physical":      uint64(10737418240),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"libvirt_block",
+			map[string]string{
+				"domain": "web",
+				"device": "sda",
+			},
+			map[string]interface{}{
+				"read_ops":   uint64(12),
+				"read_bytes": uint64(49152),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherError(t *testing.T) {
+	runCmd = func(timeout internal.Duration, sudo bool, command string, a ...string) ([]byte, error) {
+		return []byte("error: failed to connect to the hypervisor\n"), errors.New("exit status 1")
+	}
+
+	l := &Libvirt{VirshPath: "virsh"}
+	require.NoError(t, l.Init())
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(l.Gather)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to connect to the hypervisor")
+}
+
+func TestParseDomstatsInvalid(t *testing.T) {
+	_, err := parseDomstats([]byte("  block.0.rd.reqs=5\n"))
+	require.Error(t, err)
+
+	_, err = parseDomstats([]byte("Domain: 'web'\n  block.0.rd.reqs=five\n"))
+	require.Error(t, err)
+}
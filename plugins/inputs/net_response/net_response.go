@@ -31,6 +31,7 @@ type NetResponse struct {
 	Send        string
 	Expect      string
 	Protocol    string
+	Count       int
 }
 
 var description = "Collect response time of a TCP or UDP connection"
@@ -54,6 +55,11 @@ var sampleConfig = `
   ## Set read timeout (only used if expecting a response)
   # read_timeout = "1s"
 
+  ## Number of TCP connections made at each interval.  With more than one,
+  ## the failed connections are counted in percent_failed and the response
+  ## time is the average of the successful ones.
+  # count = 1
+
   ## The following options are required for UDP checks. For TCP, they are
   ## optional. The plugin will send the given string to the server and then
   ## expect to receive the given 'expect' string back.
@@ -131,6 +137,43 @@ func (n *NetResponse) TCPGather() (tags map[string]string, fields map[string]int
 	return tags, fields
 }
 
+// TCPProbe makes count TCP connections and reports how many failed.  The
+// result is success when at least one connection succeeded, otherwise the
+// result of the last connection.
+func (n *NetResponse) TCPProbe() (tags map[string]string, fields map[string]interface{}) {
+	var successes int
+	var total, min, max float64
+	for i := 0; i < n.Count; i++ {
+		attemptTags, attemptFields := n.TCPGather()
+		if attemptTags["result"] != "success" {
+			if successes == 0 {
+				tags, fields = attemptTags, attemptFields
+			}
+			continue
+		}
+
+		responseTime := attemptFields["response_time"].(float64)
+		if successes == 0 || responseTime < min {
+			min = responseTime
+		}
+		if responseTime > max {
+			max = responseTime
+		}
+		total += responseTime
+		successes++
+		tags, fields = attemptTags, attemptFields
+	}
+
+	if successes > 0 {
+		fields["response_time"] = total / float64(successes)
+		fields["response_time_min"] = min
+		fields["response_time_max"] = max
+	}
+	fields["attempts"] = n.Count
+	fields["percent_failed"] = float64(n.Count-successes) / float64(n.Count) * 100
+	return tags, fields
+}
+
 // UDPGather will execute if there are UDP tests defined in the configuration.
 // It will return a map[string]interface{} for fields and a map[string]string for tags
 func (n *NetResponse) UDPGather() (tags map[string]string, fields map[string]interface{}) {
@@ -214,7 +257,10 @@ func (n *NetResponse) Gather(acc telegraf.Accumulator) error {
 	var fields map[string]interface{}
 	var returnTags map[string]string
 	// Gather data
-	if n.Protocol == "tcp" {
+	if n.Protocol == "tcp" && n.Count > 1 {
+		returnTags, fields = n.TCPProbe()
+		tags["protocol"] = "tcp"
+	} else if n.Protocol == "tcp" {
 		returnTags, fields = n.TCPGather()
 		tags["protocol"] = "tcp"
 	} else if n.Protocol == "udp" {
@@ -198,6 +198,63 @@ func TestTCPOK2(t *testing.T) {
 	wg.Wait()
 }
 
+func TestTCPCount(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	var acc testutil.Accumulator
+	c := NetResponse{
+		Address:  l.Addr().String(),
+		Timeout:  internal.Duration{Duration: time.Second},
+		Protocol: "tcp",
+		Count:    3,
+	}
+	require.NoError(t, c.Gather(&acc))
+
+	m, ok := acc.Get("net_response")
+	require.True(t, ok)
+	require.Equal(t, "success", m.Tags["result"])
+	require.Equal(t, 3, m.Fields["attempts"])
+	require.Equal(t, 0.0, m.Fields["percent_failed"])
+	require.True(t, m.Fields["response_time_min"].(float64) <= m.Fields["response_time"].(float64))
+	require.True(t, m.Fields["response_time"].(float64) <= m.Fields["response_time_max"].(float64))
+}
+
+func TestTCPCountError(t *testing.T) {
+	var acc testutil.Accumulator
+	c := NetResponse{
+		Address:  ":9999",
+		Protocol: "tcp",
+		Count:    2,
+	}
+	require.NoError(t, c.Gather(&acc))
+	acc.AssertContainsTaggedFields(t,
+		"net_response",
+		map[string]interface{}{
+			"result_code":    uint64(2),
+			"result_type":    "connection_failed",
+			"attempts":       2,
+			"percent_failed": 100.0,
+		},
+		map[string]string{
+			"server":   "",
+			"port":     "9999",
+			"protocol": "tcp",
+			"result":   "connection_failed",
+		},
+	)
+}
+
 func TestUDPError(t *testing.T) {
 	var acc testutil.Accumulator
 	// Init plugin
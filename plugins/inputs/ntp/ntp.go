@@ -0,0 +1,219 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultPort = "123"
+	packetSize  = 48
+
+	// Seconds between the NTP era, 1900, and the Unix epoch.
+	ntpEpochOffset = 2208988800
+)
+
+// NTP queries time servers with SNTP and reports the offset of the local
+// clock.  Unlike the chrony, ntpq and openntpd inputs it does not depend on
+// the time daemon running on the host.
+type NTP struct {
+	Servers []string          `toml:"servers"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	now func() time.Time
+}
+
+// response holds the fields of a server packet used in the metrics.
+type response struct {
+	leap           uint8
+	stratum        uint8
+	referenceID    string
+	rootDelay      time.Duration
+	rootDispersion time.Duration
+	originTime     time.Time
+	receiveTime    time.Time
+	transmitTime   time.Time
+}
+
+var sampleConfig = `
+  ## Time servers to query, as "host" or "host:port".  Querying the same
+  ## servers from every host of a cluster makes the clock skew between the
+  ## hosts visible.
+  servers = ["pool.ntp.org"]
+
+  ## Timeout of each query.
+  # timeout = "5s"
+`
+
+func (n *NTP) Description() string {
+	return "Query NTP servers for the offset of the local clock"
+}
+
+func (n *NTP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NTP) Init() error {
+	if len(n.Servers) == 0 {
+		return errors.New("no servers configured")
+	}
+	if n.now == nil {
+		n.now = time.Now
+	}
+	return nil
+}
+
+func (n *NTP) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, server := range n.Servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			if err := n.gatherServer(acc, server); err != nil {
+				acc.AddError(fmt.Errorf("%s: %v", server, err))
+			}
+		}(server)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (n *NTP) gatherServer(acc telegraf.Accumulator, server string) error {
+	address := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		address = net.JoinHostPort(server, defaultPort)
+	}
+
+	conn, err := net.DialTimeout("udp", address, n.Timeout.Duration)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(n.Timeout.Duration))
+
+	// Version 4, client mode, the transmit timestamp is echoed back by the
+	// server as origin timestamp.
+	req := make([]byte, packetSize)
+	req[0] = 4<<3 | 3
+	t1 := n.now()
+	putTimestamp(req[40:], t1)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	buf := make([]byte, packetSize)
+	size, err := conn.Read(buf)
+	t4 := n.now()
+	if err != nil {
+		return err
+	}
+	resp, err := parseResponse(buf[:size])
+	if err != nil {
+		return err
+	}
+	if resp.originTime.UnixNano() != truncate(t1).UnixNano() {
+		return errors.New("response does not match the request")
+	}
+	if resp.stratum == 0 {
+		return fmt.Errorf("kiss-of-death from server: %s", resp.referenceID)
+	}
+
+	t2, t3 := resp.receiveTime, resp.transmitTime
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	delay := t4.Sub(t1) - t3.Sub(t2)
+
+	// The reference ID is a field, the upstream server of a pool changes
+	// between the queries.
+	tags := map[string]string{"server": server}
+	fields := map[string]interface{}{
+		"reference_id":    resp.referenceID,
+		"offset":          offset.Seconds(),
+		"delay":           delay.Seconds(),
+		"stratum":         int64(resp.stratum),
+		"leap":            int64(resp.leap),
+		"root_delay":      resp.rootDelay.Seconds(),
+		"root_dispersion": resp.rootDispersion.Seconds(),
+	}
+	acc.AddFields("ntp", fields, tags)
+	return nil
+}
+
+func parseResponse(buf []byte) (*response, error) {
+	if len(buf) < packetSize {
+		return nil, fmt.Errorf("short response of %d bytes", len(buf))
+	}
+	if mode := buf[0] & 7; mode != 4 {
+		return nil, fmt.Errorf("unexpected mode %d in response", mode)
+	}
+
+	resp := &response{
+		leap:           buf[0] >> 6,
+		stratum:        buf[1],
+		rootDelay:      shortDuration(binary.BigEndian.Uint32(buf[4:])),
+		rootDispersion: shortDuration(binary.BigEndian.Uint32(buf[8:])),
+		originTime:     timestamp(buf[24:]),
+		receiveTime:    timestamp(buf[32:]),
+		transmitTime:   timestamp(buf[40:]),
+	}
+
+	// The reference ID of stratum 0 and 1 servers is a code, ie "GPS", of
+	// other servers the IPv4 address of their upstream server.
+	if resp.stratum <= 1 {
+		resp.referenceID = string(trimNull(buf[12:16]))
+	} else {
+		resp.referenceID = net.IP(buf[12:16]).String()
+	}
+	return resp, nil
+}
+
+func trimNull(b []byte) []byte {
+	for i, c := range b {
+		if c == 0 {
+			return b[:i]
+		}
+	}
+	return b
+}
+
+// shortDuration converts an NTP short format, 16 bits of seconds and 16 bits
+// of fraction.
+func shortDuration(v uint32) time.Duration {
+	return time.Duration(uint64(v) * uint64(time.Second) >> 16)
+}
+
+// timestamp converts an NTP timestamp, 32 bits of seconds since 1900 and 32
+// bits of fraction.
+func timestamp(b []byte) time.Time {
+	sec := int64(binary.BigEndian.Uint32(b)) - ntpEpochOffset
+	frac := uint64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(sec, int64(frac*uint64(time.Second)>>32))
+}
+
+func putTimestamp(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:], uint32(uint64(t.Nanosecond())<<32/uint64(time.Second)))
+}
+
+// truncate returns the time as it survives a round trip through an NTP
+// timestamp.
+func truncate(t time.Time) time.Time {
+	b := make([]byte, 8)
+	putTimestamp(b, t)
+	return timestamp(b)
+}
+
+func init() {
+	inputs.Add("ntp", func() telegraf.Input {
+		return &NTP{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// serve answers one request with a server clock ahead of the client by
+// offset, receiving the request after latency and answering after process.
+func serve(t *testing.T, conn net.PacketConn, stratum uint8, refID []byte, offset, latency, process time.Duration) {
+	buf := make([]byte, packetSize)
+	_, addr, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	t1 := timestamp(buf[40:])
+	resp := make([]byte, packetSize)
+	resp[0] = 0<<6 | 4<<3 | 4
+	resp[1] = stratum
+	binary.BigEndian.PutUint32(resp[4:], 0x00000800)
+	binary.BigEndian.PutUint32(resp[8:], 0x00010000)
+	copy(resp[12:], refID)
+	copy(resp[24:], buf[40:48])
+	putTimestamp(resp[32:], t1.Add(offset+latency))
+	putTimestamp(resp[40:], t1.Add(offset+latency+process))
+	_, err = conn.WriteTo(resp, addr)
+	require.NoError(t, err)
+}
+
+func TestGather(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	go serve(t, conn, 2, []byte{192, 168, 1, 22}, 2*time.Second, 3*time.Millisecond, time.Millisecond)
+
+	base := time.Unix(1578000000, 0)
+	times := []time.Time{base, base.Add(7 * time.Millisecond)}
+	plugin := &NTP{
+		Servers: []string{conn.LocalAddr().String()},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		now: func() time.Time {
+			t := times[0]
+			times = times[1:]
+			return t
+		},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+
+	m := acc.Metrics[0]
+	require.Equal(t, "ntp", m.Measurement)
+	require.Equal(t, map[string]string{"server": conn.LocalAddr().String()}, m.Tags)
+	require.Equal(t, "192.168.1.22", m.Fields["reference_id"])
+	require.InDelta(t, 2.0, m.Fields["offset"], 1e-6)
+	require.InDelta(t, 0.006, m.Fields["delay"], 1e-6)
+	require.Equal(t, int64(2), m.Fields["stratum"])
+	require.Equal(t, int64(0), m.Fields["leap"])
+	require.InDelta(t, 0.03125, m.Fields["root_delay"], 1e-6)
+	require.InDelta(t, 1.0, m.Fields["root_dispersion"], 1e-6)
+}
+
+func TestGatherKissOfDeath(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	go serve(t, conn, 0, []byte("RATE"), 0, 0, 0)
+
+	plugin := &NTP{
+		Servers: []string{conn.LocalAddr().String()},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "RATE")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestParseResponseReferenceID(t *testing.T) {
+	buf := make([]byte, packetSize)
+	buf[0] = 4<<3 | 4
+	buf[1] = 1
+	copy(buf[12:], "GPS")
+	resp, err := parseResponse(buf)
+	require.NoError(t, err)
+	require.Equal(t, "GPS", resp.referenceID)
+
+	buf[0] = 4<<3 | 3
+	_, err = parseResponse(buf)
+	require.Error(t, err)
+
+	_, err = parseResponse(buf[:12])
+	require.Error(t, err)
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	tm := time.Unix(1578000000, 123456789)
+	require.InDelta(t, tm.UnixNano(), truncate(tm).UnixNano(), 1)
+}
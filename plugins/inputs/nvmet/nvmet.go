@@ -0,0 +1,41 @@
+package nvmet
+
+// Nvmet reads the subsystems and namespaces exported by the NVMe over
+// Fabrics target of Linux.
+type Nvmet struct {
+	// Path of the nvmet configfs
+	ConfigPath string `toml:"config_path"`
+
+	// Path of the nvmet debugfs, with the connected controllers
+	DebugPath string `toml:"debug_path"`
+
+	// Path of the block devices in sysfs, with their IO statistics
+	BlockPath string `toml:"block_path"`
+}
+
+const (
+	pluginName = "nvmet"
+
+	sampleConfig = `
+  ## Path of the nvmet configfs.
+  # config_path = "/sys/kernel/config/nvmet"
+
+  ## Path of the nvmet debugfs, with the controllers connected to the
+  ## subsystems.  Requires Linux 6.8 or later and a mounted debugfs, the
+  ## controllers are not reported without it.
+  # debug_path = "/sys/kernel/debug/nvmet"
+
+  ## Path of the block devices in sysfs, with the IO statistics of the
+  ## devices of the namespaces, by default $HOST_SYS/class/block.
+  # block_path = "/sys/class/block"
+`
+)
+
+func (n *Nvmet) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a one-sentence description on the Input
+func (n *Nvmet) Description() string {
+	return "Gets the subsystems, namespaces and connected hosts of the NVMe-oF target"
+}
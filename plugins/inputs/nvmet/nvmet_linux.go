@@ -0,0 +1,159 @@
+// +build linux
+
+package nvmet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// blockStats are the fields of the stat file of the block devices, in
+// order, see Documentation/block/stat.rst.
+var blockStats = []string{
+	"read_ios", "read_merges", "read_sectors", "read_ticks_ms",
+	"write_ios", "write_merges", "write_sectors", "write_ticks_ms",
+	"in_flight", "io_ticks_ms", "time_in_queue_ms",
+}
+
+func (n *Nvmet) Gather(acc telegraf.Accumulator) error {
+	subsystems, err := ioutil.ReadDir(filepath.Join(n.ConfigPath, "subsystems"))
+	if err != nil {
+		return fmt.Errorf("nvmet not found, is the nvmet module loaded: %v", err)
+	}
+
+	ports := n.subsystemPorts()
+	for _, subsystem := range subsystems {
+		n.gatherSubsystem(acc, subsystem.Name(), ports[subsystem.Name()])
+	}
+	return nil
+}
+
+// subsystemPorts counts the ports exporting each subsystem.
+func (n *Nvmet) subsystemPorts() map[string]int {
+	counts := make(map[string]int)
+	links, _ := filepath.Glob(filepath.Join(n.ConfigPath, "ports", "*", "subsystems", "*"))
+	for _, link := range links {
+		counts[filepath.Base(link)]++
+	}
+	return counts
+}
+
+func (n *Nvmet) gatherSubsystem(acc telegraf.Accumulator, subsystem string, ports int) {
+	dir := filepath.Join(n.ConfigPath, "subsystems", subsystem)
+	tags := map[string]string{"subsystem": subsystem}
+
+	namespaces, err := ioutil.ReadDir(filepath.Join(dir, "namespaces"))
+	if err != nil {
+		acc.AddError(fmt.Errorf("subsystem %s: %v", subsystem, err))
+		return
+	}
+	hosts, _ := ioutil.ReadDir(filepath.Join(dir, "allowed_hosts"))
+
+	enabled := 0
+	for _, namespace := range namespaces {
+		if n.gatherNamespace(acc, subsystem, namespace.Name()) {
+			enabled++
+		}
+	}
+
+	fields := map[string]interface{}{
+		"namespaces":         len(namespaces),
+		"namespaces_enabled": enabled,
+		"allowed_hosts":      len(hosts),
+		"allow_any_host":     readString(filepath.Join(dir, "attr_allow_any_host")) == "1",
+		"ports":              ports,
+	}
+
+	controllers, err := ioutil.ReadDir(filepath.Join(n.DebugPath, subsystem))
+	if err == nil {
+		connected := make(map[string]int)
+		for _, controller := range controllers {
+			if !strings.HasPrefix(controller.Name(), "ctrl") {
+				continue
+			}
+			host := readString(filepath.Join(n.DebugPath, subsystem, controller.Name(), "hostnqn"))
+			if host != "" {
+				connected[host]++
+			}
+		}
+
+		total := 0
+		for host, count := range connected {
+			acc.AddFields("nvmet_host",
+				map[string]interface{}{"controllers": count},
+				map[string]string{"subsystem": subsystem, "hostnqn": host})
+			total += count
+		}
+		fields["controllers"] = total
+	}
+
+	acc.AddFields("nvmet_subsystem", fields, tags)
+}
+
+// gatherNamespace adds the namespace with the IO statistics of its block
+// device, and returns whether it is enabled.
+func (n *Nvmet) gatherNamespace(acc telegraf.Accumulator, subsystem, nsid string) bool {
+	dir := filepath.Join(n.ConfigPath, "subsystems", subsystem, "namespaces", nsid)
+	devicePath := readString(filepath.Join(dir, "device_path"))
+	enabled := readString(filepath.Join(dir, "enable")) == "1"
+
+	tags := map[string]string{
+		"subsystem": subsystem,
+		"nsid":      nsid,
+		"device":    devicePath,
+	}
+	fields := map[string]interface{}{"enabled": enabled}
+
+	// The device path is usually a link, like /dev/zvol/tank/vol to
+	// /dev/zd0.  A file backed namespace has no statistics.
+	if devicePath != "" {
+		if device, err := filepath.EvalSymlinks(devicePath); err == nil {
+			stats := strings.Fields(readString(filepath.Join(n.BlockPath, filepath.Base(device), "stat")))
+			for i, name := range blockStats {
+				if i >= len(stats) {
+					break
+				}
+				if value, err := strconv.ParseUint(stats[i], 10, 64); err == nil {
+					fields[name] = value
+				}
+			}
+		}
+	}
+
+	acc.AddFields("nvmet_namespace", fields, tags)
+	return enabled
+}
+
+// readString returns the trimmed contents of the file, empty when it cannot
+// be read.
+func readString(filename string) string {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+func hostSys() string {
+	if sys := os.Getenv("HOST_SYS"); sys != "" {
+		return sys
+	}
+	return "/sys"
+}
+
+func init() {
+	inputs.Add(pluginName, func() telegraf.Input {
+		return &Nvmet{
+			ConfigPath: "/sys/kernel/config/nvmet",
+			DebugPath:  "/sys/kernel/debug/nvmet",
+			BlockPath:  filepath.Join(hostSys(), "class/block"),
+		}
+	})
+}
@@ -0,0 +1,21 @@
+// +build !linux
+
+package nvmet
+
+import (
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (n *Nvmet) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add(pluginName, func() telegraf.Input {
+		log.Print("W! [inputs.nvmet] Current platform is not supported")
+		return &Nvmet{}
+	})
+}
@@ -0,0 +1,128 @@
+// +build linux
+
+package nvmet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func makeFakeTarget(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "nvmet")
+	require.NoError(t, err)
+
+	write := func(name, contents string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	mkdir := func(name string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+	}
+
+	// A zvol, /dev/zvol/tank/vol1 linking to /dev/zd0.
+	write("dev/zd0", "")
+	mkdir("dev/zvol/tank")
+	require.NoError(t, os.Symlink(filepath.Join(dir, "dev/zd0"), filepath.Join(dir, "dev/zvol/tank/vol1")))
+	write("block/zd0/stat", "     100        0     1600       50      200        0     3200      80        1      120      130\n")
+
+	write("config/subsystems/nqn.tank/attr_allow_any_host", "0\n")
+	write("config/subsystems/nqn.tank/namespaces/1/device_path", filepath.Join(dir, "dev/zvol/tank/vol1")+"\n")
+	write("config/subsystems/nqn.tank/namespaces/1/enable", "1\n")
+	write("config/subsystems/nqn.tank/namespaces/2/device_path", "/srv/image.raw\n")
+	write("config/subsystems/nqn.tank/namespaces/2/enable", "0\n")
+	mkdir("config/subsystems/nqn.tank/allowed_hosts/nqn.host-a")
+	mkdir("config/subsystems/nqn.tank/allowed_hosts/nqn.host-b")
+	mkdir("config/ports/1/subsystems/nqn.tank")
+	mkdir("config/ports/2/subsystems/nqn.tank")
+
+	write("debug/nqn.tank/ctrl1/hostnqn", "nqn.host-a\n")
+	write("debug/nqn.tank/ctrl2/hostnqn", "nqn.host-a\n")
+	write("debug/nqn.tank/ctrl3/hostnqn", "nqn.host-b\n")
+
+	return dir
+}
+
+func TestGather(t *testing.T) {
+	dir := makeFakeTarget(t)
+	defer os.RemoveAll(dir)
+
+	var acc testutil.Accumulator
+	plugin := &Nvmet{
+		ConfigPath: filepath.Join(dir, "config"),
+		DebugPath:  filepath.Join(dir, "debug"),
+		BlockPath:  filepath.Join(dir, "block"),
+	}
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "nvmet_subsystem",
+		map[string]interface{}{
+			"namespaces":         2,
+			"namespaces_enabled": 1,
+			"allowed_hosts":      2,
+			"allow_any_host":     false,
+			"ports":              2,
+			"controllers":        3,
+		},
+		map[string]string{"subsystem": "nqn.tank"},
+	)
+	acc.AssertContainsTaggedFields(t, "nvmet_namespace",
+		map[string]interface{}{
+			"enabled":          true,
+			"read_ios":         uint64(100),
+			"read_merges":      uint64(0),
+			"read_sectors":     uint64(1600),
+			"read_ticks_ms":    uint64(50),
+			"write_ios":        uint64(200),
+			"write_merges":     uint64(0),
+			"write_sectors":    uint64(3200),
+			"write_ticks_ms":   uint64(80),
+			"in_flight":        uint64(1),
+			"io_ticks_ms":      uint64(120),
+			"time_in_queue_ms": uint64(130),
+		},
+		map[string]string{"subsystem": "nqn.tank", "nsid": "1", "device": filepath.Join(dir, "dev/zvol/tank/vol1")},
+	)
+	acc.AssertContainsTaggedFields(t, "nvmet_namespace",
+		map[string]interface{}{"enabled": false},
+		map[string]string{"subsystem": "nqn.tank", "nsid": "2", "device": "/srv/image.raw"},
+	)
+	acc.AssertContainsTaggedFields(t, "nvmet_host",
+		map[string]interface{}{"controllers": 2},
+		map[string]string{"subsystem": "nqn.tank", "hostnqn": "nqn.host-a"},
+	)
+	acc.AssertContainsTaggedFields(t, "nvmet_host",
+		map[string]interface{}{"controllers": 1},
+		map[string]string{"subsystem": "nqn.tank", "hostnqn": "nqn.host-b"},
+	)
+}
+
+func TestGatherNoDebugfs(t *testing.T) {
+	dir := makeFakeTarget(t)
+	defer os.RemoveAll(dir)
+
+	var acc testutil.Accumulator
+	plugin := &Nvmet{
+		ConfigPath: filepath.Join(dir, "config"),
+		DebugPath:  filepath.Join(dir, "missing"),
+		BlockPath:  filepath.Join(dir, "block"),
+	}
+	require.NoError(t, plugin.Gather(&acc))
+
+	m, ok := acc.Get("nvmet_subsystem")
+	require.True(t, ok)
+	require.NotContains(t, m.Fields, "controllers")
+	require.False(t, acc.HasMeasurement("nvmet_host"))
+}
+
+func TestGatherNotLoaded(t *testing.T) {
+	var acc testutil.Accumulator
+	plugin := &Nvmet{ConfigPath: "/nonexistent/nvmet"}
+	require.Error(t, plugin.Gather(&acc))
+}
@@ -0,0 +1,317 @@
+package open_files
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// OpenFiles reports the number of open files and file locks per mounted
+// filesystem.
+type OpenFiles struct {
+	MountPoints []string `toml:"mount_points"`
+	FSTypes     []string `toml:"fstypes"`
+	OpenFiles   bool     `toml:"open_files"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	procPath    string
+	mountPoints filter.Filter
+	fstypes     filter.Filter
+}
+
+// device is a device number as major and minor.
+type device struct {
+	major, minor uint64
+}
+
+type mount struct {
+	path   string
+	fstype string
+	source string
+}
+
+type counts struct {
+	files   int64
+	posix   int64
+	flock   int64
+	ofd     int64
+	leases  int64
+	blocked int64
+}
+
+var sampleConfig = `
+  ## Mount points and filesystem types to report, glob patterns are
+  ## supported.  By default all the filesystems are reported.
+  # mount_points = ["/tank/*"]
+  # fstypes = ["zfs", "nfs", "nfs4"]
+
+  ## Count the open files by scanning the file descriptors of all the
+  ## processes, which requires running as root and can be slow on hosts with
+  ## many open files.  Otherwise only the locks are reported.
+  # open_files = true
+`
+
+func (o *OpenFiles) Description() string {
+	return "Count the open files and file locks per mounted filesystem"
+}
+
+func (o *OpenFiles) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenFiles) Init() error {
+	if o.procPath == "" {
+		o.procPath = os.Getenv("HOST_PROC")
+		if o.procPath == "" {
+			o.procPath = "/proc"
+		}
+	}
+
+	var err error
+	if o.mountPoints, err = filter.Compile(o.MountPoints); err != nil {
+		return fmt.Errorf("error compiling mount_points: %v", err)
+	}
+	if o.fstypes, err = filter.Compile(o.FSTypes); err != nil {
+		return fmt.Errorf("error compiling fstypes: %v", err)
+	}
+	return nil
+}
+
+func (o *OpenFiles) Gather(acc telegraf.Accumulator) error {
+	f, err := os.Open(filepath.Join(o.procPath, "self", "mountinfo"))
+	if err != nil {
+		return err
+	}
+	mounts, err := parseMountinfo(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	// Filesystems mounted several times, such as with bind mounts, share a
+	// device and are reported once.
+	byDevice := make(map[device]*counts)
+	for dev, m := range mounts {
+		if o.mountPoints != nil && !o.mountPoints.Match(m.path) {
+			continue
+		}
+		if o.fstypes != nil && !o.fstypes.Match(m.fstype) {
+			continue
+		}
+		byDevice[dev] = &counts{}
+	}
+
+	f, err = os.Open(filepath.Join(o.procPath, "locks"))
+	if err != nil {
+		return err
+	}
+	err = countLocks(f, byDevice)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	if o.OpenFiles {
+		if err := o.countFiles(byDevice); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	for dev, c := range byDevice {
+		m := mounts[dev]
+		tags := map[string]string{
+			"path":   m.path,
+			"fstype": m.fstype,
+			"device": m.source,
+		}
+		fields := map[string]interface{}{
+			"posix_locks":   c.posix,
+			"flock_locks":   c.flock,
+			"ofd_locks":     c.ofd,
+			"leases":        c.leases,
+			"blocked_locks": c.blocked,
+		}
+		if o.OpenFiles {
+			fields["open_files"] = c.files
+		}
+		acc.AddFields("open_files", fields, tags)
+	}
+	return nil
+}
+
+// countFiles counts the file descriptors of all the processes referring to
+// files of the devices.  Processes exiting during the scan are skipped.
+func (o *OpenFiles) countFiles(byDevice map[device]*counts) error {
+	dirs, err := filepath.Glob(filepath.Join(o.procPath, "[0-9]*", "fd"))
+	if err != nil {
+		return err
+	}
+
+	denied := 0
+	for _, dir := range dirs {
+		fds, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsPermission(err) {
+				denied++
+			}
+			continue
+		}
+		for _, fd := range fds {
+			info, err := os.Stat(filepath.Join(dir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			dev, ok := deviceOf(info)
+			if !ok {
+				continue
+			}
+			if c, ok := byDevice[dev]; ok {
+				c.files++
+			}
+		}
+	}
+	if denied > 0 {
+		o.Log.Debugf("Permission denied reading the file descriptors of %d processes", denied)
+	}
+	return nil
+}
+
+// parseMountinfo parses /proc/<pid>/mountinfo:
+//
+//   36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// The first mount of each device is returned.
+func parseMountinfo(r io.Reader) (map[device]mount, error) {
+	mounts := make(map[device]mount)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 5 || len(fields) < sep+3 {
+			continue
+		}
+
+		parts := strings.SplitN(fields[2], ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		major, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		minor, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		dev := device{major: major, minor: minor}
+		if _, ok := mounts[dev]; ok {
+			continue
+		}
+		mounts[dev] = mount{
+			path:   unescape(fields[4]),
+			fstype: fields[sep+1],
+			source: unescape(fields[sep+2]),
+		}
+	}
+	return mounts, scanner.Err()
+}
+
+// unescape decodes the octal escapes of the spaces, tabs, newlines and
+// backslashes of the paths in mountinfo.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// countLocks counts the locks of /proc/locks by device:
+//
+//   1: POSIX  ADVISORY  WRITE 1234 00:2f:1234567 0 EOF
+//   1: -> POSIX  ADVISORY  WRITE 1235 00:2f:1234567 0 EOF
+//   2: FLOCK  ADVISORY  WRITE 5678 fd:01:393218 0 EOF
+//
+// The device numbers are hexadecimal, lines with "->" are the locks waiting
+// for the lock above them.
+func countLocks(r io.Reader, byDevice map[device]*counts) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		blocked := len(fields) > 1 && fields[1] == "->"
+		if blocked {
+			fields = append(fields[:1], fields[2:]...)
+		}
+		if len(fields) < 6 {
+			continue
+		}
+
+		parts := strings.Split(fields[5], ":")
+		if len(parts) != 3 {
+			continue
+		}
+		major, err := strconv.ParseUint(parts[0], 16, 64)
+		if err != nil {
+			continue
+		}
+		minor, err := strconv.ParseUint(parts[1], 16, 64)
+		if err != nil {
+			continue
+		}
+		c, ok := byDevice[device{major: major, minor: minor}]
+		if !ok {
+			continue
+		}
+
+		if blocked {
+			c.blocked++
+			continue
+		}
+		switch fields[1] {
+		case "POSIX":
+			c.posix++
+		case "FLOCK":
+			c.flock++
+		case "OFDLCK":
+			c.ofd++
+		case "LEASE", "BREAKING", "BREAKING_LEASE", "DELEG":
+			c.leases++
+		}
+	}
+	return scanner.Err()
+}
+
+func init() {
+	inputs.Add("open_files", func() telegraf.Input {
+		return &OpenFiles{
+			OpenFiles: true,
+		}
+	})
+}
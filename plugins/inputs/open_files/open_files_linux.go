@@ -0,0 +1,19 @@
+package open_files
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceOf returns the device holding the file.
+func deviceOf(info os.FileInfo) (device, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return device{}, false
+	}
+	dev := uint64(stat.Dev)
+	return device{
+		major: (dev>>8)&0xfff | (dev>>32)&^0xfff,
+		minor: dev&0xff | (dev>>12)&^0xff,
+	}, true
+}
@@ -0,0 +1,75 @@
+package open_files
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGather counts the files opened by a fake process, pointing its file
+// descriptors to files of the temporary directory.
+func TestGather(t *testing.T) {
+	dir, err := ioutil.TempDir("", "open_files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	dev, ok := deviceOf(info)
+	require.True(t, ok)
+
+	proc := filepath.Join(dir, "proc")
+	require.NoError(t, os.MkdirAll(filepath.Join(proc, "self"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(proc, "42", "fd"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "self", "mountinfo"), []byte(fmt.Sprintf(
+		"45 21 %d:%d / /tank/home rw - zfs tank/home rw\n"+
+			"46 21 1023:1023 / /tank/empty rw - zfs tank/empty rw\n"+
+			"47 21 1022:1022 / /boot rw - ext4 /dev/sda1 rw\n",
+		dev.major, dev.minor)), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(proc, "locks"), []byte(fmt.Sprintf(
+		"1: POSIX  ADVISORY  WRITE 42 %02x:%02x:1234 0 EOF\n", dev.major, dev.minor)), 0644))
+
+	for i := 0; i < 3; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		require.NoError(t, ioutil.WriteFile(file, nil, 0644))
+		require.NoError(t, os.Symlink(file, filepath.Join(proc, "42", "fd", fmt.Sprint(i))))
+	}
+
+	plugin := &OpenFiles{
+		FSTypes:   []string{"zfs"},
+		OpenFiles: true,
+		Log:       testutil.Logger{},
+		procPath:  proc,
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Metrics, 2)
+
+	acc.AssertContainsTaggedFields(t, "open_files",
+		map[string]interface{}{
+			"open_files":    int64(3),
+			"posix_locks":   int64(1),
+			"flock_locks":   int64(0),
+			"ofd_locks":     int64(0),
+			"leases":        int64(0),
+			"blocked_locks": int64(0),
+		},
+		map[string]string{"path": "/tank/home", "fstype": "zfs", "device": "tank/home"})
+	acc.AssertContainsTaggedFields(t, "open_files",
+		map[string]interface{}{
+			"open_files":    int64(0),
+			"posix_locks":   int64(0),
+			"flock_locks":   int64(0),
+			"ofd_locks":     int64(0),
+			"leases":        int64(0),
+			"blocked_locks": int64(0),
+		},
+		map[string]string{"path": "/tank/empty", "fstype": "zfs", "device": "tank/empty"})
+}
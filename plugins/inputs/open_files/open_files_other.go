@@ -0,0 +1,9 @@
+// +build !linux
+
+package open_files
+
+import "os"
+
+func deviceOf(info os.FileInfo) (device, bool) {
+	return device{}, false
+}
@@ -0,0 +1,57 @@
+package open_files
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const mountinfo = `21 1 0:19 / / rw,relatime shared:1 - zfs rpool/ROOT/default rw,xattr,posixacl
+45 21 0:41 / /tank/home rw,relatime shared:24 - zfs tank/home rw,xattr,posixacl
+46 21 0:42 / /tank/smb\040share rw,relatime shared:25 - zfs tank/smb\040share rw,xattr,posixacl
+47 21 0:41 / /srv/home rw,relatime shared:24 - zfs tank/home rw,xattr,posixacl
+48 21 8:1 / /boot rw,relatime shared:26 - ext4 /dev/sda1 rw
+`
+
+const locks = `1: POSIX  ADVISORY  WRITE 1234 00:29:1234567 0 EOF
+1: -> POSIX  ADVISORY  WRITE 1235 00:29:1234567 0 EOF
+1: -> POSIX  ADVISORY  WRITE 1236 00:29:1234567 0 EOF
+2: FLOCK  ADVISORY  WRITE 5678 00:29:393218 0 EOF
+3: OFDLCK ADVISORY  READ  -1 00:2a:1234 0 EOF
+4: LEASE  ACTIVE    READ 2001 00:2a:1235 0 EOF
+5: DELEG  ACTIVE    READ 2001 00:2a:1236 0 EOF
+6: POSIX  ADVISORY  READ 3001 08:01:12 0 EOF
+7: POSIX  ADVISORY  READ 3002 00:13:12 0 EOF
+`
+
+func TestParseMountinfo(t *testing.T) {
+	mounts, err := parseMountinfo(strings.NewReader(mountinfo))
+	require.NoError(t, err)
+	require.Equal(t, map[device]mount{
+		{0, 19}: {path: "/", fstype: "zfs", source: "rpool/ROOT/default"},
+		{0, 41}: {path: "/tank/home", fstype: "zfs", source: "tank/home"},
+		{0, 42}: {path: "/tank/smb share", fstype: "zfs", source: "tank/smb share"},
+		{8, 1}:  {path: "/boot", fstype: "ext4", source: "/dev/sda1"},
+	}, mounts)
+}
+
+func TestCountLocks(t *testing.T) {
+	byDevice := map[device]*counts{
+		{0, 41}: {},
+		{0, 42}: {},
+		{8, 1}:  {},
+	}
+	require.NoError(t, countLocks(strings.NewReader(locks), byDevice))
+	require.Equal(t, map[device]*counts{
+		{0, 41}: {posix: 1, flock: 1, blocked: 2},
+		{0, 42}: {ofd: 1, leases: 2},
+		{8, 1}:  {posix: 1},
+	}, byDevice)
+}
+
+func TestUnescape(t *testing.T) {
+	require.Equal(t, "/mnt/a b", unescape(`/mnt/a\040b`))
+	require.Equal(t, `/mnt/a\b`, unescape(`/mnt/a\134b`))
+	require.Equal(t, `/mnt/a\0`, unescape(`/mnt/a\0`))
+}
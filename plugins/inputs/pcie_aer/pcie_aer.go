@@ -0,0 +1,157 @@
+package pcie_aer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const defaultPath = "/sys/bus/pci/devices"
+
+// The AER counter files of a device, by the prefix of their fields.
+var aerFiles = map[string]string{
+	"correctable": "aer_dev_correctable",
+	"fatal":       "aer_dev_fatal",
+	"nonfatal":    "aer_dev_nonfatal",
+}
+
+// PCIeAER reports the PCIe Advanced Error Reporting counters of the PCI
+// devices.
+type PCIeAER struct {
+	Path    string   `toml:"path"`
+	Devices []string `toml:"devices"`
+	Drivers []string `toml:"drivers"`
+
+	devices filter.Filter
+	drivers filter.Filter
+}
+
+var sampleConfig = `
+  ## Directory of the PCI devices in sysfs.
+  # path = "/sys/bus/pci/devices"
+
+  ## Only report the devices with these addresses or bound to these
+  ## drivers, glob patterns are supported.  By default all the devices
+  ## supporting AER are reported.
+  # devices = ["0000:01:00.0"]
+  # drivers = ["nvme", "mpt3sas"]
+`
+
+func (p *PCIeAER) Description() string {
+	return "Read the PCIe Advanced Error Reporting counters of PCI devices"
+}
+
+func (p *PCIeAER) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PCIeAER) Init() error {
+	if p.Path == "" {
+		p.Path = defaultPath
+	}
+
+	var err error
+	if p.devices, err = filter.Compile(p.Devices); err != nil {
+		return fmt.Errorf("error compiling devices: %v", err)
+	}
+	if p.drivers, err = filter.Compile(p.Drivers); err != nil {
+		return fmt.Errorf("error compiling drivers: %v", err)
+	}
+	return nil
+}
+
+func (p *PCIeAER) Gather(acc telegraf.Accumulator) error {
+	dirs, err := ioutil.ReadDir(p.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		address := dir.Name()
+		if p.devices != nil && !p.devices.Match(address) {
+			continue
+		}
+		devicePath := filepath.Join(p.Path, address)
+
+		// Devices not bound to a driver have no driver link.
+		driver := ""
+		if link, err := os.Readlink(filepath.Join(devicePath, "driver")); err == nil {
+			driver = filepath.Base(link)
+		}
+		if p.drivers != nil && !p.drivers.Match(driver) {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for prefix, file := range aerFiles {
+			buf, err := ioutil.ReadFile(filepath.Join(devicePath, file))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+			if err := parseCounters(fields, prefix, buf); err != nil {
+				acc.AddError(fmt.Errorf("%s: %v", address, err))
+			}
+		}
+		// The device or its kernel does not support AER.
+		if len(fields) == 0 {
+			continue
+		}
+
+		tags := map[string]string{"device": address}
+		if driver != "" {
+			tags["driver"] = driver
+		}
+		acc.AddFields("pcie_aer", fields, tags)
+	}
+	return nil
+}
+
+// parseCounters parses the content of an AER counter file:
+//
+//   RxErr 0
+//   BadTLP 3
+//   TOTAL_ERR_COR 3
+//
+// The fields are named after the lowercase counter names with the prefix,
+// the TOTAL_ERR_* counter is named total.
+func parseCounters(fields map[string]interface{}, prefix string, buf []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %v", parts[0], err)
+		}
+		name := strings.ToLower(parts[0])
+		if strings.HasPrefix(parts[0], "TOTAL_ERR_") {
+			name = "total"
+		}
+		fields[prefix+"_"+name] = value
+	}
+	return scanner.Err()
+}
+
+func init() {
+	metric.RegisterFieldType("pcie_aer", telegraf.Counter, "*")
+
+	inputs.Add("pcie_aer", func() telegraf.Input {
+		return &PCIeAER{}
+	})
+}
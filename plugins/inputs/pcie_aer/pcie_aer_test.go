@@ -0,0 +1,144 @@
+package pcie_aer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const correctable = `RxErr 0
+BadTLP 12
+BadDLLP 3
+Rollover 0
+Timeout 0
+NonFatalErr 0
+CorrIntErr 0
+HeaderOF 0
+TOTAL_ERR_COR 15
+`
+
+const fatal = `Undefined 0
+DLP 0
+SDES 0
+TLP 0
+FCP 0
+CmpltTO 0
+CmpltAbrt 0
+UnxCmplt 0
+RxOF 0
+MalfTLP 0
+ECRC 0
+UnsupReq 0
+ACSViol 0
+UncorrIntErr 0
+BlockedTLP 0
+AtomicOpBlocked 0
+TLPBlockedErr 0
+TOTAL_ERR_FATAL 0
+`
+
+// sysfs creates a directory of PCI devices: an NVMe drive supporting AER, a
+// device without driver supporting AER and a device without AER.
+func sysfs(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pcie_aer")
+	require.NoError(t, err)
+
+	write := func(device, file, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, device), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, device, file), []byte(content), 0644))
+	}
+	write("0000:01:00.0", "aer_dev_correctable", correctable)
+	write("0000:01:00.0", "aer_dev_fatal", fatal)
+	require.NoError(t, os.Symlink("../../../bus/pci/drivers/nvme", filepath.Join(dir, "0000:01:00.0", "driver")))
+	write("0000:02:00.0", "aer_dev_correctable", "RxErr 1\nTOTAL_ERR_COR 1\n")
+	write("0000:00:1f.0", "vendor", "0x8086\n")
+	return dir
+}
+
+func TestGather(t *testing.T) {
+	dir := sysfs(t)
+	defer os.RemoveAll(dir)
+
+	plugin := &PCIeAER{Path: dir}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("pcie_aer",
+			map[string]string{"device": "0000:01:00.0", "driver": "nvme"},
+			map[string]interface{}{
+				"correctable_rxerr":       uint64(0),
+				"correctable_badtlp":      uint64(12),
+				"correctable_baddllp":     uint64(3),
+				"correctable_rollover":    uint64(0),
+				"correctable_timeout":     uint64(0),
+				"correctable_nonfatalerr": uint64(0),
+				"correctable_corrinterr":  uint64(0),
+				"correctable_headerof":    uint64(0),
+				"correctable_total":       uint64(15),
+				"fatal_undefined":         uint64(0),
+				"fatal_dlp":               uint64(0),
+				"fatal_sdes":              uint64(0),
+				"fatal_tlp":               uint64(0),
+				"fatal_fcp":               uint64(0),
+				"fatal_cmpltto":           uint64(0),
+				"fatal_cmpltabrt":         uint64(0),
+				"fatal_unxcmplt":          uint64(0),
+				"fatal_rxof":              uint64(0),
+				"fatal_malftlp":           uint64(0),
+				"fatal_ecrc":              uint64(0),
+				"fatal_unsupreq":          uint64(0),
+				"fatal_acsviol":           uint64(0),
+				"fatal_uncorrinterr":      uint64(0),
+				"fatal_blockedtlp":        uint64(0),
+				"fatal_atomicopblocked":   uint64(0),
+				"fatal_tlpblockederr":     uint64(0),
+				"fatal_total":             uint64(0),
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("pcie_aer",
+			map[string]string{"device": "0000:02:00.0"},
+			map[string]interface{}{
+				"correctable_rxerr": uint64(1),
+				"correctable_total": uint64(1),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherFilter(t *testing.T) {
+	dir := sysfs(t)
+	defer os.RemoveAll(dir)
+
+	plugin := &PCIeAER{Path: dir, Drivers: []string{"nvme"}}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "0000:01:00.0", acc.Metrics[0].Tags["device"])
+
+	plugin = &PCIeAER{Path: dir, Devices: []string{"0000:02:*"}}
+	require.NoError(t, plugin.Init())
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "0000:02:00.0", acc.Metrics[0].Tags["device"])
+}
+
+func TestParseCountersInvalid(t *testing.T) {
+	fields := make(map[string]interface{})
+	require.Error(t, parseCounters(fields, "fatal", []byte("DLP x\n")))
+}
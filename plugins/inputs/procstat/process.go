@@ -1,7 +1,12 @@
 package procstat
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/shirou/gopsutil/cpu"
@@ -25,6 +30,16 @@ type Process interface {
 	Times() (*cpu.TimesStat, error)
 	RlimitUsage(bool) ([]process.RlimitStat, error)
 	Username() (string, error)
+	Delays() (*DelayStat, error)
+}
+
+// DelayStat holds the time a process waited, since it started.
+type DelayStat struct {
+	// Time waiting for block IO, requires the delay accounting of the
+	// kernel, see the delayacct boot parameter.
+	IOWait time.Duration
+	// Time waiting on a run queue for a CPU.
+	RunDelay time.Duration
 }
 
 type PIDFinder interface {
@@ -74,3 +89,61 @@ func (p *Proc) Percent(interval time.Duration) (float64, error) {
 	}
 	return cpu_perc, err
 }
+
+// Delays reads the delays of the process from /proc/<pid>/stat and
+// /proc/<pid>/schedstat, it is only supported on Linux.
+func (p *Proc) Delays() (*DelayStat, error) {
+	procPath := os.Getenv("HOST_PROC")
+	if procPath == "" {
+		procPath = "/proc"
+	}
+	dir := filepath.Join(procPath, strconv.Itoa(int(p.Process.Pid)))
+
+	stat, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	schedstat, err := ioutil.ReadFile(filepath.Join(dir, "schedstat"))
+	if err != nil {
+		return nil, err
+	}
+	return parseDelays(stat, schedstat)
+}
+
+// clockTicks is the USER_HZ of the kernel, the unit of the times in
+// /proc/<pid>/stat, which is 100 on all supported architectures.
+const clockTicks = 100
+
+func parseDelays(stat, schedstat []byte) (*DelayStat, error) {
+	// The command name in the second field may contain spaces and
+	// parentheses, the fields following it start with the state.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid stat %q", stat)
+	}
+	fields := bytes.Fields(stat[i+1:])
+	// delayacct_blkio_ticks is the 42nd field.
+	if len(fields) < 40 {
+		return nil, fmt.Errorf("missing delayacct_blkio_ticks in stat")
+	}
+	ticks, err := strconv.ParseInt(string(fields[39]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delayacct_blkio_ticks: %v", err)
+	}
+
+	// Time on CPU, time waiting on a run queue and number of time slices,
+	// in nanoseconds.
+	sched := bytes.Fields(schedstat)
+	if len(sched) < 2 {
+		return nil, fmt.Errorf("invalid schedstat %q", schedstat)
+	}
+	runDelay, err := strconv.ParseInt(string(sched[1]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run delay: %v", err)
+	}
+
+	return &DelayStat{
+		IOWait:   time.Duration(ticks) * time.Second / clockTicks,
+		RunDelay: time.Duration(runDelay),
+	}, nil
+}
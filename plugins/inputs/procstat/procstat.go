@@ -216,6 +216,12 @@ func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
 		fields[prefix+"write_bytes"] = io.WriteBytes
 	}
 
+	delays, err := proc.Delays()
+	if err == nil {
+		fields[prefix+"io_wait_ns"] = delays.IOWait.Nanoseconds()
+		fields[prefix+"run_delay_ns"] = delays.RunDelay.Nanoseconds()
+	}
+
 	cpu_time, err := proc.Times()
 	if err == nil {
 		fields[prefix+"cpu_time_user"] = cpu_time.User
@@ -160,6 +160,10 @@ func (p *testProc) RlimitUsage(gatherUsage bool) ([]process.RlimitStat, error) {
 	return []process.RlimitStat{}, nil
 }
 
+func (p *testProc) Delays() (*DelayStat, error) {
+	return &DelayStat{IOWait: 120 * time.Millisecond, RunDelay: 3500}, nil
+}
+
 var pid PID = PID(42)
 var exe string = "foo"
 
@@ -398,3 +402,32 @@ func TestProcstatLookupMetric(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, len(p.procs)+1, len(acc.Metrics))
 }
+
+func TestGather_Delays(t *testing.T) {
+	var acc testutil.Accumulator
+
+	p := Procstat{
+		Exe:             exe,
+		createPIDFinder: pidFinder([]PID{pid}, nil),
+		createProcess:   newTestProc,
+	}
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	assert.Equal(t, int64(120000000), acc.Metrics[0].Fields["io_wait_ns"])
+	assert.Equal(t, int64(3500), acc.Metrics[0].Fields["run_delay_ns"])
+}
+
+func TestParseDelays(t *testing.T) {
+	stat := []byte("1234 (zfs (txg)) S 2 0 0 0 -1 2129984 0 0 0 0 0 52 0 0 20 0 1 0 " +
+		"1520 0 0 18446744073709551615 0 0 0 0 0 0 0 2147483647 0 0 0 0 17 3 0 0 " +
+		"731 0 0 0 0 0 0 0 0 0 0\n")
+	schedstat := []byte("5213047 88123 412\n")
+
+	delays, err := parseDelays(stat, schedstat)
+	require.NoError(t, err)
+	assert.Equal(t, 7310*time.Millisecond, delays.IOWait)
+	assert.Equal(t, 88123*time.Nanosecond, delays.RunDelay)
+
+	_, err = parseDelays([]byte("1234 (zfs) S 2"), schedstat)
+	assert.Error(t, err)
+}
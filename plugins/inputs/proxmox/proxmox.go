@@ -0,0 +1,324 @@
+package proxmox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var sampleConfig = `
+  ## URL of the API of a node of the cluster.
+  base_url = "https://localhost:8006/api2/json"
+
+  ## API token, created in Datacenter > Permissions > API Tokens, in the
+  ## format "USER@REALM!TOKENID=UUID".  The token needs the PVEAuditor role.
+  api_token = "root@pam!telegraf=00000000-0000-0000-0000-000000000000"
+
+  ## Nodes to collect data from, by default all the nodes of the cluster.
+  ## Globs are accepted.
+  # nodes = []
+
+  ## Maximum time to receive a response.
+  # response_timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Proxmox struct {
+	BaseURL         string            `toml:"base_url"`
+	APIToken        string            `toml:"api_token"`
+	Nodes           []string          `toml:"nodes"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	client     *http.Client
+	nodeFilter filter.Filter
+}
+
+type node struct {
+	Node   string `json:"node"`
+	Status string `json:"status"`
+}
+
+type guest struct {
+	VMID      json.Number `json:"vmid"`
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	CPU       float64     `json:"cpu"`
+	Mem       int64       `json:"mem"`
+	MaxMem    int64       `json:"maxmem"`
+	DiskRead  int64       `json:"diskread"`
+	DiskWrite int64       `json:"diskwrite"`
+	NetIn     int64       `json:"netin"`
+	NetOut    int64       `json:"netout"`
+	Uptime    int64       `json:"uptime"`
+}
+
+type storage struct {
+	Storage string `json:"storage"`
+	Type    string `json:"type"`
+	Active  int    `json:"active"`
+	Enabled int    `json:"enabled"`
+	Shared  int    `json:"shared"`
+	Total   int64  `json:"total"`
+	Used    int64  `json:"used"`
+	Avail   int64  `json:"avail"`
+}
+
+type zfsPool struct {
+	Name   string  `json:"name"`
+	Health string  `json:"health"`
+	Size   int64   `json:"size"`
+	Alloc  int64   `json:"alloc"`
+	Free   int64   `json:"free"`
+	Frag   int64   `json:"frag"`
+	Dedup  float64 `json:"dedup"`
+}
+
+type replicationJob struct {
+	ID        string  `json:"id"`
+	Guest     int64   `json:"guest"`
+	Target    string  `json:"target"`
+	LastSync  int64   `json:"last_sync"`
+	LastTry   int64   `json:"last_try"`
+	NextSync  int64   `json:"next_sync"`
+	Duration  float64 `json:"duration"`
+	FailCount int64   `json:"fail_count"`
+	Error     string  `json:"error"`
+}
+
+func (px *Proxmox) Description() string {
+	return "Read VM, storage and replication statistics from Proxmox VE"
+}
+
+func (px *Proxmox) SampleConfig() string {
+	return sampleConfig
+}
+
+func (px *Proxmox) Init() error {
+	if px.APIToken == "" {
+		return fmt.Errorf("api_token is required")
+	}
+
+	var err error
+	px.nodeFilter, err = filter.Compile(px.Nodes)
+	if err != nil {
+		return err
+	}
+
+	tlsCfg, err := px.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	px.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: px.ResponseTimeout.Duration,
+	}
+	return nil
+}
+
+func (px *Proxmox) Gather(acc telegraf.Accumulator) error {
+	var nodes []node
+	if err := px.get("/nodes", &nodes); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		if px.nodeFilter != nil && !px.nodeFilter.Match(n.Node) {
+			continue
+		}
+		// The API of the offline nodes is not reachable.
+		if n.Status != "online" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			px.gatherNode(acc, name)
+		}(n.Node)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (px *Proxmox) gatherNode(acc telegraf.Accumulator, node string) {
+	for _, vmType := range []string{"qemu", "lxc"} {
+		if err := px.gatherGuests(acc, node, vmType); err != nil {
+			acc.AddError(fmt.Errorf("node %s: %s", node, err))
+		}
+	}
+	if err := px.gatherStorages(acc, node); err != nil {
+		acc.AddError(fmt.Errorf("node %s: %s", node, err))
+	}
+	if err := px.gatherZFSPools(acc, node); err != nil {
+		acc.AddError(fmt.Errorf("node %s: %s", node, err))
+	}
+	if err := px.gatherReplication(acc, node); err != nil {
+		acc.AddError(fmt.Errorf("node %s: %s", node, err))
+	}
+}
+
+func (px *Proxmox) gatherGuests(acc telegraf.Accumulator, node, vmType string) error {
+	var guests []guest
+	if err := px.get("/nodes/"+url.PathEscape(node)+"/"+vmType, &guests); err != nil {
+		return err
+	}
+
+	for _, g := range guests {
+		tags := map[string]string{
+			"node":    node,
+			"vm_id":   g.VMID.String(),
+			"vm_name": g.Name,
+			"vm_type": vmType,
+			"status":  g.Status,
+		}
+		fields := map[string]interface{}{
+			"cpu":              g.CPU,
+			"mem_used":         g.Mem,
+			"mem_total":        g.MaxMem,
+			"disk_read_bytes":  g.DiskRead,
+			"disk_write_bytes": g.DiskWrite,
+			"net_in_bytes":     g.NetIn,
+			"net_out_bytes":    g.NetOut,
+			"uptime":           g.Uptime,
+		}
+		acc.AddFields("proxmox_vm", fields, tags)
+	}
+	return nil
+}
+
+func (px *Proxmox) gatherStorages(acc telegraf.Accumulator, node string) error {
+	var storages []storage
+	if err := px.get("/nodes/"+url.PathEscape(node)+"/storage", &storages); err != nil {
+		return err
+	}
+
+	for _, s := range storages {
+		tags := map[string]string{
+			"node":    node,
+			"storage": s.Storage,
+			"type":    s.Type,
+			"shared":  fmt.Sprint(s.Shared == 1),
+		}
+		fields := map[string]interface{}{
+			"active":  s.Active == 1,
+			"enabled": s.Enabled == 1,
+			"total":   s.Total,
+			"used":    s.Used,
+			"avail":   s.Avail,
+		}
+		if s.Total > 0 {
+			fields["used_percent"] = 100 * float64(s.Used) / float64(s.Total)
+		}
+		acc.AddFields("proxmox_storage", fields, tags)
+	}
+	return nil
+}
+
+func (px *Proxmox) gatherZFSPools(acc telegraf.Accumulator, node string) error {
+	var pools []zfsPool
+	if err := px.get("/nodes/"+url.PathEscape(node)+"/disks/zfs", &pools); err != nil {
+		return err
+	}
+
+	for _, p := range pools {
+		tags := map[string]string{
+			"node":   node,
+			"pool":   p.Name,
+			"health": p.Health,
+		}
+		fields := map[string]interface{}{
+			"size":          p.Size,
+			"allocated":     p.Alloc,
+			"free":          p.Free,
+			"fragmentation": p.Frag,
+			"dedupratio":    p.Dedup,
+		}
+		acc.AddFields("proxmox_zfs_pool", fields, tags)
+	}
+	return nil
+}
+
+func (px *Proxmox) gatherReplication(acc telegraf.Accumulator, node string) error {
+	var jobs []replicationJob
+	if err := px.get("/nodes/"+url.PathEscape(node)+"/replication", &jobs); err != nil {
+		return err
+	}
+
+	for _, j := range jobs {
+		tags := map[string]string{
+			"node":   node,
+			"job":    j.ID,
+			"vm_id":  fmt.Sprint(j.Guest),
+			"target": j.Target,
+		}
+		fields := map[string]interface{}{
+			"last_sync":  j.LastSync,
+			"last_try":   j.LastTry,
+			"next_sync":  j.NextSync,
+			"duration":   j.Duration,
+			"fail_count": j.FailCount,
+		}
+		if j.Error != "" {
+			fields["error"] = j.Error
+		}
+		acc.AddFields("proxmox_replication", fields, tags)
+	}
+	return nil
+}
+
+// get decodes the data of the response to a GET request of the API.
+func (px *Proxmox) get(path string, v interface{}) error {
+	req, err := http.NewRequest("GET", strings.TrimRight(px.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "PVEAPIToken="+px.APIToken)
+
+	resp, err := px.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("GET %s returned HTTP status %s: %s",
+			path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	data := struct {
+		Data interface{} `json:"data"`
+	}{Data: v}
+	return json.NewDecoder(resp.Body).Decode(&data)
+}
+
+func init() {
+	inputs.Add("proxmox", func() telegraf.Input {
+		return &Proxmox{
+			BaseURL:         "https://localhost:8006/api2/json",
+			ResponseTimeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package proxmox
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "root@pam!telegraf=5f1b0d3c"
+
+var responses = map[string]string{
+	"/nodes": `{"data": [
+		{"node": "pve1", "status": "online"},
+		{"node": "pve2", "status": "offline"}
+	]}`,
+	"/nodes/pve1/qemu": `{"data": [
+		{"vmid": 100, "name": "web", "status": "running", "cpu": 0.25,
+		 "mem": 1073741824, "maxmem": 4294967296, "diskread": 1000, "diskwrite": 2000,
+		 "netin": 300, "netout": 400, "uptime": 3600}
+	]}`,
+	"/nodes/pve1/lxc": `{"data": [
+		{"vmid": "101", "name": "dns", "status": "stopped", "cpu": 0,
+		 "mem": 0, "maxmem": 536870912, "diskread": 0, "diskwrite": 0,
+		 "netin": 0, "netout": 0, "uptime": 0}
+	]}`,
+	"/nodes/pve1/storage": `{"data": [
+		{"storage": "local-zfs", "type": "zfspool", "active": 1, "enabled": 1,
+		 "shared": 0, "total": 1000, "used": 250, "avail": 750, "content": "images,rootdir"}
+	]}`,
+	"/nodes/pve1/disks/zfs": `{"data": [
+		{"name": "rpool", "health": "ONLINE", "size": 2000, "alloc": 500,
+		 "free": 1500, "frag": 12, "dedup": 1.0}
+	]}`,
+	"/nodes/pve1/replication": `{"data": [
+		{"id": "100-0", "guest": 100, "target": "pve2", "last_sync": 1578823200,
+		 "last_try": 1578824100, "next_sync": 1578825000, "duration": 3.5,
+		 "fail_count": 1, "error": "no tunnel IP received"}
+	]}`,
+}
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "PVEAPIToken="+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, ok := responses[r.URL.Path[len("/api2/json"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, resp)
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	px := &Proxmox{
+		BaseURL:  ts.URL + "/api2/json",
+		APIToken: token,
+	}
+	require.NoError(t, px.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(px.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"proxmox_vm",
+			map[string]string{
+				"node":    "pve1",
+				"vm_id":   "100",
+				"vm_name": "web",
+				"vm_type": "qemu",
+				"status":  "running",
+			},
+			map[string]interface{}{
+				"cpu":              0.25,
+				"mem_used":         int64(1073741824),
+				"mem_total":        int64(4294967296),
+				"disk_read_bytes":  int64(1000),
+				"disk_write_bytes": int64(2000),
+				"net_in_bytes":     int64(300),
+				"net_out_bytes":    int64(400),
+				"uptime":           int64(3600),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"proxmox_vm",
+			map[string]string{
+				"node":    "pve1",
+				"vm_id":   "101",
+				"vm_name": "dns",
+				"vm_type": "lxc",
+				"status":  "stopped",
+			},
+			map[string]interface{}{
+				"cpu":              0.0,
+				"mem_used":         int64(0),
+				"mem_total":        int64(536870912),
+				"disk_read_bytes":  int64(0),
+				"disk_write_bytes": int64(0),
+				"net_in_bytes":     int64(0),
+				"net_out_bytes":    int64(0),
+				"uptime":           int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"proxmox_storage",
+			map[string]string{
+				"node":    "pve1",
+				"storage": "local-zfs",
+				"type":    "zfspool",
+				"shared":  "false",
+			},
+			map[string]interface{}{
+				"active":       true,
+				"enabled":      true,
+				"total":        int64(1000),
+				"used":         int64(250),
+				"avail":        int64(750),
+				"used_percent": 25.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"proxmox_zfs_pool",
+			map[string]string{
+				"node":   "pve1",
+				"pool":   "rpool",
+				"health": "ONLINE",
+			},
+			map[string]interface{}{
+				"size":          int64(2000),
+				"allocated":     int64(500),
+				"free":          int64(1500),
+				"fragmentation": int64(12),
+				"dedupratio":    1.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"proxmox_replication",
+			map[string]string{
+				"node":   "pve1",
+				"job":    "100-0",
+				"vm_id":  "100",
+				"target": "pve2",
+			},
+			map[string]interface{}{
+				"last_sync":  int64(1578823200),
+				"last_try":   int64(1578824100),
+				"next_sync":  int64(1578825000),
+				"duration":   3.5,
+				"fail_count": int64(1),
+				"error":      "no tunnel IP received",
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherNodeFilter(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	px := &Proxmox{
+		BaseURL:  ts.URL + "/api2/json",
+		APIToken: token,
+		Nodes:    []string{"pve2*"},
+	}
+	require.NoError(t, px.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(px.Gather))
+	require.Len(t, acc.Metrics, 0)
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	px := &Proxmox{
+		BaseURL:  ts.URL + "/api2/json",
+		APIToken: "root@pam!telegraf=wrong",
+	}
+	require.NoError(t, px.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(px.Gather))
+}
+
+func TestInitMissingToken(t *testing.T) {
+	px := &Proxmox{}
+	require.Error(t, px.Init())
+}
@@ -119,6 +119,7 @@ type Smart struct {
 	Devices    []string
 	UseSudo    bool
 	Timeout    internal.Duration
+	Cache      telegraf.Cache `toml:"-"`
 }
 
 var sampleConfig = `
@@ -199,9 +200,9 @@ var runCmd = func(timeout internal.Duration, sudo bool, command string, args ...
 
 // Scan for S.M.A.R.T. devices
 func (m *Smart) scan() ([]string, error) {
-	out, err := runCmd(m.Timeout, m.UseSudo, m.Path, "--scan")
+	out, err := m.scanOutput()
 	if err != nil {
-		return []string{}, fmt.Errorf("failed to run command '%s --scan': %s - %s", m.Path, err, string(out))
+		return []string{}, err
 	}
 
 	devices := []string{}
@@ -214,6 +215,31 @@ func (m *Smart) scan() ([]string, error) {
 	return devices, nil
 }
 
+// scanOutput runs smartctl --scan, once per interval for all the inputs
+// running the same smartctl the same way when the agent shares its cache.
+func (m *Smart) scanOutput() ([]byte, error) {
+	load := func() (interface{}, error) {
+		out, err := runCmd(m.Timeout, m.UseSudo, m.Path, "--scan")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command '%s --scan': %s - %s", m.Path, err, string(out))
+		}
+		return out, nil
+	}
+
+	var out interface{}
+	var err error
+	if m.Cache != nil {
+		key := fmt.Sprintf("smartctl --scan %s sudo=%t", m.Path, m.UseSudo)
+		out, err = m.Cache.Get(key, load)
+	} else {
+		out, err = load()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.([]byte), nil
+}
+
 func excludedDev(excludes []string, deviceLine string) bool {
 	device := strings.Split(deviceLine, " ")
 	if len(device) != 0 {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cache"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -328,6 +329,43 @@ func TestGatherAttributes(t *testing.T) {
 	}
 }
 
+func TestGatherSharedScan(t *testing.T) {
+	scans := 0
+	runCmd = func(timeout internal.Duration, sudo bool, command string, args ...string) ([]byte, error) {
+		if len(args) > 0 {
+			if args[0] == "--scan" {
+				scans++
+				return []byte(mockScanData), nil
+			} else if args[0] == "--info" {
+				return []byte(mockInfoAttributeData), nil
+			}
+		}
+		return nil, errors.New("command not found")
+	}
+
+	c := cache.New(time.Minute)
+	for i := 0; i < 2; i++ {
+		s := NewSmart()
+		s.Path = "smartctl"
+		s.Cache = c
+
+		var acc testutil.Accumulator
+		require.NoError(t, s.Gather(&acc))
+		require.True(t, acc.HasMeasurement("smart_device"))
+	}
+	require.Equal(t, 1, scans)
+
+	// The scan may differ when run through sudo.
+	s := NewSmart()
+	s.Path = "smartctl"
+	s.UseSudo = true
+	s.Cache = c
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Equal(t, 2, scans)
+}
+
 func TestGatherNoAttributes(t *testing.T) {
 	s := NewSmart()
 	s.Path = "smartctl"
@@ -429,10 +429,80 @@ func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmpConnection, t Table,
 	return nil
 }
 
+// fieldOid returns the OID of the field with a leading ".", as the OIDs of
+// the responses have, so that the prefixes match.
+func fieldOid(f Field) string {
+	if f.Oid[0] == '.' {
+		return f.Oid
+	}
+	return "." + f.Oid
+}
+
+// getScalars fetches the OIDs with GET requests of up to gosnmp.MaxOids
+// variables, returning the variables by OID.  When an agent fails a request
+// as a whole, as SNMPv1 agents do when one of the OIDs does not exist, the
+// OIDs of the request are fetched one at a time.
+func getScalars(gs snmpConnection, oids []string) (map[string]gosnmp.SnmpPDU, error) {
+	values := make(map[string]gosnmp.SnmpPDU, len(oids))
+	add := func(pkt *gosnmp.SnmpPacket) {
+		if pkt == nil {
+			return
+		}
+		for _, ent := range pkt.Variables {
+			values[ent.Name] = ent
+		}
+	}
+
+	for len(oids) > 0 {
+		batch := oids
+		if len(batch) > gosnmp.MaxOids {
+			batch = batch[:gosnmp.MaxOids]
+		}
+		oids = oids[len(batch):]
+
+		pkt, err := gs.Get(batch)
+		if err == nil && (pkt == nil || pkt.Error == gosnmp.NoError || len(batch) == 1) {
+			add(pkt)
+			continue
+		}
+		if len(batch) == 1 {
+			return nil, Errorf(err, "performing get on %s", batch[0])
+		}
+
+		for _, oid := range batch {
+			pkt, err := gs.Get([]string{oid})
+			if err != nil {
+				return nil, Errorf(err, "performing get on %s", oid)
+			}
+			add(pkt)
+		}
+	}
+	return values, nil
+}
+
 // Build retrieves all the fields specified in the table and constructs the RTable.
 func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 	rows := map[string]RTableRow{}
 
+	// The non-table fields are fetched up front, with as few requests as
+	// possible.
+	var scalars map[string]gosnmp.SnmpPDU
+	if !walk {
+		oids := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			if len(f.Oid) == 0 {
+				return nil, fmt.Errorf("cannot have empty OID on field %s", f.Name)
+			}
+			oids = append(oids, fieldOid(f))
+		}
+
+		var err error
+		scalars, err = getScalars(gs, oids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tagCount := 0
 	for _, f := range t.Fields {
 		if f.IsTag {
@@ -442,13 +512,7 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 		if len(f.Oid) == 0 {
 			return nil, fmt.Errorf("cannot have empty OID on field %s", f.Name)
 		}
-		var oid string
-		if f.Oid[0] == '.' {
-			oid = f.Oid
-		} else {
-			// make sure OID has "." because the BulkWalkAll results do, and the prefix needs to match
-			oid = "." + f.Oid
-		}
+		oid := fieldOid(f)
 
 		// ifv contains a mapping of table OID index to field value
 		ifv := map[string]interface{}{}
@@ -459,10 +523,7 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 			// We fetch the fields directly, and add them to ifv as if the index were an
 			// empty string. This results in all the non-table fields sharing the same
 			// index, and being added on the same row.
-			if pkt, err := gs.Get([]string{oid}); err != nil {
-				return nil, Errorf(err, "performing get on field %s", f.Name)
-			} else if pkt != nil && len(pkt.Variables) > 0 && pkt.Variables[0].Type != gosnmp.NoSuchObject && pkt.Variables[0].Type != gosnmp.NoSuchInstance {
-				ent := pkt.Variables[0]
+			if ent, ok := scalars[oid]; ok && ent.Type != gosnmp.NoSuchObject && ent.Type != gosnmp.NoSuchInstance && ent.Type != gosnmp.EndOfMibView {
 				fv, err := fieldConvert(f.Conversion, ent.Value)
 				if err != nil {
 					return nil, Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
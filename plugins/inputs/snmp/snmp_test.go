@@ -592,6 +592,51 @@ func TestTableBuild_noWalk(t *testing.T) {
 	assert.Contains(t, tb.Rows, rtr)
 }
 
+// v1SNMPConnection fails the whole request when one of the OIDs does not
+// exist, as SNMPv1 agents do.
+type v1SNMPConnection struct {
+	*testSNMPConnection
+	gets [][]string
+}
+
+func (c *v1SNMPConnection) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	c.gets = append(c.gets, oids)
+	if len(oids) > 1 {
+		for _, oid := range oids {
+			if _, ok := c.values[oid]; !ok {
+				return &gosnmp.SnmpPacket{Error: gosnmp.NoSuchName}, nil
+			}
+		}
+	}
+	return c.testSNMPConnection.Get(oids)
+}
+
+func TestTableBuild_noWalkBatched(t *testing.T) {
+	fields := []Field{
+		{Name: "myfield1", Oid: ".1.0.0.1.1", IsTag: true},
+		{Name: "myfield2", Oid: "1.0.0.1.2"},
+		{Name: "myfield3", Oid: ".1.0.0.1.3"},
+	}
+	rtr := RTableRow{
+		Tags:   map[string]string{"myfield1": "baz"},
+		Fields: map[string]interface{}{"myfield2": 234, "myfield3": "byte slice"},
+	}
+
+	gs := &v1SNMPConnection{testSNMPConnection: tsc}
+	tb, err := Table{Name: "mytable", Fields: fields}.Build(gs, false)
+	require.NoError(t, err)
+	require.Equal(t, []RTableRow{rtr}, tb.Rows)
+	require.Equal(t, [][]string{{".1.0.0.1.1", ".1.0.0.1.2", ".1.0.0.1.3"}}, gs.gets)
+
+	// A missing OID fails the batch, the OIDs are then fetched one by one.
+	gs = &v1SNMPConnection{testSNMPConnection: tsc}
+	fields = append(fields, Field{Name: "noexist", Oid: ".1.2.3.4.5"})
+	tb, err = Table{Name: "mytable", Fields: fields}.Build(gs, false)
+	require.NoError(t, err)
+	require.Equal(t, []RTableRow{rtr}, tb.Rows)
+	require.Len(t, gs.gets, 5)
+}
+
 func TestGather(t *testing.T) {
 	s := &Snmp{
 		Agents: []string{"TestGather"},
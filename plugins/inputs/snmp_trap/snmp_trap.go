@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	"github.com/soniah/gosnmp"
@@ -30,6 +31,7 @@ type mibEntry struct {
 type SnmpTrap struct {
 	ServiceAddress string            `toml:"service_address"`
 	Timeout        internal.Duration `toml:"timeout"`
+	Events         bool              `toml:"events"`
 
 	acc      telegraf.Accumulator
 	listener *gosnmp.TrapListener
@@ -53,6 +55,9 @@ var sampleConfig = `
   # service_address = udp://:162
   ## Timeout running snmptranslate command
   # timeout = "5s"
+  ## Report the notifications as events, with a title naming the trap and a
+  ## text listing its variables.
+  # events = false
 `
 
 func (s *SnmpTrap) SampleConfig() string {
@@ -155,6 +160,10 @@ func makeTrapHandler(s *SnmpTrap) handler {
 		tags["version"] = packet.Version.String()
 		tags["source"] = addr.IP.String()
 
+		// vars are the variables in the order of the notification, for the
+		// text of events.
+		var vars []string
+
 		for _, v := range packet.Variables {
 			// Use system mibs to resolve oids.  Don't fall back to
 			// numeric oid because it's not useful enough to the end
@@ -207,6 +216,18 @@ func makeTrapHandler(s *SnmpTrap) handler {
 			name := e.oidText
 
 			fields[name] = value
+			vars = append(vars, fmt.Sprintf("%s=%v", name, value))
+		}
+
+		if s.Events {
+			title := "SNMP trap from " + tags["source"]
+			if tags["name"] != "" {
+				title = tags["mib"] + "::" + tags["name"] + " from " + tags["source"]
+			}
+			fields[metric.EventTitleField] = title
+			if len(vars) > 0 {
+				fields[metric.EventTextField] = strings.Join(vars, ", ")
+			}
 		}
 
 		s.acc.AddFields("snmp_trap", fields, tags, tm)
@@ -220,3 +220,51 @@ func TestMissingOid(t *testing.T) {
 		expected, acc.GetTelegrafMetrics(),
 		testutil.SortMetrics())
 }
+
+func TestTrapEvents(t *testing.T) {
+	var fakeTime = time.Now()
+	var acc testutil.Accumulator
+	s := &SnmpTrap{
+		Events:   true,
+		acc:      &acc,
+		timeFunc: func() time.Time { return fakeTime },
+		Log:      testutil.Logger{},
+	}
+	require.Nil(t, s.Init())
+
+	s.load(".1.3.6.1.6.3.1.1.4.1.0", mibEntry{"SNMPv2-MIB", "snmpTrapOID.0"})
+	s.load(".1.3.6.1.4.1.789.0.22", mibEntry{"NETAPP-MIB", "diskFailedShutdown"})
+	s.load(".1.3.6.1.2.1.1.3.0", mibEntry{"DISMAN-EVENT-MIB", "sysUpTimeInstance"})
+	s.load(".1.3.6.1.4.1.789.1.2.4.1.0", mibEntry{"NETAPP-MIB", "diskFailedMessage.0"})
+
+	packet := &gosnmp.SnmpPacket{
+		Version: gosnmp.Version2c,
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(42)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.789.0.22"},
+			{Name: ".1.3.6.1.4.1.789.1.2.4.1.0", Type: gosnmp.OctetString, Value: "disk 0a.10 failed"},
+		},
+	}
+	makeTrapHandler(s)(packet, &net.UDPAddr{IP: net.ParseIP("10.0.0.5")})
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"snmp_trap",
+			map[string]string{
+				"oid":     ".1.3.6.1.4.1.789.0.22",
+				"name":    "diskFailedShutdown",
+				"mib":     "NETAPP-MIB",
+				"version": "2c",
+				"source":  "10.0.0.5",
+			},
+			map[string]interface{}{
+				"sysUpTimeInstance":   uint32(42),
+				"diskFailedMessage.0": "disk 0a.10 failed",
+				"title":               "NETAPP-MIB::diskFailedShutdown from 10.0.0.5",
+				"text":                "sysUpTimeInstance=42, diskFailedMessage.0=disk 0a.10 failed",
+			},
+			fakeTime,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
@@ -0,0 +1,137 @@
+// +build linux
+
+package sockstat
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type Sockstat struct {
+	// Sockstat files (proc filesystem), the missing ones are skipped
+	statFiles []string
+	// Directory of the tcp_* limits (proc filesystem)
+	sysctlDir string
+}
+
+func (s *Sockstat) Description() string {
+	return "Get the socket counts and limits from procfs"
+}
+
+func (s *Sockstat) SampleConfig() string {
+	return ""
+}
+
+func (s *Sockstat) Gather(acc telegraf.Accumulator) error {
+	fields := make(map[string]interface{})
+	for _, statFile := range s.statFiles {
+		if err := parseSockstat(statFile, fields); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("no socket statistics found in %s", strings.Join(s.statFiles, ", "))
+	}
+
+	s.gatherLimits(fields)
+	acc.AddGauge("sockstat", fields, map[string]string{})
+	return nil
+}
+
+// parseSockstat adds the values of a sockstat file, given as a protocol
+// followed by key value pairs:
+//
+//   TCP: inuse 4 orphan 0 tw 5 alloc 4 mem 0
+//
+// as the fields tcp_inuse, tcp_orphan...
+func parseSockstat(statFile string, fields map[string]interface{}) error {
+	file, err := os.Open(statFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		dataFields := strings.Fields(scanner.Text())
+		if len(dataFields) < 3 || len(dataFields)%2 != 1 {
+			return fmt.Errorf("invalid line %q in %s", scanner.Text(), statFile)
+		}
+		protocol := strings.ToLower(strings.TrimSuffix(dataFields[0], ":"))
+		for i := 1; i < len(dataFields); i += 2 {
+			value, err := strconv.ParseInt(dataFields[i+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %q in %s", dataFields[i+1], statFile)
+			}
+			fields[protocol+"_"+dataFields[i]] = value
+		}
+	}
+	return scanner.Err()
+}
+
+// gatherLimits adds the limits of the TCP sockets, the missing ones are
+// skipped.
+func (s *Sockstat) gatherLimits(fields map[string]interface{}) {
+	for _, name := range []string{"tcp_max_orphans", "tcp_max_tw_buckets"} {
+		values := readValues(path.Join(s.sysctlDir, name))
+		if len(values) == 1 {
+			fields[name] = values[0]
+		}
+	}
+
+	// tcp_mem is the low, pressure and max thresholds, in pages like the
+	// tcp_mem field.
+	values := readValues(path.Join(s.sysctlDir, "tcp_mem"))
+	if len(values) == 3 {
+		fields["tcp_mem_pressure"] = values[1]
+		fields["tcp_mem_max"] = values[2]
+	}
+}
+
+func readValues(filename string) []int64 {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	var values []int64
+	for _, field := range strings.Fields(string(contents)) {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func getHostProc() string {
+	procPath := "/proc"
+	if os.Getenv("HOST_PROC") != "" {
+		procPath = os.Getenv("HOST_PROC")
+	}
+	return procPath
+}
+
+func init() {
+	inputs.Add("sockstat", func() telegraf.Input {
+		return &Sockstat{
+			statFiles: []string{
+				path.Join(getHostProc(), "/net/sockstat"),
+				path.Join(getHostProc(), "/net/sockstat6"),
+			},
+			sysctlDir: path.Join(getHostProc(), "/sys/net/ipv4"),
+		}
+	})
+}
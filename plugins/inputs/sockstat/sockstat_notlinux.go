@@ -0,0 +1,31 @@
+// +build !linux
+
+package sockstat
+
+import (
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type Sockstat struct{}
+
+func (s *Sockstat) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *Sockstat) Description() string {
+	return ""
+}
+
+func (s *Sockstat) SampleConfig() string {
+	return ""
+}
+
+func init() {
+	inputs.Add("sockstat", func() telegraf.Input {
+		log.Print("W! [inputs.sockstat] Current platform is not supported")
+		return &Sockstat{}
+	})
+}
@@ -0,0 +1,116 @@
+// +build linux
+
+package sockstat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const sockstatContents = `sockets: used 18
+TCP: inuse 4 orphan 1 tw 5 alloc 6 mem 7
+UDP: inuse 2 mem 3
+UDPLITE: inuse 0
+RAW: inuse 0
+FRAG: inuse 0 memory 0
+`
+
+const sockstat6Contents = `TCP6: inuse 3
+UDP6: inuse 1
+UDPLITE6: inuse 0
+RAW6: inuse 0
+FRAG6: inuse 0 memory 0
+`
+
+func makeFakeProc(t *testing.T, ipv6 bool) (string, *Sockstat) {
+	dir, err := ioutil.TempDir("", "sockstat")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "net"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sys/net/ipv4"), 0755))
+
+	write := func(name, contents string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+	write("net/sockstat", sockstatContents)
+	if ipv6 {
+		write("net/sockstat6", sockstat6Contents)
+	}
+	write("sys/net/ipv4/tcp_mem", "70686\t94250\t141372\n")
+	write("sys/net/ipv4/tcp_max_orphans", "32768\n")
+
+	return dir, &Sockstat{
+		statFiles: []string{
+			filepath.Join(dir, "net/sockstat"),
+			filepath.Join(dir, "net/sockstat6"),
+		},
+		sysctlDir: filepath.Join(dir, "sys/net/ipv4"),
+	}
+}
+
+func TestSockstat(t *testing.T) {
+	dir, s := makeFakeProc(t, true)
+	defer os.RemoveAll(dir)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Gather(&acc))
+
+	acc.AssertContainsFields(t, "sockstat", map[string]interface{}{
+		"sockets_used":     int64(18),
+		"tcp_inuse":        int64(4),
+		"tcp_orphan":       int64(1),
+		"tcp_tw":           int64(5),
+		"tcp_alloc":        int64(6),
+		"tcp_mem":          int64(7),
+		"udp_inuse":        int64(2),
+		"udp_mem":          int64(3),
+		"udplite_inuse":    int64(0),
+		"raw_inuse":        int64(0),
+		"frag_inuse":       int64(0),
+		"frag_memory":      int64(0),
+		"tcp6_inuse":       int64(3),
+		"udp6_inuse":       int64(1),
+		"udplite6_inuse":   int64(0),
+		"raw6_inuse":       int64(0),
+		"frag6_inuse":      int64(0),
+		"frag6_memory":     int64(0),
+		"tcp_max_orphans":  int64(32768),
+		"tcp_mem_pressure": int64(94250),
+		"tcp_mem_max":      int64(141372),
+	})
+}
+
+func TestSockstatNoIPv6(t *testing.T) {
+	dir, s := makeFakeProc(t, false)
+	defer os.RemoveAll(dir)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Gather(&acc))
+
+	m, ok := acc.Get("sockstat")
+	require.True(t, ok)
+	require.Equal(t, int64(4), m.Fields["tcp_inuse"])
+	require.NotContains(t, m.Fields, "tcp6_inuse")
+}
+
+func TestSockstatInvalid(t *testing.T) {
+	dir, s := makeFakeProc(t, false)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(s.statFiles[0], []byte("TCP: inuse x\n"), 0644))
+
+	acc := testutil.Accumulator{}
+	err := s.Gather(&acc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid value")
+}
+
+func TestNoSockstatFile(t *testing.T) {
+	s := &Sockstat{statFiles: []string{"/nonexistent/sockstat"}}
+
+	acc := testutil.Accumulator{}
+	require.Error(t, s.Gather(&acc))
+}
@@ -0,0 +1,270 @@
+package sysctl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// reader returns the values of the keys, the error reports the keys that
+// could not be read.
+type reader func(keys []string) (map[string]string, error)
+
+// Sysctl samples the values of kernel parameters.
+type Sysctl struct {
+	Keys  []string          `toml:"keys"`
+	Types map[string]string `toml:"types"`
+
+	read  reader
+	types []typeHint
+}
+
+type typeHint struct {
+	keys filter.Filter
+	tp   string
+}
+
+var sampleConfig = `
+  ## Keys to sample.  A key ending with ".*" samples all the keys below it,
+  ## ie "vfs.zfs.*" on FreeBSD or "vm.*" on Linux.
+  keys = ["vm.dirty_ratio", "vm.dirty_background_ratio", "vm.swappiness"]
+
+  ## Type of the values, one of "int", "float", "string" or "auto".  Values
+  ## of type "auto" are integers or floats when they parse as such, and
+  ## strings otherwise.  Values made of several numbers, such as
+  ## net.ipv4.tcp_rmem, are strings unless typed as "int" or "float", in
+  ## which case a field is added per number with the index as suffix.  Keys
+  ## support glob patterns.
+  # [inputs.sysctl.types]
+  #   "net.ipv4.tcp_rmem" = "int"
+  #   "kernel.hostname" = "string"
+`
+
+func (s *Sysctl) Description() string {
+	return "Sample the values of sysctl kernel parameters on Linux and FreeBSD"
+}
+
+func (s *Sysctl) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Sysctl) Init() error {
+	if len(s.Keys) == 0 {
+		return fmt.Errorf("no keys configured")
+	}
+
+	patterns := make([]string, 0, len(s.Types))
+	for pattern := range s.Types {
+		patterns = append(patterns, pattern)
+	}
+	// Exact keys take precedence over patterns, longer patterns are
+	// assumed to be more specific.
+	sort.Slice(patterns, func(i, j int) bool {
+		return len(patterns[i]) > len(patterns[j])
+	})
+	for _, pattern := range patterns {
+		tp := s.Types[pattern]
+		switch tp {
+		case "int", "float", "string", "auto":
+		default:
+			return fmt.Errorf("invalid type %q for %q", tp, pattern)
+		}
+		f, err := filter.Compile([]string{pattern})
+		if err != nil {
+			return err
+		}
+		s.types = append(s.types, typeHint{keys: f, tp: tp})
+	}
+
+	if s.read == nil {
+		switch runtime.GOOS {
+		case "linux":
+			s.read = procSysReader("/proc/sys")
+		case "freebsd":
+			s.read = commandReader
+		default:
+			return fmt.Errorf("the sysctl input is not supported on %s", runtime.GOOS)
+		}
+	}
+	return nil
+}
+
+func (s *Sysctl) Gather(acc telegraf.Accumulator) error {
+	values, err := s.read(s.Keys)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if err := addField(fields, key, value, s.typeOf(key)); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	acc.AddFields("sysctl", fields, nil)
+	return nil
+}
+
+func (s *Sysctl) typeOf(key string) string {
+	if tp, ok := s.Types[key]; ok {
+		return tp
+	}
+	for _, hint := range s.types {
+		if hint.keys.Match(key) {
+			return hint.tp
+		}
+	}
+	return "auto"
+}
+
+func addField(fields map[string]interface{}, key, value, tp string) error {
+	value = strings.TrimSpace(value)
+	switch tp {
+	case "string":
+		fields[key] = value
+		return nil
+	case "auto":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[key] = v
+		} else if v, err := strconv.ParseFloat(value, 64); err == nil {
+			fields[key] = v
+		} else {
+			fields[key] = value
+		}
+		return nil
+	}
+
+	values := strings.Fields(value)
+	for i, value := range values {
+		name := key
+		if len(values) > 1 {
+			name = key + "." + strconv.Itoa(i)
+		}
+
+		switch tp {
+		case "int":
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("error parsing %s as int: %v", key, err)
+			}
+			fields[name] = v
+		case "float":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("error parsing %s as float: %v", key, err)
+			}
+			fields[name] = v
+		}
+	}
+	return nil
+}
+
+// procSysReader reads the keys from the files below root, the Linux way.
+func procSysReader(root string) reader {
+	return func(keys []string) (map[string]string, error) {
+		values := make(map[string]string)
+		var missing []string
+		for _, key := range keys {
+			if strings.HasSuffix(key, ".*") {
+				prefix := strings.TrimSuffix(key, ".*")
+				dir := filepath.Join(root, strings.Replace(prefix, ".", "/", -1))
+				if _, err := os.Stat(dir); err != nil {
+					missing = append(missing, key)
+					continue
+				}
+				err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+					if err != nil || info.IsDir() {
+						return nil
+					}
+					// Write-only parameters, such as vm.drop_caches, cannot
+					// be sampled.
+					buf, err := ioutil.ReadFile(path)
+					if err != nil {
+						return nil
+					}
+					rel, err := filepath.Rel(root, path)
+					if err != nil {
+						return nil
+					}
+					values[strings.Replace(rel, "/", ".", -1)] = string(buf)
+					return nil
+				})
+				if err != nil {
+					missing = append(missing, key)
+				}
+				continue
+			}
+
+			path := filepath.Join(root, strings.Replace(key, ".", "/", -1))
+			buf, err := ioutil.ReadFile(path)
+			if err != nil {
+				missing = append(missing, key)
+				continue
+			}
+			values[key] = string(buf)
+		}
+
+		if len(missing) > 0 {
+			return values, fmt.Errorf("error reading keys: %s", strings.Join(missing, ", "))
+		}
+		return values, nil
+	}
+}
+
+var execCommand = exec.Command // execCommand is used to mock commands in tests.
+
+// commandReader reads the keys with the sysctl command, the FreeBSD way.
+func commandReader(keys []string) (map[string]string, error) {
+	args := []string{"-e", "-i"}
+	for _, key := range keys {
+		args = append(args, strings.TrimSuffix(key, ".*"))
+	}
+
+	cmd := execCommand("sysctl", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := internal.RunTimeout(cmd, 5*time.Second); err != nil {
+		return nil, fmt.Errorf("failed to run command %s: %v - %s",
+			strings.Join(cmd.Args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return parseSysctlOutput(stdout.Bytes()), nil
+}
+
+// parseSysctlOutput parses the "name=value" lines printed by sysctl -e.
+// Lines without a separator continue multi-line values and are ignored.
+func parseSysctlOutput(out []byte) map[string]string {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) != 2 || strings.ContainsAny(parts[0], " \t") {
+			continue
+		}
+		values[parts[0]] = parts[1]
+	}
+	return values
+}
+
+func init() {
+	inputs.Add("sysctl", func() telegraf.Input {
+		return &Sysctl{}
+	})
+}
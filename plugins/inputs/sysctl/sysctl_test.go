@@ -0,0 +1,85 @@
+package sysctl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherProcSys(t *testing.T) {
+	plugin := &Sysctl{
+		Keys: []string{"vm.*", "net.ipv4.tcp_rmem", "kernel.hostname"},
+		Types: map[string]string{
+			"net.ipv4.*":    "int",
+			"vm.swappiness": "float",
+		},
+		read: procSysReader("testdata/proc/sys"),
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("sysctl",
+			map[string]string{},
+			map[string]interface{}{
+				"vm.dirty_ratio":            int64(20),
+				"vm.dirty_background_ratio": int64(10),
+				"vm.swappiness":             float64(60),
+				"net.ipv4.tcp_rmem.0":       int64(4096),
+				"net.ipv4.tcp_rmem.1":       int64(131072),
+				"net.ipv4.tcp_rmem.2":       int64(6291456),
+				"kernel.hostname":           "nas",
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherMissingKey(t *testing.T) {
+	plugin := &Sysctl{
+		Keys: []string{"vm.dirty_ratio", "vm.missing", "fs.*"},
+		read: procSysReader("testdata/proc/sys"),
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "vm.missing, fs.*")
+	require.True(t, acc.HasInt64Field("sysctl", "vm.dirty_ratio"))
+}
+
+func TestParseSysctlOutput(t *testing.T) {
+	out := []byte(`vfs.zfs.arc_max=8589934592
+vfs.zfs.arc_min=1073741824
+vfs.zfs.version.module=5000
+vfs.zfs.vdev.bio_flush_disable=0
+kern.geom.conftxt=0 DISK ada0 500107862016 512
+1 PART ada0p1 524288 512 i 1 o 20480 ty freebsd-boot
+`)
+	values := parseSysctlOutput(out)
+	require.Equal(t, map[string]string{
+		"vfs.zfs.arc_max":                "8589934592",
+		"vfs.zfs.arc_min":                "1073741824",
+		"vfs.zfs.version.module":         "5000",
+		"vfs.zfs.vdev.bio_flush_disable": "0",
+		"kern.geom.conftxt":              "0 DISK ada0 500107862016 512",
+	}, values)
+}
+
+func TestInitInvalidType(t *testing.T) {
+	plugin := &Sysctl{
+		Keys:  []string{"vm.dirty_ratio"},
+		Types: map[string]string{"vm.dirty_ratio": "bool"},
+	}
+	require.Error(t, plugin.Init())
+
+	plugin = &Sysctl{}
+	require.Error(t, plugin.Init())
+}
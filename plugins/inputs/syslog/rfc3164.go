@@ -0,0 +1,198 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxOctetCount is the largest message length accepted with octet counting
+// framing.
+const maxOctetCount = 64 * 1024
+
+var severityLevels = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+var facilityLevels = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console",
+	"solaris-cron", "local0", "local1", "local2", "local3", "local4",
+	"local5", "local6", "local7",
+}
+
+// bsdMessage is a message in the BSD syslog format.
+type bsdMessage struct {
+	priority  int
+	timestamp time.Time
+	hostname  string
+	appname   string
+	procid    string
+	message   string
+}
+
+// parseRFC3164 parses a message in the BSD syslog format of RFC3164:
+//
+//   <34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick
+//
+// The timestamp may also be in the RFC3339 format, as sent by rsyslog.  The
+// year missing from the timestamp is the one of now, or the year before
+// when the timestamp would be more than a day ahead.  In best effort mode,
+// the text following an invalid header is taken as the message.
+func parseRFC3164(b []byte, now time.Time, bestEffort bool) (*bsdMessage, error) {
+	msg := &bsdMessage{}
+
+	line := string(bytes.TrimRightFunc(b, unicode.IsSpace))
+	if !strings.HasPrefix(line, "<") {
+		return nil, errors.New("expecting a priority value within angle brackets")
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return nil, errors.New("expecting a priority value within angle brackets")
+	}
+	priority, err := strconv.Atoi(line[1:end])
+	if err != nil || priority < 0 || priority > 191 {
+		return nil, fmt.Errorf("invalid priority value %q", line[1:end])
+	}
+	msg.priority = priority
+	rest := line[end+1:]
+
+	var ok bool
+	if msg.timestamp, rest, ok = parseBSDTimestamp(rest, now); !ok {
+		if !bestEffort {
+			return nil, fmt.Errorf("invalid timestamp in %q", rest)
+		}
+		msg.message = rest
+		return msg, nil
+	}
+
+	if i := strings.IndexByte(rest, ' '); i > 0 {
+		msg.hostname, rest = rest[:i], rest[i+1:]
+	} else if !bestEffort {
+		return nil, errors.New("expecting a hostname")
+	}
+
+	// The tag is made of alphanumeric characters, followed by the optional
+	// process id within brackets and a colon.
+	i := strings.IndexFunc(rest, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_./", r))
+	})
+	if i > 0 && i < len(rest) {
+		tag, after := rest[:i], rest[i:]
+		if strings.HasPrefix(after, "[") {
+			if j := strings.Index(after, "]:"); j > 0 {
+				msg.appname, msg.procid = tag, after[1:j]
+				rest = after[j+2:]
+			}
+		} else if strings.HasPrefix(after, ":") {
+			msg.appname = tag
+			rest = after[1:]
+		}
+	}
+	msg.message = strings.TrimLeft(rest, " ")
+	return msg, nil
+}
+
+// parseBSDTimestamp parses the timestamp at the start of s, returning the
+// text following it.
+func parseBSDTimestamp(s string, now time.Time) (time.Time, string, bool) {
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, s[:i]); err == nil {
+			return ts, s[i+1:], true
+		}
+	}
+
+	const layout = "Jan _2 15:04:05"
+	if len(s) <= len(layout) || s[len(layout)] != ' ' {
+		return time.Time{}, s, false
+	}
+	ts, err := time.ParseInLocation(layout, s[:len(layout)], now.Location())
+	if err != nil {
+		return time.Time{}, s, false
+	}
+
+	ts = time.Date(now.Year(), ts.Month(), ts.Day(),
+		ts.Hour(), ts.Minute(), ts.Second(), 0, now.Location())
+	if ts.Sub(now) > 24*time.Hour {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	return ts, s[len(layout)+1:], true
+}
+
+func (m *bsdMessage) tags() map[string]string {
+	ts := map[string]string{
+		"severity": severityLevels[m.priority%8],
+		"facility": facilityLevels[m.priority/8],
+	}
+	if m.hostname != "" {
+		ts["hostname"] = m.hostname
+	}
+	if m.appname != "" {
+		ts["appname"] = m.appname
+	}
+	return ts
+}
+
+func (m *bsdMessage) fields() map[string]interface{} {
+	flds := map[string]interface{}{
+		"severity_code": m.priority % 8,
+		"facility_code": m.priority / 8,
+	}
+	if !m.timestamp.IsZero() {
+		flds["timestamp"] = m.timestamp.UnixNano()
+	}
+	if m.procid != "" {
+		flds["procid"] = m.procid
+	}
+	if m.message != "" {
+		flds["message"] = m.message
+	}
+	return flds
+}
+
+// splitOctetCounting is a bufio.SplitFunc for the octet counting framing,
+// where each message is preceded by its length and a space.
+func splitOctetCounting(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexByte(data, ' ')
+	if i < 0 {
+		if len(data) > len(strconv.Itoa(maxOctetCount)) {
+			return 0, nil, errors.New("expecting a message length")
+		}
+		if atEOF && len(data) > 0 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+
+	length, err := strconv.Atoi(string(data[:i]))
+	if err != nil || length <= 0 || length > maxOctetCount {
+		return 0, nil, fmt.Errorf("invalid message length %q", data[:i])
+	}
+	if len(data) < i+1+length {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	return i + 1 + length, data[i+1 : i+1+length], nil
+}
+
+// splitTrailer returns a bufio.SplitFunc for the non-transparent framing,
+// where messages are terminated by the trailer.
+func splitTrailer(trailer byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, trailer); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
@@ -0,0 +1,210 @@
+package syslog
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2020, 1, 12, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		data       string
+		bestEffort bool
+		want       *bsdMessage
+		werr       bool
+	}{
+		{
+			name: "complete",
+			data: "<34>Jan 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick\n",
+			want: &bsdMessage{
+				priority:  34,
+				timestamp: time.Date(2020, 1, 11, 22, 14, 15, 0, time.UTC),
+				hostname:  "mymachine",
+				appname:   "su",
+				procid:    "230",
+				message:   "'su root' failed for lonvick",
+			},
+		},
+		{
+			name: "no pid",
+			data: "<13>Jan  2 03:04:05 nas1 zed: eid=42 class=statechange pool=tank",
+			want: &bsdMessage{
+				priority:  13,
+				timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				hostname:  "nas1",
+				appname:   "zed",
+				message:   "eid=42 class=statechange pool=tank",
+			},
+		},
+		{
+			name: "no tag",
+			data: "<13>Jan  2 03:04:05 nas1 disk sda failed",
+			want: &bsdMessage{
+				priority:  13,
+				timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				hostname:  "nas1",
+				message:   "disk sda failed",
+			},
+		},
+		{
+			name: "previous year",
+			data: "<13>Dec 31 23:59:59 nas1 kernel: shutdown",
+			want: &bsdMessage{
+				priority:  13,
+				timestamp: time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC),
+				hostname:  "nas1",
+				appname:   "kernel",
+				message:   "shutdown",
+			},
+		},
+		{
+			name: "rfc3339 timestamp",
+			data: "<14>2020-01-12T09:59:58.5Z nas1 smartd[812]: Device: /dev/sda, SMART Failure",
+			want: &bsdMessage{
+				priority:  14,
+				timestamp: time.Date(2020, 1, 12, 9, 59, 58, 500000000, time.UTC),
+				hostname:  "nas1",
+				appname:   "smartd",
+				procid:    "812",
+				message:   "Device: /dev/sda, SMART Failure",
+			},
+		},
+		{
+			name: "missing priority",
+			data: "Jan 11 22:14:15 mymachine su: failed",
+			werr: true,
+		},
+		{
+			name: "invalid priority",
+			data: "<192>Jan 11 22:14:15 mymachine su: failed",
+			werr: true,
+		},
+		{
+			name: "invalid timestamp",
+			data: "<34>yesterday mymachine su: failed",
+			werr: true,
+		},
+		{
+			name:       "invalid timestamp best effort",
+			data:       "<34>yesterday mymachine su: failed",
+			bestEffort: true,
+			want: &bsdMessage{
+				priority: 34,
+				message:  "yesterday mymachine su: failed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRFC3164([]byte(tt.data), now, tt.bestEffort)
+			if tt.werr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitRFC3164Framing(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("4 <1>A11 <13>message"))
+	scanner.Split(splitOctetCounting)
+	var got []string
+	for scanner.Scan() {
+		got = append(got, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{"<1>A", "<13>message"}, got)
+
+	scanner = bufio.NewScanner(strings.NewReader("<1>A\x00<2>B\x00<3>C"))
+	scanner.Split(splitTrailer(0))
+	got = nil
+	for scanner.Scan() {
+		got = append(got, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{"<1>A", "<2>B", "<3>C"}, got)
+
+	scanner = bufio.NewScanner(strings.NewReader("x <1>A"))
+	scanner.Split(splitOctetCounting)
+	require.False(t, scanner.Scan())
+	require.Error(t, scanner.Err())
+}
+
+func TestRFC3164_udp(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://"+address, false)
+	receiver.SyslogStandard = "RFC3164"
+	receiver.MessagePatterns = []string{`latency (?P<latency_ms>\d+) ms on pool (?P<pool>\S+)`}
+	receiver.now = func() time.Time {
+		return time.Date(2020, 1, 12, 10, 0, 0, 0, time.UTC)
+	}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	conn, err := net.Dial("udp", address)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("<12>Jan 12 09:59:00 nas1 iomon[42]: latency 250 ms on pool tank"))
+	require.NoError(t, err)
+	conn.Close()
+	acc.Wait(1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"syslog",
+			map[string]string{
+				"severity": "warning",
+				"facility": "user",
+				"hostname": "nas1",
+				"appname":  "iomon",
+			},
+			map[string]interface{}{
+				"severity_code": 4,
+				"facility_code": 1,
+				"timestamp":     time.Date(2020, 1, 12, 9, 59, 0, 0, time.UTC).UnixNano(),
+				"procid":        "42",
+				"message":       "latency 250 ms on pool tank",
+				"latency_ms":    int64(250),
+				"pool":          "tank",
+			},
+			time.Date(2020, 1, 12, 10, 0, 0, 0, time.UTC),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestRFC3164_tcp(t *testing.T) {
+	receiver := newTCPSyslogReceiver("tcp://"+address, nil, 0, false, framing.NonTransparent)
+	receiver.SyslogStandard = "RFC3164"
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("<13>Jan  2 03:04:05 nas1 zed: scrub started\n<13>Jan  2 03:04:06 nas1 zed: scrub finished\n"))
+	require.NoError(t, err)
+	conn.Close()
+	acc.Wait(2)
+
+	require.Len(t, acc.Metrics, 2)
+	require.Equal(t, "scrub started", acc.Metrics[0].Fields["message"])
+	require.Equal(t, "scrub finished", acc.Metrics[1].Fields["message"])
+}
+
+func TestUnknownSyslogStandard(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://"+address, false)
+	receiver.SyslogStandard = "RFC1234"
+	require.Error(t, receiver.Start(&testutil.Accumulator{}))
+}
@@ -1,12 +1,15 @@
 package syslog
 
 import (
+	"bufio"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -36,7 +39,11 @@ type Syslog struct {
 	Framing         framing.Framing
 	Trailer         nontransparent.TrailerType
 	BestEffort      bool
-	Separator       string `toml:"sdparam_separator"`
+	Separator       string   `toml:"sdparam_separator"`
+	SyslogStandard  string   `toml:"syslog_standard"`
+	MessagePatterns []string `toml:"message_patterns"`
+
+	patterns []*regexp.Regexp
 
 	now      func() time.Time
 	lastTime time.Time
@@ -101,6 +108,15 @@ var sampleConfig = `
   ## For each combination a field is created.
   ## Its name is created concatenating identifier, sdparam_separator, and parameter name.
   # sdparam_separator = "_"
+
+  ## The syslog standard of the messages, "RFC5424" or "RFC3164" for the BSD
+  ## format still sent by many appliances (default = "RFC5424").
+  # syslog_standard = "RFC5424"
+
+  ## Regular expressions applied to the message, the named groups matched
+  ## are added as fields.  Values are added as integers or floats when they
+  ## are numbers.
+  # message_patterns = ['latency (?P<latency_ms>\d+) ms on pool (?P<pool>\S+)']
 `
 
 // SampleConfig returns sample configuration message
@@ -110,7 +126,7 @@ func (s *Syslog) SampleConfig() string {
 
 // Description returns the plugin description
 func (s *Syslog) Description() string {
-	return "Accepts syslog messages following RFC5424 format with transports as per RFC5426, RFC5425, or RFC6587"
+	return "Accepts syslog messages following RFC5424 or RFC3164 format with transports as per RFC5426, RFC5425, or RFC6587"
 }
 
 // Gather ...
@@ -123,6 +139,21 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	switch s.SyslogStandard {
+	case "", "RFC5424", "RFC3164":
+	default:
+		return fmt.Errorf("unknown syslog standard %q", s.SyslogStandard)
+	}
+
+	s.patterns = s.patterns[:0]
+	for _, pattern := range s.MessagePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("error compiling message pattern %q: %v", pattern, err)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+
 	scheme, host, err := getAddressParts(s.Address)
 	if err != nil {
 		return err
@@ -233,9 +264,19 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 			break
 		}
 
+		if s.SyslogStandard == "RFC3164" {
+			message, err := parseRFC3164(b[:n], s.now(), s.BestEffort)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+			s.addMessage(acc, message.fields(), message.tags())
+			continue
+		}
+
 		message, err := p.Parse(b[:n])
 		if message != nil {
-			acc.AddFields("syslog", fields(message, s), tags(message), s.time())
+			s.addMessage(acc, fields(message, s), tags(message))
 		}
 		if err != nil {
 			acc.AddError(err)
@@ -296,6 +337,11 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 		conn.Close()
 	}()
 
+	if s.SyslogStandard == "RFC3164" {
+		s.handleRFC3164(conn, acc)
+		return
+	}
+
 	var p syslog.Parser
 
 	emit := func(r *syslog.Result) {
@@ -349,8 +395,77 @@ func (s *Syslog) store(res syslog.Result, acc telegraf.Accumulator) {
 		acc.AddError(res.Error)
 	}
 	if res.Message != nil {
-		acc.AddFields("syslog", fields(res.Message, s), tags(res.Message), s.time())
+		s.addMessage(acc, fields(res.Message, s), tags(res.Message))
+	}
+}
+
+// handleRFC3164 reads the messages of a stream in the BSD syslog format.
+func (s *Syslog) handleRFC3164(conn net.Conn, acc telegraf.Accumulator) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxOctetCount+16)
+	if s.Framing == framing.OctetCounting {
+		scanner.Split(splitOctetCounting)
+	} else {
+		trailer := byte('\n')
+		if s.Trailer == nontransparent.NUL {
+			trailer = 0
+		}
+		scanner.Split(splitTrailer(trailer))
+	}
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			message, err := parseRFC3164(scanner.Bytes(), s.now(), s.BestEffort)
+			if err != nil {
+				acc.AddError(err)
+			} else {
+				s.addMessage(acc, message.fields(), message.tags())
+			}
+		}
+		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
+		}
+	}
+	if err := scanner.Err(); err != nil && !isClosedOrTimeout(err) {
+		acc.AddError(err)
+	}
+}
+
+func isClosedOrTimeout(err error) bool {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	return strings.HasSuffix(err.Error(), ": use of closed network connection")
+}
+
+// addMessage adds the fields extracted by the message patterns and adds the
+// message to the accumulator.
+func (s *Syslog) addMessage(acc telegraf.Accumulator, flds map[string]interface{}, tags map[string]string) {
+	if message, ok := flds["message"].(string); ok {
+		for _, re := range s.patterns {
+			match := re.FindStringSubmatch(message)
+			if match == nil {
+				continue
+			}
+			for i, name := range re.SubexpNames() {
+				if name != "" && i < len(match) {
+					flds[name] = parseValue(match[i])
+				}
+			}
+		}
+	}
+	acc.AddFields("syslog", flds, tags, s.time())
+}
+
+// parseValue returns the value as an integer or a float when it is a number.
+func parseValue(v string) interface{} {
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return f
 	}
+	return v
 }
 
 func tags(msg syslog.Message) map[string]string {
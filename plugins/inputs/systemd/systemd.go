@@ -0,0 +1,264 @@
+package systemd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type runner func(timeout time.Duration, name string, args ...string) ([]byte, error)
+
+// Systemd reports the state of systemd units and the number of journal
+// messages they logged at error priority.
+type Systemd struct {
+	Units           []string          `toml:"units"`
+	JournalPriority string            `toml:"journal_priority"`
+	Timeout         internal.Duration `toml:"timeout"`
+
+	State telegraf.StateStore `toml:"-"`
+	Log   telegraf.Logger     `toml:"-"`
+
+	run    runner
+	since  time.Time
+	cursor string
+}
+
+var sampleConfig = `
+  ## Units to report, glob patterns are supported.  Units listed by name
+  ## are reported as "not-found" when they are not installed.  The default
+  ## is to report all service units.
+  # units = ["zfs-zed.service", "zfs-import*.service", "nfs-server.service"]
+
+  ## Count the journal messages logged by the units at this priority or
+  ## higher, one of "emerg", "alert", "crit", "err", "warning", "notice",
+  ## "info" or "debug".  Set to "" to disable.
+  # journal_priority = "err"
+
+  ## Timeout for the systemctl and journalctl commands.
+  # timeout = "5s"
+`
+
+const journalCursorKey = "journal_cursor"
+
+var loadCodes = map[string]int{
+	"loaded":      0,
+	"stub":        1,
+	"not-found":   2,
+	"bad-setting": 3,
+	"error":       4,
+	"merged":      5,
+	"masked":      6,
+}
+
+var activeCodes = map[string]int{
+	"active":       0,
+	"reloading":    1,
+	"inactive":     2,
+	"failed":       3,
+	"activating":   4,
+	"deactivating": 5,
+}
+
+var priorities = map[string]bool{
+	"emerg":   true,
+	"alert":   true,
+	"crit":    true,
+	"err":     true,
+	"warning": true,
+	"notice":  true,
+	"info":    true,
+	"debug":   true,
+}
+
+func (s *Systemd) Description() string {
+	return "Read the state of systemd units and count their journal errors"
+}
+
+func (s *Systemd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Systemd) Init() error {
+	if s.JournalPriority != "" && !priorities[s.JournalPriority] {
+		return fmt.Errorf("invalid journal_priority %q", s.JournalPriority)
+	}
+
+	if len(s.Units) == 0 {
+		s.Units = []string{"*.service"}
+	}
+
+	s.since = time.Now()
+	if s.State != nil {
+		if _, err := s.State.Get(journalCursorKey, &s.cursor); err != nil {
+			s.Log.Warnf("Ignoring saved journal cursor: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *Systemd) Gather(acc telegraf.Accumulator) error {
+	units, err := s.gatherUnits(acc)
+	if err != nil {
+		return err
+	}
+
+	if s.JournalPriority != "" {
+		if err := s.gatherJournal(acc, units); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+// gatherUnits adds the state of the units and returns their names.
+func (s *Systemd) gatherUnits(acc telegraf.Accumulator) ([]string, error) {
+	args := []string{"list-units", "--all", "--plain", "--no-legend", "--no-pager"}
+	args = append(args, s.Units...)
+	out, err := s.run(s.Timeout.Duration, "systemctl", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	names := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// UNIT LOAD ACTIVE SUB DESCRIPTION
+		cols := strings.Fields(scanner.Text())
+		if len(cols) < 4 {
+			continue
+		}
+		addUnit(acc, cols[0], cols[1], cols[2], cols[3])
+		seen[cols[0]] = true
+		names = append(names, cols[0])
+	}
+
+	// Units requested by name are reported even when they are not
+	// installed, so that a missing daemon can be alerted on.
+	for _, unit := range s.Units {
+		if strings.ContainsAny(unit, "*?[") || seen[unit] {
+			continue
+		}
+		addUnit(acc, unit, "not-found", "inactive", "dead")
+		names = append(names, unit)
+	}
+	return names, nil
+}
+
+func addUnit(acc telegraf.Accumulator, name, load, active, sub string) {
+	tags := map[string]string{
+		"name":   name,
+		"load":   load,
+		"active": active,
+		"sub":    sub,
+	}
+	fields := map[string]interface{}{}
+	if code, ok := loadCodes[load]; ok {
+		fields["load_code"] = code
+	}
+	if code, ok := activeCodes[active]; ok {
+		fields["active_code"] = code
+	}
+	if len(fields) == 0 {
+		return
+	}
+	acc.AddFields("systemd_units", fields, tags)
+}
+
+// gatherJournal adds the number of messages logged by each unit since the
+// last gather.  The position in the journal is persisted so that a restart
+// does not count messages twice.
+func (s *Systemd) gatherJournal(acc telegraf.Accumulator, units []string) error {
+	args := []string{"--no-pager", "--quiet", "--output=json", "--priority=" + s.JournalPriority}
+	for _, unit := range s.Units {
+		args = append(args, "--unit="+unit)
+	}
+	if s.cursor != "" {
+		args = append(args, "--after-cursor="+s.cursor)
+	} else {
+		args = append(args, "--since=@"+strconv.FormatInt(s.since.Unix(), 10))
+	}
+
+	out, err := s.run(s.Timeout.Duration, "journalctl", args...)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int64, len(units))
+	for _, unit := range units {
+		counts[unit] = 0
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("error parsing journal entry: %v", err)
+		}
+
+		if cursor, ok := entry["__CURSOR"].(string); ok {
+			s.cursor = cursor
+		}
+
+		// Messages logged by systemd about a unit, such as a failure to
+		// start, are attributed to the unit rather than to systemd.
+		unit, ok := entry["UNIT"].(string)
+		if !ok {
+			unit, ok = entry["_SYSTEMD_UNIT"].(string)
+		}
+		if ok {
+			counts[unit]++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for unit, count := range counts {
+		acc.AddFields("systemd_journal",
+			map[string]interface{}{"messages": count},
+			map[string]string{"name": unit, "priority": s.JournalPriority})
+	}
+
+	if s.State != nil && s.cursor != "" {
+		return s.State.Set(journalCursorKey, s.cursor)
+	}
+	return nil
+}
+
+func runCommand(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found: verify that systemd is installed and that %s is in your PATH", name, name)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := internal.RunTimeout(cmd, timeout); err != nil {
+		return nil, fmt.Errorf("failed to run command %s: %v - %s",
+			strings.Join(cmd.Args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func init() {
+	inputs.Add("systemd", func() telegraf.Input {
+		return &Systemd{
+			JournalPriority: "err",
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			run:             runCommand,
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package systemd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/state"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const listUnitsOutput = `zfs-import-cache.service loaded active exited Import ZFS pools by cache file
+zfs-zed.service          loaded failed failed ZFS Event Daemon (zed)
+`
+
+const journalOutput = `{"__CURSOR":"s=1;i=10","_SYSTEMD_UNIT":"zfs-zed.service","PRIORITY":"3","MESSAGE":"zed: error"}
+{"__CURSOR":"s=1;i=11","_SYSTEMD_UNIT":"init.scope","UNIT":"zfs-zed.service","PRIORITY":"3","MESSAGE":"Failed to start ZFS Event Daemon (zed)."}
+{"__CURSOR":"s=1;i=12","_SYSTEMD_UNIT":"zfs-import-cache.service","PRIORITY":"3","MESSAGE":"cannot import"}
+`
+
+type fakeRunner struct {
+	outputs map[string]string
+	calls   [][]string
+}
+
+func (f *fakeRunner) run(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	out, ok := f.outputs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	return []byte(out), nil
+}
+
+func newSystemd(f *fakeRunner) *Systemd {
+	return &Systemd{
+		Units:           []string{"zfs-*.service", "nfs-server.service"},
+		JournalPriority: "err",
+		State:           state.New().Scope("inputs.systemd"),
+		Log:             testutil.Logger{},
+		run:             f.run,
+	}
+}
+
+func TestGather(t *testing.T) {
+	f := &fakeRunner{outputs: map[string]string{
+		"systemctl":  listUnitsOutput,
+		"journalctl": journalOutput,
+	}}
+	plugin := newSystemd(f)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("systemd_units",
+			map[string]string{"name": "zfs-import-cache.service", "load": "loaded", "active": "active", "sub": "exited"},
+			map[string]interface{}{"load_code": 0, "active_code": 0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("systemd_units",
+			map[string]string{"name": "zfs-zed.service", "load": "loaded", "active": "failed", "sub": "failed"},
+			map[string]interface{}{"load_code": 0, "active_code": 3},
+			time.Unix(0, 0)),
+		testutil.MustMetric("systemd_units",
+			map[string]string{"name": "nfs-server.service", "load": "not-found", "active": "inactive", "sub": "dead"},
+			map[string]interface{}{"load_code": 2, "active_code": 2},
+			time.Unix(0, 0)),
+		testutil.MustMetric("systemd_journal",
+			map[string]string{"name": "nfs-server.service", "priority": "err"},
+			map[string]interface{}{"messages": int64(0)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("systemd_journal",
+			map[string]string{"name": "zfs-import-cache.service", "priority": "err"},
+			map[string]interface{}{"messages": int64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("systemd_journal",
+			map[string]string{"name": "zfs-zed.service", "priority": "err"},
+			map[string]interface{}{"messages": int64(2)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
+		testutil.IgnoreTime(), testutil.SortMetrics())
+
+	require.Equal(t, []string{"systemctl", "list-units", "--all", "--plain", "--no-legend", "--no-pager",
+		"zfs-*.service", "nfs-server.service"}, f.calls[0])
+	require.Contains(t, strings.Join(f.calls[1], " "), "--since=@")
+}
+
+func TestGatherResumesFromCursor(t *testing.T) {
+	f := &fakeRunner{outputs: map[string]string{
+		"systemctl":  listUnitsOutput,
+		"journalctl": journalOutput,
+	}}
+	plugin := newSystemd(f)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+
+	// A restarted plugin continues after the last message counted.
+	restarted := newSystemd(f)
+	restarted.State = plugin.State
+	require.NoError(t, restarted.Init())
+	f.outputs["journalctl"] = ""
+	require.NoError(t, restarted.Gather(&acc))
+	require.Contains(t, f.calls[3], "--after-cursor=s=1;i=12")
+}
+
+func TestGatherJournalDisabled(t *testing.T) {
+	f := &fakeRunner{outputs: map[string]string{
+		"systemctl": listUnitsOutput,
+	}}
+	plugin := newSystemd(f)
+	plugin.JournalPriority = ""
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, f.calls, 1)
+	require.Len(t, acc.Errors, 0)
+	require.False(t, acc.HasMeasurement("systemd_journal"))
+}
+
+func TestInitInvalidPriority(t *testing.T) {
+	plugin := newSystemd(&fakeRunner{})
+	plugin.JournalPriority = "fatal"
+	require.Error(t, plugin.Init())
+}
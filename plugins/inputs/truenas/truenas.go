@@ -0,0 +1,324 @@
+package truenas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const apiPath = "/api/v2.0"
+
+var sampleConfig = `
+  ## TrueNAS or FreeNAS servers to poll.
+  servers = ["https://truenas.example.org"]
+
+  ## API key created in the web interface (Settings > API Keys), or the
+  ## credentials of the root user for the older FreeNAS releases.
+  # api_key = ""
+  # username = "root"
+  # password = ""
+
+  ## Data to collect, any of "pools", "alerts", "replication" and
+  ## "temperatures".  By default all of them are collected.
+  # collect = ["pools", "alerts", "replication", "temperatures"]
+
+  ## Report the alerts dismissed in the web interface.
+  # include_dismissed_alerts = false
+
+  ## Maximum time to receive a response.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type TrueNAS struct {
+	Servers                []string          `toml:"servers"`
+	APIKey                 string            `toml:"api_key"`
+	Username               string            `toml:"username"`
+	Password               string            `toml:"password"`
+	Collect                []string          `toml:"collect"`
+	IncludeDismissedAlerts bool              `toml:"include_dismissed_alerts"`
+	Timeout                internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client *http.Client
+}
+
+// date is the representation of the dates in the API responses.
+type date struct {
+	Millis int64 `json:"$date"`
+}
+
+type pool struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Healthy bool   `json:"healthy"`
+	Scan    *struct {
+		Function string `json:"function"`
+		State    string `json:"state"`
+		Errors   int64  `json:"errors"`
+	} `json:"scan"`
+}
+
+type alert struct {
+	UUID      string `json:"uuid"`
+	Klass     string `json:"klass"`
+	Level     string `json:"level"`
+	Formatted string `json:"formatted"`
+	Dismissed bool   `json:"dismissed"`
+	Datetime  date   `json:"datetime"`
+}
+
+type replication struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	State   struct {
+		State    string  `json:"state"`
+		Error    *string `json:"error"`
+		Datetime *date   `json:"datetime"`
+	} `json:"state"`
+}
+
+func (t *TrueNAS) Description() string {
+	return "Read pool, alert, replication and temperature data from TrueNAS servers"
+}
+
+func (t *TrueNAS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *TrueNAS) Init() error {
+	for _, c := range t.Collect {
+		switch c {
+		case "pools", "alerts", "replication", "temperatures":
+		default:
+			return fmt.Errorf("unknown data to collect %q", c)
+		}
+	}
+
+	tlsCfg, err := t.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	t.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: t.Timeout.Duration,
+	}
+	return nil
+}
+
+func (t *TrueNAS) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, server := range t.Servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			t.gatherServer(acc, server)
+		}(server)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (t *TrueNAS) collects(data string) bool {
+	if len(t.Collect) == 0 {
+		return true
+	}
+	for _, c := range t.Collect {
+		if c == data {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TrueNAS) gatherServer(acc telegraf.Accumulator, server string) {
+	u, err := url.Parse(server)
+	if err != nil {
+		acc.AddError(fmt.Errorf("[url=%s]: %s", server, err))
+		return
+	}
+	base := strings.TrimRight(server, "/") + apiPath
+	source := u.Hostname()
+
+	if t.collects("pools") {
+		if err := t.gatherPools(acc, base, source); err != nil {
+			acc.AddError(fmt.Errorf("[url=%s]: %s", server, err))
+		}
+	}
+	if t.collects("alerts") {
+		if err := t.gatherAlerts(acc, base, source); err != nil {
+			acc.AddError(fmt.Errorf("[url=%s]: %s", server, err))
+		}
+	}
+	if t.collects("replication") {
+		if err := t.gatherReplication(acc, base, source); err != nil {
+			acc.AddError(fmt.Errorf("[url=%s]: %s", server, err))
+		}
+	}
+	if t.collects("temperatures") {
+		if err := t.gatherTemperatures(acc, base, source); err != nil {
+			acc.AddError(fmt.Errorf("[url=%s]: %s", server, err))
+		}
+	}
+}
+
+func (t *TrueNAS) gatherPools(acc telegraf.Accumulator, base, source string) error {
+	var pools []pool
+	if err := t.call("GET", base+"/pool", nil, &pools); err != nil {
+		return err
+	}
+
+	for _, p := range pools {
+		tags := map[string]string{
+			"source": source,
+			"pool":   p.Name,
+			"status": p.Status,
+		}
+		fields := map[string]interface{}{
+			"healthy": p.Healthy,
+		}
+		if p.Scan != nil {
+			fields["scan_function"] = p.Scan.Function
+			fields["scan_state"] = p.Scan.State
+			fields["scan_errors"] = p.Scan.Errors
+		}
+		acc.AddFields("truenas_pool", fields, tags)
+	}
+	return nil
+}
+
+func (t *TrueNAS) gatherAlerts(acc telegraf.Accumulator, base, source string) error {
+	var alerts []alert
+	if err := t.call("GET", base+"/alert/list", nil, &alerts); err != nil {
+		return err
+	}
+
+	for _, a := range alerts {
+		if a.Dismissed && !t.IncludeDismissedAlerts {
+			continue
+		}
+		tags := map[string]string{
+			"source": source,
+			"klass":  a.Klass,
+			"level":  strings.ToLower(a.Level),
+		}
+		fields := map[string]interface{}{
+			"uuid":      a.UUID,
+			"message":   a.Formatted,
+			"dismissed": a.Dismissed,
+			"raised":    a.Datetime.Millis / 1000,
+		}
+		acc.AddFields("truenas_alert", fields, tags)
+	}
+	return nil
+}
+
+func (t *TrueNAS) gatherReplication(acc telegraf.Accumulator, base, source string) error {
+	var tasks []replication
+	if err := t.call("GET", base+"/replication", nil, &tasks); err != nil {
+		return err
+	}
+
+	for _, r := range tasks {
+		tags := map[string]string{
+			"source": source,
+			"name":   r.Name,
+			"state":  strings.ToLower(r.State.State),
+		}
+		fields := map[string]interface{}{
+			"enabled": r.Enabled,
+		}
+		if r.State.Datetime != nil {
+			fields["last_run"] = r.State.Datetime.Millis / 1000
+		}
+		if r.State.Error != nil {
+			fields["error"] = *r.State.Error
+		}
+		acc.AddFields("truenas_replication", fields, tags)
+	}
+	return nil
+}
+
+func (t *TrueNAS) gatherTemperatures(acc telegraf.Accumulator, base, source string) error {
+	// The disks in standby are not woken up to read their temperature.
+	body := []byte(`{"names": [], "powermode": "STANDBY"}`)
+	var temps map[string]*float64
+	if err := t.call("POST", base+"/disk/temperatures", body, &temps); err != nil {
+		return err
+	}
+
+	for disk, temp := range temps {
+		if temp == nil {
+			continue
+		}
+		tags := map[string]string{
+			"source": source,
+			"disk":   disk,
+		}
+		fields := map[string]interface{}{
+			"temperature": *temp,
+		}
+		acc.AddFields("truenas_disk", fields, tags)
+	}
+	return nil
+}
+
+func (t *TrueNAS) call(method, url string, body []byte, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	} else if t.Username != "" || t.Password != "" {
+		req.SetBasicAuth(t.Username, t.Password)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("%s %s returned HTTP status %s: %s",
+			method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func init() {
+	inputs.Add("truenas", func() telegraf.Input {
+		return &TrueNAS{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package truenas
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const poolResponse = `[
+  {
+    "id": 1,
+    "name": "tank",
+    "guid": "14432394846217830137",
+    "path": "/mnt/tank",
+    "status": "DEGRADED",
+    "healthy": false,
+    "scan": {
+      "function": "SCRUB",
+      "state": "FINISHED",
+      "errors": 2
+    }
+  }
+]`
+
+const alertResponse = `[
+  {
+    "uuid": "a1b2",
+    "source": "",
+    "klass": "VolumeStatus",
+    "level": "CRITICAL",
+    "formatted": "Pool tank state is DEGRADED",
+    "dismissed": false,
+    "datetime": {"$date": 1578823200000}
+  },
+  {
+    "uuid": "c3d4",
+    "klass": "SMART",
+    "level": "WARNING",
+    "formatted": "Device /dev/ada1 has pending sectors",
+    "dismissed": true,
+    "datetime": {"$date": 1578823200000}
+  }
+]`
+
+const replicationResponse = `[
+  {
+    "id": 1,
+    "name": "tank/data - backup/data",
+    "enabled": true,
+    "state": {
+      "state": "ERROR",
+      "datetime": {"$date": 1578823200000},
+      "error": "No route to host"
+    }
+  },
+  {
+    "id": 2,
+    "name": "tank/home - backup/home",
+    "enabled": false,
+    "state": {"state": "PENDING"}
+  }
+]`
+
+const temperatureResponse = `{"ada0": 34.0, "ada1": null}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	responses := map[string]string{
+		"GET /api/v2.0/pool":               poolResponse,
+		"GET /api/v2.0/alert/list":         alertResponse,
+		"GET /api/v2.0/replication":        replicationResponse,
+		"POST /api/v2.0/disk/temperatures": temperatureResponse,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == "POST" {
+			body, _ := ioutil.ReadAll(r.Body)
+			require.Equal(t, `{"names": [], "powermode": "STANDBY"}`, string(body))
+		}
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, resp)
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	plugin := &TrueNAS{
+		Servers: []string{ts.URL},
+		APIKey:  "secret",
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"truenas_pool",
+			map[string]string{
+				"source": "127.0.0.1",
+				"pool":   "tank",
+				"status": "DEGRADED",
+			},
+			map[string]interface{}{
+				"healthy":       false,
+				"scan_function": "SCRUB",
+				"scan_state":    "FINISHED",
+				"scan_errors":   int64(2),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"truenas_alert",
+			map[string]string{
+				"source": "127.0.0.1",
+				"klass":  "VolumeStatus",
+				"level":  "critical",
+			},
+			map[string]interface{}{
+				"uuid":      "a1b2",
+				"message":   "Pool tank state is DEGRADED",
+				"dismissed": false,
+				"raised":    int64(1578823200),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"truenas_replication",
+			map[string]string{
+				"source": "127.0.0.1",
+				"name":   "tank/data - backup/data",
+				"state":  "error",
+			},
+			map[string]interface{}{
+				"enabled":  true,
+				"last_run": int64(1578823200),
+				"error":    "No route to host",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"truenas_replication",
+			map[string]string{
+				"source": "127.0.0.1",
+				"name":   "tank/home - backup/home",
+				"state":  "pending",
+			},
+			map[string]interface{}{
+				"enabled": false,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"truenas_disk",
+			map[string]string{
+				"source": "127.0.0.1",
+				"disk":   "ada0",
+			},
+			map[string]interface{}{
+				"temperature": 34.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherCollect(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	plugin := &TrueNAS{
+		Servers:                []string{ts.URL},
+		APIKey:                 "secret",
+		Collect:                []string{"alerts"},
+		IncludeDismissedAlerts: true,
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 2)
+	for _, m := range acc.Metrics {
+		require.Equal(t, "truenas_alert", m.Measurement)
+	}
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	plugin := &TrueNAS{
+		Servers: []string{ts.URL},
+		APIKey:  "wrong",
+		Collect: []string{"pools"},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 0)
+}
+
+func TestInitUnknownCollect(t *testing.T) {
+	plugin := &TrueNAS{
+		Collect: []string{"datasets"},
+	}
+	require.Error(t, plugin.Init())
+}
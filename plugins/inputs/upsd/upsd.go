@@ -0,0 +1,304 @@
+package upsd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultAddress = "127.0.0.1:3493"
+	statusKey      = "status"
+)
+
+// Upsd reports the status of the UPSes served by the upsd daemon of Network
+// UPS Tools.
+type Upsd struct {
+	Servers []string          `toml:"servers"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	State telegraf.StateStore `toml:"-"`
+	Log   telegraf.Logger     `toml:"-"`
+
+	// Last status and number of transfers to battery of each UPS, keyed by
+	// server and UPS name.
+	status map[string]*upsStatus
+}
+
+type upsStatus struct {
+	OnBattery bool  `json:"on_battery"`
+	Transfers int64 `json:"transfers"`
+}
+
+var sampleConfig = `
+  ## Addresses of the upsd servers, as "host:port".
+  # servers = ["127.0.0.1:3493"]
+
+  ## Timeout for connecting to and reading from the servers.
+  # timeout = "5s"
+`
+
+// Variables reported as fields, not all UPSes provide all of them.
+var floatVars = map[string]string{
+	"battery.charge":  "battery_charge_percent",
+	"battery.runtime": "battery_runtime_seconds",
+	"battery.voltage": "battery_voltage",
+	"input.voltage":   "input_voltage",
+	"input.frequency": "input_frequency",
+	"output.voltage":  "output_voltage",
+	"ups.load":        "load_percent",
+	"ups.realpower":   "real_power_watts",
+	"ups.temperature": "internal_temp",
+}
+
+func (u *Upsd) Description() string {
+	return "Monitor UPSes connected to Network UPS Tools"
+}
+
+func (u *Upsd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (u *Upsd) Init() error {
+	if len(u.Servers) == 0 {
+		u.Servers = []string{defaultAddress}
+	}
+
+	u.status = make(map[string]*upsStatus)
+	if u.State != nil {
+		var status map[string]*upsStatus
+		if _, err := u.State.Get(statusKey, &status); err != nil {
+			u.Log.Warnf("Ignoring saved status: %v", err)
+		} else if status != nil {
+			u.status = status
+		}
+	}
+	return nil
+}
+
+func (u *Upsd) Gather(acc telegraf.Accumulator) error {
+	for _, server := range u.Servers {
+		if err := u.gatherServer(acc, server); err != nil {
+			acc.AddError(fmt.Errorf("%s: %v", server, err))
+		}
+	}
+
+	if u.State != nil {
+		return u.State.Set(statusKey, u.status)
+	}
+	return nil
+}
+
+func (u *Upsd) gatherServer(acc telegraf.Accumulator, server string) error {
+	conn, err := net.DialTimeout("tcp", server, u.Timeout.Duration)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(u.Timeout.Duration))
+
+	c := &client{conn: conn, reader: bufio.NewReader(conn)}
+	defer c.logout()
+
+	upses, err := c.list("UPS")
+	if err != nil {
+		return err
+	}
+	for _, ups := range upses {
+		// UPS <name> "<description>"
+		if len(ups) < 2 {
+			continue
+		}
+		name := ups[1]
+
+		vars, err := c.list("VAR", name)
+		if err != nil {
+			acc.AddError(fmt.Errorf("%s: %s: %v", server, name, err))
+			continue
+		}
+		values := make(map[string]string, len(vars))
+		for _, v := range vars {
+			// VAR <ups> <name> "<value>"
+			if len(v) == 4 {
+				values[v[2]] = v[3]
+			}
+		}
+		u.addUPS(acc, server, name, values)
+	}
+	return nil
+}
+
+func (u *Upsd) addUPS(acc telegraf.Accumulator, server, name string, values map[string]string) {
+	status := values["ups.status"]
+	flags := make(map[string]bool)
+	for _, flag := range strings.Fields(status) {
+		flags[flag] = true
+	}
+
+	tags := map[string]string{
+		"server":   server,
+		"ups_name": name,
+	}
+	if model := firstOf(values, "device.model", "ups.model"); model != "" {
+		tags["model"] = model
+	}
+	if serial := firstOf(values, "device.serial", "ups.serial"); serial != "" {
+		tags["serial"] = serial
+	}
+
+	// The transfers are counted by comparing the status with the previous
+	// one, short outages between two gathers are missed.
+	key := server + "/" + name
+	last, ok := u.status[key]
+	if !ok || last == nil {
+		last = &upsStatus{OnBattery: flags["OB"]}
+		u.status[key] = last
+	}
+	if flags["OB"] && !last.OnBattery {
+		last.Transfers++
+	}
+	last.OnBattery = flags["OB"]
+
+	// The status changes with every charge or discharge, it is reported as
+	// a field to keep the number of series low.
+	fields := map[string]interface{}{
+		"status":          status,
+		"on_battery":      flags["OB"],
+		"low_battery":     flags["LB"],
+		"replace_battery": flags["RB"],
+		"overload":        flags["OVER"],
+		"transfers":       last.Transfers,
+	}
+	for variable, field := range floatVars {
+		value, ok := values[variable]
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			u.Log.Debugf("Ignoring %s of %s: %v", variable, name, err)
+			continue
+		}
+		fields[field] = v
+	}
+	acc.AddFields("upsd", fields, tags)
+}
+
+func firstOf(values map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := values[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// client implements the subset of the NUT network protocol used to read the
+// variables of the UPSes.
+type client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// list sends a LIST command and returns the words of the lines between the
+// BEGIN and END lines of the response.
+func (c *client) list(args ...string) ([][]string, error) {
+	query := strings.Join(args, " ")
+	if _, err := fmt.Fprintf(c.conn, "LIST %s\n", query); err != nil {
+		return nil, err
+	}
+
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line != "BEGIN LIST "+query {
+		return nil, fmt.Errorf("unexpected response to LIST %s: %q", query, line)
+	}
+
+	var items [][]string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "END LIST "+query {
+			return items, nil
+		}
+		words, err := splitWords(line)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, words)
+	}
+}
+
+func (c *client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "ERR ") {
+		return "", fmt.Errorf("server error: %s", strings.TrimPrefix(line, "ERR "))
+	}
+	return line, nil
+}
+
+func (c *client) logout() {
+	fmt.Fprintf(c.conn, "LOGOUT\n")
+}
+
+// splitWords splits a response line into words, double quoted words may
+// contain spaces and backslash escaped quotes and backslashes.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, quoted, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case r == ' ' && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted || escaped {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func init() {
+	metric.RegisterFieldType("upsd", telegraf.Counter, "transfers")
+
+	inputs.Add("upsd", func() telegraf.Input {
+		return &Upsd{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package upsd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/state"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// listen serves the responses to the LIST commands until the listener is
+// closed, status returns the ups.status variable of each connection.
+func listen(t *testing.T, status func() string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			serve(conn, status())
+		}
+	}()
+	return l
+}
+
+func serve(conn net.Conn, status string) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "LIST UPS":
+			fmt.Fprint(conn, "BEGIN LIST UPS\n"+
+				"UPS eaton \"Eaton 5P \\\"rack\\\"\"\n"+
+				"UPS broken \"Not responding\"\n"+
+				"END LIST UPS\n")
+		case "LIST VAR eaton":
+			fmt.Fprint(conn, "BEGIN LIST VAR eaton\n"+
+				"VAR eaton battery.charge \"87\"\n"+
+				"VAR eaton battery.runtime \"1520\"\n"+
+				"VAR eaton device.model \"5P 1550\"\n"+
+				"VAR eaton device.serial \"G123\"\n"+
+				"VAR eaton input.voltage \"231.0\"\n"+
+				"VAR eaton ups.load \"23\"\n"+
+				"VAR eaton ups.status \""+status+"\"\n"+
+				"END LIST VAR eaton\n")
+		case "LIST VAR broken":
+			fmt.Fprint(conn, "ERR DRIVER-NOT-CONNECTED\n")
+		case "LOGOUT":
+			fmt.Fprint(conn, "OK Goodbye\n")
+			return
+		default:
+			fmt.Fprint(conn, "ERR UNKNOWN-COMMAND\n")
+		}
+	}
+}
+
+func TestGather(t *testing.T) {
+	l := listen(t, func() string { return "OB DISCHRG" })
+	defer l.Close()
+
+	plugin := &Upsd{
+		Servers: []string{l.Addr().String()},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "DRIVER-NOT-CONNECTED")
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("upsd",
+			map[string]string{
+				"server":   l.Addr().String(),
+				"ups_name": "eaton",
+				"model":    "5P 1550",
+				"serial":   "G123",
+			},
+			map[string]interface{}{
+				"status":                  "OB DISCHRG",
+				"on_battery":              true,
+				"low_battery":             false,
+				"replace_battery":         false,
+				"overload":                false,
+				"transfers":               int64(0),
+				"battery_charge_percent":  float64(87),
+				"battery_runtime_seconds": float64(1520),
+				"input_voltage":           float64(231),
+				"load_percent":            float64(23),
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestTransfers(t *testing.T) {
+	statuses := []string{"OL", "OB", "OB LB", "OL CHRG", "OB"}
+	l := listen(t, func() string {
+		s := statuses[0]
+		statuses = statuses[1:]
+		return s
+	})
+	defer l.Close()
+
+	store := state.New()
+	newPlugin := func() *Upsd {
+		plugin := &Upsd{
+			Servers: []string{l.Addr().String()},
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+			State:   store.Scope("upsd"),
+			Log:     testutil.Logger{},
+		}
+		require.NoError(t, plugin.Init())
+		return plugin
+	}
+
+	plugin := newPlugin()
+	var transfers []int64
+	for i := 0; i < 4; i++ {
+		// A restart must not lose the count.
+		if i == 2 {
+			plugin = newPlugin()
+		}
+		var acc testutil.Accumulator
+		require.NoError(t, plugin.Gather(&acc))
+		v, ok := acc.Get("upsd")
+		require.True(t, ok)
+		transfers = append(transfers, v.Fields["transfers"].(int64))
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	v, _ := acc.Get("upsd")
+	transfers = append(transfers, v.Fields["transfers"].(int64))
+
+	require.Equal(t, []int64{0, 1, 1, 1, 2}, transfers)
+}
+
+func TestNullSavedStatus(t *testing.T) {
+	l := listen(t, func() string { return "OB" })
+	defer l.Close()
+
+	store := state.New()
+	require.NoError(t, store.Scope("upsd").Set(statusKey, nil))
+
+	plugin := &Upsd{
+		Servers: []string{l.Addr().String()},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		State:   store.Scope("upsd"),
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+}
+
+func TestSplitWords(t *testing.T) {
+	words, err := splitWords(`VAR eaton ups.mfr "Eaton \"EU\" \\ Co"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"VAR", "eaton", "ups.mfr", `Eaton "EU" \ Co`}, words)
+
+	words, err = splitWords(`VAR eaton ups.id ""`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"VAR", "eaton", "ups.id", ""}, words)
+
+	_, err = splitWords(`VAR eaton ups.mfr "Eaton`)
+	require.Error(t, err)
+}
+
+func TestGatherConnectionRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	plugin := &Upsd{
+		Servers: []string{addr},
+		Timeout: internal.Duration{Duration: time.Second},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.True(t, strings.HasPrefix(acc.Errors[0].Error(), addr))
+}
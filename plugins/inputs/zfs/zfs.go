@@ -1,14 +1,95 @@
 package zfs
 
-type Sysctl func(metric string) ([]string, error)
-type Zpool func() ([]string, error)
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// CommandRunner runs the ZFS commands and returns the lines of their output.
+// It is replaced in the tests by recorded outputs.
+type CommandRunner interface {
+	Run(command string, args ...string) ([]string, error)
+}
+
+type execRunner struct{}
+
+func (execRunner) Run(command string, args ...string) ([]string, error) {
+	return run(command, args...)
+}
 
 type Zfs struct {
-	KstatPath    string
-	KstatMetrics []string
-	PoolMetrics  bool
-	sysctl       Sysctl
-	zpool        Zpool
+	KstatPath        string
+	KstatMetrics     []string
+	KstatDiscover    bool
+	PoolMetrics      bool
+	DatasetMetrics   bool
+	PoolWorkers      int
+	PoolTimeout      internal.Duration
+	CheckPools       bool
+	PoolHealthTag    bool
+	VersionMetrics   bool
+	VersionTags      bool
+	ReplayDir        string
+	TimingMetrics    bool
+	OmitDashValues   bool
+	LegacyFieldNames bool
+
+	WatchSnapshots []string
+	CloneMetrics   bool
+	ZvolMetrics    bool
+	MemoryMetrics  bool
+	IcpMetrics     bool
+
+	CapacityForecast  bool
+	CapacityThreshold float64
+	CapacityWindow    internal.Duration
+
+	RemoteHosts       []string
+	RemoteKey         string
+	RemoteConcurrency int
+
+	runner CommandRunner
+	// remoteRunner returns the runner of the commands on a remote host.
+	remoteRunner func(host string) CommandRunner
+
+	// sysModulePath is the sysfs directory of the kernel modules on Linux.
+	sysModulePath string
+	// meminfoPath is /proc/meminfo on Linux.
+	meminfoPath string
+	// versions are the tags of the ZFS versions, collected once.
+	versions map[string]string
+	// datasetNames resolves the objset IDs of the kstats of a pool to dataset
+	// names, for the kstats without dataset_name.
+	datasetNames func(pool string, ids []uint64) (map[uint64]string, error)
+	// pendingPools are the pools being read, guarded by pendingMu.
+	pendingPools map[string]bool
+	pendingMu    sync.Mutex
+	// snapshotsSeen are the snapshots of the watched datasets at the previous
+	// interval.
+	snapshotsSeen map[string]map[string]snapshotInfo
+	// snapshots are the directories replayed in turn when replayDir is set.
+	snapshots []string
+	snapshot  int
+
+	Log   telegraf.Logger     `toml:"-"`
+	State telegraf.StateStore `toml:"-"`
+	Cache telegraf.Cache      `toml:"-"`
+
+	// history is the allocation of the pools for capacityForecast.
+	history map[string][]allocSample
+	now     func() time.Time
 }
 
 var sampleConfig = `
@@ -21,9 +102,102 @@ var sampleConfig = `
   # kstatMetrics = ["arcstats", "zfetchstats", "vdev_cache_stats"]
   ## For Linux, the default is:
   # kstatMetrics = ["abdstats", "arcstats", "dnodestats", "dbufcachestats",
-  #   "dmu_tx", "fm", "vdev_mirror_stats", "zfetchstats", "zil"]
+  #   "dmu_tx", "fm", "qat", "vdev_mirror_stats", "zfetchstats", "zil"]
+  ## Gather all the kstats found in kstatPath instead, including the ones
+  ## added by newer ZFS releases.  Use fieldpass and fielddrop to select the
+  ## fields.  Cannot be combined with kstatMetrics.  Only supported on Linux.
+  # kstatDiscover = false
   ## By default, don't gather zpool stats
   # poolMetrics = false
+  ## Number of pools read concurrently, and timeout for reading the metrics
+  ## of a pool.  Only supported on Linux.
+  # poolWorkers = 4
+  # poolTimeout = "5s"
+  ## Compare the pools found in the kstats with the pools listed by zpool,
+  ## report the pools found in only one of them in the zfs_pool_mismatch
+  ## measurement, and only read the metrics of the pools found in both.
+  ## Only supported on Linux.
+  # checkPools = false
+  ## Tag the zfs_pool metrics with the health of the pool, read from its
+  ## state kstat since ZFS 0.8.  A pool changing health starts a new series.
+  ## Only supported on Linux, the health is always a tag on FreeBSD.
+  # poolHealthTag = false
+  ## Report the reads and writes of each dataset from the objset kstats of
+  ## the pools in the zfs_dataset measurement.  Requires ZFS 0.8 or later.
+  ## Only supported on Linux.
+  # datasetMetrics = false
+  ## Report the pool metrics of Linux with their kstat names, like wtime and
+  ## rcnt, rather than with descriptive names, like wait_time_ns and
+  ## run_queue_length.  Defaults to true when not set, so that the existing
+  ## configurations keep their field names.
+  # legacyFieldNames = true
+
+  ## Report the versions of the loaded ZFS modules and of the userland
+  ## tools, and whether they differ.  Only supported on Linux.
+  # versionMetrics = false
+
+  ## Tag the zfs and zfs_pool measurements with the versions of the userland
+  ## tools and of the kernel module, collected once.
+  ## Requires ZFS 0.8 or later.
+  # versionTags = false
+
+  ## Do not report the values printed as "-" by zpool, like the fragmentation
+  ## of read-only pools, instead of reporting them as 0.  Only supported on
+  ## FreeBSD.
+  # omitDashValues = false
+
+  ## Report the snapshots of these datasets created or destroyed since the
+  ## previous interval, in the zfs_snapshot_event measurement.
+  # watchSnapshots = ["tank/home"]
+
+  ## Report the clones, with their origin snapshot and their space, in the
+  ## zfs_clone measurement.
+  # cloneMetrics = false
+
+  ## Report the size, refreservation and usage of the zvols in the zfs_zvol
+  ## measurement, and how much the pools are overcommitted by thin
+  ## provisioned zvols in the zfs_zvol_pool measurement.
+  # zvolMetrics = false
+
+  ## Report how the memory is split between the ARC, the page cache, the
+  ## anonymous memory and the free memory in the zfs_memory measurement.
+  ## Only supported on Linux.
+  # memoryMetrics = false
+
+  ## Report the implementations of AES and GCM selected by the ICP, the
+  ## crypto module of ZFS, and its kcf kstats in the zfs_icp measurement.
+  ## Only supported on Linux.
+  # icpMetrics = false
+
+  ## Project in how many days the pools reach capacityThreshold percent of
+  ## their size, from the growth of their allocation over capacityWindow, in
+  ## the zfs_pool_forecast measurement.  The history of the allocation is
+  ## kept across restarts when the agent statefile is set.
+  # capacityForecast = false
+  # capacityThreshold = 90.0
+  # capacityWindow = "168h"
+
+  ## Gather the pools of these hosts, with zpool list run over ssh, instead
+  ## of the local ones.  The metrics are tagged with the remote host.  The
+  ## hosts are given as to ssh, as host or user@host, and ssh must be able to
+  ## log in without a password, with a key.  Only the zfs_pool measurement of
+  ## zpool list is gathered, the options reading the kstats or running other
+  ## commands cannot be set with remoteHosts.
+  # remoteHosts = []
+  ## The private key used to log in to the remote hosts.
+  # remoteKey = ""
+  ## Number of commands run concurrently on each remote host, the hosts are
+  ## gathered concurrently.  The commands run on a remote host are killed
+  ## after poolTimeout, or 5s if it is not set.
+  # remoteConcurrency = 4
+
+  ## Report how long the gathering of the kstats, of the pools and of the
+  ## versions took in the zfs_gather measurement.
+  # timingMetrics = false
+
+  ## Replay the kstats and the command outputs recorded in this directory
+  ## instead of reading them from the system, see the README for its layout.
+  # replayDir = ""
 `
 
 func (z *Zfs) SampleConfig() string {
@@ -33,3 +207,300 @@ func (z *Zfs) SampleConfig() string {
 func (z *Zfs) Description() string {
 	return "Read metrics of ZFS from arcstats, zfetchstats, vdev_cache_stats, and pools"
 }
+
+func run(command string, args ...string) ([]string, error) {
+	return runTimeout(0, command, args...)
+}
+
+// runTimeout runs the command, killing it if it does not complete within
+// the timeout.  A timeout of 0 waits for the command.
+func runTimeout(timeout time.Duration, command string, args ...string) ([]string, error) {
+	cmd := exec.Command(command, args...)
+	// The ZFS commands format the numbers according to the locale, like the
+	// decimal comma of the dedup ratio.
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	var outbuf, errbuf bytes.Buffer
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+	var err error
+	if timeout > 0 {
+		err = internal.RunTimeout(cmd, timeout)
+	} else {
+		err = cmd.Run()
+	}
+	if err == internal.TimeoutErr {
+		return nil, fmt.Errorf("%s timed out after %s", command, timeout)
+	}
+
+	stdout := strings.TrimSpace(outbuf.String())
+	stderr := strings.TrimSpace(errbuf.String())
+
+	if _, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("%s error: %s", command, stderr)
+	}
+	// The command may also not be found or not be executable.
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %v", command, err)
+	}
+	return strings.Split(stdout, "\n"), nil
+}
+
+// zpool runs zpool list, once per interval for all the inputs of the host
+// when the agent shares its cache.  The replayed outputs are not shared, as
+// each input may replay its own.
+func (z *Zfs) zpool() ([]string, error) {
+	args := []string{"list", "-Hp", "-o", "name,health,size,alloc,free,fragmentation,capacity,dedupratio"}
+	if z.Cache == nil || z.ReplayDir != "" {
+		return z.runner.Run("zpool", args...)
+	}
+
+	out, err := z.Cache.Get("zpool "+strings.Join(args, " "), func() (interface{}, error) {
+		return z.runner.Run("zpool", args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.([]string), nil
+}
+
+type zpoolStats struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// parseZpoolList parses the output of zpool list, the lines that do not have
+// all the columns are skipped.
+func parseZpoolList(lines []string, omitDashValues bool) ([]zpoolStats, error) {
+	var stats []zpoolStats
+	for _, line := range lines {
+		col := strings.Split(line, "\t")
+		if len(col) != 8 {
+			continue
+		}
+
+		tags := map[string]string{"pool": col[0], "health": col[1]}
+		fields := map[string]interface{}{}
+
+		if tags["health"] == "UNAVAIL" {
+
+			fields["size"] = int64(0)
+
+		} else {
+
+			size, err := strconv.ParseInt(col[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing size: %s", err)
+			}
+			fields["size"] = size
+
+			alloc, err := strconv.ParseInt(col[3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing allocation: %s", err)
+			}
+			fields["allocated"] = alloc
+
+			free, err := strconv.ParseInt(col[4], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing free: %s", err)
+			}
+			fields["free"] = free
+
+			// The fragmentation is - for read-only pools.
+			if col[5] != "-" || !omitDashValues {
+				frag, err := strconv.ParseInt(strings.TrimSuffix(col[5], "%"), 10, 0)
+				if err != nil {
+					frag = 0
+				}
+				fields["fragmentation"] = frag
+			}
+
+			capval, err := strconv.ParseInt(col[6], 10, 0)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing capacity: %s", err)
+			}
+			fields["capacity"] = capval
+
+			// Recorded outputs may use a decimal comma.
+			ratio := strings.Replace(strings.TrimSuffix(col[7], "x"), ",", ".", 1)
+			dedup, err := strconv.ParseFloat(ratio, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Error parsing dedupratio: %s", err)
+			}
+			fields["dedupratio"] = dedup
+		}
+
+		stats = append(stats, zpoolStats{tags: tags, fields: fields})
+	}
+	return stats, nil
+}
+
+// transcriptRunner replays the outputs of the commands recorded in a
+// transcript, each output following its command line:
+//
+//   $ zfs version
+//   zfs-2.1.5-1
+//   zfs-kmod-2.1.4-1
+type transcriptRunner map[string][]string
+
+func (r transcriptRunner) Run(command string, args ...string) ([]string, error) {
+	line := strings.Join(append([]string{command}, args...), " ")
+	out, ok := r[line]
+	if !ok {
+		return nil, fmt.Errorf("%s error: not recorded", line)
+	}
+	return out, nil
+}
+
+func loadTranscript(path string) (transcriptRunner, error) {
+	runner := make(transcriptRunner)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return runner, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var command string
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.HasPrefix(line, "$ ") {
+			command = strings.TrimSpace(strings.TrimPrefix(line, "$ "))
+			runner[command] = []string{}
+			continue
+		}
+		if command == "" {
+			continue
+		}
+		runner[command] = append(runner[command], line)
+	}
+	// The outputs are trimmed like the ones of the commands that are run.
+	for command, out := range runner {
+		runner[command] = strings.Split(strings.TrimSpace(strings.Join(out, "\n")), "\n")
+	}
+	return runner, nil
+}
+
+// initReplay lists the snapshots of replayDir: its subdirectories in order,
+// or replayDir itself when it contains a single snapshot.
+func (z *Zfs) initReplay() error {
+	files, err := ioutil.ReadDir(z.ReplayDir)
+	if err != nil {
+		return fmt.Errorf("replay directory %s not readable: %v", z.ReplayDir, err)
+	}
+
+	z.snapshots = nil
+	for _, file := range files {
+		if file.Name() == "commands" || file.Name() == "kstat" {
+			z.snapshots = []string{z.ReplayDir}
+			return nil
+		}
+		if file.IsDir() {
+			z.snapshots = append(z.snapshots, filepath.Join(z.ReplayDir, file.Name()))
+		}
+	}
+	if len(z.snapshots) == 0 {
+		return fmt.Errorf("replay directory %s contains no snapshot", z.ReplayDir)
+	}
+	return nil
+}
+
+// nextSnapshot returns the directory of the snapshot to replay at this
+// interval, and replays the commands recorded in it.
+func (z *Zfs) nextSnapshot() (string, error) {
+	dir := z.snapshots[z.snapshot]
+	z.snapshot = (z.snapshot + 1) % len(z.snapshots)
+
+	runner, err := loadTranscript(filepath.Join(dir, "commands"))
+	if err != nil {
+		return "", err
+	}
+	z.runner = runner
+	return dir, nil
+}
+
+func (z *Zfs) zfsVersion() ([]string, error) {
+	return z.runner.Run("zfs", "version")
+}
+
+// parseUserlandVersion returns the version of the userland tools printed by
+// zfs version, available since ZFS 0.8:
+//
+//   zfs-0.8.3-1ubuntu12
+//   zfs-kmod-0.8.3-1ubuntu12
+func parseUserlandVersion(lines []string) string {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "zfs-") && !strings.HasPrefix(line, "zfs-kmod-") {
+			return strings.TrimPrefix(line, "zfs-")
+		}
+	}
+	return ""
+}
+
+// parseKmodVersion returns the version of the kernel module printed by zfs
+// version.
+func parseKmodVersion(lines []string) string {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "zfs-kmod-") {
+			return strings.TrimPrefix(line, "zfs-kmod-")
+		}
+	}
+	return ""
+}
+
+// addVersionTags adds the tags of the ZFS versions when versionTags is set.
+// The versions are collected on the first call only, so that the series do
+// not change after an upgrade until Telegraf is restarted, like the modules
+// are not reloaded until the host is rebooted.
+func (z *Zfs) addVersionTags(tags map[string]string) {
+	if !z.VersionTags {
+		return
+	}
+
+	if z.versions == nil {
+		z.versions = make(map[string]string)
+		lines, err := z.zfsVersion()
+		if err != nil {
+			z.Log.Warnf("Cannot get the versions for the version tags: %v", err)
+		} else {
+			if userland := parseUserlandVersion(lines); userland != "" {
+				z.versions["zfs_version"] = userland
+			}
+			if kmod := parseKmodVersion(lines); kmod != "" {
+				z.versions["zfs_kmod_version"] = kmod
+			}
+		}
+	}
+
+	for k, v := range z.versions {
+		tags[k] = v
+	}
+}
+
+func init() {
+	// Most kstats are counters, the exceptions are the sizes, limits and
+	// current counts.
+	metric.RegisterFieldType("zfs", telegraf.Gauge,
+		"*_size", "*_cnt", "*_max", "*_min", "*_limit",
+		"*_evictable_data", "*_evictable_metadata",
+		"*_evict_data", "*_evict_metadata",
+		"arcstats_c", "arcstats_p", "arcstats_arc_meta_used",
+		"arcstats_arc_no_grow", "arcstats_arc_tempreserve",
+		"arcstats_arc_loaned_bytes", "arcstats_arc_sys_free",
+		"arcstats_duplicate_buffers", "arcstats_hash_chains",
+		"arcstats_hash_elements", "arcstats_l2_asize",
+		"arcstats_memory_all_bytes", "arcstats_memory_free_bytes",
+		"arcstats_memory_available_bytes")
+	metric.RegisterFieldType("zfs", telegraf.Counter, "*")
+
+	metric.RegisterFieldType("zfs_pool", telegraf.Counter,
+		"nread", "nwritten", "reads", "writes",
+		"rtime", "rlentime", "wtime", "wlentime")
+	metric.RegisterFieldType("zfs_pool", telegraf.Counter,
+		"read_bytes", "write_bytes", "read_ops", "write_ops",
+		"run_time_ns", "run_queue_time_ns", "wait_time_ns", "wait_queue_time_ns")
+	metric.RegisterFieldType("zfs_pool", telegraf.Gauge, "*")
+
+	metric.RegisterFieldType("zfs_gather", telegraf.Gauge, "*")
+}
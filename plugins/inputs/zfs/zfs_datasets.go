@@ -0,0 +1,190 @@
+package zfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+type snapshotInfo struct {
+	used     int64
+	creation int64
+}
+
+// gatherSnapshotEvents lists the snapshots of the watched datasets and
+// reports the ones created or destroyed since the previous interval.  The
+// first listing of a dataset is the reference and reports nothing.
+func (z *Zfs) gatherSnapshotEvents(acc telegraf.Accumulator) {
+	if z.snapshotsSeen == nil {
+		z.snapshotsSeen = make(map[string]map[string]snapshotInfo)
+	}
+
+	for _, dataset := range z.WatchSnapshots {
+		lines, err := z.runner.Run("zfs", "list", "-Hp", "-t", "snapshot",
+			"-o", "name,used,creation", "-d", "1", dataset)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+
+		current := make(map[string]snapshotInfo)
+		for _, line := range lines {
+			cols := strings.Split(line, "\t")
+			if len(cols) != 3 {
+				continue
+			}
+			used, _ := strconv.ParseInt(cols[1], 10, 64)
+			creation, _ := strconv.ParseInt(cols[2], 10, 64)
+			current[cols[0]] = snapshotInfo{used: used, creation: creation}
+		}
+
+		previous, ok := z.snapshotsSeen[dataset]
+		z.snapshotsSeen[dataset] = current
+		if !ok {
+			continue
+		}
+
+		for name, info := range current {
+			if _, found := previous[name]; !found {
+				addSnapshotEvent(acc, dataset, name, "created", info)
+			}
+		}
+		for name, info := range previous {
+			if _, found := current[name]; !found {
+				addSnapshotEvent(acc, dataset, name, "destroyed", info)
+			}
+		}
+	}
+}
+
+func addSnapshotEvent(acc telegraf.Accumulator, dataset, name, event string, info snapshotInfo) {
+	tags := map[string]string{
+		"dataset":  dataset,
+		"snapshot": strings.TrimPrefix(name, dataset+"@"),
+		"event":    event,
+	}
+	fields := map[string]interface{}{
+		"used":     info.used,
+		"creation": info.creation,
+	}
+	acc.AddFields("zfs_snapshot_event", fields, tags)
+}
+
+// gatherClones reports the datasets that are clones of a snapshot.  A clone
+// holds its origin snapshot, which cannot be destroyed until the clone is
+// destroyed or promoted.
+func (z *Zfs) gatherClones(acc telegraf.Accumulator) error {
+	lines, err := z.runner.Run("zfs", "list", "-Hp", "-t", "filesystem,volume",
+		"-o", "name,origin,used,referenced")
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		cols := strings.Split(line, "\t")
+		if len(cols) != 4 || cols[1] == "-" {
+			continue
+		}
+		used, err := strconv.ParseInt(cols[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Error parsing used of %s: %s", cols[0], err)
+		}
+		referenced, err := strconv.ParseInt(cols[3], 10, 64)
+		if err != nil {
+			return fmt.Errorf("Error parsing referenced of %s: %s", cols[0], err)
+		}
+
+		tags := map[string]string{
+			"pool":    strings.SplitN(cols[0], "/", 2)[0],
+			"dataset": cols[0],
+			"origin":  cols[1],
+		}
+		fields := map[string]interface{}{
+			"used":       used,
+			"referenced": referenced,
+		}
+		acc.AddFields("zfs_clone", fields, tags)
+	}
+	return nil
+}
+
+type zvolPool struct {
+	volsize        int64
+	refreservation int64
+	capacity       int64
+}
+
+// gatherZvols reports the zvols, and for each pool the sum of their sizes
+// compared to the capacity of the pool.  The zvols without a refreservation
+// are thin provisioned: writing them can fill the pool even though their
+// size fits in it.
+func (z *Zfs) gatherZvols(acc telegraf.Accumulator) error {
+	lines, err := z.runner.Run("zfs", "list", "-Hp", "-t", "filesystem,volume",
+		"-o", "name,type,volsize,refreservation,used,available")
+	if err != nil {
+		return err
+	}
+
+	pools := make(map[string]*zvolPool)
+	var names []string
+	for _, line := range lines {
+		cols := strings.Split(line, "\t")
+		if len(cols) != 6 {
+			continue
+		}
+		name := cols[0]
+		poolName := strings.SplitN(name, "/", 2)[0]
+		pool, ok := pools[poolName]
+		if !ok {
+			pool = &zvolPool{}
+			pools[poolName] = pool
+			names = append(names, poolName)
+		}
+
+		// The capacity of the pool is the one of its root dataset.
+		if name == poolName {
+			used, _ := strconv.ParseInt(cols[4], 10, 64)
+			available, _ := strconv.ParseInt(cols[5], 10, 64)
+			pool.capacity = used + available
+		}
+		if cols[1] != "volume" {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for i, field := range []string{"volsize", "refreservation", "used"} {
+			value, err := strconv.ParseInt(cols[i+2], 10, 64)
+			if err != nil {
+				return fmt.Errorf("Error parsing %s of %s: %s", field, name, err)
+			}
+			fields[field] = value
+		}
+		pool.volsize += fields["volsize"].(int64)
+		pool.refreservation += fields["refreservation"].(int64)
+
+		tags := map[string]string{
+			"pool":    poolName,
+			"dataset": name,
+		}
+		acc.AddFields("zfs_zvol", fields, tags)
+	}
+
+	for _, name := range names {
+		pool := pools[name]
+		if pool.volsize == 0 {
+			continue
+		}
+		fields := map[string]interface{}{
+			"volsize":        pool.volsize,
+			"refreservation": pool.refreservation,
+			"capacity":       pool.capacity,
+		}
+		if pool.capacity > 0 {
+			fields["overcommit_ratio"] = float64(pool.volsize) / float64(pool.capacity)
+		}
+		acc.AddFields("zfs_zvol_pool", fields, map[string]string{"pool": name})
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package zfs
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const forecastKey = "capacity_history"
+
+// forecastSamples is the number of samples kept in the window, the samples
+// closer than window/forecastSamples to the previous one are not kept.
+const forecastSamples = 128
+
+type allocSample struct {
+	Time      int64 `json:"time"`
+	Allocated int64 `json:"allocated"`
+}
+
+// gatherForecast projects when the pools reach capacityThreshold, from the
+// linear regression of their allocation over capacityWindow.  The history
+// is kept in the state store, so that a restart does not reset it.
+func (z *Zfs) gatherForecast(acc telegraf.Accumulator) error {
+	if z.history == nil {
+		z.history = make(map[string][]allocSample)
+		if z.State != nil {
+			if _, err := z.State.Get(forecastKey, &z.history); err != nil {
+				z.Log.Warnf("Ignoring saved capacity history: %v", err)
+			}
+		}
+	}
+
+	lines, err := z.zpool()
+	if err != nil {
+		return err
+	}
+	stats, err := parseZpoolList(lines, true)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if z.now != nil {
+		now = z.now()
+	}
+	window := z.CapacityWindow.Duration
+	spacing := int64(window / forecastSamples / time.Second)
+
+	pools := make(map[string][]allocSample)
+	for _, pool := range stats {
+		name := pool.tags["pool"]
+		allocated, ok := pool.fields["allocated"].(int64)
+		if !ok {
+			continue
+		}
+		size := pool.fields["size"].(int64)
+
+		history := z.history[name]
+		// Forget the samples out of the window.
+		for len(history) > 0 && now.Unix()-history[0].Time > int64(window/time.Second) {
+			history = history[1:]
+		}
+		if len(history) == 0 || now.Unix()-history[len(history)-1].Time >= spacing {
+			history = append(history, allocSample{Time: now.Unix(), Allocated: allocated})
+		}
+		pools[name] = history
+
+		fields := map[string]interface{}{
+			"samples": len(history),
+		}
+		if slope, ok := allocSlope(history); ok {
+			perDay := slope * 86400
+			fields["growth_per_day"] = perDay
+			threshold := float64(size) * z.CapacityThreshold / 100
+			if perDay > 0 {
+				days := (threshold - float64(allocated)) / perDay
+				if days < 0 {
+					days = 0
+				}
+				fields["days_until_full"] = days
+			}
+		}
+		acc.AddFields("zfs_pool_forecast", fields, map[string]string{"pool": name}, now)
+	}
+
+	// The pools that are gone are forgotten.
+	z.history = pools
+	if z.State != nil {
+		return z.State.Set(forecastKey, z.history)
+	}
+	return nil
+}
+
+// allocSlope returns the slope of the least squares regression of the
+// allocation over time, in bytes per second.  At least three samples are
+// needed.
+func allocSlope(samples []allocSample) (float64, bool) {
+	if len(samples) < 3 {
+		return 0, false
+	}
+
+	t0 := samples[0].Time
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := float64(s.Time - t0)
+		y := float64(s.Allocated)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
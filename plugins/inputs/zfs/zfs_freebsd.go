@@ -3,13 +3,14 @@
 package zfs
 
 import (
-	"bytes"
 	"fmt"
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -28,78 +29,77 @@ func (z *Zfs) gatherPoolStats(acc telegraf.Accumulator) (string, error) {
 	}
 
 	if z.PoolMetrics {
-		for _, line := range lines {
-			col := strings.Split(line, "\t")
-			if len(col) != 8 {
-				continue
-			}
-
-			tags := map[string]string{"pool": col[0], "health": col[1]}
-			fields := map[string]interface{}{}
-
-			if tags["health"] == "UNAVAIL" {
-
-				fields["size"] = int64(0)
-
-			} else {
-
-				size, err := strconv.ParseInt(col[2], 10, 64)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing size: %s", err)
-				}
-				fields["size"] = size
-
-				alloc, err := strconv.ParseInt(col[3], 10, 64)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing allocation: %s", err)
-				}
-				fields["allocated"] = alloc
-
-				free, err := strconv.ParseInt(col[4], 10, 64)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing free: %s", err)
-				}
-				fields["free"] = free
-
-				frag, err := strconv.ParseInt(strings.TrimSuffix(col[5], "%"), 10, 0)
-				if err != nil { // This might be - for RO devs
-					frag = 0
-				}
-				fields["fragmentation"] = frag
-
-				capval, err := strconv.ParseInt(col[6], 10, 0)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing capacity: %s", err)
-				}
-				fields["capacity"] = capval
-
-				dedup, err := strconv.ParseFloat(strings.TrimSuffix(col[7], "x"), 32)
-				if err != nil {
-					return "", fmt.Errorf("Error parsing dedupratio: %s", err)
-				}
-				fields["dedupratio"] = dedup
-			}
-
-			acc.AddFields("zfs_pool", fields, tags)
+		stats, err := parseZpoolList(lines, z.OmitDashValues)
+		if err != nil {
+			return "", err
+		}
+		for _, pool := range stats {
+			z.addVersionTags(pool.tags)
+			acc.AddFields("zfs_pool", pool.fields, pool.tags)
 		}
 	}
 
 	return strings.Join(pools, "::"), nil
 }
 
+// Init checks that the zpool command is available and that the configured
+// kstat metrics exist, so that a misconfiguration fails at startup rather
+// than at every interval.
+func (z *Zfs) Init() error {
+	if len(z.RemoteHosts) > 0 {
+		return z.initRemote()
+	}
+	if z.ReplayDir != "" {
+		return z.initReplay()
+	}
+
+	if _, err := exec.LookPath("zpool"); err != nil {
+		return fmt.Errorf("zpool not found: verify that ZFS is installed and that zpool is in your PATH")
+	}
+
+	var missing []string
+	for _, metric := range z.KstatMetrics {
+		if _, err := z.sysctl(metric); err != nil {
+			missing = append(missing, metric)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("kstat metrics %s not found in kstat.zfs.misc", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 func (z *Zfs) Gather(acc telegraf.Accumulator) error {
+	if len(z.RemoteHosts) > 0 {
+		z.gatherRemote(acc)
+		return nil
+	}
+
+	start := time.Now()
+	timings := make(map[string]interface{})
+
 	kstatMetrics := z.KstatMetrics
 	if len(kstatMetrics) == 0 {
 		kstatMetrics = []string{"arcstats", "zfetchstats", "vdev_cache_stats"}
 	}
 
+	if z.ReplayDir != "" {
+		if _, err := z.nextSnapshot(); err != nil {
+			return err
+		}
+	}
+
 	tags := map[string]string{}
+	zpoolStart := time.Now()
 	poolNames, err := z.gatherPoolStats(acc)
 	if err != nil {
 		return err
 	}
+	timings["zpool_time_ns"] = time.Since(zpoolStart).Nanoseconds()
 	tags["pools"] = poolNames
+	z.addVersionTags(tags)
 
+	sysctlStart := time.Now()
 	fields := make(map[string]interface{})
 	for _, metric := range kstatMetrics {
 		stdout, err := z.sysctl(metric)
@@ -114,38 +114,45 @@ func (z *Zfs) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 	acc.AddFields("zfs", fields, tags)
-	return nil
-}
-
-func run(command string, args ...string) ([]string, error) {
-	cmd := exec.Command(command, args...)
-	var outbuf, errbuf bytes.Buffer
-	cmd.Stdout = &outbuf
-	cmd.Stderr = &errbuf
-	err := cmd.Run()
+	timings["sysctl_time_ns"] = time.Since(sysctlStart).Nanoseconds()
 
-	stdout := strings.TrimSpace(outbuf.String())
-	stderr := strings.TrimSpace(errbuf.String())
-
-	if _, ok := err.(*exec.ExitError); ok {
-		return nil, fmt.Errorf("%s error: %s", command, stderr)
+	if z.CapacityForecast {
+		if err := z.gatherForecast(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if len(z.WatchSnapshots) > 0 {
+		z.gatherSnapshotEvents(acc)
+	}
+	if z.CloneMetrics {
+		if err := z.gatherClones(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if z.ZvolMetrics {
+		if err := z.gatherZvols(acc); err != nil {
+			acc.AddError(err)
+		}
 	}
-	return strings.Split(stdout, "\n"), nil
-}
 
-func zpool() ([]string, error) {
-	return run("zpool", []string{"list", "-Hp", "-o", "name,health,size,alloc,free,fragmentation,capacity,dedupratio"}...)
+	if z.TimingMetrics {
+		timings["gather_time_ns"] = time.Since(start).Nanoseconds()
+		acc.AddFields("zfs_gather", timings, nil)
+	}
+	return nil
 }
 
-func sysctl(metric string) ([]string, error) {
-	return run("sysctl", []string{"-q", fmt.Sprintf("kstat.zfs.misc.%s", metric)}...)
+func (z *Zfs) sysctl(metric string) ([]string, error) {
+	return z.runner.Run("sysctl", []string{"-q", fmt.Sprintf("kstat.zfs.misc.%s", metric)}...)
 }
 
 func init() {
 	inputs.Add("zfs", func() telegraf.Input {
 		return &Zfs{
-			sysctl: sysctl,
-			zpool:  zpool,
+			RemoteConcurrency: 4,
+			CapacityThreshold: 90,
+			CapacityWindow:    internal.Duration{Duration: 7 * 24 * time.Hour},
+			runner:            execRunner{},
 		}
 	})
 }
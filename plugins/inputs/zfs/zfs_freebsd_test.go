@@ -3,7 +3,6 @@
 package zfs
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -18,19 +17,11 @@ var zpool_output = []string{
 	"temp2	ONLINE	2989297238016	626958278656	2362338959360	12%	20	1.00x",
 }
 
-func mock_zpool() ([]string, error) {
-	return zpool_output, nil
-}
-
 // $ zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio
 var zpool_output_unavail = []string{
 	"temp2	UNAVAIL	-	-	-	-	-	-",
 }
 
-func mock_zpool_unavail() ([]string, error) {
-	return zpool_output_unavail, nil
-}
-
 // sysctl -q kstat.zfs.misc.arcstats
 
 // sysctl -q kstat.zfs.misc.vdev_cache_stats
@@ -47,14 +38,12 @@ var kstat_zfetchstats_output = []string{
 	"kstat.zfs.misc.zfetchstats.hits: 0",
 }
 
-func mock_sysctl(metric string) ([]string, error) {
-	if metric == "vdev_cache_stats" {
-		return kstat_vdev_cache_stats_output, nil
-	}
-	if metric == "zfetchstats" {
-		return kstat_zfetchstats_output, nil
-	}
-	return []string{}, fmt.Errorf("Invalid arg")
+func mock_runner(zpool []string) *fixtureRunner {
+	return &fixtureRunner{outputs: map[string][]string{
+		"zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio": zpool,
+		"sysctl -q kstat.zfs.misc.vdev_cache_stats":                                       kstat_vdev_cache_stats_output,
+		"sysctl -q kstat.zfs.misc.zfetchstats":                                            kstat_zfetchstats_output,
+	}}
 }
 
 func TestZfsPoolMetrics(t *testing.T) {
@@ -62,8 +51,7 @@ func TestZfsPoolMetrics(t *testing.T) {
 
 	z := &Zfs{
 		KstatMetrics: []string{"vdev_cache_stats"},
-		sysctl:       mock_sysctl,
-		zpool:        mock_zpool,
+		runner:       mock_runner(zpool_output),
 	}
 	err := z.Gather(&acc)
 	require.NoError(t, err)
@@ -74,8 +62,7 @@ func TestZfsPoolMetrics(t *testing.T) {
 	z = &Zfs{
 		KstatMetrics: []string{"vdev_cache_stats"},
 		PoolMetrics:  true,
-		sysctl:       mock_sysctl,
-		zpool:        mock_zpool,
+		runner:       mock_runner(zpool_output),
 	}
 	err = z.Gather(&acc)
 	require.NoError(t, err)
@@ -97,8 +84,7 @@ func TestZfsPoolMetrics_unavail(t *testing.T) {
 
 	z := &Zfs{
 		KstatMetrics: []string{"vdev_cache_stats"},
-		sysctl:       mock_sysctl,
-		zpool:        mock_zpool_unavail,
+		runner:       mock_runner(zpool_output_unavail),
 	}
 	err := z.Gather(&acc)
 	require.NoError(t, err)
@@ -109,8 +95,7 @@ func TestZfsPoolMetrics_unavail(t *testing.T) {
 	z = &Zfs{
 		KstatMetrics: []string{"vdev_cache_stats"},
 		PoolMetrics:  true,
-		sysctl:       mock_sysctl,
-		zpool:        mock_zpool_unavail,
+		runner:       mock_runner(zpool_output_unavail),
 	}
 	err = z.Gather(&acc)
 	require.NoError(t, err)
@@ -131,8 +116,7 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 
 	z := &Zfs{
 		KstatMetrics: []string{"vdev_cache_stats"},
-		sysctl:       mock_sysctl,
-		zpool:        mock_zpool,
+		runner:       mock_runner(zpool_output),
 	}
 	err := z.Gather(&acc)
 	require.NoError(t, err)
@@ -149,8 +133,7 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 
 	z = &Zfs{
 		KstatMetrics: []string{"zfetchstats", "vdev_cache_stats"},
-		sysctl:       mock_sysctl,
-		zpool:        mock_zpool,
+		runner:       mock_runner(zpool_output),
 	}
 	err = z.Gather(&acc)
 	require.NoError(t, err)
@@ -196,3 +179,69 @@ func getKstatMetricsVdevAndZfetch() map[string]interface{} {
 		"zfetchstats_hits":             int64(0),
 	}
 }
+
+func TestZfsTimingMetrics(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		KstatMetrics:  []string{"vdev_cache_stats"},
+		TimingMetrics: true,
+		runner:        mock_runner(zpool_output),
+	}
+	err := z.Gather(&acc)
+	require.NoError(t, err)
+
+	for _, field := range []string{"gather_time_ns", "zpool_time_ns", "sysctl_time_ns"} {
+		require.True(t, acc.HasInt64Field("zfs_gather", field), field)
+	}
+}
+
+func TestZfsPoolMetrics_omitDashValues(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		KstatMetrics:   []string{"vdev_cache_stats"},
+		PoolMetrics:    true,
+		OmitDashValues: true,
+		runner:         mock_runner(zpool_output),
+	}
+	err := z.Gather(&acc)
+	require.NoError(t, err)
+
+	//the fragmentation of freenas-boot is -
+	tags := map[string]string{
+		"pool":   "freenas-boot",
+		"health": "ONLINE",
+	}
+	poolMetrics := getFreeNasBootPoolMetrics()
+	delete(poolMetrics, "fragmentation")
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics, tags)
+}
+
+func TestZfsPoolMetrics_decimalComma(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		KstatMetrics: []string{"vdev_cache_stats"},
+		PoolMetrics:  true,
+		runner: mock_runner([]string{
+			"red1	ONLINE	8933531975680	1126164848640	7807367127040	8%	12	1,83x",
+		}),
+	}
+	err := z.Gather(&acc)
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"pool":   "red1",
+		"health": "ONLINE",
+	}
+	acc.AssertContainsTaggedFields(t, "zfs_pool", map[string]interface{}{
+		"allocated":     int64(1126164848640),
+		"capacity":      int64(12),
+		"dedupratio":    float64(float32(1.83)),
+		"free":          int64(7807367127040),
+		"size":          int64(8933531975680),
+		"fragmentation": int64(8),
+	}, tags)
+}
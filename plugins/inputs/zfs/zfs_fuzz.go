@@ -0,0 +1,24 @@
+// +build gofuzz,linux
+
+package zfs
+
+import "strings"
+
+// Fuzz is the entry point of go-fuzz for the kstat parsers:
+//
+//   go-fuzz-build github.com/influxdata/telegraf/plugins/inputs/zfs
+//   go-fuzz -bin zfs-fuzz.zip -workdir /tmp/zfs-fuzz
+//
+// The kstats captured in testdata are a starting corpus, copied to the
+// corpus directory of the workdir.
+func Fuzz(data []byte) int {
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines {
+		parseKstatLine(line)
+	}
+	fields, err := parsePoolStats(lines)
+	if err != nil || fields == nil {
+		return 0
+	}
+	return 1
+}
@@ -4,9 +4,13 @@ package zfs
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
@@ -44,14 +48,29 @@ func getTags(pools []poolInfo) map[string]string {
 	return map[string]string{"pools": poolNames}
 }
 
-func gatherPoolStats(pool poolInfo, acc telegraf.Accumulator) error {
+// readPoolStats returns the fields of the io kstat of the pool, nil when the
+// kstat is incomplete.
+func readPoolStats(pool poolInfo) (map[string]interface{}, error) {
 	lines, err := internal.ReadLines(pool.ioFilename)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return parsePoolStats(lines)
+}
+
+// readPoolState returns the health of the pool from its state kstat, added
+// in ZFS 0.8, or "" if it is not available.
+func readPoolState(pool poolInfo) string {
+	state, err := ioutil.ReadFile(filepath.Join(filepath.Dir(pool.ioFilename), "state"))
+	if err != nil {
+		return ""
 	}
+	return strings.TrimSpace(string(state))
+}
 
+func parsePoolStats(lines []string) (map[string]interface{}, error) {
 	if len(lines) != 3 {
-		return err
+		return nil, nil
 	}
 
 	keys := strings.Fields(lines[1])
@@ -60,79 +79,742 @@ func gatherPoolStats(pool poolInfo, acc telegraf.Accumulator) error {
 	keyCount := len(keys)
 
 	if keyCount != len(values) {
-		return fmt.Errorf("Key and value count don't match Keys:%v Values:%v", keys, values)
+		return nil, fmt.Errorf("Key and value count don't match Keys:%v Values:%v", keys, values)
 	}
 
-	tag := map[string]string{"pool": pool.name}
 	fields := make(map[string]interface{})
 	for i := 0; i < keyCount; i++ {
 		value, err := strconv.ParseInt(values[i], 10, 64)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fields[keys[i]] = value
 	}
-	acc.AddFields("zfs_pool", fields, tag)
+	return fields, nil
+}
+
+// poolFieldNames maps the names of the pool io kstat fields, which are the
+// names of the kstat_io_t members of Solaris, to the names reported unless
+// legacyFieldNames is enabled.
+var poolFieldNames = map[string]string{
+	"nread":    "read_bytes",
+	"nwritten": "write_bytes",
+	"reads":    "read_ops",
+	"writes":   "write_ops",
+	"wtime":    "wait_time_ns",
+	"wlentime": "wait_queue_time_ns",
+	"wupdate":  "wait_updated_ns",
+	"rtime":    "run_time_ns",
+	"rlentime": "run_queue_time_ns",
+	"rupdate":  "run_updated_ns",
+	"wcnt":     "wait_queue_length",
+	"rcnt":     "run_queue_length",
+}
+
+func renamePoolFields(fields map[string]interface{}) map[string]interface{} {
+	renamed := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if name, ok := poolFieldNames[key]; ok {
+			key = name
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
+
+// checkPools compares the pools found in the kstats with the pools listed
+// by zpool, which differ while a pool is imported, exported or destroyed,
+// and returns the pools found in both.  A zfs_pool_mismatch metric is added
+// for each pool found in only one of them.  When zpool fails, the pools of
+// the kstats are returned.
+func (z *Zfs) checkPools(pools []poolInfo, acc telegraf.Accumulator) []poolInfo {
+	lines, err := z.zpool()
+	if err != nil {
+		acc.AddError(fmt.Errorf("checking the pools: %v", err))
+		return pools
+	}
+
+	listed := make(map[string]bool)
+	for _, line := range lines {
+		// zpool lists no pools with an empty output.
+		if line == "" {
+			continue
+		}
+		listed[strings.Split(line, "\t")[0]] = true
+	}
+
+	checked := make([]poolInfo, 0, len(pools))
+	for _, pool := range pools {
+		if listed[pool.name] {
+			checked = append(checked, pool)
+			delete(listed, pool.name)
+			continue
+		}
+		acc.AddFields("zfs_pool_mismatch",
+			map[string]interface{}{"in_kstat": true, "in_zpool": false},
+			map[string]string{"pool": pool.name})
+	}
+	for name := range listed {
+		acc.AddFields("zfs_pool_mismatch",
+			map[string]interface{}{"in_kstat": false, "in_zpool": true},
+			map[string]string{"pool": name})
+	}
+	return checked
+}
+
+type poolStats struct {
+	fields map[string]interface{}
+	health string
+	err    error
+}
+
+// gatherPoolStats reads the pools concurrently, with at most poolWorkers
+// reads at a time, so that a pool whose kstats are slow to read does not
+// delay the others.  A pool that is not read within poolTimeout is reported
+// as an error; its read is abandoned and releases its worker.  No other read
+// of the pool is started until it completes, so that a pool whose reads
+// block does not leak a goroutine at every interval.
+func (z *Zfs) gatherPoolStats(pools []poolInfo, acc telegraf.Accumulator) {
+	z.pendingMu.Lock()
+	if z.pendingPools == nil {
+		z.pendingPools = make(map[string]bool)
+	}
+	z.pendingMu.Unlock()
+
+	workers := z.PoolWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	for _, pool := range pools {
+		tags := map[string]string{"pool": pool.name}
+		z.addVersionTags(tags)
+
+		z.pendingMu.Lock()
+		pending := z.pendingPools[pool.name]
+		z.pendingPools[pool.name] = true
+		z.pendingMu.Unlock()
+		if pending {
+			acc.AddError(fmt.Errorf("pool %s: previous read still pending", pool.name))
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(pool poolInfo, tags map[string]string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			result := make(chan poolStats, 1)
+			go func() {
+				fields, err := readPoolStats(pool)
+				health := readPoolState(pool)
+
+				// The pool is released before its result is sent, so that a
+				// gather starting as soon as this one returns does not find
+				// it pending.
+				z.pendingMu.Lock()
+				delete(z.pendingPools, pool.name)
+				z.pendingMu.Unlock()
+
+				result <- poolStats{fields: fields, health: health, err: err}
+			}()
 
+			var timeout <-chan time.Time
+			if z.PoolTimeout.Duration > 0 {
+				timer := time.NewTimer(z.PoolTimeout.Duration)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+
+			select {
+			case stats := <-result:
+				if stats.err != nil {
+					acc.AddError(fmt.Errorf("pool %s: %v", pool.name, stats.err))
+				} else if stats.fields != nil {
+					if !z.LegacyFieldNames {
+						stats.fields = renamePoolFields(stats.fields)
+					}
+					if z.PoolHealthTag && stats.health != "" {
+						tags["health"] = stats.health
+					}
+					acc.AddFields("zfs_pool", stats.fields, tags)
+				}
+			case <-timeout:
+				acc.AddError(fmt.Errorf("pool %s: timeout after %s", pool.name, z.PoolTimeout.Duration))
+			}
+		}(pool, tags)
+	}
+	wg.Wait()
+}
+
+// Init checks that the ZFS kstats are available, and that the configured
+// kstat metrics exist, so that a misconfiguration fails at startup rather
+// than by silently gathering nothing.
+func (z *Zfs) Init() error {
+	if len(z.RemoteHosts) > 0 {
+		return z.initRemote()
+	}
+	if z.KstatDiscover && len(z.KstatMetrics) > 0 {
+		return fmt.Errorf("kstatMetrics cannot be set when kstatDiscover is enabled")
+	}
+	if z.ReplayDir != "" {
+		return z.initReplay()
+	}
+
+	kstatPath := z.KstatPath
+	if len(kstatPath) == 0 {
+		kstatPath = "/proc/spl/kstat/zfs"
+	}
+
+	info, err := os.Stat(kstatPath)
+	if err != nil {
+		return fmt.Errorf("kstat path %s not readable, check that the zfs module is loaded: %v", kstatPath, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("kstat path %s is not a directory", kstatPath)
+	}
+
+	var missing []string
+	for _, metric := range z.KstatMetrics {
+		if _, err := os.Stat(filepath.Join(kstatPath, metric)); err != nil {
+			missing = append(missing, metric)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("kstat metrics %s not found in %s, available are: %s",
+			strings.Join(missing, ", "), kstatPath, strings.Join(listKstats(kstatPath), ", "))
+	}
 	return nil
 }
 
+// listKstats returns the kstat metrics of the directory, pools are
+// directories and are not included.
+func listKstats(kstatPath string) []string {
+	files, _ := ioutil.ReadDir(kstatPath)
+	names := []string{}
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names
+}
+
+// isNamedKstat tells whether the header line of a kstat file is the one of a
+// named kstat, the type is the second column:
+//
+//   13 1 0x01 147 39984 5591575838 209785486744
+//
+// The other types, like the raw dbgmsg or the benchmark tables, are not
+// lists of name and value.
+func isNamedKstat(header string) bool {
+	cols := strings.Fields(header)
+	return len(cols) > 1 && cols[1] == "1"
+}
+
+// parseKstatLine returns the name and the value of a line of a named kstat:
+//
+//   hits                            4    5968846374
+//
+// It slices the line rather than splitting it, as it is called for every
+// kstat at every interval.  Values that are not integers are reported as 0.
+func parseKstatLine(line string) (string, int64, bool) {
+	end := strings.IndexByte(line, ' ')
+	if end < 1 {
+		return "", 0, false
+	}
+	value, _ := strconv.ParseInt(line[strings.LastIndexByte(line, ' ')+1:], 10, 64)
+	return line[:end], value, true
+}
+
 func (z *Zfs) Gather(acc telegraf.Accumulator) error {
+	if len(z.RemoteHosts) > 0 {
+		z.gatherRemote(acc)
+		return nil
+	}
+
+	start := time.Now()
+	timings := make(map[string]interface{})
+
 	kstatMetrics := z.KstatMetrics
 	if len(kstatMetrics) == 0 {
 		// vdev_cache_stats is deprecated
 		// xuio_stats are ignored because as of Sep-2016, no known
 		// consumers of xuio exist on Linux
+		// qat is only present with Intel QuickAssist offload
 		kstatMetrics = []string{"abdstats", "arcstats", "dnodestats", "dbufcachestats",
-			"dmu_tx", "fm", "vdev_mirror_stats", "zfetchstats", "zil"}
+			"dmu_tx", "fm", "qat", "vdev_mirror_stats", "zfetchstats", "zil"}
 	}
 
 	kstatPath := z.KstatPath
 	if len(kstatPath) == 0 {
 		kstatPath = "/proc/spl/kstat/zfs"
 	}
+	if z.ReplayDir != "" {
+		dir, err := z.nextSnapshot()
+		if err != nil {
+			return err
+		}
+		kstatPath = filepath.Join(dir, "kstat")
+		z.sysModulePath = filepath.Join(dir, "module")
+		z.meminfoPath = filepath.Join(dir, "meminfo")
+	}
+	if z.KstatDiscover {
+		kstatMetrics = listKstats(kstatPath)
+	}
 
 	pools := getPools(kstatPath)
 	tags := getTags(pools)
+	z.addVersionTags(tags)
 
 	if z.PoolMetrics {
-		for _, pool := range pools {
-			err := gatherPoolStats(pool, acc)
-			if err != nil {
-				return err
-			}
+		poolsStart := time.Now()
+		if z.CheckPools {
+			pools = z.checkPools(pools, acc)
 		}
+		z.gatherPoolStats(pools, acc)
+		timings["pools_time_ns"] = time.Since(poolsStart).Nanoseconds()
+	}
+	if z.DatasetMetrics {
+		z.gatherDatasets(pools, acc)
 	}
 
+	kstatsStart := time.Now()
 	fields := make(map[string]interface{})
 	for _, metric := range kstatMetrics {
 		lines, err := internal.ReadLines(kstatPath + "/" + metric)
 		if err != nil {
+			z.Log.Debugf("Skipping %s: %v", metric, err)
+			continue
+		}
+		if z.KstatDiscover && (len(lines) == 0 || !isNamedKstat(lines[0])) {
 			continue
 		}
 		for i, line := range lines {
 			if i == 0 || i == 1 {
 				continue
 			}
-			if len(line) < 1 {
+			name, value, ok := parseKstatLine(line)
+			if !ok {
 				continue
 			}
-			rawData := strings.Split(line, " ")
-			key := metric + "_" + rawData[0]
+			key := metric + "_" + name
 			if metric == "zil" || metric == "dmu_tx" || metric == "dnodestats" {
-				key = rawData[0]
+				key = name
 			}
-			rawValue := rawData[len(rawData)-1]
-			value, _ := strconv.ParseInt(rawValue, 10, 64)
 			fields[key] = value
 		}
 	}
 	acc.AddFields("zfs", fields, tags)
+	timings["kstats_time_ns"] = time.Since(kstatsStart).Nanoseconds()
+
+	if z.VersionMetrics {
+		versionStart := time.Now()
+		z.gatherVersion(acc)
+		timings["version_time_ns"] = time.Since(versionStart).Nanoseconds()
+	}
+
+	if z.MemoryMetrics {
+		if err := z.gatherMemory(acc, kstatPath); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if z.IcpMetrics {
+		z.gatherIcp(acc, kstatPath)
+	}
+	if z.CapacityForecast {
+		if err := z.gatherForecast(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if len(z.WatchSnapshots) > 0 {
+		z.gatherSnapshotEvents(acc)
+	}
+	if z.CloneMetrics {
+		if err := z.gatherClones(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if z.ZvolMetrics {
+		if err := z.gatherZvols(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if z.TimingMetrics {
+		timings["gather_time_ns"] = time.Since(start).Nanoseconds()
+		acc.AddFields("zfs_gather", timings, nil)
+	}
+	return nil
+}
+
+// gatherVersion reports the versions of the loaded zfs and spl modules and of
+// the userland tools.  They differ when the tools have been upgraded without
+// reloading the modules, or when DKMS failed to build the new modules.
+func (z *Zfs) gatherVersion(acc telegraf.Accumulator) {
+	sysModulePath := z.sysModulePath
+	if len(sysModulePath) == 0 {
+		sysModulePath = "/sys/module"
+	}
+
+	fields := make(map[string]interface{})
+	kmod := readVersion(filepath.Join(sysModulePath, "zfs", "version"))
+	if kmod != "" {
+		fields["kmod_version"] = kmod
+	}
+	if spl := readVersion(filepath.Join(sysModulePath, "spl", "version")); spl != "" {
+		fields["spl_version"] = spl
+	}
+
+	// zfs version is not available before ZFS 0.8.
+	lines, err := z.zfsVersion()
+	if err != nil {
+		z.Log.Debugf("Cannot get the userland version: %v", err)
+	} else if userland := parseUserlandVersion(lines); userland != "" {
+		fields["userland_version"] = userland
+		if kmod != "" {
+			fields["version_mismatch"] = userland != kmod
+		}
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields("zfs_version", fields, nil)
+	}
+}
+
+// gatherMemory reports how the memory is split between the ARC and the rest
+// of the memory.  The ARC is not part of the page cache: it is counted as
+// used memory by free and top, and it shrinks under memory pressure, later
+// than the page cache.
+func (z *Zfs) gatherMemory(acc telegraf.Accumulator, kstatPath string) error {
+	lines, err := internal.ReadLines(filepath.Join(kstatPath, "arcstats"))
+	if err != nil {
+		return err
+	}
+	var arc int64
+	for _, line := range lines {
+		if name, value, ok := parseKstatLine(line); ok && name == "size" {
+			arc = value
+		}
+	}
+
+	meminfoPath := z.meminfoPath
+	if len(meminfoPath) == 0 {
+		meminfoPath = "/proc/meminfo"
+	}
+	lines, err = internal.ReadLines(meminfoPath)
+	if err != nil {
+		return err
+	}
+	// The values are in kB:
+	//
+	//   MemTotal:       16303136 kB
+	meminfo := make(map[string]int64)
+	for _, line := range lines {
+		cols := strings.Fields(line)
+		if len(cols) < 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(cols[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		meminfo[strings.TrimSuffix(cols[0], ":")] = value * 1024
+	}
+
+	total := meminfo["MemTotal"]
+	free := meminfo["MemFree"]
+	pageCache := meminfo["Buffers"] + meminfo["Cached"]
+	anonymous := meminfo["AnonPages"]
+	fields := map[string]interface{}{
+		"total":      total,
+		"free":       free,
+		"arc":        arc,
+		"page_cache": pageCache,
+		"anonymous":  anonymous,
+		"other":      total - free - arc - pageCache - anonymous,
+	}
+	if total > 0 {
+		fields["arc_percent"] = float64(arc) / float64(total) * 100
+	}
+	acc.AddFields("zfs_memory", fields, nil)
 	return nil
 }
 
+// hardwareImpls are the implementations of the ICP using the CPU extensions.
+var hardwareImpls = map[string][]string{
+	"aes": {"aesni"},
+	"gcm": {"avx", "pclmulqdq"},
+}
+
+// gatherIcp reports whether the encryption of ZFS uses the CPU extensions.
+// The implementations of the ICP are listed in its module parameters, the
+// selected one between brackets:
+//
+//   cycle [fastest] generic x86_64 aesni
+//
+// fastest selects the CPU extensions when they are available.
+func (z *Zfs) gatherIcp(acc telegraf.Accumulator, kstatPath string) {
+	sysModulePath := z.sysModulePath
+	if len(sysModulePath) == 0 {
+		sysModulePath = "/sys/module"
+	}
+
+	fields := make(map[string]interface{})
+	for _, algorithm := range []string{"aes", "gcm"} {
+		impls := strings.Fields(readVersion(filepath.Join(sysModulePath, "icp", "parameters", "icp_"+algorithm+"_impl")))
+		if len(impls) == 0 {
+			continue
+		}
+
+		var selected string
+		available := make(map[string]bool)
+		for _, impl := range impls {
+			if strings.HasPrefix(impl, "[") {
+				impl = strings.Trim(impl, "[]")
+				selected = impl
+			}
+			available[impl] = true
+		}
+		accelerated := false
+		for _, hw := range hardwareImpls[algorithm] {
+			if selected == hw || (selected == "fastest" && available[hw]) {
+				accelerated = true
+			}
+		}
+		fields[algorithm+"_impl"] = selected
+		fields[algorithm+"_accelerated"] = accelerated
+	}
+
+	// The kcf kstats are next to the zfs ones.
+	kcfStats, _ := filepath.Glob(filepath.Join(filepath.Dir(kstatPath), "kcf", "*"))
+	for _, kstat := range kcfStats {
+		lines, err := internal.ReadLines(kstat)
+		if err != nil || len(lines) == 0 || !isNamedKstat(lines[0]) {
+			continue
+		}
+		for _, line := range lines[2:] {
+			if name, value, ok := parseKstatLine(line); ok {
+				if !strings.HasPrefix(name, "kcf_") {
+					name = "kcf_" + name
+				}
+				fields[name] = value
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields("zfs_icp", fields, nil)
+	}
+}
+
+func readVersion(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// gatherDatasets reports the objset kstats of the pools, one per dataset.
+// The dataset name is in the kstats since ZFS 0.8.3, before it is resolved
+// from the objset ID in the file name.
+func (z *Zfs) gatherDatasets(pools []poolInfo, acc telegraf.Accumulator) {
+	if z.datasetNames == nil {
+		z.datasetNames = newObjsetResolver(z.zfsListObjsets, objsetNamesMaxAge).resolve
+	}
+
+	for _, pool := range pools {
+		files, err := filepath.Glob(filepath.Join(filepath.Dir(pool.ioFilename), "objset-0x*"))
+		if err != nil {
+			acc.AddError(fmt.Errorf("pool %s: %v", pool.name, err))
+			continue
+		}
+
+		type objset struct {
+			name   string
+			fields map[string]interface{}
+		}
+		objsets := make(map[uint64]*objset)
+		var unnamed []uint64
+		for _, file := range files {
+			id, err := parseObjsetID(filepath.Base(file))
+			if err != nil {
+				continue
+			}
+			lines, err := internal.ReadLines(file)
+			if err != nil || len(lines) < 2 || !isNamedKstat(lines[0]) {
+				// The kstat of a destroyed dataset may disappear while it
+				// is read.
+				continue
+			}
+
+			set := &objset{fields: make(map[string]interface{})}
+			for _, line := range lines[2:] {
+				if strings.HasPrefix(line, "dataset_name ") {
+					set.name = kstatString(line)
+					continue
+				}
+				if name, value, ok := parseKstatLine(line); ok {
+					set.fields[name] = value
+				}
+			}
+			if set.name == "" {
+				unnamed = append(unnamed, id)
+			}
+			objsets[id] = set
+		}
+
+		if len(unnamed) > 0 {
+			names, err := z.datasetNames(pool.name, unnamed)
+			if err != nil {
+				acc.AddError(fmt.Errorf("pool %s: resolving datasets: %v", pool.name, err))
+			}
+			for id, name := range names {
+				objsets[id].name = name
+			}
+		}
+
+		for id, set := range objsets {
+			if set.name == "" {
+				z.Log.Debugf("Skipping objset 0x%x of pool %s: unknown dataset", id, pool.name)
+				continue
+			}
+			tags := map[string]string{"pool": pool.name, "dataset": set.name}
+			z.addVersionTags(tags)
+			acc.AddFields("zfs_dataset", set.fields, tags)
+		}
+	}
+}
+
+// kstatString returns the value of a line of a named kstat of type string,
+// which may contain spaces:
+//
+//   dataset_name                    7    tank/home
+func kstatString(line string) string {
+	for i := 0; i < 2; i++ {
+		line = strings.TrimLeft(line, " \t")
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			return ""
+		}
+		line = line[end:]
+	}
+	return strings.TrimSpace(line)
+}
+
+// objsetNamesMaxAge is how long the dataset names of a pool are cached, a
+// renamed dataset keeps its objset ID and is reported under its new name
+// once they are listed again.
+const objsetNamesMaxAge = 10 * time.Minute
+
+// objsetResolver resolves the objset IDs of the objset-0x<id> kstats of the
+// pools to dataset names.  The names are listed with zfs list, which is slow
+// with thousands of datasets, so they are cached per pool and listed again
+// when an unknown objset appears, that is when a dataset is created, or after
+// maxAge.  The objsets of destroyed datasets are dropped from the cache.
+type objsetResolver struct {
+	list   func(pool string) ([]string, error)
+	maxAge time.Duration
+	pools  map[string]map[uint64]string
+	listed map[string]time.Time
+	now    func() time.Time
+}
+
+func newObjsetResolver(list func(pool string) ([]string, error), maxAge time.Duration) *objsetResolver {
+	return &objsetResolver{
+		list:   list,
+		maxAge: maxAge,
+		pools:  make(map[string]map[uint64]string),
+		listed: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// resolve returns the dataset names of the objsets of the pool.  The IDs
+// that cannot be resolved are not in the result.
+func (r *objsetResolver) resolve(pool string, ids []uint64) (map[uint64]string, error) {
+	now := r.now()
+	names, ok := r.pools[pool]
+	if now.Sub(r.listed[pool]) >= r.maxAge {
+		ok = false
+	}
+	for _, id := range ids {
+		if _, found := names[id]; !found {
+			ok = false
+			break
+		}
+	}
+
+	if !ok {
+		lines, err := r.list(pool)
+		if err != nil {
+			return nil, err
+		}
+		r.listed[pool] = now
+		names = make(map[uint64]string)
+		for _, line := range lines {
+			cols := strings.SplitN(line, "\t", 2)
+			if len(cols) != 2 {
+				continue
+			}
+			id, err := strconv.ParseUint(cols[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			names[id] = cols[1]
+		}
+	}
+
+	// Only the objsets still in the kstats are kept, the ones that zfs list
+	// does not know are cached with an empty name so that they do not cause
+	// a listing at every interval.
+	cached := make(map[uint64]string, len(ids))
+	resolved := make(map[uint64]string, len(ids))
+	for _, id := range ids {
+		name := names[id]
+		cached[id] = name
+		if name != "" {
+			resolved[id] = name
+		}
+	}
+	r.pools[pool] = cached
+	return resolved, nil
+}
+
+// parseObjsetID returns the ID of an objset kstat file name, objset-0x36.
+func parseObjsetID(filename string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(filename, "objset-0x"), 16, 64)
+}
+
+func (z *Zfs) zfsListObjsets(pool string) ([]string, error) {
+	return z.runner.Run("zfs", "list", "-H", "-p", "-r", "-t", "filesystem,volume",
+		"-o", "objsetid,name", pool)
+}
+
+func hostProc() string {
+	if procPath := os.Getenv("HOST_PROC"); procPath != "" {
+		return procPath
+	}
+	return "/proc"
+}
+
 func init() {
 	inputs.Add("zfs", func() telegraf.Input {
-		return &Zfs{}
+		return &Zfs{
+			PoolWorkers:       4,
+			PoolTimeout:       internal.Duration{Duration: 5 * time.Second},
+			RemoteConcurrency: 4,
+			LegacyFieldNames:  true,
+			CapacityThreshold: 90,
+			CapacityWindow:    internal.Duration{Duration: 7 * 24 * time.Hour},
+			meminfoPath:       filepath.Join(hostProc(), "meminfo"),
+			runner:            execRunner{},
+		}
 	})
 }
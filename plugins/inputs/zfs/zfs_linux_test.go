@@ -3,10 +3,19 @@
 package zfs
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -262,14 +271,14 @@ func TestZfsPoolMetrics(t *testing.T) {
 
 	var acc testutil.Accumulator
 
-	z := &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}}
+	z := &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}}
 	err = z.Gather(&acc)
 	require.NoError(t, err)
 
 	require.False(t, acc.HasMeasurement("zfs_pool"))
 	acc.Metrics = nil
 
-	z = &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true}
+	z = &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true}
 	err = z.Gather(&acc)
 	require.NoError(t, err)
 
@@ -279,6 +288,34 @@ func TestZfsPoolMetrics(t *testing.T) {
 	}
 
 	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics, tags)
+	acc.Metrics = nil
+
+	// The existing configurations keep the kstat names.
+	z = &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true, LegacyFieldNames: true}
+	err = z.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getLegacyPoolMetrics(), tags)
+	acc.Metrics = nil
+
+	// The health is read from the state kstat of ZFS 0.8 and later, and
+	// only tagged when enabled.
+	err = ioutil.WriteFile(testKstatPath+"/HOME/state", []byte("DEGRADED\n"), 0644)
+	require.NoError(t, err)
+	z = &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true}
+	err = z.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics,
+		map[string]string{"pool": "HOME"})
+	acc.Metrics = nil
+
+	z = &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, PoolMetrics: true, PoolHealthTag: true}
+	err = z.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics,
+		map[string]string{"pool": "HOME", "health": "DEGRADED"})
 
 	err = os.RemoveAll(os.TempDir() + "/telegraf")
 	require.NoError(t, err)
@@ -321,7 +358,7 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 		"pools": "HOME",
 	}
 
-	z := &Zfs{KstatPath: testKstatPath}
+	z := &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath}
 	err = z.Gather(&acc)
 	require.NoError(t, err)
 
@@ -339,7 +376,7 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 		"pools": "HOME::STORAGE",
 	}
 
-	z = &Zfs{KstatPath: testKstatPath}
+	z = &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath}
 	acc2 := testutil.Accumulator{}
 	err = z.Gather(&acc2)
 	require.NoError(t, err)
@@ -350,7 +387,7 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 	intMetrics = getKstatMetricsArcOnly()
 
 	//two pools, one metric
-	z = &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}}
+	z = &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}}
 	acc3 := testutil.Accumulator{}
 	err = z.Gather(&acc3)
 	require.NoError(t, err)
@@ -523,6 +560,23 @@ func getKstatMetricsAll() map[string]interface{} {
 }
 
 func getPoolMetrics() map[string]interface{} {
+	return map[string]interface{}{
+		"read_bytes":         int64(1884160),
+		"write_bytes":        int64(6450688),
+		"read_ops":           int64(22),
+		"write_ops":          int64(978),
+		"wait_time_ns":       int64(272187126),
+		"wait_queue_time_ns": int64(2850519036),
+		"wait_updated_ns":    int64(2263669418655),
+		"run_time_ns":        int64(424226814),
+		"run_queue_time_ns":  int64(2850519036),
+		"run_updated_ns":     int64(2263669871823),
+		"wait_queue_length":  int64(0),
+		"run_queue_length":   int64(0),
+	}
+}
+
+func getLegacyPoolMetrics() map[string]interface{} {
 	return map[string]interface{}{
 		"nread":    int64(1884160),
 		"nwritten": int64(6450688),
@@ -538,3 +592,843 @@ func getPoolMetrics() map[string]interface{} {
 		"rcnt":     int64(0),
 	}
 }
+
+func TestZfsFieldTypes(t *testing.T) {
+	m := testutil.MustMetric("zfs", map[string]string{}, map[string]interface{}{}, time.Now())
+	require.Equal(t, telegraf.Counter, metric.FieldType(m, "arcstats_hits"))
+	require.Equal(t, telegraf.Counter, metric.FieldType(m, "arcstats_l2_read_bytes"))
+	require.Equal(t, telegraf.Counter, metric.FieldType(m, "zil_commit_count"))
+	require.Equal(t, telegraf.Gauge, metric.FieldType(m, "arcstats_size"))
+	require.Equal(t, telegraf.Gauge, metric.FieldType(m, "arcstats_c_max"))
+	require.Equal(t, telegraf.Gauge, metric.FieldType(m, "abdstats_linear_cnt"))
+
+	m = testutil.MustMetric("zfs_pool", map[string]string{}, map[string]interface{}{}, time.Now())
+	require.Equal(t, telegraf.Counter, metric.FieldType(m, "nread"))
+	require.Equal(t, telegraf.Gauge, metric.FieldType(m, "wcnt"))
+	require.Equal(t, telegraf.Counter, metric.FieldType(m, "write_bytes"))
+	require.Equal(t, telegraf.Gauge, metric.FieldType(m, "run_queue_length"))
+}
+
+func TestZfsInit(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/zil", []byte(zilContents), 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{KstatPath: testKstatPath}
+	require.NoError(t, z.Init())
+
+	z = &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats", "zil"}}
+	require.NoError(t, z.Init())
+
+	z = &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats", "arcstat", "xuio_stats"}}
+	err = z.Init()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "kstat metrics arcstat, xuio_stats not found")
+	require.Contains(t, err.Error(), "available are: arcstats, zil")
+
+	z = &Zfs{KstatPath: testKstatPath + "/missing"}
+	err = z.Init()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "check that the zfs module is loaded")
+}
+
+func TestZfsVersionMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath, 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+
+	sysModulePath := os.TempDir() + "/telegraf/sys/module"
+	for module, version := range map[string]string{"zfs": "0.8.3-1ubuntu12", "spl": "0.8.3-1ubuntu12"} {
+		err = os.MkdirAll(sysModulePath+"/"+module, 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(sysModulePath+"/"+module+"/version", []byte(version+"\n"), 0644)
+		require.NoError(t, err)
+	}
+
+	z := &Zfs{
+		Log:            testutil.Logger{},
+		KstatPath:      testKstatPath,
+		KstatMetrics:   []string{"arcstats"},
+		VersionMetrics: true,
+		sysModulePath:  sysModulePath,
+		runner: &fixtureRunner{outputs: map[string][]string{
+			"zfs version": {"zfs-0.8.4-1ubuntu11", "zfs-kmod-0.8.3-1ubuntu12"},
+		}},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	acc.AssertContainsFields(t, "zfs_version", map[string]interface{}{
+		"kmod_version":     "0.8.3-1ubuntu12",
+		"spl_version":      "0.8.3-1ubuntu12",
+		"userland_version": "0.8.4-1ubuntu11",
+		"version_mismatch": true,
+	})
+
+	// zfs version is not available before ZFS 0.8.
+	z.runner = &fixtureRunner{errors: map[string]error{
+		"zfs version": errors.New("zfs error: unrecognized command 'version'"),
+	}}
+	acc.ClearMetrics()
+	require.NoError(t, z.Gather(&acc))
+
+	acc.AssertContainsFields(t, "zfs_version", map[string]interface{}{
+		"kmod_version": "0.8.3-1ubuntu12",
+		"spl_version":  "0.8.3-1ubuntu12",
+	})
+}
+
+func TestZfsVersionTags(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+
+	runner := &fixtureRunner{outputs: map[string][]string{
+		"zfs version": {"zfs-2.1.5-1", "zfs-kmod-2.1.4-1"},
+	}}
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		VersionTags:  true,
+		runner:       runner,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	require.NoError(t, z.Gather(&acc))
+	require.Equal(t, []string{"zfs version"}, runner.calls)
+
+	acc.AssertContainsTaggedFields(t, "zfs", getKstatMetricsArcOnly(), map[string]string{
+		"pools":            "HOME",
+		"zfs_version":      "2.1.5-1",
+		"zfs_kmod_version": "2.1.4-1",
+	})
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{
+		"pool":             "HOME",
+		"zfs_version":      "2.1.5-1",
+		"zfs_kmod_version": "2.1.4-1",
+	})
+}
+
+const fletcher4BenchContents = `0 0 0x01 -1 0 2210528226 27312530426
+implementation   native         byteswap
+scalar           4837189498     3705237544
+superscalar      6248297640     4634219566
+`
+
+func TestZfsKstatDiscover(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/fletcher_4_bench", []byte(fletcher4BenchContents), 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatDiscover: true}
+	require.NoError(t, z.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "zfs", getKstatMetricsArcOnly(), map[string]string{"pools": "HOME"})
+
+	z = &Zfs{KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}, KstatDiscover: true}
+	require.Error(t, z.Init())
+}
+
+func TestObjsetResolver(t *testing.T) {
+	calls := 0
+	datasets := []string{"54\ttank", "387\ttank/home", "1290\ttank/vm-100-disk-0"}
+	r := newObjsetResolver(func(pool string) ([]string, error) {
+		require.Equal(t, "tank", pool)
+		calls++
+		return datasets, nil
+	}, 10*time.Minute)
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+
+	names, err := r.resolve("tank", []uint64{54, 387})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{54: "tank", 387: "tank/home"}, names)
+	require.Equal(t, 1, calls)
+
+	// Cached, and the unknown objsets are not listed again.
+	names, err = r.resolve("tank", []uint64{54, 387})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{54: "tank", 387: "tank/home"}, names)
+	names, err = r.resolve("tank", []uint64{54, 387, 1290, 9999})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{54: "tank", 387: "tank/home", 1290: "tank/vm-100-disk-0"}, names)
+	require.Equal(t, 2, calls)
+	_, err = r.resolve("tank", []uint64{54, 9999})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	// A created dataset is listed.
+	datasets = append(datasets, "1410\ttank/new")
+	names, err = r.resolve("tank", []uint64{54, 1410})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{54: "tank", 1410: "tank/new"}, names)
+	require.Equal(t, 3, calls)
+
+	// A renamed dataset keeps its objset, it is listed again after maxAge.
+	datasets = []string{"54\ttank", "1410\ttank/renamed"}
+	now = now.Add(5 * time.Minute)
+	names, err = r.resolve("tank", []uint64{54, 1410})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{54: "tank", 1410: "tank/new"}, names)
+	now = now.Add(10 * time.Minute)
+	names, err = r.resolve("tank", []uint64{54, 1410})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]string{54: "tank", 1410: "tank/renamed"}, names)
+	require.Equal(t, 4, calls)
+
+	id, err := parseObjsetID("objset-0x582")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1410), id)
+}
+
+func TestZfsCheckPools(t *testing.T) {
+	for _, pool := range []string{"HOME", "GONE"} {
+		err := os.MkdirAll(testKstatPath+"/"+pool, 0755)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(testKstatPath+"/"+pool+"/io", []byte(pool_ioContents), 0644)
+		require.NoError(t, err)
+	}
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		CheckPools:   true,
+		runner: &fixtureRunner{outputs: map[string][]string{
+			"zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio": {
+				"HOME	ONLINE	1000000	500000	500000	3%	50	1.00x",
+				"NEW	ONLINE	1000000	0	1000000	0%	0	1.00x",
+			},
+		}},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "HOME"})
+	acc.AssertDoesNotContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "GONE"})
+	acc.AssertContainsTaggedFields(t, "zfs_pool_mismatch",
+		map[string]interface{}{"in_kstat": true, "in_zpool": false}, map[string]string{"pool": "GONE"})
+	acc.AssertContainsTaggedFields(t, "zfs_pool_mismatch",
+		map[string]interface{}{"in_kstat": false, "in_zpool": true}, map[string]string{"pool": "NEW"})
+
+	// Without zpool, all the pools of the kstats are read.
+	z.runner = &fixtureRunner{}
+	acc = testutil.Accumulator{}
+	require.NoError(t, z.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "GONE"})
+	require.False(t, acc.HasMeasurement("zfs_pool_mismatch"))
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestZfsCheckPoolsNoPools(t *testing.T) {
+	require.NoError(t, os.MkdirAll(testKstatPath, 0755))
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		CheckPools:   true,
+		runner: &fixtureRunner{outputs: map[string][]string{
+			// The output of zpool list without pools.
+			"zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio": {""},
+		}},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	require.False(t, acc.HasMeasurement("zfs_pool_mismatch"))
+}
+
+func TestZfsPoolMetricsConcurrent(t *testing.T) {
+	for _, pool := range []string{"HOME", "BROKEN", "STUCK", "TANK"} {
+		err := os.MkdirAll(testKstatPath+"/"+pool, 0755)
+		require.NoError(t, err)
+	}
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err := ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/TANK/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/BROKEN/io", []byte("11 3 0x00 1 80\nnread nwritten\n1\n"), 0644)
+	require.NoError(t, err)
+	// Opening a FIFO blocks until it is opened for writing.
+	err = syscall.Mkfifo(testKstatPath+"/STUCK/io", 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		PoolWorkers:  2,
+		PoolTimeout:  internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	// Unblock the abandoned read.
+	fifo, err := os.OpenFile(testKstatPath+"/STUCK/io", os.O_WRONLY, 0)
+	require.NoError(t, err)
+	fifo.Close()
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "HOME"})
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "TANK"})
+	acc.AssertContainsTaggedFields(t, "zfs", getKstatMetricsArcOnly(), map[string]string{"pools": "BROKEN::HOME::STUCK::TANK"})
+	require.Len(t, acc.Errors, 2)
+	var errs []string
+	for _, err := range acc.Errors {
+		errs = append(errs, err.Error())
+	}
+	sort.Strings(errs)
+	require.Contains(t, errs[0], "pool BROKEN: Key and value count don't match")
+	require.Equal(t, "pool STUCK: timeout after 100ms", errs[1])
+}
+
+func TestParseKstatLine(t *testing.T) {
+	name, value, ok := parseKstatLine("hits                            4    5968846374")
+	require.True(t, ok)
+	require.Equal(t, "hits", name)
+	require.Equal(t, int64(5968846374), value)
+
+	name, value, ok = parseKstatLine("dataset_name                    7    tank/home")
+	require.True(t, ok)
+	require.Equal(t, "dataset_name", name)
+	require.Equal(t, int64(0), value)
+
+	_, _, ok = parseKstatLine("")
+	require.False(t, ok)
+	_, _, ok = parseKstatLine("hits")
+	require.False(t, ok)
+}
+
+func BenchmarkParseKstatLine(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		parseKstatLine("evict_l2_eligible               4    16345402777088")
+	}
+}
+
+func BenchmarkParsePoolStats(b *testing.B) {
+	lines := strings.Split(strings.TrimSpace(pool_ioContents), "\n")
+	for n := 0; n < b.N; n++ {
+		parsePoolStats(lines)
+	}
+}
+
+func BenchmarkGather(b *testing.B) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(b, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	for name, contents := range map[string]string{
+		"HOME/io":           pool_ioContents,
+		"arcstats":          arcstatsContents,
+		"zfetchstats":       zfetchstatsContents,
+		"zil":               zilContents,
+		"fm":                fmContents,
+		"dmu_tx":            dmu_txContents,
+		"abdstats":          abdstatsContents,
+		"dbufcachestats":    dbufcachestatsContents,
+		"dnodestats":        dnodestatsContents,
+		"vdev_mirror_stats": vdevmirrorcachestatsContents,
+	} {
+		err = ioutil.WriteFile(testKstatPath+"/"+name, []byte(contents), 0644)
+		require.NoError(b, err)
+	}
+
+	z := &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, PoolMetrics: true}
+	var acc testutil.Accumulator
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		z.Gather(&acc)
+		acc.ClearMetrics()
+	}
+}
+
+func TestZfsReplay(t *testing.T) {
+	replayDir, err := ioutil.TempDir("", "zfs-replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(replayDir)
+
+	for name, contents := range map[string]string{
+		"0001/kstat/arcstats":     arcstatsContents,
+		"0001/kstat/HOME/io":      pool_ioContents,
+		"0001/module/zfs/version": "2.1.4-1\n",
+		"0001/commands":           "$ zfs version\nzfs-2.1.5-1\nzfs-kmod-2.1.4-1\n",
+		"0002/kstat/arcstats":     arcstatsContents,
+		"0002/kstat/TANK/io":      pool_ioContents,
+	} {
+		path := filepath.Join(replayDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+
+	z := &Zfs{
+		Log:            testutil.Logger{},
+		KstatMetrics:   []string{"arcstats"},
+		PoolMetrics:    true,
+		VersionMetrics: true,
+		ReplayDir:      replayDir,
+	}
+	require.NoError(t, z.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "HOME"})
+	acc.AssertContainsFields(t, "zfs_version", map[string]interface{}{
+		"kmod_version":     "2.1.4-1",
+		"userland_version": "2.1.5-1",
+		"version_mismatch": true,
+	})
+
+	acc.ClearMetrics()
+	require.NoError(t, z.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "TANK"})
+	require.False(t, acc.HasMeasurement("zfs_version"))
+
+	// The snapshots are replayed in a loop.
+	acc.ClearMetrics()
+	require.NoError(t, z.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "zfs_pool", getPoolMetrics(), map[string]string{"pool": "HOME"})
+
+	z = &Zfs{ReplayDir: filepath.Join(replayDir, "0001")}
+	require.NoError(t, z.Init())
+	require.Equal(t, []string{filepath.Join(replayDir, "0001")}, z.snapshots)
+
+	z = &Zfs{ReplayDir: filepath.Join(replayDir, "missing")}
+	require.Error(t, z.Init())
+}
+
+// TestZfsVersionFixtures parses the kstats and the command outputs captured
+// from each ZFS release in testdata.
+func TestZfsVersionFixtures(t *testing.T) {
+	tests := []struct {
+		version  string
+		pools    []string
+		health   string
+		userland string
+	}{
+		{version: "zfs-0.7", pools: []string{"tank"}},
+		{version: "zfs-0.8", pools: []string{"tank"}, health: "ONLINE", userland: "0.8.3-1ubuntu12"},
+		// The io kstat of the pools was removed in ZFS 2.1.
+		{version: "zfs-2.1", userland: "2.1.5-1ubuntu6"},
+		{version: "zfs-2.2", userland: "2.2.2-0ubuntu9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			dir := filepath.Join("testdata", tt.version)
+
+			for _, metric := range []string{"arcstats", "dmu_tx", "zil"} {
+				lines, err := internal.ReadLines(filepath.Join(dir, "kstat", metric))
+				require.NoError(t, err)
+				require.True(t, isNamedKstat(lines[0]))
+				for _, line := range lines[2:] {
+					_, _, ok := parseKstatLine(line)
+					require.True(t, ok, line)
+				}
+			}
+
+			pools := getPools(filepath.Join(dir, "kstat"))
+			require.Len(t, pools, len(tt.pools))
+			for _, pool := range pools {
+				fields, err := readPoolStats(pool)
+				require.NoError(t, err)
+				require.Len(t, fields, 12)
+			}
+
+			z := &Zfs{
+				Log:            testutil.Logger{},
+				KstatMetrics:   []string{"arcstats", "dmu_tx", "zil"},
+				PoolMetrics:    true,
+				CheckPools:     true,
+				PoolHealthTag:  true,
+				VersionMetrics: true,
+				ReplayDir:      dir,
+			}
+			require.NoError(t, z.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, z.Gather(&acc))
+			require.Empty(t, acc.Errors)
+
+			m, ok := acc.Get("zfs")
+			require.True(t, ok)
+			require.Equal(t, int64(5968846374), m.Fields["arcstats_hits"])
+			require.Equal(t, int64(12156513), m.Fields["zil_commit_count"])
+			require.Equal(t, int64(20134719), m.Fields["dmu_tx_assigned"])
+
+			var pooled []string
+			for _, m := range acc.Metrics {
+				if m.Measurement == "zfs_pool" {
+					pooled = append(pooled, m.Tags["pool"])
+					require.Equal(t, tt.health, m.Tags["health"])
+				}
+			}
+			require.Equal(t, tt.pools, pooled)
+
+			m, ok = acc.Get("zfs_version")
+			require.True(t, ok)
+			if tt.userland != "" {
+				require.Equal(t, tt.userland, m.Fields["userland_version"])
+				require.Equal(t, false, m.Fields["version_mismatch"])
+			} else {
+				require.NotContains(t, m.Fields, "userland_version")
+			}
+		})
+	}
+}
+
+func TestZfsTimingMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath, KstatMetrics: []string{"arcstats"}}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	require.False(t, acc.HasMeasurement("zfs_gather"))
+
+	z.PoolMetrics = true
+	z.TimingMetrics = true
+	require.NoError(t, z.Gather(&acc))
+	for _, field := range []string{"gather_time_ns", "kstats_time_ns", "pools_time_ns"} {
+		require.True(t, acc.HasInt64Field("zfs_gather", field), field)
+	}
+	require.False(t, acc.HasInt64Field("zfs_gather", "version_time_ns"))
+}
+
+func TestZfsPoolMetricsPendingRead(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/STUCK", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = syscall.Mkfifo(testKstatPath+"/STUCK/io", 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		PoolWorkers:  1,
+		PoolTimeout:  internal.Duration{Duration: 50 * time.Millisecond},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	require.NoError(t, z.Gather(&acc))
+	require.Len(t, acc.Errors, 2)
+	require.Equal(t, "pool STUCK: timeout after 50ms", acc.Errors[0].Error())
+	require.Equal(t, "pool STUCK: previous read still pending", acc.Errors[1].Error())
+
+	// Once the read completes, the pool is read again.
+	fifo, err := os.OpenFile(testKstatPath+"/STUCK/io", os.O_WRONLY, 0)
+	require.NoError(t, err)
+	fifo.Close()
+	for i := 0; i < 100; i++ {
+		z.pendingMu.Lock()
+		pending := z.pendingPools["STUCK"]
+		z.pendingMu.Unlock()
+		if !pending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	z.pendingMu.Lock()
+	require.False(t, z.pendingPools["STUCK"])
+	z.pendingMu.Unlock()
+}
+
+func TestZfsPoolMetricsNotPendingAfterGather(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{
+		Log:          testutil.Logger{},
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+	}
+	for i := 0; i < 100; i++ {
+		var acc testutil.Accumulator
+		require.NoError(t, z.Gather(&acc))
+		require.Empty(t, acc.Errors)
+
+		z.pendingMu.Lock()
+		require.Empty(t, z.pendingPools)
+		z.pendingMu.Unlock()
+	}
+}
+
+func TestZfsMemoryMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath, 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/arcstats", []byte(arcstatsContents), 0644)
+	require.NoError(t, err)
+	meminfoPath := os.TempDir() + "/telegraf/proc/meminfo"
+	err = ioutil.WriteFile(meminfoPath, []byte(`MemTotal:       33554432 kB
+MemFree:         1048576 kB
+Buffers:          524288 kB
+Cached:          4194304 kB
+AnonPages:       8388608 kB
+HugePages_Total:       0
+`), 0644)
+	require.NoError(t, err)
+
+	z := &Zfs{
+		Log:           testutil.Logger{},
+		KstatPath:     testKstatPath,
+		KstatMetrics:  []string{"arcstats"},
+		MemoryMetrics: true,
+		meminfoPath:   meminfoPath,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	acc.AssertContainsFields(t, "zfs_memory", map[string]interface{}{
+		"total":       int64(34359738368),
+		"free":        int64(1073741824),
+		"arc":         int64(16319887096),
+		"page_cache":  int64(4831838208),
+		"anonymous":   int64(8589934592),
+		"other":       int64(34359738368 - 1073741824 - 16319887096 - 4831838208 - 8589934592),
+		"arc_percent": float64(16319887096) / float64(34359738368) * 100,
+	})
+}
+
+func TestZfsIcpMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath, 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	kcfPath := os.TempDir() + "/telegraf/proc/spl/kstat/kcf"
+	sysModulePath := os.TempDir() + "/telegraf/sys/module"
+	for name, contents := range map[string]string{
+		kcfPath + "/NONAME_provider_stats": "1 1 0x01 4 192 3216519131 1271390412957\n" +
+			"name                            type data\n" +
+			"kcf_ops_total                   4    1048576\n" +
+			"kcf_ops_passed                  4    1048570\n" +
+			"kcf_ops_failed                  4    6\n" +
+			"kcf_ops_returned_busy           4    0\n",
+		sysModulePath + "/icp/parameters/icp_aes_impl": "cycle [fastest] generic x86_64 aesni \n",
+		sysModulePath + "/icp/parameters/icp_gcm_impl": "cycle fastest [generic] pclmulqdq \n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, ioutil.WriteFile(name, []byte(contents), 0644))
+	}
+
+	z := &Zfs{
+		Log:           testutil.Logger{},
+		KstatPath:     testKstatPath,
+		KstatMetrics:  []string{"arcstats"},
+		IcpMetrics:    true,
+		sysModulePath: sysModulePath,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	acc.AssertContainsFields(t, "zfs_icp", map[string]interface{}{
+		"aes_impl":              "fastest",
+		"aes_accelerated":       true,
+		"gcm_impl":              "generic",
+		"gcm_accelerated":       false,
+		"kcf_ops_total":         int64(1048576),
+		"kcf_ops_passed":        int64(1048570),
+		"kcf_ops_failed":        int64(6),
+		"kcf_ops_returned_busy": int64(0),
+	})
+}
+
+const qatContents = `19 1 0x01 17 4624 5486227364 1278843582624
+name                            type data
+comp_requests                   4    1047
+comp_total_in_bytes             4    68612096
+comp_total_out_bytes            4    5173760
+decomp_requests                 4    12
+decomp_total_in_bytes           4    61440
+decomp_total_out_bytes          4    786432
+dc_fails                        4    3
+encrypt_requests                4    0
+encrypt_total_in_bytes          4    0
+encrypt_total_out_bytes         4    0
+decrypt_requests                4    0
+decrypt_total_in_bytes          4    0
+decrypt_total_out_bytes         4    0
+crypt_fails                     4    0
+cksum_requests                  4    512
+cksum_total_in_bytes            4    33554432
+cksum_fails                     4    0
+`
+
+func TestZfsQatMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath, 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/qat", []byte(qatContents), 0644)
+	require.NoError(t, err)
+
+	// qat is gathered by default when present.
+	z := &Zfs{Log: testutil.Logger{}, KstatPath: testKstatPath}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	for field, value := range map[string]int64{
+		"qat_comp_requests":       1047,
+		"qat_comp_total_in_bytes": 68612096,
+		"qat_dc_fails":            3,
+		"qat_cksum_requests":      512,
+	} {
+		v, ok := acc.Int64Field("zfs", field)
+		require.True(t, ok, field)
+		require.Equal(t, value, v, field)
+	}
+}
+
+const objsetContents = `41 1 0x01 7 2160 5214787248 179617374806
+name                            type data
+dataset_name                    7    tank/home dir
+writes                          4    12
+nwritten                        4    49152
+reads                           4    30
+nread                           4    122880
+nunlinks                        4    1
+nunlinked                       4    1
+`
+
+// objsetOldContents is an objset kstat of ZFS 0.8.0 to 0.8.2, without the
+// dataset name.
+const objsetOldContents = `42 1 0x01 6 1920 5214787248 179617374806
+name                            type data
+writes                          4    3
+nwritten                        4    4096
+reads                           4    7
+nread                           4    28672
+nunlinks                        4    0
+nunlinked                       4    0
+`
+
+func TestZfsDatasetMetrics(t *testing.T) {
+	require.NoError(t, os.MkdirAll(testKstatPath+"/tank", 0755))
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+	files := map[string]string{
+		"tank/io":          pool_ioContents,
+		"tank/objset-0x36": objsetContents,
+		"tank/objset-0x87": objsetOldContents,
+		"tank/objset-0x99": objsetOldContents,
+		"arcstats":         arcstatsContents,
+	}
+	for name, contents := range files {
+		require.NoError(t, ioutil.WriteFile(testKstatPath+"/"+name, []byte(contents), 0644))
+	}
+
+	runner := &fixtureRunner{outputs: map[string][]string{
+		"zfs list -H -p -r -t filesystem,volume -o objsetid,name tank": {
+			"54\ttank/home dir",
+			"135\ttank/vm-100-disk-0",
+		},
+	}}
+	z := &Zfs{
+		Log:            testutil.Logger{},
+		KstatPath:      testKstatPath,
+		KstatMetrics:   []string{"arcstats"},
+		DatasetMetrics: true,
+		runner:         runner,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"writes":    int64(12),
+			"nwritten":  int64(49152),
+			"reads":     int64(30),
+			"nread":     int64(122880),
+			"nunlinks":  int64(1),
+			"nunlinked": int64(1),
+		},
+		map[string]string{"pool": "tank", "dataset": "tank/home dir"})
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"writes":    int64(3),
+			"nwritten":  int64(4096),
+			"reads":     int64(7),
+			"nread":     int64(28672),
+			"nunlinks":  int64(0),
+			"nunlinked": int64(0),
+		},
+		map[string]string{"pool": "tank", "dataset": "tank/vm-100-disk-0"})
+	// The objset unknown to zfs list is skipped.
+	datasets := 0
+	for _, m := range acc.Metrics {
+		if m.Measurement == "zfs_dataset" {
+			datasets++
+		}
+	}
+	require.Equal(t, 2, datasets)
+
+	// The names are listed only for the kstats without dataset_name, and
+	// cached.
+	require.NoError(t, z.Gather(&acc))
+	require.Equal(t, []string{"zfs list -H -p -r -t filesystem,volume -o objsetid,name tank"}, runner.calls)
+}
+
+func TestKstatString(t *testing.T) {
+	require.Equal(t, "tank/home dir", kstatString("dataset_name                    7    tank/home dir"))
+	require.Equal(t, "", kstatString("dataset_name"))
+}
+
+func TestRunTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := runTimeout(50*time.Millisecond, "sleep", "10")
+	require.EqualError(t, err, "sleep timed out after 50ms")
+	require.True(t, time.Since(start) < 5*time.Second)
+
+	lines, err := runTimeout(time.Second, "echo", "tank")
+	require.NoError(t, err)
+	require.Equal(t, []string{"tank"}, lines)
+
+	_, err = runTimeout(time.Second, "zpool-missing", "list")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "zpool-missing error: ")
+}
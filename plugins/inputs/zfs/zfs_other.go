@@ -1,18 +1,35 @@
-// +build !linux,!freebsd
+// +build !linux,!freebsd,!windows
 
 package zfs
 
 import (
+	"runtime"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
+// Init accepts only the remote hosts, there is no local ZFS to gather.  As
+// in previous versions the input then gathers nothing, it only warns once.
+func (z *Zfs) Init() error {
+	if len(z.RemoteHosts) > 0 {
+		return z.initRemote()
+	}
+	z.Log.Warnf("The zfs input is not supported on %s, no metrics are gathered", runtime.GOOS)
+	return nil
+}
+
 func (z *Zfs) Gather(acc telegraf.Accumulator) error {
+	if len(z.RemoteHosts) > 0 {
+		z.gatherRemote(acc)
+	}
 	return nil
 }
 
 func init() {
 	inputs.Add("zfs", func() telegraf.Input {
-		return &Zfs{}
+		return &Zfs{
+			RemoteConcurrency: 4,
+		}
 	})
 }
@@ -0,0 +1,151 @@
+package zfs
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// defaultRemoteTimeout bounds the commands run on the remote hosts when
+// poolTimeout is not set.
+const defaultRemoteTimeout = 5 * time.Second
+
+// sshRunner runs the commands on a remote host with ssh, at most as many
+// at a time as sem holds.  The authentication is left to ssh, with a key as
+// it cannot prompt for a password.  A command not completed within the
+// timeout, such as zpool on a suspended pool, is killed.
+type sshRunner struct {
+	host    string
+	key     string
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+func (r *sshRunner) args(command string, args ...string) []string {
+	sshArgs := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10",
+		"-o", "ServerAliveInterval=10"}
+	if r.key != "" {
+		sshArgs = append(sshArgs, "-i", r.key)
+	}
+	// env sets the locale whatever the login shell, csh does not support
+	// the variable assignment prefix.
+	// The host ends the options, so that it is not parsed as one.
+	sshArgs = append(sshArgs, "--", r.host, "env", "LC_ALL=C", command)
+	return append(sshArgs, args...)
+}
+
+func (r *sshRunner) Run(command string, args ...string) ([]string, error) {
+	r.sem <- struct{}{}
+	defer func() { <-r.sem }()
+	return runTimeout(r.timeout, "ssh", r.args(command, args...)...)
+}
+
+func (z *Zfs) initRemote() error {
+	for _, host := range z.RemoteHosts {
+		if host == "" || strings.HasPrefix(host, "-") {
+			return fmt.Errorf("invalid remote host %q", host)
+		}
+	}
+	if unsupported := z.localOptions(); len(unsupported) > 0 {
+		return fmt.Errorf("%s cannot be combined with remoteHosts, only zpool list is run on the remote hosts",
+			strings.Join(unsupported, ", "))
+	}
+	if z.remoteRunner != nil {
+		return nil
+	}
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return fmt.Errorf("ssh not found: it is required to gather remoteHosts")
+	}
+	timeout := z.PoolTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+	concurrency := z.RemoteConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// The runners are kept across the gathers, so that the limit of each
+	// host also applies to the commands of a previous gather still running.
+	runners := make(map[string]*sshRunner, len(z.RemoteHosts))
+	for _, host := range z.RemoteHosts {
+		runners[host] = &sshRunner{
+			host:    host,
+			key:     z.RemoteKey,
+			timeout: timeout,
+			sem:     make(chan struct{}, concurrency),
+		}
+	}
+	z.remoteRunner = func(host string) CommandRunner {
+		return runners[host]
+	}
+	return nil
+}
+
+// localOptions returns the options set that gather the local system, which
+// are not supported on the remote hosts.
+func (z *Zfs) localOptions() []string {
+	var options []string
+	set := func(name string, isSet bool) {
+		if isSet {
+			options = append(options, name)
+		}
+	}
+	set("kstatPath", z.KstatPath != "")
+	set("kstatMetrics", len(z.KstatMetrics) > 0)
+	set("kstatDiscover", z.KstatDiscover)
+	set("datasetMetrics", z.DatasetMetrics)
+	set("checkPools", z.CheckPools)
+	set("poolHealthTag", z.PoolHealthTag)
+	set("versionMetrics", z.VersionMetrics)
+	set("versionTags", z.VersionTags)
+	set("replayDir", z.ReplayDir != "")
+	set("timingMetrics", z.TimingMetrics)
+	set("memoryMetrics", z.MemoryMetrics)
+	set("icpMetrics", z.IcpMetrics)
+	set("capacityForecast", z.CapacityForecast)
+	set("watchSnapshots", len(z.WatchSnapshots) > 0)
+	set("cloneMetrics", z.CloneMetrics)
+	set("zvolMetrics", z.ZvolMetrics)
+	return options
+}
+
+// gatherRemote gathers the pools of the remote hosts concurrently, the
+// runner of each host limits the commands run at a time on it.
+func (z *Zfs) gatherRemote(acc telegraf.Accumulator) {
+	var wg sync.WaitGroup
+	for _, host := range z.RemoteHosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			if err := z.gatherRemoteHost(host, acc); err != nil {
+				acc.AddError(fmt.Errorf("%s: %v", host, err))
+			}
+		}(host)
+	}
+	wg.Wait()
+}
+
+func (z *Zfs) gatherRemoteHost(host string, acc telegraf.Accumulator) error {
+	remote := &Zfs{runner: z.remoteRunner(host)}
+	lines, err := remote.zpool()
+	if err != nil {
+		return err
+	}
+	stats, err := parseZpoolList(lines, z.OmitDashValues)
+	if err != nil {
+		return err
+	}
+
+	// The host may be given as user@host.
+	hostname := host[strings.LastIndex(host, "@")+1:]
+	for _, pool := range stats {
+		pool.tags["host"] = hostname
+		acc.AddFields("zfs_pool", pool.fields, pool.tags)
+	}
+	return nil
+}
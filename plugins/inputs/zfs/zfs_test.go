@@ -0,0 +1,316 @@
+package zfs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cache"
+	"github.com/influxdata/telegraf/internal/state"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureRunner is a CommandRunner replaying the recorded outputs of the
+// commands, keyed by the command line.  The commands without a recorded
+// output fail like a command that is not installed.
+type fixtureRunner struct {
+	outputs map[string][]string
+	errors  map[string]error
+	calls   []string
+}
+
+func (r *fixtureRunner) Run(command string, args ...string) ([]string, error) {
+	line := strings.Join(append([]string{command}, args...), " ")
+	r.calls = append(r.calls, line)
+	if err, ok := r.errors[line]; ok {
+		return nil, err
+	}
+	if out, ok := r.outputs[line]; ok {
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s: command not found", command)
+}
+
+func TestLoadTranscript(t *testing.T) {
+	f, err := ioutil.TempFile("", "commands")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("$ zfs version\nzfs-2.1.5-1\nzfs-kmod-2.1.4-1\n\n" +
+		"$ zpool list -Hp -o name,health\ntank\tONLINE\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	runner, err := loadTranscript(f.Name())
+	require.NoError(t, err)
+
+	out, err := runner.Run("zfs", "version")
+	require.NoError(t, err)
+	require.Equal(t, []string{"zfs-2.1.5-1", "zfs-kmod-2.1.4-1"}, out)
+	out, err = runner.Run("zpool", "list", "-Hp", "-o", "name,health")
+	require.NoError(t, err)
+	require.Equal(t, []string{"tank\tONLINE"}, out)
+	_, err = runner.Run("zpool", "status")
+	require.Error(t, err)
+
+	runner, err = loadTranscript(f.Name() + ".missing")
+	require.NoError(t, err)
+	require.Len(t, runner, 0)
+}
+
+func TestZfsRemote(t *testing.T) {
+	zpoolList := "zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio"
+	runners := map[string]CommandRunner{
+		"admin@nas1": &fixtureRunner{outputs: map[string][]string{
+			zpoolList: {"tank	ONLINE	2989297238016	626958278656	2362338959360	12%	20	1.00x"},
+		}},
+		"nas2": &fixtureRunner{errors: map[string]error{
+			zpoolList: errors.New("ssh error: Permission denied (publickey)"),
+		}},
+	}
+
+	z := &Zfs{
+		RemoteHosts:       []string{"admin@nas1", "nas2"},
+		RemoteConcurrency: 2,
+		remoteRunner: func(host string) CommandRunner {
+			return runners[host]
+		},
+	}
+	require.NoError(t, z.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", map[string]interface{}{
+		"allocated":     int64(626958278656),
+		"capacity":      int64(20),
+		"dedupratio":    float64(1),
+		"free":          int64(2362338959360),
+		"size":          int64(2989297238016),
+		"fragmentation": int64(12),
+	}, map[string]string{"pool": "tank", "health": "ONLINE", "host": "nas1"})
+	require.False(t, acc.HasMeasurement("zfs"))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "nas2: ssh error: Permission denied")
+}
+
+func TestZpoolSharedList(t *testing.T) {
+	list := "zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio"
+	runner := &fixtureRunner{outputs: map[string][]string{
+		list: {"tank	ONLINE	1000	500	500	10	50	1.00"},
+	}}
+
+	c := cache.New(time.Minute)
+	for i := 0; i < 2; i++ {
+		z := &Zfs{runner: runner, Cache: c}
+		lines, err := z.zpool()
+		require.NoError(t, err)
+		require.Len(t, lines, 1)
+	}
+	require.Equal(t, []string{list}, runner.calls)
+}
+
+func TestSSHRunnerArgs(t *testing.T) {
+	r := &sshRunner{host: "admin@nas1", key: "/etc/telegraf/id_ed25519"}
+	require.Equal(t, []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10",
+		"-o", "ServerAliveInterval=10", "-i", "/etc/telegraf/id_ed25519",
+		"--", "admin@nas1", "env", "LC_ALL=C", "zfs", "version"},
+		r.args("zfs", "version"))
+}
+
+func TestZfsRemoteInvalidHost(t *testing.T) {
+	z := &Zfs{RemoteHosts: []string{"nas1", "-oProxyCommand=sh"}}
+	require.EqualError(t, z.Init(), `invalid remote host "-oProxyCommand=sh"`)
+}
+
+func TestZfsRemoteLocalOptions(t *testing.T) {
+	z := &Zfs{
+		RemoteHosts:    []string{"nas1"},
+		PoolMetrics:    true,
+		DatasetMetrics: true,
+		KstatMetrics:   []string{"arcstats"},
+	}
+	require.EqualError(t, z.Init(), "kstatMetrics, datasetMetrics cannot be combined with remoteHosts, "+
+		"only zpool list is run on the remote hosts")
+}
+
+func TestSSHRunnerConcurrency(t *testing.T) {
+	z := &Zfs{RemoteHosts: []string{"nas1", "nas2"}, RemoteConcurrency: 2}
+	if err := z.Init(); err != nil {
+		t.Skip(err)
+	}
+	nas1 := z.remoteRunner("nas1").(*sshRunner)
+	nas2 := z.remoteRunner("nas2").(*sshRunner)
+	require.Equal(t, 2, cap(nas1.sem))
+	require.Equal(t, 2, cap(nas2.sem))
+	require.True(t, nas1 != nas2)
+	require.True(t, nas1 == z.remoteRunner("nas1"))
+}
+
+func TestZfsSnapshotEvents(t *testing.T) {
+	listSnapshots := "zfs list -Hp -t snapshot -o name,used,creation -d 1 tank/home"
+	runner := &fixtureRunner{outputs: map[string][]string{
+		listSnapshots: {
+			"tank/home@daily-1	1048576	1571011200",
+			"tank/home@daily-2	2097152	1571097600",
+		},
+	}}
+	z := &Zfs{WatchSnapshots: []string{"tank/home"}, runner: runner}
+
+	var acc testutil.Accumulator
+	z.gatherSnapshotEvents(&acc)
+	require.Len(t, acc.Metrics, 0)
+
+	runner.outputs[listSnapshots] = []string{
+		"tank/home@daily-2	2097152	1571097600",
+		"tank/home@daily-3	0	1571184000",
+	}
+	z.gatherSnapshotEvents(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"zfs_snapshot_event",
+			map[string]string{"dataset": "tank/home", "snapshot": "daily-3", "event": "created"},
+			map[string]interface{}{"used": int64(0), "creation": int64(1571184000)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"zfs_snapshot_event",
+			map[string]string{"dataset": "tank/home", "snapshot": "daily-1", "event": "destroyed"},
+			map[string]interface{}{"used": int64(1048576), "creation": int64(1571011200)},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestZfsClones(t *testing.T) {
+	z := &Zfs{runner: &fixtureRunner{outputs: map[string][]string{
+		"zfs list -Hp -t filesystem,volume -o name,origin,used,referenced": {
+			"tank	-	21474836480	98304",
+			"tank/images	-	10737418240	10737418240",
+			"tank/vm-100-disk-0	tank/images@base	1073741824	10737418240",
+		},
+	}}}
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.gatherClones(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"zfs_clone",
+			map[string]string{"pool": "tank", "dataset": "tank/vm-100-disk-0", "origin": "tank/images@base"},
+			map[string]interface{}{"used": int64(1073741824), "referenced": int64(10737418240)},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestZfsZvols(t *testing.T) {
+	z := &Zfs{runner: &fixtureRunner{outputs: map[string][]string{
+		"zfs list -Hp -t filesystem,volume -o name,type,volsize,refreservation,used,available": {
+			"tank	filesystem	-	0	32212254720	75161927680",
+			"tank/vm-100-disk-0	volume	85899345920	0	21474836480	75161927680",
+			"tank/vm-101-disk-0	volume	10737418240	11811160064	11811160064	86973087744",
+			"rpool	filesystem	-	0	5368709120	53687091200",
+		},
+	}}}
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.gatherZvols(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"zfs_zvol",
+			map[string]string{"pool": "tank", "dataset": "tank/vm-100-disk-0"},
+			map[string]interface{}{
+				"volsize":        int64(85899345920),
+				"refreservation": int64(0),
+				"used":           int64(21474836480),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"zfs_zvol",
+			map[string]string{"pool": "tank", "dataset": "tank/vm-101-disk-0"},
+			map[string]interface{}{
+				"volsize":        int64(10737418240),
+				"refreservation": int64(11811160064),
+				"used":           int64(11811160064),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"zfs_zvol_pool",
+			map[string]string{"pool": "tank"},
+			map[string]interface{}{
+				"volsize":          int64(96636764160),
+				"refreservation":   int64(11811160064),
+				"capacity":         int64(107374182400),
+				"overcommit_ratio": float64(0.9),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestZfsCapacityForecast(t *testing.T) {
+	store := state.New()
+	start := time.Unix(1600000000, 0)
+	newPlugin := func() *Zfs {
+		return &Zfs{
+			CapacityForecast:  true,
+			CapacityThreshold: 90,
+			CapacityWindow:    internal.Duration{Duration: 7 * 24 * time.Hour},
+			State:             store.Scope("zfs"),
+			Log:               testutil.Logger{},
+		}
+	}
+
+	z := newPlugin()
+	var days []interface{}
+	for i := 0; i < 4; i++ {
+		// A restart must not lose the history.
+		if i == 2 {
+			z = newPlugin()
+		}
+		now := start.Add(time.Duration(i) * 24 * time.Hour)
+		z.now = func() time.Time { return now }
+		z.runner = &fixtureRunner{outputs: map[string][]string{
+			"zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio": {
+				fmt.Sprintf("tank	ONLINE	1000000	%d	%d	3%%	50	1.00x", 500000+i*10000, 500000-i*10000),
+			},
+		}}
+
+		var acc testutil.Accumulator
+		require.NoError(t, z.gatherForecast(&acc))
+		m, ok := acc.Get("zfs_pool_forecast")
+		require.True(t, ok)
+		require.Equal(t, i+1, m.Fields["samples"])
+		days = append(days, m.Fields["days_until_full"])
+	}
+
+	// The projection needs three samples.
+	require.Equal(t, []interface{}{nil, nil, 38.0, 37.0}, days)
+}
+
+func TestAllocSlope(t *testing.T) {
+	_, ok := allocSlope([]allocSample{{Time: 0, Allocated: 1}, {Time: 1, Allocated: 2}})
+	require.False(t, ok)
+
+	slope, ok := allocSlope([]allocSample{
+		{Time: 100, Allocated: 1000},
+		{Time: 200, Allocated: 1100},
+		{Time: 300, Allocated: 1200},
+	})
+	require.True(t, ok)
+	require.InDelta(t, 1.0, slope, 1e-9)
+}
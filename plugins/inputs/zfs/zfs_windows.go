@@ -0,0 +1,102 @@
+// +build windows
+
+package zfs
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// Init checks that zpool.exe is available.  OpenZFS on Windows does not
+// expose its kstats as files or sysctls, so only the pool metrics are
+// supported.
+func (z *Zfs) Init() error {
+	if len(z.RemoteHosts) > 0 {
+		return z.initRemote()
+	}
+	if len(z.KstatMetrics) > 0 {
+		return fmt.Errorf("kstatMetrics is not supported on windows")
+	}
+	if z.ReplayDir != "" {
+		return z.initReplay()
+	}
+
+	if _, err := exec.LookPath("zpool"); err != nil {
+		return fmt.Errorf("zpool not found: verify that OpenZFS on Windows is installed and that zpool is in your PATH")
+	}
+	return nil
+}
+
+func (z *Zfs) Gather(acc telegraf.Accumulator) error {
+	if len(z.RemoteHosts) > 0 {
+		z.gatherRemote(acc)
+		return nil
+	}
+
+	start := time.Now()
+	timings := make(map[string]interface{})
+
+	if z.ReplayDir != "" {
+		if _, err := z.nextSnapshot(); err != nil {
+			return err
+		}
+	}
+
+	if z.PoolMetrics {
+		zpoolStart := time.Now()
+		lines, err := z.zpool()
+		if err != nil {
+			return err
+		}
+		stats, err := parseZpoolList(lines, z.OmitDashValues)
+		if err != nil {
+			return err
+		}
+		for _, pool := range stats {
+			z.addVersionTags(pool.tags)
+			acc.AddFields("zfs_pool", pool.fields, pool.tags)
+		}
+		timings["zpool_time_ns"] = time.Since(zpoolStart).Nanoseconds()
+	}
+
+	if z.CapacityForecast {
+		if err := z.gatherForecast(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if len(z.WatchSnapshots) > 0 {
+		z.gatherSnapshotEvents(acc)
+	}
+	if z.CloneMetrics {
+		if err := z.gatherClones(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	if z.ZvolMetrics {
+		if err := z.gatherZvols(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if z.TimingMetrics {
+		timings["gather_time_ns"] = time.Since(start).Nanoseconds()
+		acc.AddFields("zfs_gather", timings, nil)
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("zfs", func() telegraf.Input {
+		return &Zfs{
+			RemoteConcurrency: 4,
+			CapacityThreshold: 90,
+			CapacityWindow:    internal.Duration{Duration: 7 * 24 * time.Hour},
+			runner:            execRunner{},
+		}
+	})
+}
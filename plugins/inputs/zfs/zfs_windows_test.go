@@ -0,0 +1,51 @@
+// +build windows
+
+package zfs
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// > zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio
+var zpool_output = []string{
+	"tank	ONLINE	2989297238016	626958278656	2362338959360	12%	20	1.00x",
+}
+
+func TestZfsPoolMetrics(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		runner: &fixtureRunner{outputs: map[string][]string{
+			"zpool list -Hp -o name,health,size,alloc,free,fragmentation,capacity,dedupratio": zpool_output,
+		}},
+	}
+	err := z.Gather(&acc)
+	require.NoError(t, err)
+	require.False(t, acc.HasMeasurement("zfs_pool"))
+
+	z.PoolMetrics = true
+	err = z.Gather(&acc)
+	require.NoError(t, err)
+
+	tags := map[string]string{
+		"pool":   "tank",
+		"health": "ONLINE",
+	}
+	acc.AssertContainsTaggedFields(t, "zfs_pool", map[string]interface{}{
+		"allocated":     int64(626958278656),
+		"capacity":      int64(20),
+		"dedupratio":    float64(1),
+		"free":          int64(2362338959360),
+		"size":          int64(2989297238016),
+		"fragmentation": int64(12),
+	}, tags)
+	require.False(t, acc.HasMeasurement("zfs"))
+}
+
+func TestZfsInit(t *testing.T) {
+	z := &Zfs{KstatMetrics: []string{"arcstats"}}
+	require.Error(t, z.Init())
+}
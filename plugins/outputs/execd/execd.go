@@ -0,0 +1,187 @@
+package execd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon.  The program receives each batch of metrics on
+  ## STDIN in line protocol followed by an empty line, and must reply on
+  ## STDOUT with an empty line once the batch is written, or with a single
+  ## line describing the error.
+  command = ["telegraf-site-writer", "--url", "https://metrics.example.org"]
+
+  ## Time to wait for the program to acknowledge a batch.
+  # timeout = "5s"
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay is doubled after each consecutive failure up to
+  ## max_restart_delay, and reset once the process stays up for longer than
+  ## max_restart_delay.
+  # restart_delay = "10s"
+  # max_restart_delay = "5m"
+`
+
+type Execd struct {
+	Command         []string          `toml:"command"`
+	Timeout         internal.Duration `toml:"timeout"`
+	RestartDelay    internal.Duration `toml:"restart_delay"`
+	MaxRestartDelay internal.Duration `toml:"max_restart_delay"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	process    *process.Process
+	serializer *influx.Serializer
+	replies    chan string
+
+	// writeMu serializes the batches, the process reads a single stream
+	// and replies to the batches in order.
+	writeMu sync.Mutex
+
+	// skip is the number of replies still to be discarded after timeouts.
+	skip int
+	// exited is set when the process exits, the replies of the previous
+	// process are then not discarded from those of the next one.
+	exited int32
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+func (e *Execd) Connect() error {
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return err
+	}
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.MaxRestartDelay = e.MaxRestartDelay.Duration
+	e.process.Log = e.Log
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+	e.process.OnExitFn = func(err error) {
+		atomic.StoreInt32(&e.exited, 1)
+		e.Log.Error(err)
+	}
+
+	e.serializer = influx.NewSerializer()
+	e.serializer.SetFieldTypeSupport(influx.UintSupport)
+	e.replies = make(chan string, 100)
+
+	return e.process.Start()
+}
+
+func (e *Execd) Close() error {
+	if e.process == nil {
+		return nil
+	}
+
+	e.process.Stop()
+	return nil
+}
+
+// Write sends the batch to the process and waits for its reply.  Concurrent
+// writes, with max_concurrent_writes, are sent one after the other.
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	if atomic.CompareAndSwapInt32(&e.exited, 1, 0) {
+		e.resync()
+	}
+
+	octets, err := e.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return err
+	}
+	octets = append(octets, '\n')
+
+	if _, err := e.process.Write(octets); err != nil {
+		return fmt.Errorf("error writing to stdin: %s", err)
+	}
+
+	timeout := time.NewTimer(e.Timeout.Duration)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case reply := <-e.replies:
+			if e.skip > 0 {
+				e.skip--
+				continue
+			}
+			if reply != "" {
+				return errors.New(reply)
+			}
+			return nil
+		case <-timeout.C:
+			e.skip++
+			return errors.New("timeout waiting for reply")
+		}
+	}
+}
+
+// resync discards the replies still expected from the previous process,
+// which will never come, and the replies it left unread.
+func (e *Execd) resync() {
+	e.skip = 0
+	for {
+		select {
+		case <-e.replies:
+		default:
+			return
+		}
+	}
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.replies <- strings.TrimSpace(scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %s", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %s", err)
+	}
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return &Execd{
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			RestartDelay:    internal.Duration{Duration: 10 * time.Second},
+			MaxRestartDelay: internal.Duration{Duration: 5 * time.Minute},
+		}
+	})
+}
@@ -0,0 +1,119 @@
+// +build !windows
+
+package execd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newExecd(script string) *Execd {
+	return &Execd{
+		Command:      []string{"/bin/sh", "-c", script},
+		Timeout:      internal.Duration{Duration: 5 * time.Second},
+		RestartDelay: internal.Duration{Duration: 10 * time.Millisecond},
+		Log:          testutil.Logger{},
+	}
+}
+
+func testMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric("zpool",
+			map[string]string{"pool": "tank"},
+			map[string]interface{}{"alloc": int64(42)},
+			time.Unix(0, 0)),
+	}
+}
+
+func TestExecdWrite(t *testing.T) {
+	e := newExecd(`while read -r line; do [ -z "$line" ] && echo; done`)
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.NoError(t, e.Write(testMetrics()))
+	require.NoError(t, e.Write(testMetrics()))
+}
+
+func TestExecdWriteError(t *testing.T) {
+	e := newExecd(`while read -r line; do [ -z "$line" ] && echo "pool is full"; done`)
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	err := e.Write(testMetrics())
+	require.EqualError(t, err, "pool is full")
+}
+
+func TestExecdWriteTimeout(t *testing.T) {
+	e := newExecd("cat > /dev/null")
+	e.Timeout = internal.Duration{Duration: 10 * time.Millisecond}
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.Error(t, e.Write(testMetrics()))
+}
+
+func TestExecdWriteAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "execd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The first process exits without replying, the next one acknowledges
+	// the batches.
+	marker := filepath.Join(dir, "started")
+	e := newExecd(`if [ ! -e ` + marker + ` ]; then
+  touch ` + marker + `
+  read -r line
+  exit 1
+fi
+while read -r line; do [ -z "$line" ] && echo; done`)
+	e.Timeout = internal.Duration{Duration: 100 * time.Millisecond}
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.Error(t, e.Write(testMetrics()))
+
+	for atomic.LoadInt32(&e.exited) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NoError(t, e.Write(testMetrics()))
+}
+
+func TestExecdWriteConcurrent(t *testing.T) {
+	// Each batch holds a single metric, the process fails the batch if
+	// the lines of several batches are interleaved.
+	e := newExecd(`n=0
+while read -r line; do
+  if [ -n "$line" ]; then n=$((n+1)); continue; fi
+  if [ $n -eq 1 ]; then echo; else echo "interleaved"; fi
+  n=0
+done`)
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- e.Write(testMetrics())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
@@ -1,6 +1,7 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -21,10 +22,28 @@ type File struct {
 	Log                 telegraf.Logger   `toml:"-"`
 
 	writer     io.Writer
+	targets    []*target
 	closers    []io.Closer
 	serializer serializers.Serializer
 }
 
+// target is a file, or stdout, the metrics are written to.
+type target struct {
+	io.Writer
+	filename string
+	written  bool
+}
+
+// empty returns whether nothing was written to the target, including by a
+// previous run of telegraf, or since the file was rotated.
+func (t *target) empty() bool {
+	if t.filename == "" {
+		return !t.written
+	}
+	info, err := os.Stat(t.filename)
+	return err == nil && info.Size() == 0
+}
+
 var sampleConfig = `
   ## Files to write to, "stdout" is a specially handled file.
   files = ["stdout", "/tmp/metrics.out"]
@@ -46,7 +65,9 @@ var sampleConfig = `
   ## If set to -1, no archives are removed.
   # rotation_max_archives = 5
 
-  ## Data format to output.
+  ## Data format to output.  When the format has a header, like the column
+  ## names of csv, it is written at the start of each file, including after
+  ## a rotation, and use_batch_format is ignored.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
@@ -59,6 +80,7 @@ func (f *File) SetSerializer(serializer serializers.Serializer) {
 
 func (f *File) Connect() error {
 	writers := []io.Writer{}
+	f.targets = nil
 
 	if len(f.Files) == 0 {
 		f.Files = []string{"stdout"}
@@ -67,6 +89,7 @@ func (f *File) Connect() error {
 	for _, file := range f.Files {
 		if file == "stdout" {
 			writers = append(writers, os.Stdout)
+			f.targets = append(f.targets, &target{Writer: os.Stdout})
 		} else {
 			of, err := rotate.NewFileWriter(
 				file, f.RotationInterval.Duration, f.RotationMaxSize.Size, f.RotationMaxArchives)
@@ -75,6 +98,7 @@ func (f *File) Connect() error {
 			}
 
 			writers = append(writers, of)
+			f.targets = append(f.targets, &target{Writer: of, filename: file})
 			f.closers = append(f.closers, of)
 		}
 	}
@@ -104,6 +128,10 @@ func (f *File) Description() string {
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error = nil
 
+	if hs, ok := f.serializer.(serializers.HeaderSerializer); ok {
+		return f.writeWithHeader(hs, metrics)
+	}
+
 	if f.UseBatchFormat {
 		octets, err := f.serializer.SerializeBatch(metrics)
 		if err != nil {
@@ -131,6 +159,45 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	return writeErr
 }
 
+// writeWithHeader writes the metrics line by line and starts the empty files
+// with the header of the first metric.
+func (f *File) writeWithHeader(hs serializers.HeaderSerializer, metrics []telegraf.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	header, err := hs.SerializeHeader(metrics[0])
+	if err != nil {
+		return fmt.Errorf("could not serialize header: %v", err)
+	}
+
+	var body bytes.Buffer
+	for _, metric := range metrics {
+		b, err := f.serializer.Serialize(metric)
+		if err != nil {
+			f.Log.Debugf("Could not serialize metric: %v", err)
+			continue
+		}
+		body.Write(b)
+	}
+
+	var writeErr error
+	for _, t := range f.targets {
+		if len(header) > 0 && t.empty() {
+			if _, err := t.Write(header); err != nil {
+				writeErr = fmt.Errorf("failed to write header: %v", err)
+				continue
+			}
+		}
+		if _, err := t.Write(body.Bytes()); err != nil {
+			writeErr = fmt.Errorf("failed to write message: %v", err)
+			continue
+		}
+		t.written = true
+	}
+	return writeErr
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
@@ -180,6 +180,40 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileCSVHeader(t *testing.T) {
+	s, _ := serializers.NewCSVSerializer(true, "", "")
+	fh1 := createFile()
+	defer os.Remove(fh1.Name())
+	fh2 := tmpFile()
+	defer os.Remove(fh2)
+	f := File{
+		Files:          []string{fh1.Name(), fh2},
+		UseBatchFormat: true,
+		serializer:     s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	// The header is only written to the empty files.
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+	validateFile(fh1.Name(), "cpu,cpu=cpu0 value=100 1455312810012459582\n"+
+		"1257894000,test1,value1,1\n", t)
+	validateFile(fh2, "timestamp,measurement,tag1,value\n"+
+		"1257894000,test1,value1,1\n", t)
+
+	// As after a rotation.
+	assert.NoError(t, os.Truncate(fh2, 0))
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+	validateFile(fh2, "timestamp,measurement,tag1,value\n"+
+		"1257894000,test1,value1,1\n", t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func createFile() *os.File {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
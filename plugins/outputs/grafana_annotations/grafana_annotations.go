@@ -0,0 +1,186 @@
+package grafana_annotations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultURL     = "http://localhost:3000"
+	defaultTimeout = 5 * time.Second
+)
+
+var sampleConfig = `
+  ## Grafana server URL.
+  # url = "http://localhost:3000"
+
+  ## Grafana API key, or basic auth credentials.
+  # api_key = ""
+  # username = ""
+  # password = ""
+
+  ## Dashboard and panel the annotations are attached to, by default the
+  ## annotations are organization wide and can be queried by tags.
+  # dashboard_uid = ""
+  # panel_id = 0
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type GrafanaAnnotations struct {
+	URL          string            `toml:"url"`
+	APIKey       string            `toml:"api_key"`
+	Username     string            `toml:"username"`
+	Password     string            `toml:"password"`
+	DashboardUID string            `toml:"dashboard_uid"`
+	PanelID      int64             `toml:"panel_id"`
+	Timeout      internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
+}
+
+type annotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	PanelID      int64    `json:"panelId,omitempty"`
+	Time         int64    `json:"time"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+func (g *GrafanaAnnotations) Description() string {
+	return "Write events as Grafana annotations"
+}
+
+func (g *GrafanaAnnotations) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GrafanaAnnotations) Connect() error {
+	tlsCfg, err := g.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	g.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: g.Timeout.Duration,
+	}
+	return nil
+}
+
+func (g *GrafanaAnnotations) Close() error {
+	return nil
+}
+
+// Write posts an annotation for every event, other metrics are ignored.
+func (g *GrafanaAnnotations) Write(metrics []telegraf.Metric) error {
+	var skipped int
+	for _, m := range metrics {
+		if !metric.IsEvent(m) {
+			skipped++
+			continue
+		}
+
+		if err := g.post(g.annotation(m)); err != nil {
+			return err
+		}
+	}
+
+	if skipped > 0 {
+		g.Log.Debugf("Skipped %d metrics not following the event conventions", skipped)
+	}
+	return nil
+}
+
+// annotation converts an event, the tags of the annotation are the
+// measurement name and the metric tags in the "key:value" form.
+func (g *GrafanaAnnotations) annotation(m telegraf.Metric) *annotation {
+	text, _ := metric.EventTitle(m)
+	if details := metric.EventText(m); details != "" {
+		text += "\n\n" + details
+	}
+
+	tags := make([]string, 0, len(m.TagList())+1)
+	tags = append(tags, m.Name())
+	for _, tag := range m.TagList() {
+		tags = append(tags, tag.Key+":"+tag.Value)
+	}
+
+	return &annotation{
+		DashboardUID: g.DashboardUID,
+		PanelID:      g.PanelID,
+		Time:         m.Time().UnixNano() / int64(time.Millisecond),
+		Tags:         tags,
+		Text:         text,
+	}
+}
+
+func (g *GrafanaAnnotations) post(a *annotation) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(g.URL, "/") + "/api/annotations"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
+	req.Header.Set("Content-Type", "application/json")
+	if g.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.APIKey)
+	} else if g.Username != "" || g.Password != "" {
+		req.SetBasicAuth(g.Username, g.Password)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("when writing to [%s] received status code %d: %s",
+			url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	return err
+}
+
+func init() {
+	outputs.Add("grafana_annotations", func() telegraf.Output {
+		return &GrafanaAnnotations{
+			URL:     defaultURL,
+			Timeout: internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package grafana_annotations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	var received []annotation
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/annotations", r.URL.Path)
+		auth = r.Header.Get("Authorization")
+
+		var a annotation
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		received = append(received, a)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plugin := &GrafanaAnnotations{
+		URL:          ts.URL,
+		APIKey:       "secret",
+		DashboardUID: "zfs",
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Connect())
+
+	event, err := metric.NewEvent("zfs_events",
+		map[string]string{"pool": "tank"},
+		"scrub finished", "repaired 0B with 0 errors",
+		time.Unix(1580000000, 0))
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		event,
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "tank"},
+			map[string]interface{}{"size": 42},
+			time.Unix(1580000000, 0)),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Equal(t, "Bearer secret", auth)
+	require.Equal(t, []annotation{
+		{
+			DashboardUID: "zfs",
+			Time:         1580000000000,
+			Tags:         []string{"zfs_events", "pool:tank"},
+			Text:         "scrub finished\n\nrepaired 0B with 0 errors",
+		},
+	}, received)
+}
+
+func TestWriteError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Invalid API key"}`))
+	}))
+	defer ts.Close()
+
+	plugin := &GrafanaAnnotations{
+		URL: ts.URL,
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Connect())
+
+	event, err := metric.NewEvent("zfs_events", nil, "pool degraded", "", time.Now())
+	require.NoError(t, err)
+
+	err = plugin.Write([]telegraf.Metric{event})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid API key")
+}
@@ -18,12 +18,15 @@ import (
 type Graphite struct {
 	GraphiteTagSupport bool
 	// URL is only for backwards compatibility
-	Servers  []string
-	Prefix   string
-	Template string
-	Timeout  int
-	conns    []net.Conn
+	Servers   []string
+	Prefix    string
+	Template  string
+	Templates []string
+	Timeout   int
+	conns     []net.Conn
 	tlsint.ClientConfig
+
+	serializer serializers.Serializer
 }
 
 var sampleConfig = `
@@ -37,6 +40,14 @@ var sampleConfig = `
   ## see https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   template = "host.tags.measurement.field"
 
+  ## Graphite templates selected by measurement name, the first template with
+  ## a filter matching the measurement is used, otherwise the template above.
+  ## A template without a filter replaces the template above.
+  # templates = [
+  #   "cpu tags.measurement.host.field",
+  #   "zfs* host.measurement.tags.field",
+  # ]
+
   ## Enable Graphite tags support
   # graphite_tag_support = false
 
@@ -60,6 +71,12 @@ func (g *Graphite) Connect() error {
 		g.Servers = append(g.Servers, "localhost:2003")
 	}
 
+	serializer, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport, g.Templates)
+	if err != nil {
+		return err
+	}
+	g.serializer = serializer
+
 	// Set tls config
 	tlsConfig, err := g.ClientConfig.TLSConfig()
 	if err != nil {
@@ -134,20 +151,15 @@ func checkEOF(conn net.Conn) {
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var batch []byte
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport)
-	if err != nil {
-		return err
-	}
-
 	for _, metric := range metrics {
-		buf, err := s.Serialize(metric)
+		buf, err := g.serializer.Serialize(metric)
 		if err != nil {
 			log.Printf("E! Error serializing some metrics to graphite: %s", err.Error())
 		}
 		batch = append(batch, buf...)
 	}
 
-	err = g.send(batch)
+	err := g.send(batch)
 
 	// try to reconnect and retry to send
 	if err != nil {
@@ -56,9 +56,13 @@ var sampleConfig = `
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   # data_format = "influx"
 
-  ## HTTP Content-Encoding for write request body, can be set to "gzip" to
-  ## compress body or "identity" to apply no encoding.
+  ## HTTP Content-Encoding for write request body, can be set to "gzip" or
+  ## "zstd" to compress body or "identity" to apply no encoding.
   # content_encoding = "identity"
+  ## Compression level, from 1 to 9 for gzip and from 1 to 22 for zstd.  The
+  ## default of 0 uses the default level of the encoding.  zstd only has two
+  ## speeds: levels 1 and 2 use the fastest one, higher levels the default.
+  # content_encoding_level = 0
 
   ## Additional HTTP headers
   # [outputs.http.headers]
@@ -73,17 +77,18 @@ const (
 )
 
 type HTTP struct {
-	URL             string            `toml:"url"`
-	Timeout         internal.Duration `toml:"timeout"`
-	Method          string            `toml:"method"`
-	Username        string            `toml:"username"`
-	Password        string            `toml:"password"`
-	Headers         map[string]string `toml:"headers"`
-	ClientID        string            `toml:"client_id"`
-	ClientSecret    string            `toml:"client_secret"`
-	TokenURL        string            `toml:"token_url"`
-	Scopes          []string          `toml:"scopes"`
-	ContentEncoding string            `toml:"content_encoding"`
+	URL                  string            `toml:"url"`
+	Timeout              internal.Duration `toml:"timeout"`
+	Method               string            `toml:"method"`
+	Username             string            `toml:"username"`
+	Password             string            `toml:"password"`
+	Headers              map[string]string `toml:"headers"`
+	ClientID             string            `toml:"client_id"`
+	ClientSecret         string            `toml:"client_secret"`
+	TokenURL             string            `toml:"token_url"`
+	Scopes               []string          `toml:"scopes"`
+	ContentEncoding      string            `toml:"content_encoding"`
+	ContentEncodingLevel int               `toml:"content_encoding_level"`
 	tls.ClientConfig
 
 	client     *http.Client
@@ -135,6 +140,10 @@ func (h *HTTP) Connect() error {
 		h.Timeout.Duration = defaultClientTimeout
 	}
 
+	if _, err := internal.NewContentEncoderLevel(h.ContentEncoding, h.ContentEncodingLevel); err != nil {
+		return err
+	}
+
 	ctx := context.Background()
 	client, err := h.createClient(ctx)
 	if err != nil {
@@ -175,8 +184,8 @@ func (h *HTTP) write(reqBody []byte) error {
 	var reqBodyBuffer io.Reader = bytes.NewBuffer(reqBody)
 
 	var err error
-	if h.ContentEncoding == "gzip" {
-		rc, err := internal.CompressWithGzip(reqBodyBuffer)
+	if h.compressed() {
+		rc, err := internal.CompressWithEncoding(reqBodyBuffer, h.ContentEncoding, h.ContentEncodingLevel)
 		if err != nil {
 			return err
 		}
@@ -195,8 +204,8 @@ func (h *HTTP) write(reqBody []byte) error {
 
 	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
 	req.Header.Set("Content-Type", defaultContentType)
-	if h.ContentEncoding == "gzip" {
-		req.Header.Set("Content-Encoding", "gzip")
+	if h.compressed() {
+		req.Header.Set("Content-Encoding", h.ContentEncoding)
 	}
 	for k, v := range h.Headers {
 		if strings.ToLower(k) == "host" {
@@ -219,6 +228,10 @@ func (h *HTTP) write(reqBody []byte) error {
 	return nil
 }
 
+func (h *HTTP) compressed() bool {
+	return h.ContentEncoding != "" && h.ContentEncoding != "identity"
+}
+
 func init() {
 	outputs.Add("http", func() telegraf.Output {
 		return &HTTP{
@@ -258,6 +258,23 @@ func TestContentEncodingGzip(t *testing.T) {
 			},
 			expected: "gzip",
 		},
+		{
+			name: "gzip with compression level",
+			plugin: &HTTP{
+				URL:                  u.String(),
+				ContentEncoding:      "gzip",
+				ContentEncodingLevel: 9,
+			},
+			expected: "gzip",
+		},
+		{
+			name: "zstd content encoding",
+			plugin: &HTTP{
+				URL:             u.String(),
+				ContentEncoding: "zstd",
+			},
+			expected: "zstd",
+		},
 	}
 
 	for _, tt := range tests {
@@ -274,6 +291,12 @@ func TestContentEncodingGzip(t *testing.T) {
 
 				payload, err := ioutil.ReadAll(body)
 				require.NoError(t, err)
+				if r.Header.Get("Content-Encoding") == "zstd" {
+					dec, err := internal.NewContentDecoder("zstd")
+					require.NoError(t, err)
+					payload, err = dec.Decode(payload)
+					require.NoError(t, err)
+				}
 				require.Contains(t, string(payload), "cpu value=42")
 
 				w.WriteHeader(http.StatusNoContent)
@@ -290,6 +313,21 @@ func TestContentEncodingGzip(t *testing.T) {
 	}
 }
 
+func TestInvalidContentEncoding(t *testing.T) {
+	plugin := &HTTP{
+		URL:                  "http://localhost:8080",
+		ContentEncoding:      "zstd",
+		ContentEncodingLevel: 23,
+	}
+	require.Error(t, plugin.Connect())
+
+	plugin = &HTTP{
+		URL:             "http://localhost:8080",
+		ContentEncoding: "br",
+	}
+	require.Error(t, plugin.Connect())
+}
+
 func TestBasicAuth(t *testing.T) {
 	ts := httptest.NewServer(http.NotFoundHandler())
 	defer ts.Close()
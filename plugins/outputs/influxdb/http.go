@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -92,6 +93,7 @@ type HTTPConfig struct {
 	Proxy                *url.URL
 	Headers              map[string]string
 	ContentEncoding      string
+	ContentEncodingLevel int
 	Database             string
 	DatabaseTag          string
 	ExcludeDatabaseTag   bool
@@ -121,6 +123,10 @@ func NewHTTPClient(config HTTPConfig) (*httpClient, error) {
 		config.Database = defaultDatabase
 	}
 
+	if _, err := internal.NewContentEncoderLevel(config.ContentEncoding, config.ContentEncodingLevel); err != nil {
+		return nil, err
+	}
+
 	if config.Timeout == 0 {
 		config.Timeout = defaultRequestTimeout
 	}
@@ -237,15 +243,20 @@ func (c *httpClient) CreateDatabase(ctx context.Context, database string) error
 }
 
 // Write sends the metrics to InfluxDB
+// Write writes the metrics.  When the metrics are written to several
+// databases and only some of the writes fail, an internal.PartialWriteError
+// lists the metrics written, so that only the others are retried.
 func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error {
 	batches := make(map[string][]telegraf.Metric)
+	// indices are the indices in metrics of the metrics of each batch.
+	indices := make(map[string][]int)
 	if c.config.DatabaseTag == "" {
 		err := c.writeBatch(ctx, c.config.Database, metrics)
 		if err != nil {
 			return err
 		}
 	} else {
-		for _, metric := range metrics {
+		for i, metric := range metrics {
 			db, ok := metric.GetTag(c.config.DatabaseTag)
 			if !ok {
 				db = c.config.Database
@@ -263,8 +274,11 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 			}
 
 			batches[db] = append(batches[db], metric)
+			indices[db] = append(indices[db], i)
 		}
 
+		var accepted []int
+		var writeErr error
 		for db, batch := range batches {
 			if !c.config.SkipDatabaseCreation && !c.createdDatabases[db] {
 				err := c.CreateDatabase(ctx, db)
@@ -276,8 +290,20 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 
 			err := c.writeBatch(ctx, db, batch)
 			if err != nil {
-				return err
+				if writeErr == nil {
+					writeErr = err
+				}
+				continue
 			}
+			accepted = append(accepted, indices[db]...)
+		}
+
+		if writeErr != nil {
+			if len(accepted) == 0 {
+				return writeErr
+			}
+			sort.Ints(accepted)
+			return &internal.PartialWriteError{Err: writeErr, MetricsAccept: accepted}
 		}
 	}
 	return nil
@@ -401,8 +427,8 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
 	c.addHeaders(req)
 
-	if c.config.ContentEncoding == "gzip" {
-		req.Header.Set("Content-Encoding", "gzip")
+	if isCompressed(c.config.ContentEncoding) {
+		req.Header.Set("Content-Encoding", c.config.ContentEncoding)
 	}
 
 	return req, nil
@@ -413,8 +439,8 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser, error) {
 	reader := influx.NewReader(metrics, c.config.Serializer)
 
-	if c.config.ContentEncoding == "gzip" {
-		rc, err := internal.CompressWithGzip(reader)
+	if isCompressed(c.config.ContentEncoding) {
+		rc, err := internal.CompressWithEncoding(reader, c.config.ContentEncoding, c.config.ContentEncodingLevel)
 		if err != nil {
 			return nil, err
 		}
@@ -425,6 +451,10 @@ func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser
 	return ioutil.NopCloser(reader), nil
 }
 
+func isCompressed(encoding string) bool {
+	return encoding != "" && encoding != "identity"
+}
+
 func (c *httpClient) addHeaders(req *http.Request) {
 	if c.config.Username != "" || c.config.Password != "" {
 		req.SetBasicAuth(c.config.Username, c.config.Password)
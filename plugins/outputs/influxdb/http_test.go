@@ -733,3 +733,63 @@ func TestHTTP_WriteDatabaseTagWorksOnRetry(t *testing.T) {
 	err = client.Write(ctx, metrics)
 	require.NoError(t, err)
 }
+
+func TestHTTP_WriteDatabaseTagPartial(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/write":
+				r.ParseForm()
+				if r.Form.Get("db") == "bar" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}),
+	)
+	defer ts.Close()
+
+	addr := &url.URL{
+		Scheme: "http",
+		Host:   ts.Listener.Addr().String(),
+	}
+
+	config := influxdb.HTTPConfig{
+		URL:                  addr,
+		Database:             "telegraf",
+		DatabaseTag:          "database",
+		SkipDatabaseCreation: true,
+		Log:                  testutil.Logger{},
+	}
+
+	client, err := influxdb.NewHTTPClient(config)
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"database": "foo"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"database": "bar"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+	}
+
+	// The metrics of the databases written are accepted, the others are
+	// retried.
+	err = client.Write(context.Background(), metrics)
+	require.Error(t, err)
+	partial, ok := err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	require.Equal(t, []int{0, 2}, partial.MetricsAccept)
+}
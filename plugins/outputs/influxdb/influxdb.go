@@ -46,6 +46,7 @@ type InfluxDB struct {
 	HTTPProxy            string            `toml:"http_proxy"`
 	HTTPHeaders          map[string]string `toml:"http_headers"`
 	ContentEncoding      string            `toml:"content_encoding"`
+	ContentEncodingLevel int               `toml:"content_encoding_level"`
 	SkipDatabaseCreation bool              `toml:"skip_database_creation"`
 	InfluxUintSupport    bool              `toml:"influx_uint_support"`
 	tls.ClientConfig
@@ -120,9 +121,13 @@ var sampleConfig = `
   ## Additional HTTP headers
   # http_headers = {"X-Special-Header" = "Special-Value"}
 
-  ## HTTP Content-Encoding for write request body, can be set to "gzip" to
-  ## compress body or "identity" to apply no encoding.
+  ## HTTP Content-Encoding for write request body, can be set to "gzip" or
+  ## "zstd" to compress body or "identity" to apply no encoding.
   # content_encoding = "identity"
+  ## Compression level, from 1 to 9 for gzip and from 1 to 22 for zstd.  The
+  ## default of 0 uses the default level of the encoding.  zstd only has two
+  ## speeds: levels 1 and 2 use the fastest one, higher levels the default.
+  # content_encoding_level = 0
 
   ## When true, Telegraf will output unsigned integers as unsigned values,
   ## i.e.: "42u".  You will need a version of InfluxDB supporting unsigned
@@ -201,6 +206,9 @@ func (i *InfluxDB) SampleConfig() string {
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	ctx := context.Background()
 
+	// partial is the error of a client that wrote only part of the metrics,
+	// returned if no client writes them all.
+	var partial *internal.PartialWriteError
 	var err error
 	p := rand.Perm(len(i.clients))
 	for _, n := range p {
@@ -210,6 +218,13 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			return nil
 		}
 
+		if e, ok := err.(*internal.PartialWriteError); ok {
+			if partial == nil || len(e.MetricsAccept) > len(partial.MetricsAccept) {
+				partial = e
+			}
+			err = e.Err
+		}
+
 		switch apiError := err.(type) {
 		case *DatabaseNotFoundError:
 			if !i.SkipDatabaseCreation {
@@ -224,6 +239,9 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 		i.Log.Errorf("When writing to [%s]: %v", client.URL(), err)
 	}
 
+	if partial != nil {
+		return partial
+	}
 	return errors.New("could not write any address")
 }
 
@@ -258,6 +276,7 @@ func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy *url.URL)
 		Password:             i.Password,
 		Proxy:                proxy,
 		ContentEncoding:      i.ContentEncoding,
+		ContentEncodingLevel: i.ContentEncodingLevel,
 		Headers:              i.HTTPHeaders,
 		Database:             i.Database,
 		DatabaseTag:          i.DatabaseTag,
@@ -2,12 +2,14 @@ package influxdb_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/models"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb"
@@ -215,3 +217,33 @@ func TestWriteRecreateDatabaseIfDatabaseNotFound(t *testing.T) {
 	// We only have one URL, so we expect an error
 	require.Error(t, err)
 }
+
+func TestWritePartial(t *testing.T) {
+	output := influxdb.InfluxDB{
+		URLs:                 []string{"http://localhost:8086"},
+		SkipDatabaseCreation: true,
+		CreateHTTPClientF: func(config *influxdb.HTTPConfig) (influxdb.Client, error) {
+			return &MockClient{
+				WriteF: func(ctx context.Context, metrics []telegraf.Metric) error {
+					return &internal.PartialWriteError{
+						Err:           errors.New("database not writable"),
+						MetricsAccept: []int{0},
+					}
+				},
+				URLF: func() string {
+					return "http://localhost:8086"
+				},
+			}, nil
+		},
+	}
+	output.Log = testutil.Logger{}
+	require.NoError(t, output.Connect())
+
+	ro := models.NewRunningOutput("influxdb", &output, &models.OutputConfig{Name: "influxdb"}, 10, 10)
+	ro.AddMetric(testutil.TestMetric(1, "cpu"))
+	ro.AddMetric(testutil.TestMetric(2, "mem"))
+
+	// Only the rejected metric is kept in the buffer.
+	require.Error(t, ro.Write())
+	require.Equal(t, 1, ro.BufferLength())
+}
@@ -41,30 +41,32 @@ const (
 )
 
 type HTTPConfig struct {
-	URL              *url.URL
-	Token            string
-	Organization     string
-	Bucket           string
-	BucketTag        string
-	ExcludeBucketTag bool
-	Timeout          time.Duration
-	Headers          map[string]string
-	Proxy            *url.URL
-	UserAgent        string
-	ContentEncoding  string
-	TLSConfig        *tls.Config
+	URL                  *url.URL
+	Token                string
+	Organization         string
+	Bucket               string
+	BucketTag            string
+	ExcludeBucketTag     bool
+	Timeout              time.Duration
+	Headers              map[string]string
+	Proxy                *url.URL
+	UserAgent            string
+	ContentEncoding      string
+	ContentEncodingLevel int
+	TLSConfig            *tls.Config
 
 	Serializer *influx.Serializer
 }
 
 type httpClient struct {
-	ContentEncoding  string
-	Timeout          time.Duration
-	Headers          map[string]string
-	Organization     string
-	Bucket           string
-	BucketTag        string
-	ExcludeBucketTag bool
+	ContentEncoding      string
+	ContentEncodingLevel int
+	Timeout              time.Duration
+	Headers              map[string]string
+	Organization         string
+	Bucket               string
+	BucketTag            string
+	ExcludeBucketTag     bool
 
 	client     *http.Client
 	serializer *influx.Serializer
@@ -82,6 +84,10 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		timeout = defaultRequestTimeout
 	}
 
+	if _, err := internal.NewContentEncoderLevel(config.ContentEncoding, config.ContentEncodingLevel); err != nil {
+		return nil, err
+	}
+
 	userAgent := config.UserAgent
 	if userAgent == "" {
 		userAgent = "Telegraf/" + internal.Version()
@@ -133,14 +139,15 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 			Timeout:   timeout,
 			Transport: transport,
 		},
-		url:              config.URL,
-		ContentEncoding:  config.ContentEncoding,
-		Timeout:          timeout,
-		Headers:          headers,
-		Organization:     config.Organization,
-		Bucket:           config.Bucket,
-		BucketTag:        config.BucketTag,
-		ExcludeBucketTag: config.ExcludeBucketTag,
+		url:                  config.URL,
+		ContentEncoding:      config.ContentEncoding,
+		ContentEncodingLevel: config.ContentEncodingLevel,
+		Timeout:              timeout,
+		Headers:              headers,
+		Organization:         config.Organization,
+		Bucket:               config.Bucket,
+		BucketTag:            config.BucketTag,
+		ExcludeBucketTag:     config.ExcludeBucketTag,
 	}
 	return client, nil
 }
@@ -297,8 +304,8 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
 	c.addHeaders(req)
 
-	if c.ContentEncoding == "gzip" {
-		req.Header.Set("Content-Encoding", "gzip")
+	if isCompressed(c.ContentEncoding) {
+		req.Header.Set("Content-Encoding", c.ContentEncoding)
 	}
 
 	return req, nil
@@ -309,8 +316,8 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser, error) {
 	reader := influx.NewReader(metrics, c.serializer)
 
-	if c.ContentEncoding == "gzip" {
-		rc, err := internal.CompressWithGzip(reader)
+	if isCompressed(c.ContentEncoding) {
+		rc, err := internal.CompressWithEncoding(reader, c.ContentEncoding, c.ContentEncodingLevel)
 		if err != nil {
 			return nil, err
 		}
@@ -321,6 +328,10 @@ func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser
 	return ioutil.NopCloser(reader), nil
 }
 
+func isCompressed(encoding string) bool {
+	return encoding != "" && encoding != "identity"
+}
+
 func (c *httpClient) addHeaders(req *http.Request) {
 	for header, value := range c.Headers {
 		req.Header.Set(header, value)
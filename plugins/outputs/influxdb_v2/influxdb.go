@@ -59,9 +59,13 @@ var sampleConfig = `
   ## HTTP User-Agent
   # user_agent = "telegraf"
 
-  ## Content-Encoding for write request body, can be set to "gzip" to
-  ## compress body or "identity" to apply no encoding.
+  ## Content-Encoding for write request body, can be set to "gzip" or "zstd"
+  ## to compress body or "identity" to apply no encoding.
   # content_encoding = "gzip"
+  ## Compression level, from 1 to 9 for gzip and from 1 to 22 for zstd.  The
+  ## default of 0 uses the default level of the encoding.  zstd only has two
+  ## speeds: levels 1 and 2 use the fastest one, higher levels the default.
+  # content_encoding_level = 0
 
   ## Enable or disable uint support for writing uints influxdb 2.0.
   # influx_uint_support = false
@@ -82,18 +86,19 @@ type Client interface {
 }
 
 type InfluxDB struct {
-	URLs             []string          `toml:"urls"`
-	Token            string            `toml:"token"`
-	Organization     string            `toml:"organization"`
-	Bucket           string            `toml:"bucket"`
-	BucketTag        string            `toml:"bucket_tag"`
-	ExcludeBucketTag bool              `toml:"exclude_bucket_tag"`
-	Timeout          internal.Duration `toml:"timeout"`
-	HTTPHeaders      map[string]string `toml:"http_headers"`
-	HTTPProxy        string            `toml:"http_proxy"`
-	UserAgent        string            `toml:"user_agent"`
-	ContentEncoding  string            `toml:"content_encoding"`
-	UintSupport      bool              `toml:"influx_uint_support"`
+	URLs                 []string          `toml:"urls"`
+	Token                string            `toml:"token"`
+	Organization         string            `toml:"organization"`
+	Bucket               string            `toml:"bucket"`
+	BucketTag            string            `toml:"bucket_tag"`
+	ExcludeBucketTag     bool              `toml:"exclude_bucket_tag"`
+	Timeout              internal.Duration `toml:"timeout"`
+	HTTPHeaders          map[string]string `toml:"http_headers"`
+	HTTPProxy            string            `toml:"http_proxy"`
+	UserAgent            string            `toml:"user_agent"`
+	ContentEncoding      string            `toml:"content_encoding"`
+	ContentEncodingLevel int               `toml:"content_encoding_level"`
+	UintSupport          bool              `toml:"influx_uint_support"`
 	tls.ClientConfig
 
 	clients []Client
@@ -178,19 +183,20 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 	}
 
 	config := &HTTPConfig{
-		URL:              url,
-		Token:            i.Token,
-		Organization:     i.Organization,
-		Bucket:           i.Bucket,
-		BucketTag:        i.BucketTag,
-		ExcludeBucketTag: i.ExcludeBucketTag,
-		Timeout:          i.Timeout.Duration,
-		Headers:          i.HTTPHeaders,
-		Proxy:            proxy,
-		UserAgent:        i.UserAgent,
-		ContentEncoding:  i.ContentEncoding,
-		TLSConfig:        tlsConfig,
-		Serializer:       i.newSerializer(),
+		URL:                  url,
+		Token:                i.Token,
+		Organization:         i.Organization,
+		Bucket:               i.Bucket,
+		BucketTag:            i.BucketTag,
+		ExcludeBucketTag:     i.ExcludeBucketTag,
+		Timeout:              i.Timeout.Duration,
+		Headers:              i.HTTPHeaders,
+		Proxy:                proxy,
+		UserAgent:            i.UserAgent,
+		ContentEncoding:      i.ContentEncoding,
+		ContentEncodingLevel: i.ContentEncodingLevel,
+		TLSConfig:            tlsConfig,
+		Serializer:           i.newSerializer(),
 	}
 
 	c, err := NewHTTPClient(config)
@@ -0,0 +1,283 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	maxErrorBytes  = 512
+)
+
+var sampleConfig = `
+  ## Every metric written to this output is sent as a notification, select
+  ## the metrics with the namepass or tagpass options.
+  namepass = ["alert"]
+
+  ## Webhook URL the notifications are sent to.
+  url = "https://hooks.slack.com/services/XXX/YYY/ZZZ"
+  ## HTTP method, one of "POST" or "PUT".
+  # method = "POST"
+  ## Additional HTTP headers.
+  # [outputs.notify.headers]
+  #   Authorization = "Bearer @{env:WEBHOOK_TOKEN}"
+
+  ## Command run for each notification, with the payload on stdin, instead
+  ## of calling a webhook.
+  # command = ["/usr/local/bin/notify"]
+
+  ## Timeout for the webhook request or command.
+  # timeout = "5s"
+
+  ## Payload format, one of "json", "slack" or "pagerduty".
+  # format = "json"
+  ## Go template of the payload, overrides the format.
+  # template = '{"text": {{json .Title}}}'
+  ## Variables available as .Vars in the template, the pagerduty format
+  ## requires the routing_key variable.
+  # [outputs.notify.vars]
+  #   routing_key = "@{env:PAGERDUTY_ROUTING_KEY}"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// formats are the predefined payload templates.
+var formats = map[string]string{
+	"json": `{"name": {{json .Name}}, "title": {{json .Title}}, "text": {{json .Text}}, ` +
+		`"tags": {{json .Tags}}, "fields": {{json .Fields}}, "time": {{json .Time}}}`,
+	"slack": `{"text": {{json (printf "*%s*\n%s" .Title .Text)}}}`,
+	"pagerduty": `{"routing_key": {{json .Vars.routing_key}}, ` +
+		`"event_action": {{if eq (printf "%v" .Fields.firing) "false"}}"resolve"{{else}}"trigger"{{end}}, ` +
+		`"dedup_key": {{json .ID}}, ` +
+		`"payload": {"summary": {{json .Title}}, "source": {{json (or .Tags.host "telegraf")}}, ` +
+		`"severity": {{json (or .Tags.severity "warning")}}, "timestamp": {{json .Time}}, ` +
+		`"custom_details": {{json .Fields}}}}`,
+}
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+type Notify struct {
+	URL      string            `toml:"url"`
+	Method   string            `toml:"method"`
+	Headers  map[string]string `toml:"headers"`
+	Command  []string          `toml:"command"`
+	Timeout  internal.Duration `toml:"timeout"`
+	Format   string            `toml:"format"`
+	Template string            `toml:"template"`
+	Vars     map[string]string `toml:"vars"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// notification is the data of the payload template.
+type notification struct {
+	// ID identifies the series of the metric, the same for all the
+	// notifications of an alert.
+	ID     string
+	Name   string
+	Title  string
+	Text   string
+	Tags   map[string]string
+	Fields map[string]interface{}
+	Time   time.Time
+	Vars   map[string]string
+}
+
+func (n *Notify) Description() string {
+	return "Send metrics as notifications to a webhook or command"
+}
+
+func (n *Notify) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *Notify) Init() error {
+	if (n.URL == "") == (len(n.Command) == 0) {
+		return fmt.Errorf("exactly one of url or command must be set")
+	}
+
+	n.Method = strings.ToUpper(n.Method)
+	if n.Method != http.MethodPost && n.Method != http.MethodPut {
+		return fmt.Errorf("invalid method %q", n.Method)
+	}
+
+	text := n.Template
+	if text == "" {
+		var ok bool
+		text, ok = formats[n.Format]
+		if !ok {
+			return fmt.Errorf("invalid format %q", n.Format)
+		}
+	}
+	if n.Format == "pagerduty" && n.Template == "" && n.Vars["routing_key"] == "" {
+		return fmt.Errorf("the pagerduty format requires the routing_key variable")
+	}
+
+	var err error
+	n.tmpl, err = template.New("payload").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return fmt.Errorf("error parsing template: %v", err)
+	}
+	return nil
+}
+
+func (n *Notify) Connect() error {
+	if n.URL == "" {
+		return nil
+	}
+
+	tlsCfg, err := n.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	n.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: n.Timeout.Duration,
+	}
+	return nil
+}
+
+func (n *Notify) Close() error {
+	return nil
+}
+
+// Write sends a notification for every metric.  On error the whole batch is
+// retried, so notifications sent before the error may be sent again.
+func (n *Notify) Write(metrics []telegraf.Metric) error {
+	for _, m := range metrics {
+		payload, err := n.payload(m)
+		if err != nil {
+			n.Log.Errorf("Error rendering payload for %s, dropping notification: %v", m.Name(), err)
+			continue
+		}
+
+		if n.URL != "" {
+			err = n.post(payload)
+		} else {
+			err = n.run(payload)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Notify) payload(m telegraf.Metric) ([]byte, error) {
+	title, ok := metric.EventTitle(m)
+	if !ok {
+		title = m.Name()
+	}
+
+	data := &notification{
+		ID:     strconv.FormatUint(m.HashID(), 16),
+		Name:   m.Name(),
+		Title:  title,
+		Text:   metric.EventText(m),
+		Tags:   m.Tags(),
+		Fields: m.Fields(),
+		Time:   m.Time(),
+		Vars:   n.Vars,
+	}
+
+	var buf bytes.Buffer
+	if err := n.tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *Notify) post(payload []byte) error {
+	req, err := http.NewRequest(n.Method, n.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.Headers {
+		if strings.ToLower(k) == "host" {
+			req.Host = v
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBytes))
+		return fmt.Errorf("when writing to [%s] received status code %d: %s",
+			n.URL, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	return err
+}
+
+func (n *Notify) run(payload []byte) error {
+	cmd := exec.Command(n.Command[0], n.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := internal.RunTimeout(cmd, n.Timeout.Duration)
+	if err == nil {
+		return nil
+	}
+	if err == internal.TimeoutErr {
+		return fmt.Errorf("%q timed out and was killed", n.Command)
+	}
+
+	msg := stderr.String()
+	if len(msg) > maxErrorBytes {
+		msg = msg[:maxErrorBytes] + "..."
+	}
+	return fmt.Errorf("%q failed with %v: %s", n.Command, err, strings.TrimSpace(msg))
+}
+
+func init() {
+	outputs.Add("notify", func() telegraf.Output {
+		return &Notify{
+			Method:  http.MethodPost,
+			Format:  "json",
+			Timeout: internal.Duration{Duration: defaultTimeout},
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newAlert(firing bool) telegraf.Metric {
+	state := "ok"
+	if firing {
+		state = "firing"
+	}
+	m, err := metric.NewEvent("alert",
+		map[string]string{"alert": "zpool_capacity", "pool": "tank", "severity": "critical"},
+		"zpool_capacity is "+state,
+		"zfs_pool.capacity = 95, fires when > 90, clears when <= 85",
+		time.Unix(1580000000, 0).UTC())
+	if err != nil {
+		panic(err)
+	}
+	m.AddField("firing", firing)
+	return m
+}
+
+func newNotify(url string) *Notify {
+	return &Notify{
+		URL:     url,
+		Method:  http.MethodPost,
+		Format:  "json",
+		Timeout: internal.Duration{Duration: defaultTimeout},
+		Log:     testutil.Logger{},
+	}
+}
+
+// serve starts a webhook server decoding the JSON payloads it receives.
+func serve(t *testing.T, status int) (*httptest.Server, *[]map[string]interface{}) {
+	var payloads []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+		w.WriteHeader(status)
+	}))
+	return ts, &payloads
+}
+
+func TestWriteJSON(t *testing.T) {
+	ts, payloads := serve(t, http.StatusOK)
+	defer ts.Close()
+
+	plugin := newNotify(ts.URL)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write([]telegraf.Metric{newAlert(true)}))
+
+	require.Len(t, *payloads, 1)
+	payload := (*payloads)[0]
+	require.Equal(t, "alert", payload["name"])
+	require.Equal(t, "zpool_capacity is firing", payload["title"])
+	require.Equal(t, "2020-01-26T00:53:20Z", payload["time"])
+	require.Equal(t, "tank", payload["tags"].(map[string]interface{})["pool"])
+	require.Equal(t, true, payload["fields"].(map[string]interface{})["firing"])
+}
+
+func TestWriteSlack(t *testing.T) {
+	ts, payloads := serve(t, http.StatusOK)
+	defer ts.Close()
+
+	plugin := newNotify(ts.URL)
+	plugin.Format = "slack"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write([]telegraf.Metric{newAlert(true)}))
+
+	require.Equal(t, []map[string]interface{}{
+		{"text": "*zpool_capacity is firing*\nzfs_pool.capacity = 95, fires when > 90, clears when <= 85"},
+	}, *payloads)
+}
+
+func TestWritePagerDuty(t *testing.T) {
+	ts, payloads := serve(t, http.StatusAccepted)
+	defer ts.Close()
+
+	plugin := newNotify(ts.URL)
+	plugin.Format = "pagerduty"
+	require.Error(t, plugin.Init())
+
+	plugin.Vars = map[string]string{"routing_key": "key"}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write([]telegraf.Metric{newAlert(true), newAlert(false)}))
+
+	require.Len(t, *payloads, 2)
+	trigger, resolve := (*payloads)[0], (*payloads)[1]
+	require.Equal(t, "key", trigger["routing_key"])
+	require.Equal(t, "trigger", trigger["event_action"])
+	require.Equal(t, "resolve", resolve["event_action"])
+	require.Equal(t, trigger["dedup_key"], resolve["dedup_key"])
+
+	details := trigger["payload"].(map[string]interface{})
+	require.Equal(t, "zpool_capacity is firing", details["summary"])
+	require.Equal(t, "telegraf", details["source"])
+	require.Equal(t, "critical", details["severity"])
+}
+
+func TestWriteTemplate(t *testing.T) {
+	ts, payloads := serve(t, http.StatusOK)
+	defer ts.Close()
+
+	plugin := newNotify(ts.URL)
+	plugin.Template = `{"pool": {{json .Tags.pool}}, "channel": {{json .Vars.channel}}}`
+	plugin.Vars = map[string]string{"channel": "#storage"}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write([]telegraf.Metric{newAlert(true)}))
+
+	require.Equal(t, []map[string]interface{}{
+		{"pool": "tank", "channel": "#storage"},
+	}, *payloads)
+}
+
+func TestWriteError(t *testing.T) {
+	ts, _ := serve(t, http.StatusInternalServerError)
+	defer ts.Close()
+
+	plugin := newNotify(ts.URL)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.Error(t, plugin.Write([]telegraf.Metric{newAlert(true)}))
+}
+
+func TestWriteCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "notify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	plugin := newNotify("")
+	plugin.Command = []string{"sh", "-c", "cat >> " + out}
+	plugin.Template = `{{.Title}}` + "\n"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write([]telegraf.Metric{newAlert(true), newAlert(false)}))
+
+	contents, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "zpool_capacity is firing\nzpool_capacity is ok\n", string(contents))
+
+	plugin.Command = []string{"false"}
+	require.Error(t, plugin.Write([]telegraf.Metric{newAlert(true)}))
+}
+
+func TestInitErrors(t *testing.T) {
+	plugin := newNotify("")
+	require.Error(t, plugin.Init())
+
+	plugin = newNotify("http://localhost")
+	plugin.Command = []string{"true"}
+	require.Error(t, plugin.Init())
+
+	plugin = newNotify("http://localhost")
+	plugin.Format = "xml"
+	require.Error(t, plugin.Init())
+
+	plugin = newNotify("http://localhost")
+	plugin.Template = "{{.Title"
+	require.Error(t, plugin.Init())
+}
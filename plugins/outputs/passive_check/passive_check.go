@@ -0,0 +1,526 @@
+package passive_check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	maxErrorBytes  = 512
+	resultPath     = "/v1/actions/process-check-result"
+)
+
+var sampleConfig = `
+  ## Icinga 2 API the check results are submitted to.
+  url = "https://localhost:5665"
+  # username = "telegraf"
+  # password = "@{env:ICINGA_API_PASSWORD}"
+
+  ## External command file of Nagios or Icinga the check results are written
+  ## to, instead of the Icinga 2 API.
+  # command_file = "/var/run/icinga2/cmd/icinga2.cmd"
+
+  ## Timeout for the API request, or for writing to the command file.
+  # timeout = "5s"
+
+  ## Tag holding the host name of the check results, metrics without this tag
+  ## are ignored.
+  # host_tag = "host"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  [[outputs.passive_check.check]]
+    ## Service name of the check results, a Go template of the metric tags.
+    service = "zpool {{.pool}} capacity"
+    ## Measurement names the check applies to, any name if empty.
+    measurement = ["zfs_pool"]
+    ## Field checked.
+    field = "capacity"
+    ## Comparison operator of numeric fields to the thresholds, one of ">",
+    ## ">=", "<" or "<=".
+    # operator = ">"
+    warning = 80.0
+    critical = 90.0
+
+  [[outputs.passive_check.check]]
+    service = "zpool {{.pool}} health"
+    measurement = ["zfs_pool"]
+    field = "health"
+    ## Values of string or boolean fields mapped to a state, any other value
+    ## is unknown.
+    ok_values = ["ONLINE"]
+    warning_values = ["DEGRADED"]
+    critical_values = ["FAULTED", "OFFLINE", "REMOVED", "UNAVAIL", "SUSPENDED"]
+`
+
+// State is the exit status of a check.
+type State int
+
+const (
+	StateOK State = iota
+	StateWarning
+	StateCritical
+	StateUnknown
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOK:
+		return "OK"
+	case StateWarning:
+		return "WARNING"
+	case StateCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+var operators = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+}
+
+type Check struct {
+	Service        string   `toml:"service"`
+	Measurement    []string `toml:"measurement"`
+	Field          string   `toml:"field"`
+	Operator       string   `toml:"operator"`
+	Warning        *float64 `toml:"warning"`
+	Critical       *float64 `toml:"critical"`
+	OKValues       []string `toml:"ok_values"`
+	WarningValues  []string `toml:"warning_values"`
+	CriticalValues []string `toml:"critical_values"`
+
+	service     *template.Template
+	measurement filter.Filter
+	compare     func(a, b float64) bool
+	values      map[string]State
+}
+
+type PassiveCheck struct {
+	URL         string            `toml:"url"`
+	Username    string            `toml:"username"`
+	Password    string            `toml:"password"`
+	CommandFile string            `toml:"command_file"`
+	Timeout     internal.Duration `toml:"timeout"`
+	HostTag     string            `toml:"host_tag"`
+	Checks      []*Check          `toml:"check"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
+}
+
+// result is the result of a check for a host and service.
+type result struct {
+	Host        string
+	Service     string
+	State       State
+	Output      string
+	Performance string
+	Time        time.Time
+}
+
+func (p *PassiveCheck) Description() string {
+	return "Submit metrics as passive check results to Icinga or Nagios"
+}
+
+func (p *PassiveCheck) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PassiveCheck) Init() error {
+	if (p.URL == "") == (p.CommandFile == "") {
+		return fmt.Errorf("exactly one of url or command_file must be set")
+	}
+	if len(p.Checks) == 0 {
+		return fmt.Errorf("no check configured")
+	}
+
+	for i, check := range p.Checks {
+		if err := check.init(); err != nil {
+			return fmt.Errorf("check %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c *Check) init() error {
+	if c.Service == "" {
+		return fmt.Errorf("service must not be empty")
+	}
+	if c.Field == "" {
+		return fmt.Errorf("field must not be empty")
+	}
+
+	var err error
+	c.service, err = template.New("service").Option("missingkey=zero").Parse(c.Service)
+	if err != nil {
+		return fmt.Errorf("error parsing service: %v", err)
+	}
+
+	c.measurement, err = filter.Compile(c.Measurement)
+	if err != nil {
+		return fmt.Errorf("error compiling measurement: %v", err)
+	}
+
+	hasThresholds := c.Warning != nil || c.Critical != nil
+	hasValues := len(c.OKValues)+len(c.WarningValues)+len(c.CriticalValues) > 0
+	if hasThresholds == hasValues {
+		return fmt.Errorf("exactly one of the thresholds or the values must be set")
+	}
+
+	if hasThresholds {
+		if c.Operator == "" {
+			c.Operator = ">"
+		}
+		var ok bool
+		c.compare, ok = operators[c.Operator]
+		if !ok {
+			return fmt.Errorf("invalid operator %q", c.Operator)
+		}
+		return nil
+	}
+
+	c.values = make(map[string]State)
+	for state, values := range map[State][]string{
+		StateOK:       c.OKValues,
+		StateWarning:  c.WarningValues,
+		StateCritical: c.CriticalValues,
+	} {
+		for _, value := range values {
+			if _, ok := c.values[value]; ok {
+				return fmt.Errorf("value %q mapped to more than one state", value)
+			}
+			c.values[value] = state
+		}
+	}
+	return nil
+}
+
+func (p *PassiveCheck) Connect() error {
+	if p.URL == "" {
+		return nil
+	}
+
+	tlsCfg, err := p.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	p.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: p.Timeout.Duration,
+	}
+	return nil
+}
+
+func (p *PassiveCheck) Close() error {
+	return nil
+}
+
+// Write submits the latest result of each host and service in the metrics.
+// When only some of the results are submitted to the API, the metrics whose
+// results were all submitted are acknowledged with a PartialWriteError.
+func (p *PassiveCheck) Write(metrics []telegraf.Metric) error {
+	latest := make(map[string]*result)
+	// resultKeys holds the keys of the results of each metric.
+	resultKeys := make([][]string, len(metrics))
+	for i, m := range metrics {
+		host, ok := m.GetTag(p.HostTag)
+		if !ok {
+			continue
+		}
+
+		for _, check := range p.Checks {
+			r, err := check.evaluate(m)
+			if err != nil {
+				p.Log.Errorf("Error checking %s: %v", m.Name(), err)
+				continue
+			}
+			if r == nil {
+				continue
+			}
+
+			r.Host = host
+			key := r.Host + "!" + r.Service
+			resultKeys[i] = append(resultKeys[i], key)
+			if prev, ok := latest[key]; !ok || !r.Time.Before(prev.Time) {
+				latest[key] = r
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(latest))
+	for key := range latest {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	results := make([]*result, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, latest[key])
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+	if p.URL == "" {
+		return p.writeCommandFile(results)
+	}
+
+	n, err := p.submit(results)
+	if err == nil || n == 0 {
+		return err
+	}
+
+	submitted := make(map[string]bool, n)
+	for _, key := range keys[:n] {
+		submitted[key] = true
+	}
+	var accepted []int
+	for i, metricKeys := range resultKeys {
+		ok := true
+		for _, key := range metricKeys {
+			ok = ok && submitted[key]
+		}
+		if ok {
+			accepted = append(accepted, i)
+		}
+	}
+	return &internal.PartialWriteError{Err: err, MetricsAccept: accepted}
+}
+
+// evaluate returns the result of the check for the metric, or nil if the
+// check does not apply to the metric.
+func (c *Check) evaluate(m telegraf.Metric) (*result, error) {
+	if c.measurement != nil && !c.measurement.Match(m.Name()) {
+		return nil, nil
+	}
+	value, ok := m.GetField(c.Field)
+	if !ok {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := c.service.Execute(&buf, m.Tags()); err != nil {
+		return nil, fmt.Errorf("error rendering service: %v", err)
+	}
+
+	r := &result{
+		Service: buf.String(),
+		Time:    m.Time(),
+	}
+
+	if c.values != nil {
+		text := fmt.Sprint(value)
+		state, ok := c.values[text]
+		if !ok {
+			state = StateUnknown
+		}
+		r.State = state
+		r.Output = fmt.Sprintf("%s - %s %s is %s", state, m.Name(), c.Field, text)
+		return r, nil
+	}
+
+	v, ok := toFloat(value)
+	if !ok {
+		r.State = StateUnknown
+		r.Output = fmt.Sprintf("%s - %s %s is not numeric: %v", r.State, m.Name(), c.Field, value)
+		return r, nil
+	}
+
+	switch {
+	case c.Critical != nil && c.compare(v, *c.Critical):
+		r.State = StateCritical
+	case c.Warning != nil && c.compare(v, *c.Warning):
+		r.State = StateWarning
+	default:
+		r.State = StateOK
+	}
+	r.Output = fmt.Sprintf("%s - %s %s is %s", r.State, m.Name(), c.Field, formatFloat(v))
+	r.Performance = c.performance(v)
+	return r, nil
+}
+
+// performance returns the performance data of the value in the Nagios plugin
+// format, with the thresholds as ranges: "80" alerts above 80 and "10:"
+// below 10.
+func (c *Check) performance(v float64) string {
+	threshold := func(t *float64) string {
+		if t == nil {
+			return ""
+		}
+		if c.Operator == "<" || c.Operator == "<=" {
+			return formatFloat(*t) + ":"
+		}
+		return formatFloat(*t)
+	}
+	perf := fmt.Sprintf("%s=%s;%s;%s", c.Field, formatFloat(v), threshold(c.Warning), threshold(c.Critical))
+	return strings.TrimRight(perf, ";")
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// checkResult is the request body of the process-check-result action.
+type checkResult struct {
+	Type            string   `json:"type"`
+	Filter          string   `json:"filter"`
+	ExitStatus      State    `json:"exit_status"`
+	PluginOutput    string   `json:"plugin_output"`
+	PerformanceData []string `json:"performance_data,omitempty"`
+	ExecutionStart  int64    `json:"execution_start"`
+	ExecutionEnd    int64    `json:"execution_end"`
+}
+
+// submit posts the results to the Icinga 2 API and returns the number of
+// results submitted before an error.  A result for an unknown host or
+// service is dropped, since retrying it would block the output.
+func (p *PassiveCheck) submit(results []*result) (int, error) {
+	url := strings.TrimRight(p.URL, "/") + resultPath
+	for i, r := range results {
+		body := &checkResult{
+			Type: "Service",
+			Filter: fmt.Sprintf("host.name==%s && service.name==%s",
+				strconv.Quote(r.Host), strconv.Quote(r.Service)),
+			ExitStatus:     r.State,
+			PluginOutput:   r.Output,
+			ExecutionStart: r.Time.Unix(),
+			ExecutionEnd:   r.Time.Unix(),
+		}
+		if r.Performance != "" {
+			body.PerformanceData = []string{r.Performance}
+		}
+
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return i, err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(payload))
+		if err != nil {
+			return i, err
+		}
+		req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if p.Username != "" || p.Password != "" {
+			req.SetBasicAuth(p.Username, p.Password)
+		}
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return i, err
+		}
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBytes))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			p.Log.Errorf("No service %q on host %q, dropping check result: %s",
+				r.Service, r.Host, strings.TrimSpace(string(msg)))
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			return i, fmt.Errorf("when writing to [%s] received status code %d: %s",
+				url, resp.StatusCode, strings.TrimSpace(string(msg)))
+		}
+	}
+	return len(results), nil
+}
+
+// writeCommandFile writes the results as PROCESS_SERVICE_CHECK_RESULT
+// external commands.
+func (p *PassiveCheck) writeCommandFile(results []*result) error {
+	var buf bytes.Buffer
+	for _, r := range results {
+		output := r.Output
+		if r.Performance != "" {
+			output += "|" + r.Performance
+		}
+		fmt.Fprintf(&buf, "[%d] PROCESS_SERVICE_CHECK_RESULT;%s;%s;%d;%s\n",
+			r.Time.Unix(), commandArg(r.Host), commandArg(r.Service), r.State, oneLine(output))
+	}
+
+	// The command file is usually a named pipe, opened for each write so
+	// that a restarted monitoring core is picked up.  It is opened without
+	// blocking, which fails when no core reads the pipe, and the write is
+	// bounded by the timeout.
+	f, err := os.OpenFile(p.CommandFile, os.O_WRONLY|os.O_APPEND|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		if e, ok := err.(*os.PathError); ok && e.Err == syscall.ENXIO {
+			return fmt.Errorf("no monitoring core is reading %s", p.CommandFile)
+		}
+		return err
+	}
+	// Regular files do not support deadlines, their writes do not block.
+	if p.Timeout.Duration > 0 {
+		f.SetWriteDeadline(time.Now().Add(p.Timeout.Duration))
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// commandArg replaces the characters separating external commands and their
+// arguments.
+func commandArg(s string) string {
+	return strings.Replace(oneLine(s), ";", "_", -1)
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func init() {
+	outputs.Add("passive_check", func() telegraf.Output {
+		return &PassiveCheck{
+			Timeout: internal.Duration{Duration: defaultTimeout},
+			HostTag: "host",
+		}
+	})
+}
@@ -0,0 +1,32 @@
+// +build !windows
+
+package passive_check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCommandFileNoReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "passive_check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "icinga2.cmd")
+	require.NoError(t, syscall.Mkfifo(path, 0600))
+
+	plugin := newPassiveCheck()
+	plugin.CommandFile = path
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	err = plugin.Write([]telegraf.Metric{newPool("tank", 10, "ONLINE", 1580000000)})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no monitoring core is reading")
+}
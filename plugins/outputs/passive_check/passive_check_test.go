@@ -0,0 +1,235 @@
+package passive_check
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func newPassiveCheck() *PassiveCheck {
+	return &PassiveCheck{
+		Timeout: internal.Duration{Duration: defaultTimeout},
+		HostTag: "host",
+		Checks: []*Check{
+			{
+				Service:     "zpool {{.pool}} capacity",
+				Measurement: []string{"zfs_pool"},
+				Field:       "capacity",
+				Warning:     float(80),
+				Critical:    float(90),
+			},
+			{
+				Service:        "zpool {{.pool}} health",
+				Measurement:    []string{"zfs_pool"},
+				Field:          "health",
+				OKValues:       []string{"ONLINE"},
+				WarningValues:  []string{"DEGRADED"},
+				CriticalValues: []string{"FAULTED", "UNAVAIL"},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+}
+
+func newPool(pool string, capacity int64, health string, ts int64) telegraf.Metric {
+	return testutil.MustMetric("zfs_pool",
+		map[string]string{"host": "nas1", "pool": pool},
+		map[string]interface{}{"capacity": capacity, "health": health},
+		time.Unix(ts, 0),
+	)
+}
+
+func TestWriteAPI(t *testing.T) {
+	var requests []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, resultPath, r.URL.Path)
+		username, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "telegraf", username)
+		require.Equal(t, "secret", password)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plugin := newPassiveCheck()
+	plugin.URL = ts.URL
+	plugin.Username = "telegraf"
+	plugin.Password = "secret"
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	// Only the latest result of each service is submitted.
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		newPool("tank", 95, "DEGRADED", 1580000010),
+		newPool("tank", 85, "ONLINE", 1580000000),
+	}))
+
+	require.Len(t, requests, 2)
+	require.Equal(t, map[string]interface{}{
+		"type":             "Service",
+		"filter":           `host.name=="nas1" && service.name=="zpool tank capacity"`,
+		"exit_status":      float64(StateCritical),
+		"plugin_output":    "CRITICAL - zfs_pool capacity is 95",
+		"performance_data": []interface{}{"capacity=95;80;90"},
+		"execution_start":  float64(1580000010),
+		"execution_end":    float64(1580000010),
+	}, requests[0])
+	require.Equal(t, float64(StateWarning), requests[1]["exit_status"])
+	require.Equal(t, "WARNING - zfs_pool health is DEGRADED", requests[1]["plugin_output"])
+	require.Nil(t, requests[1]["performance_data"])
+}
+
+func TestWriteAPIUnknownService(t *testing.T) {
+	status := http.StatusNotFound
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	plugin := newPassiveCheck()
+	plugin.URL = ts.URL
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	metrics := []telegraf.Metric{newPool("tank", 10, "ONLINE", 1580000000)}
+	require.NoError(t, plugin.Write(metrics))
+
+	status = http.StatusInternalServerError
+	require.Error(t, plugin.Write(metrics))
+}
+
+func TestWriteAPIPartial(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body checkResult
+		json.NewDecoder(r.Body).Decode(&body)
+		if strings.Contains(body.Filter, "tank") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plugin := newPassiveCheck()
+	plugin.URL = ts.URL
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	err := plugin.Write([]telegraf.Metric{
+		newPool("tank", 10, "ONLINE", 1580000000),
+		newPool("rpool", 10, "ONLINE", 1580000000),
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "rpool"},
+			map[string]interface{}{"capacity": int64(10)},
+			time.Unix(1580000000, 0),
+		),
+		newPool("tank", 20, "ONLINE", 1580000060),
+	})
+	require.Error(t, err)
+	partial, ok := err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	// The results of rpool are submitted before those of tank fail, the
+	// metric without host tag has no result.
+	require.Equal(t, []int{1, 2}, partial.MetricsAccept)
+}
+
+func TestWriteCommandFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "passive_check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "icinga2.cmd")
+	require.NoError(t, ioutil.WriteFile(path, nil, 0600))
+
+	plugin := newPassiveCheck()
+	plugin.CommandFile = path
+	plugin.Checks[0].Operator = "<"
+	plugin.Checks[0].Warning = float(20)
+	plugin.Checks[0].Critical = nil
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		newPool("tank", 10, "SUSPENDED", 1580000000),
+		// Metrics without the host tag are ignored.
+		testutil.MustMetric("zfs_pool",
+			map[string]string{"pool": "rpool"},
+			map[string]interface{}{"capacity": int64(10)},
+			time.Unix(1580000000, 0),
+		),
+	}))
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t,
+		"[1580000000] PROCESS_SERVICE_CHECK_RESULT;nas1;zpool tank capacity;1;WARNING - zfs_pool capacity is 10|capacity=10;20:\n"+
+			"[1580000000] PROCESS_SERVICE_CHECK_RESULT;nas1;zpool tank health;3;UNKNOWN - zfs_pool health is SUSPENDED\n",
+		string(contents))
+}
+
+func TestBooleanValues(t *testing.T) {
+	check := &Check{
+		Service:        "smart {{.device}}",
+		Field:          "health_ok",
+		OKValues:       []string{"true"},
+		CriticalValues: []string{"false"},
+	}
+	require.NoError(t, check.init())
+
+	r, err := check.evaluate(testutil.MustMetric("smart_device",
+		map[string]string{"device": "ada0"},
+		map[string]interface{}{"health_ok": false},
+		time.Unix(0, 0),
+	))
+	require.NoError(t, err)
+	require.Equal(t, "smart ada0", r.Service)
+	require.Equal(t, StateCritical, r.State)
+
+	r, err = check.evaluate(testutil.MustMetric("smart_device",
+		map[string]string{"device": "ada0"},
+		map[string]interface{}{"temp_c": int64(40)},
+		time.Unix(0, 0),
+	))
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func TestInitErrors(t *testing.T) {
+	plugin := newPassiveCheck()
+	require.Error(t, plugin.Init())
+
+	plugin.URL = "https://localhost:5665"
+	plugin.CommandFile = "/var/run/icinga2/cmd/icinga2.cmd"
+	require.Error(t, plugin.Init())
+
+	plugin.CommandFile = ""
+	plugin.Checks[0].OKValues = []string{"ONLINE"}
+	require.Error(t, plugin.Init())
+
+	plugin.Checks[0].OKValues = nil
+	plugin.Checks[0].Operator = "=="
+	require.Error(t, plugin.Init())
+
+	plugin.Checks[0].Operator = ">="
+	plugin.Checks[1].WarningValues = []string{"ONLINE"}
+	require.Error(t, plugin.Init())
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -160,13 +161,13 @@ func addSample(fam *MetricFamily, sample *Sample, sampleID SampleID) {
 	fam.Samples[sampleID] = sample
 }
 
-func (c *Collector) addMetricFamily(point telegraf.Metric, sample *Sample, mname string, sampleID SampleID) {
+func (c *Collector) addMetricFamily(valueType telegraf.ValueType, sample *Sample, mname string, sampleID SampleID) {
 	var fam *MetricFamily
 	var ok bool
 	if fam, ok = c.fam[mname]; !ok {
 		fam = &MetricFamily{
 			Samples:           make(map[SampleID]*Sample),
-			TelegrafValueType: point.Type(),
+			TelegrafValueType: valueType,
 			LabelSet:          make(map[string]int),
 		}
 		c.fam[mname] = fam
@@ -268,7 +269,7 @@ func (c *Collector) Add(metrics []telegraf.Metric) error {
 				continue
 			}
 
-			c.addMetricFamily(point, sample, mname, sampleID)
+			c.addMetricFamily(point.Type(), sample, mname, sampleID)
 
 		case telegraf.Histogram:
 			var mname string
@@ -314,7 +315,7 @@ func (c *Collector) Add(metrics []telegraf.Metric) error {
 				continue
 			}
 
-			c.addMetricFamily(point, sample, mname, sampleID)
+			c.addMetricFamily(point.Type(), sample, mname, sampleID)
 
 		default:
 			for fn, fv := range point.Fields() {
@@ -341,7 +342,8 @@ func (c *Collector) Add(metrics []telegraf.Metric) error {
 				// Special handling of value field; supports passthrough from
 				// the prometheus input.
 				var mname string
-				switch point.Type() {
+				valueType := metric.FieldType(point, fn)
+				switch valueType {
 				case telegraf.Counter:
 					if fn == "counter" {
 						mname = sanitize(point.Name())
@@ -361,7 +363,7 @@ func (c *Collector) Add(metrics []telegraf.Metric) error {
 				if !isValidTagName(mname) {
 					continue
 				}
-				c.addMetricFamily(point, sample, mname, sampleID)
+				c.addMetricFamily(valueType, sample, mname, sampleID)
 
 			}
 		}
@@ -0,0 +1,358 @@
+package snmp_agent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The subset of the AgentX protocol, RFC 2741, needed by a read-only
+// subagent.
+
+const (
+	headerSize = 20
+	maxPayload = 1 << 20
+)
+
+// PDU types.
+const (
+	pduOpen       = 1
+	pduClose      = 2
+	pduRegister   = 3
+	pduGet        = 5
+	pduGetNext    = 6
+	pduGetBulk    = 7
+	pduTestSet    = 8
+	pduCommitSet  = 9
+	pduUndoSet    = 10
+	pduCleanupSet = 11
+	pduResponse   = 18
+)
+
+// Header flags.
+const (
+	flagNonDefaultContext = 0x08
+	flagNetworkByteOrder  = 0x10
+)
+
+// Variable binding types.
+const (
+	typeInteger        = 2
+	typeOctetString    = 4
+	typeNull           = 5
+	typeGauge32        = 66
+	typeCounter64      = 70
+	typeNoSuchObject   = 128
+	typeNoSuchInstance = 129
+	typeEndOfMibView   = 130
+)
+
+// Response errors.
+const (
+	errNone        = 0
+	errGen         = 5
+	errNotWritable = 17
+	errParse       = 266
+)
+
+// Reasons of the close PDU.
+const (
+	reasonShutdown = 5
+)
+
+var errShortPDU = errors.New("short AgentX PDU")
+
+type oid []uint32
+
+func parseOID(s string) (oid, error) {
+	s = strings.TrimPrefix(s, ".")
+	if s == "" {
+		return nil, errors.New("empty OID")
+	}
+
+	parts := strings.Split(s, ".")
+	o := make(oid, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		o = append(o, uint32(id))
+	}
+	return o, nil
+}
+
+func (o oid) String() string {
+	var b strings.Builder
+	for _, id := range o {
+		b.WriteString("." + strconv.FormatUint(uint64(id), 10))
+	}
+	return b.String()
+}
+
+// compare returns -1, 0 or 1 when o is before, equal to or after other in
+// lexicographic order.
+func (o oid) compare(other oid) int {
+	for i := 0; i < len(o) && i < len(other); i++ {
+		switch {
+		case o[i] < other[i]:
+			return -1
+		case o[i] > other[i]:
+			return 1
+		}
+	}
+	switch {
+	case len(o) < len(other):
+		return -1
+	case len(o) > len(other):
+		return 1
+	}
+	return 0
+}
+
+// append returns a new OID with the sub-identifiers appended.
+func (o oid) append(ids ...uint32) oid {
+	n := make(oid, 0, len(o)+len(ids))
+	n = append(n, o...)
+	return append(n, ids...)
+}
+
+type header struct {
+	Type          byte
+	Flags         byte
+	SessionID     uint32
+	TransactionID uint32
+	PacketID      uint32
+}
+
+type variable struct {
+	Type uint16
+	Name oid
+	// Value is an int32 for integers, an uint32 for gauges, an uint64 for
+	// counters, a []byte for octet strings and nil for the exceptions.
+	Value interface{}
+}
+
+// searchRange is a range of OIDs of a request, an empty end is unbounded.
+type searchRange struct {
+	Start   oid
+	Include bool
+	End     oid
+}
+
+func readPDU(r io.Reader) (*header, *decoder, error) {
+	var buf [headerSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, nil, err
+	}
+	if buf[0] != 1 {
+		return nil, nil, fmt.Errorf("unsupported AgentX version %d", buf[0])
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if buf[2]&flagNetworkByteOrder != 0 {
+		order = binary.BigEndian
+	}
+
+	h := &header{
+		Type:          buf[1],
+		Flags:         buf[2],
+		SessionID:     order.Uint32(buf[4:]),
+		TransactionID: order.Uint32(buf[8:]),
+		PacketID:      order.Uint32(buf[12:]),
+	}
+
+	length := order.Uint32(buf[16:])
+	if length > maxPayload {
+		return nil, nil, fmt.Errorf("AgentX PDU of %d bytes too large", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, err
+	}
+	return h, &decoder{b: payload, order: order}, nil
+}
+
+// writePDU writes a PDU in network byte order.
+func writePDU(w io.Writer, h *header, payload []byte) error {
+	buf := make([]byte, headerSize, headerSize+len(payload))
+	buf[0] = 1
+	buf[1] = h.Type
+	buf[2] = h.Flags | flagNetworkByteOrder
+	binary.BigEndian.PutUint32(buf[4:], h.SessionID)
+	binary.BigEndian.PutUint32(buf[8:], h.TransactionID)
+	binary.BigEndian.PutUint32(buf[12:], h.PacketID)
+	binary.BigEndian.PutUint32(buf[16:], uint32(len(payload)))
+
+	_, err := w.Write(append(buf, payload...))
+	return err
+}
+
+// encoder encodes the payload of a PDU in network byte order.
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) uint16(v uint16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) uint64(v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) oid(o oid, include bool) {
+	e.WriteByte(byte(len(o)))
+	e.WriteByte(0)
+	if include {
+		e.WriteByte(1)
+	} else {
+		e.WriteByte(0)
+	}
+	e.WriteByte(0)
+	for _, id := range o {
+		e.uint32(id)
+	}
+}
+
+func (e *encoder) octets(b []byte) {
+	e.uint32(uint32(len(b)))
+	e.Write(b)
+	for i := len(b); i%4 != 0; i++ {
+		e.WriteByte(0)
+	}
+}
+
+func (e *encoder) variable(v *variable) {
+	e.uint16(v.Type)
+	e.uint16(0)
+	e.oid(v.Name, false)
+	switch value := v.Value.(type) {
+	case int32:
+		e.uint32(uint32(value))
+	case uint32:
+		e.uint32(value)
+	case uint64:
+		e.uint64(value)
+	case []byte:
+		e.octets(value)
+	}
+}
+
+// decoder decodes the payload of a PDU, the first error is kept in err.
+type decoder struct {
+	b     []byte
+	order binary.ByteOrder
+	err   error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = errShortPDU
+		return nil
+	}
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p
+}
+
+func (d *decoder) more() bool {
+	return d.err == nil && len(d.b) > 0
+}
+
+func (d *decoder) uint8() byte {
+	p := d.next(1)
+	if p == nil {
+		return 0
+	}
+	return p[0]
+}
+
+func (d *decoder) uint16() uint16 {
+	p := d.next(2)
+	if p == nil {
+		return 0
+	}
+	return d.order.Uint16(p)
+}
+
+func (d *decoder) uint32() uint32 {
+	p := d.next(4)
+	if p == nil {
+		return 0
+	}
+	return d.order.Uint32(p)
+}
+
+func (d *decoder) uint64() uint64 {
+	p := d.next(8)
+	if p == nil {
+		return 0
+	}
+	return d.order.Uint64(p)
+}
+
+// oid decodes an OID and its include field.
+func (d *decoder) oid() (oid, bool) {
+	p := d.next(4)
+	if p == nil {
+		return nil, false
+	}
+
+	n, prefix, include := int(p[0]), p[1], p[2] != 0
+	o := make(oid, 0, n+5)
+	if prefix != 0 {
+		o = append(o, 1, 3, 6, 1, uint32(prefix))
+	}
+	for i := 0; i < n; i++ {
+		o = append(o, d.uint32())
+	}
+	return o, include
+}
+
+func (d *decoder) octets() []byte {
+	n := int(d.uint32())
+	p := d.next(n)
+	d.next((4 - n%4) % 4)
+	return p
+}
+
+func (d *decoder) searchRange() searchRange {
+	start, include := d.oid()
+	end, _ := d.oid()
+	return searchRange{Start: start, Include: include, End: end}
+}
+
+func (d *decoder) variable() *variable {
+	v := &variable{Type: d.uint16()}
+	d.uint16()
+	v.Name, _ = d.oid()
+	switch v.Type {
+	case typeInteger:
+		v.Value = int32(d.uint32())
+	case typeGauge32:
+		v.Value = d.uint32()
+	case typeCounter64:
+		v.Value = d.uint64()
+	case typeOctetString:
+		v.Value = d.octets()
+	}
+	return v
+}
@@ -0,0 +1,663 @@
+package snmp_agent
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	defaultAddress     = "unix:///var/agentx/master"
+	defaultOID         = ".1.3.6.1.4.1.8072.9999.9999"
+	defaultTimeout     = 5 * time.Second
+	defaultExpiration  = 60 * time.Second
+	reconnectInterval  = 5 * time.Second
+	registerPriority   = 127
+	sessionDescription = "Telegraf"
+)
+
+var sampleConfig = `
+  ## Address of the AgentX master agent, such as the snmpd of net-snmp with
+  ## "master agentx" in snmpd.conf.
+  # address = "unix:///var/agentx/master"
+  # address = "tcp://localhost:705"
+
+  ## Subtree registered with the master agent, the tables are below it.
+  # oid = ".1.3.6.1.4.1.8072.9999.9999"
+
+  ## Timeout of the requests to the master agent.
+  # timeout = "5s"
+
+  ## Rows not updated for this long are removed, 0 keeps them forever.
+  # expiration_interval = "60s"
+
+  [[outputs.snmp_agent.table]]
+    ## Table number, the table is <oid>.<number> and its rows are indexed by
+    ## the index_tag encoded as an OCTET STRING.  Column 1 holds the index
+    ## and the columns 2, 3, ... the fields in order.
+    number = 1
+    measurement = "zfs_pool"
+    index_tag = "pool"
+
+    [[outputs.snmp_agent.table.column]]
+      field = "health"
+    [[outputs.snmp_agent.table.column]]
+      field = "capacity"
+    [[outputs.snmp_agent.table.column]]
+      field = "size"
+      ## SNMP type of the column, one of "integer", "gauge", "counter64" or
+      ## "string", by default integer for integers and booleans, counter64 for
+      ## unsigned integers and string otherwise.
+      type = "counter64"
+`
+
+const (
+	columnInteger   = "integer"
+	columnGauge     = "gauge"
+	columnCounter64 = "counter64"
+	columnString    = "string"
+)
+
+type Column struct {
+	Field string `toml:"field"`
+	Type  string `toml:"type"`
+}
+
+type Table struct {
+	Number      uint32    `toml:"number"`
+	Measurement string    `toml:"measurement"`
+	IndexTag    string    `toml:"index_tag"`
+	Columns     []*Column `toml:"column"`
+
+	rows map[string]*row
+}
+
+// row holds the latest values of the fields of a table row.
+type row struct {
+	values  map[string]interface{}
+	updated time.Time
+}
+
+type SNMPAgent struct {
+	Address            string            `toml:"address"`
+	OID                string            `toml:"oid"`
+	Timeout            internal.Duration `toml:"timeout"`
+	ExpirationInterval internal.Duration `toml:"expiration_interval"`
+	Tables             []*Table          `toml:"table"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	network string
+	addr    string
+	subtree oid
+	now     func() time.Time
+	started time.Time
+
+	mu      sync.Mutex
+	session *session
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// session is an AgentX session with the master agent.
+type session struct {
+	conn net.Conn
+	id   uint32
+
+	// mu serializes the writes of the responses and of the close PDU.
+	mu sync.Mutex
+}
+
+func (s *SNMPAgent) Description() string {
+	return "Expose metrics as SNMP tables through an AgentX master agent"
+}
+
+func (s *SNMPAgent) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SNMPAgent) Init() error {
+	spl := strings.SplitN(s.Address, "://", 2)
+	if len(spl) != 2 {
+		return fmt.Errorf("invalid address: %s", s.Address)
+	}
+	switch spl[0] {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return fmt.Errorf("unsupported network %q", spl[0])
+	}
+	s.network, s.addr = spl[0], spl[1]
+
+	var err error
+	s.subtree, err = parseOID(s.OID)
+	if err != nil {
+		return err
+	}
+
+	if len(s.Tables) == 0 {
+		return fmt.Errorf("no table configured")
+	}
+	numbers := make(map[uint32]bool)
+	for _, t := range s.Tables {
+		if err := t.init(); err != nil {
+			return fmt.Errorf("table %d: %v", t.Number, err)
+		}
+		if numbers[t.Number] {
+			return fmt.Errorf("table %d: number used by another table", t.Number)
+		}
+		numbers[t.Number] = true
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+	return nil
+}
+
+func (t *Table) init() error {
+	if t.Number == 0 {
+		return fmt.Errorf("number must be greater than 0")
+	}
+	if t.Measurement == "" {
+		return fmt.Errorf("measurement must not be empty")
+	}
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("no column configured")
+	}
+	for _, c := range t.Columns {
+		if c.Field == "" {
+			return fmt.Errorf("field of column must not be empty")
+		}
+		switch c.Type {
+		case "", columnInteger, columnGauge, columnCounter64, columnString:
+		default:
+			return fmt.Errorf("invalid type %q of column %s", c.Type, c.Field)
+		}
+	}
+	t.rows = make(map[string]*row)
+	return nil
+}
+
+// Connect opens the session with the master agent.  The session is reopened
+// in the background when the master agent closes it, for example when it
+// restarts.
+func (s *SNMPAgent) Connect() error {
+	s.started = s.now()
+
+	sess, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.session = sess
+	s.mu.Unlock()
+
+	s.done = make(chan struct{})
+	s.wg.Add(1)
+	go s.run(sess)
+	return nil
+}
+
+func (s *SNMPAgent) Close() error {
+	if s.done == nil {
+		return nil
+	}
+	close(s.done)
+
+	s.mu.Lock()
+	sess := s.session
+	s.session = nil
+	s.mu.Unlock()
+	if sess != nil {
+		sess.close()
+	}
+
+	s.wg.Wait()
+	return nil
+}
+
+// Write stores the latest values of the table rows, they are read by the
+// master agent when it is polled.
+func (s *SNMPAgent) Write(metrics []telegraf.Metric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, m := range metrics {
+		for _, t := range s.Tables {
+			if m.Name() != t.Measurement {
+				continue
+			}
+
+			var index string
+			if t.IndexTag != "" {
+				var ok bool
+				index, ok = m.GetTag(t.IndexTag)
+				if !ok {
+					continue
+				}
+			}
+
+			r, ok := t.rows[index]
+			if !ok {
+				r = &row{values: make(map[string]interface{})}
+				t.rows[index] = r
+			}
+			for _, c := range t.Columns {
+				if value, ok := m.GetField(c.Field); ok {
+					r.values[c.Field] = value
+				}
+			}
+			r.updated = now
+		}
+	}
+	return nil
+}
+
+func (s *SNMPAgent) run(sess *session) {
+	defer s.wg.Done()
+
+	for {
+		err := s.serve(sess)
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		s.Log.Errorf("AgentX session closed: %v", err)
+		sess.close()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-time.After(reconnectInterval):
+			}
+
+			sess, err = s.open()
+			if err == nil {
+				break
+			}
+			s.Log.Errorf("Error opening AgentX session: %v", err)
+		}
+
+		s.mu.Lock()
+		select {
+		case <-s.done:
+			s.mu.Unlock()
+			sess.close()
+			return
+		default:
+		}
+		s.session = sess
+		s.mu.Unlock()
+		s.Log.Infof("AgentX session reopened")
+	}
+}
+
+// open connects to the master agent, opens a session and registers the
+// subtree.
+func (s *SNMPAgent) open() (*session, error) {
+	conn, err := net.DialTimeout(s.network, s.addr, s.Timeout.Duration)
+	if err != nil {
+		return nil, err
+	}
+	sess := &session{conn: conn}
+
+	timeout := s.Timeout.Duration / time.Second
+	if timeout > math.MaxUint8 {
+		timeout = math.MaxUint8
+	}
+
+	var open encoder
+	open.WriteByte(byte(timeout))
+	open.Write([]byte{0, 0, 0})
+	open.oid(nil, false)
+	open.octets([]byte(sessionDescription))
+	resp, err := s.request(sess, &header{Type: pduOpen, PacketID: 1}, open.Bytes())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error opening session: %v", err)
+	}
+	sess.id = resp.SessionID
+
+	var register encoder
+	register.WriteByte(0)
+	register.WriteByte(registerPriority)
+	register.WriteByte(0)
+	register.WriteByte(0)
+	register.oid(s.subtree, false)
+	_, err = s.request(sess, &header{Type: pduRegister, SessionID: sess.id, PacketID: 2}, register.Bytes())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error registering %s: %v", s.subtree, err)
+	}
+
+	return sess, nil
+}
+
+// request sends a PDU and reads the response while opening the session.
+func (s *SNMPAgent) request(sess *session, h *header, payload []byte) (*header, error) {
+	sess.conn.SetDeadline(time.Now().Add(s.Timeout.Duration))
+	defer sess.conn.SetDeadline(time.Time{})
+
+	if err := writePDU(sess.conn, h, payload); err != nil {
+		return nil, err
+	}
+	resp, d, err := readPDU(sess.conn)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Type != pduResponse {
+		return nil, fmt.Errorf("unexpected PDU type %d", resp.Type)
+	}
+
+	d.uint32()
+	code := d.uint16()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if code != errNone {
+		return nil, fmt.Errorf("master agent returned error %d", code)
+	}
+	return resp, nil
+}
+
+// serve answers the requests of the master agent until the session is
+// closed.
+func (s *SNMPAgent) serve(sess *session) error {
+	for {
+		h, d, err := readPDU(sess.conn)
+		if err != nil {
+			return err
+		}
+
+		var code uint16
+		var vars []*variable
+		switch h.Type {
+		case pduGet, pduGetNext, pduGetBulk:
+			if h.Flags&flagNonDefaultContext != 0 {
+				d.octets()
+			}
+			vars = s.lookup(h.Type, d)
+			if d.err != nil {
+				code, vars = errParse, nil
+			}
+		case pduTestSet:
+			code = errNotWritable
+		case pduCommitSet, pduUndoSet:
+			code = errGen
+		case pduClose:
+			return fmt.Errorf("closed by the master agent")
+		default:
+			// Cleanup sets and responses are not answered.
+			continue
+		}
+
+		if err := sess.respond(h, s.uptime(), code, vars); err != nil {
+			return err
+		}
+	}
+}
+
+// lookup answers the search ranges of a get, get next or get bulk request.
+func (s *SNMPAgent) lookup(pduType byte, d *decoder) []*variable {
+	var nonRepeaters, maxRepetitions int
+	if pduType == pduGetBulk {
+		nonRepeaters = int(d.uint16())
+		maxRepetitions = int(d.uint16())
+	}
+
+	var ranges []searchRange
+	for d.more() {
+		ranges = append(ranges, d.searchRange())
+	}
+	if d.err != nil {
+		return nil
+	}
+
+	vars := s.variables()
+	var result []*variable
+	switch pduType {
+	case pduGet:
+		for _, sr := range ranges {
+			result = append(result, get(vars, sr.Start))
+		}
+	case pduGetNext:
+		for _, sr := range ranges {
+			result = append(result, next(vars, sr))
+		}
+	case pduGetBulk:
+		if nonRepeaters > len(ranges) {
+			nonRepeaters = len(ranges)
+		}
+		for _, sr := range ranges[:nonRepeaters] {
+			result = append(result, next(vars, sr))
+		}
+
+		repeaters := append([]searchRange(nil), ranges[nonRepeaters:]...)
+		for i := 0; i < maxRepetitions && len(repeaters) > 0; i++ {
+			ended := true
+			for j := range repeaters {
+				v := next(vars, repeaters[j])
+				result = append(result, v)
+				if v.Type != typeEndOfMibView {
+					repeaters[j].Start, repeaters[j].Include = v.Name, false
+					ended = false
+				}
+			}
+			if ended {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// get returns the variable with the name, vars are sorted by name.
+func get(vars []*variable, name oid) *variable {
+	i := sort.Search(len(vars), func(i int) bool {
+		return vars[i].Name.compare(name) >= 0
+	})
+	if i < len(vars) && vars[i].Name.compare(name) == 0 {
+		return vars[i]
+	}
+	return &variable{Type: typeNoSuchObject, Name: name}
+}
+
+// next returns the first variable in the search range, vars are sorted by
+// name.
+func next(vars []*variable, sr searchRange) *variable {
+	i := sort.Search(len(vars), func(i int) bool {
+		c := vars[i].Name.compare(sr.Start)
+		return c > 0 || (c == 0 && sr.Include)
+	})
+	if i < len(vars) && (len(sr.End) == 0 || vars[i].Name.compare(sr.End) < 0) {
+		return vars[i]
+	}
+	return &variable{Type: typeEndOfMibView, Name: sr.Start}
+}
+
+// variables returns the variables of the table rows sorted by name, after
+// removing the expired rows.
+func (s *SNMPAgent) variables() []*variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var vars []*variable
+	for _, t := range s.Tables {
+		entry := s.subtree.append(t.Number, 1)
+		for index, r := range t.rows {
+			if s.ExpirationInterval.Duration > 0 && now.Sub(r.updated) > s.ExpirationInterval.Duration {
+				delete(t.rows, index)
+				continue
+			}
+
+			suffix := make([]uint32, 0, len(index)+1)
+			suffix = append(suffix, uint32(len(index)))
+			for i := 0; i < len(index); i++ {
+				suffix = append(suffix, uint32(index[i]))
+			}
+
+			vars = append(vars, &variable{
+				Type:  typeOctetString,
+				Name:  entry.append(1).append(suffix...),
+				Value: []byte(index),
+			})
+			for i, c := range t.Columns {
+				value, ok := r.values[c.Field]
+				if !ok {
+					continue
+				}
+				v := convert(c.Type, value)
+				v.Name = entry.append(uint32(i + 2)).append(suffix...)
+				vars = append(vars, v)
+			}
+		}
+	}
+
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name.compare(vars[j].Name) < 0
+	})
+	return vars
+}
+
+// convert returns the variable of a field value for the column type, out of
+// range values are clamped.
+func convert(columnType string, value interface{}) *variable {
+	if columnType == "" {
+		switch value.(type) {
+		case int64, bool:
+			columnType = columnInteger
+		case uint64:
+			columnType = columnCounter64
+		default:
+			columnType = columnString
+		}
+	}
+
+	if columnType == columnString {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			text = fmt.Sprint(v)
+		}
+		return &variable{Type: typeOctetString, Value: []byte(text)}
+	}
+
+	var f float64
+	switch v := value.(type) {
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case float64:
+		f = v
+	case bool:
+		// TruthValue of SNMPv2-TC.
+		f = 2
+		if v {
+			f = 1
+		}
+	default:
+		return &variable{Type: typeNull}
+	}
+
+	switch columnType {
+	case columnInteger:
+		return &variable{Type: typeInteger, Value: int32(clamp(f, math.MinInt32, math.MaxInt32))}
+	case columnGauge:
+		return &variable{Type: typeGauge32, Value: uint32(clamp(f, 0, math.MaxUint32))}
+	default:
+		if v, ok := value.(uint64); ok {
+			return &variable{Type: typeCounter64, Value: v}
+		}
+		if v, ok := value.(int64); ok {
+			if v < 0 {
+				v = 0
+			}
+			return &variable{Type: typeCounter64, Value: uint64(v)}
+		}
+		return &variable{Type: typeCounter64, Value: uint64(clamp(f, 0, math.MaxInt64))}
+	}
+}
+
+func clamp(v, min, max float64) float64 {
+	switch {
+	case math.IsNaN(v) || v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+// uptime is the time since the output connected in hundredths of a second.
+func (s *SNMPAgent) uptime() uint32 {
+	return uint32(s.now().Sub(s.started) / (10 * time.Millisecond))
+}
+
+func (sess *session) respond(h *header, uptime uint32, code uint16, vars []*variable) error {
+	var e encoder
+	e.uint32(uptime)
+	e.uint16(code)
+	if code != errNone {
+		e.uint16(1)
+	} else {
+		e.uint16(0)
+	}
+	for _, v := range vars {
+		e.variable(v)
+	}
+
+	resp := &header{
+		Type:          pduResponse,
+		SessionID:     h.SessionID,
+		TransactionID: h.TransactionID,
+		PacketID:      h.PacketID,
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return writePDU(sess.conn, resp, e.Bytes())
+}
+
+// close closes the session and the connection, the master agent does not
+// answer the close PDU.
+func (sess *session) close() {
+	var e encoder
+	e.WriteByte(reasonShutdown)
+	e.Write([]byte{0, 0, 0})
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	writePDU(sess.conn, &header{Type: pduClose, SessionID: sess.id}, e.Bytes())
+	sess.conn.Close()
+}
+
+func init() {
+	outputs.Add("snmp_agent", func() telegraf.Output {
+		return &SNMPAgent{
+			Address:            defaultAddress,
+			OID:                defaultOID,
+			Timeout:            internal.Duration{Duration: defaultTimeout},
+			ExpirationInterval: internal.Duration{Duration: defaultExpiration},
+		}
+	})
+}
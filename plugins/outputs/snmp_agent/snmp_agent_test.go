@@ -0,0 +1,261 @@
+package snmp_agent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionID = 42
+
+// master is an AgentX master agent accepting one session.
+type master struct {
+	t    *testing.T
+	ln   net.Listener
+	conn net.Conn
+	// subtree is the subtree registered by the subagent.
+	subtree oid
+}
+
+func newMaster(t *testing.T) *master {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return &master{t: t, ln: ln}
+}
+
+// accept accepts the connection of the subagent and answers the open and
+// register PDUs.
+func (m *master) accept() error {
+	conn, err := m.ln.Accept()
+	if err != nil {
+		return err
+	}
+	m.conn = conn
+
+	h, _, err := readPDU(conn)
+	if err != nil || h.Type != pduOpen {
+		return err
+	}
+	if err := m.respond(h); err != nil {
+		return err
+	}
+
+	h, d, err := readPDU(conn)
+	if err != nil || h.Type != pduRegister {
+		return err
+	}
+	d.next(4)
+	m.subtree, _ = d.oid()
+	return m.respond(h)
+}
+
+func (m *master) respond(h *header) error {
+	var e encoder
+	e.uint32(0)
+	e.uint16(errNone)
+	e.uint16(0)
+	return writePDU(m.conn, &header{Type: pduResponse, SessionID: sessionID, PacketID: h.PacketID}, e.Bytes())
+}
+
+// request sends a request and returns the variables of the response.
+func (m *master) request(pduType byte, prefix []byte, ranges ...searchRange) []*variable {
+	var e encoder
+	e.Write(prefix)
+	for _, sr := range ranges {
+		e.oid(sr.Start, sr.Include)
+		e.oid(sr.End, false)
+	}
+	require.NoError(m.t, writePDU(m.conn, &header{Type: pduType, SessionID: sessionID, PacketID: 7}, e.Bytes()))
+
+	h, d, err := readPDU(m.conn)
+	require.NoError(m.t, err)
+	require.Equal(m.t, byte(pduResponse), h.Type)
+	require.Equal(m.t, uint32(sessionID), h.SessionID)
+	require.Equal(m.t, uint32(7), h.PacketID)
+
+	d.uint32()
+	require.Equal(m.t, uint16(errNone), d.uint16())
+	d.uint16()
+	var vars []*variable
+	for d.more() {
+		vars = append(vars, d.variable())
+	}
+	require.NoError(m.t, d.err)
+	return vars
+}
+
+func newSNMPAgent(address string) *SNMPAgent {
+	return &SNMPAgent{
+		Address:            address,
+		OID:                defaultOID,
+		Timeout:            internal.Duration{Duration: defaultTimeout},
+		ExpirationInterval: internal.Duration{Duration: defaultExpiration},
+		Tables: []*Table{
+			{
+				Number:      1,
+				Measurement: "zfs_pool",
+				IndexTag:    "pool",
+				Columns: []*Column{
+					{Field: "health"},
+					{Field: "capacity"},
+					{Field: "size", Type: "counter64"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+}
+
+func newPool(pool string, health string, capacity int64, size uint64) telegraf.Metric {
+	return testutil.MustMetric("zfs_pool",
+		map[string]string{"pool": pool},
+		map[string]interface{}{"health": health, "capacity": capacity, "size": size},
+		time.Unix(0, 0),
+	)
+}
+
+// index is the OID suffix of an OCTET STRING index.
+func index(s string) []uint32 {
+	suffix := []uint32{uint32(len(s))}
+	for i := 0; i < len(s); i++ {
+		suffix = append(suffix, uint32(s[i]))
+	}
+	return suffix
+}
+
+func TestSNMPAgent(t *testing.T) {
+	m := newMaster(t)
+	defer m.ln.Close()
+	accepted := make(chan error, 1)
+	go func() { accepted <- m.accept() }()
+
+	plugin := newSNMPAgent("tcp://" + m.ln.Addr().String())
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, <-accepted)
+	defer m.conn.Close()
+
+	subtree, err := parseOID(defaultOID)
+	require.NoError(t, err)
+	require.Equal(t, subtree, m.subtree)
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		newPool("tank", "DEGRADED", 85, 1<<40),
+		newPool("rpool", "ONLINE", 20, 1<<30),
+		// Metrics without the index tag are ignored.
+		testutil.MustMetric("zfs_pool", map[string]string{}, map[string]interface{}{"capacity": int64(1)}, time.Unix(0, 0)),
+	}))
+
+	entry := subtree.append(1, 1)
+
+	// Get
+	vars := m.request(pduGet, nil,
+		searchRange{Start: entry.append(3).append(index("tank")...)},
+		searchRange{Start: entry.append(9).append(index("tank")...)},
+	)
+	require.Equal(t, []*variable{
+		{Type: typeInteger, Name: entry.append(3).append(index("tank")...), Value: int32(85)},
+		{Type: typeNoSuchObject, Name: entry.append(9).append(index("tank")...)},
+	}, vars)
+
+	// GetNext, the shorter index is first.
+	vars = m.request(pduGetNext, nil,
+		searchRange{Start: subtree},
+		searchRange{Start: entry.append(4).append(index("tank")...)},
+		searchRange{Start: entry.append(4).append(index("tank")...), Include: true},
+	)
+	require.Equal(t, []*variable{
+		{Type: typeOctetString, Name: entry.append(1).append(index("tank")...), Value: []byte("tank")},
+		{Type: typeCounter64, Name: entry.append(4).append(index("rpool")...), Value: uint64(1 << 30)},
+		{Type: typeCounter64, Name: entry.append(4).append(index("tank")...), Value: uint64(1 << 40)},
+	}, vars)
+
+	// GetBulk with one non repeater and three repetitions, ending the view.
+	vars = m.request(pduGetBulk, []byte{0, 1, 0, 3},
+		searchRange{Start: entry.append(1)},
+		searchRange{Start: entry.append(4).append(index("tank")...)},
+	)
+	require.Equal(t, []*variable{
+		{Type: typeOctetString, Name: entry.append(1).append(index("tank")...), Value: []byte("tank")},
+		{Type: typeCounter64, Name: entry.append(4).append(index("rpool")...), Value: uint64(1 << 30)},
+		{Type: typeEndOfMibView, Name: entry.append(4).append(index("rpool")...)},
+	}, vars)
+
+	vars = m.request(pduGetBulk, []byte{0, 0, 0, 3},
+		searchRange{Start: entry.append(2)},
+	)
+	require.Equal(t, []*variable{
+		{Type: typeOctetString, Name: entry.append(2).append(index("tank")...), Value: []byte("DEGRADED")},
+		{Type: typeOctetString, Name: entry.append(2).append(index("rpool")...), Value: []byte("ONLINE")},
+		{Type: typeInteger, Name: entry.append(3).append(index("tank")...), Value: int32(85)},
+	}, vars)
+
+	require.NoError(t, plugin.Close())
+	h, _, err := readPDU(m.conn)
+	require.NoError(t, err)
+	require.Equal(t, byte(pduClose), h.Type)
+	require.Equal(t, uint32(sessionID), h.SessionID)
+}
+
+func TestExpiration(t *testing.T) {
+	now := time.Unix(1580000000, 0)
+	plugin := newSNMPAgent("tcp://127.0.0.1:705")
+	plugin.now = func() time.Time { return now }
+	require.NoError(t, plugin.Init())
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{newPool("tank", "ONLINE", 85, 1)}))
+	require.Len(t, plugin.variables(), 4)
+
+	now = now.Add(defaultExpiration + time.Second)
+	require.Empty(t, plugin.variables())
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		columnType string
+		value      interface{}
+		expected   *variable
+	}{
+		{"", int64(-5), &variable{Type: typeInteger, Value: int32(-5)}},
+		{"", int64(1 << 40), &variable{Type: typeInteger, Value: int32(1<<31 - 1)}},
+		{"", true, &variable{Type: typeInteger, Value: int32(1)}},
+		{"", false, &variable{Type: typeInteger, Value: int32(2)}},
+		{"", uint64(1 << 40), &variable{Type: typeCounter64, Value: uint64(1 << 40)}},
+		{"", 1.5, &variable{Type: typeOctetString, Value: []byte("1.5")}},
+		{"", "ONLINE", &variable{Type: typeOctetString, Value: []byte("ONLINE")}},
+		{"gauge", int64(-1), &variable{Type: typeGauge32, Value: uint32(0)}},
+		{"gauge", 42.7, &variable{Type: typeGauge32, Value: uint32(42)}},
+		{"counter64", int64(1 << 40), &variable{Type: typeCounter64, Value: uint64(1 << 40)}},
+		{"string", int64(3), &variable{Type: typeOctetString, Value: []byte("3")}},
+		{"integer", "ONLINE", &variable{Type: typeNull}},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, convert(tt.columnType, tt.value))
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	plugin := newSNMPAgent("localhost:705")
+	require.Error(t, plugin.Init())
+
+	plugin = newSNMPAgent("tcp://localhost:705")
+	plugin.OID = ".1.3.x"
+	require.Error(t, plugin.Init())
+
+	plugin = newSNMPAgent("tcp://localhost:705")
+	plugin.Tables = append(plugin.Tables, &Table{
+		Number:      1,
+		Measurement: "zfs_dataset",
+		Columns:     []*Column{{Field: "used"}},
+	})
+	require.Error(t, plugin.Init())
+
+	plugin = newSNMPAgent("tcp://localhost:705")
+	plugin.Tables[0].Columns[0].Type = "float"
+	require.Error(t, plugin.Init())
+}
@@ -0,0 +1,217 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	jsonparser "github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/tidwall/gjson"
+)
+
+// rootPrefix marks a path that is resolved against the whole document
+// instead of the element selected by the query.
+const rootPrefix = "$."
+
+type Config struct {
+	MetricName      string
+	Query           string
+	MeasurementPath string
+	TimePath        string
+	TimeFormat      string
+	Timezone        string
+	TagPaths        map[string]string
+	FieldPaths      map[string]string
+	DefaultTags     map[string]string
+}
+
+// Parser maps values selected with JSONPath-style expressions onto the tags,
+// fields and timestamp of a metric.  The input may hold a single document or
+// several newline delimited documents (JSON lines).
+type Parser struct {
+	metricName      string
+	query           string
+	measurementPath string
+	timePath        string
+	timeFormat      string
+	timezone        string
+	tagPaths        map[string]string
+	fieldPaths      map[string]string
+	defaultTags     map[string]string
+
+	TimeFunc func() time.Time
+}
+
+func New(config *Config) (*Parser, error) {
+	if len(config.FieldPaths) == 0 {
+		return nil, fmt.Errorf("at least one field path must be defined")
+	}
+
+	if config.TimePath != "" && config.TimeFormat == "" {
+		return nil, fmt.Errorf("use of 'jsonpath_time' requires 'jsonpath_time_format'")
+	}
+
+	return &Parser{
+		metricName:      config.MetricName,
+		query:           config.Query,
+		measurementPath: config.MeasurementPath,
+		timePath:        config.TimePath,
+		timeFormat:      config.TimeFormat,
+		timezone:        config.Timezone,
+		tagPaths:        config.TagPaths,
+		fieldPaths:      config.FieldPaths,
+		defaultTags:     config.DefaultTags,
+		TimeFunc:        time.Now,
+	}, nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	for {
+		var doc json.RawMessage
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := p.parseDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m...)
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) parseDocument(doc []byte) ([]telegraf.Metric, error) {
+	root := gjson.ParseBytes(doc)
+
+	selected := root
+	if p.query != "" {
+		selected = root.Get(p.query)
+		if !selected.Exists() {
+			return nil, nil
+		}
+	}
+
+	if !selected.IsArray() {
+		m, err := p.parseElement(root, selected)
+		if err != nil {
+			return nil, err
+		}
+		return []telegraf.Metric{m}, nil
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, elem := range selected.Array() {
+		m, err := p.parseElement(root, elem)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseElement(root, elem gjson.Result) (telegraf.Metric, error) {
+	name := p.metricName
+	if p.measurementPath != "" {
+		if result := p.lookup(root, elem, p.measurementPath); result.Exists() {
+			name = result.String()
+		}
+	}
+
+	tags := make(map[string]string)
+	for k, v := range p.defaultTags {
+		tags[k] = v
+	}
+	for key, path := range p.tagPaths {
+		result := p.lookup(root, elem, path)
+		if !result.Exists() || result.Type == gjson.Null {
+			continue
+		}
+		tags[key] = result.String()
+	}
+
+	fields := make(map[string]interface{})
+	for key, path := range p.fieldPaths {
+		result := p.lookup(root, elem, path)
+		switch result.Type {
+		case gjson.Null:
+			continue
+		case gjson.Number:
+			fields[key] = result.Float()
+		case gjson.String:
+			fields[key] = result.String()
+		case gjson.True, gjson.False:
+			fields[key] = result.Bool()
+		case gjson.JSON:
+			f := jsonparser.JSONFlattener{}
+			err := f.FullFlattenJSON(key, result.Value(), true, true)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range f.Fields {
+				fields[k] = v
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields found in document")
+	}
+
+	timestamp := p.TimeFunc()
+	if p.timePath != "" {
+		result := p.lookup(root, elem, p.timePath)
+		if !result.Exists() {
+			return nil, fmt.Errorf("JSON time path %q could not be found", p.timePath)
+		}
+
+		var err error
+		timestamp, err = internal.ParseTimestamp(p.timeFormat, result.Value(), p.timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+// lookup resolves path relative to the selected element, or relative to the
+// document root when the path starts with "$.".
+func (p *Parser) lookup(root, elem gjson.Result, path string) gjson.Result {
+	if strings.HasPrefix(path, rootPrefix) {
+		return root.Get(strings.TrimPrefix(path, rootPrefix))
+	}
+	return elem.Get(path)
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("can not parse the line: %s, for data format: jsonpath ", line)
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.defaultTags = tags
+}
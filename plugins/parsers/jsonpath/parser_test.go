@@ -0,0 +1,217 @@
+package jsonpath
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const smartctlJSON = `
+{
+  "device": {"name": "/dev/sda", "protocol": "ATA"},
+  "model_name": "ST4000NM0035",
+  "smart_status": {"passed": true},
+  "temperature": {"current": 34},
+  "ata_smart_attributes": {
+    "table": [
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "raw": {"value": 0}},
+      {"id": 194, "name": "Temperature_Celsius", "value": 34, "raw": {"value": 34}}
+    ]
+  }
+}
+`
+
+func TestParseFieldsAndTags(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "smart",
+		TagPaths: map[string]string{
+			"device": "device.name",
+			"model":  "model_name",
+		},
+		FieldPaths: map[string]string{
+			"temp_c": "temperature.current",
+			"passed": "smart_status.passed",
+		},
+	})
+	require.NoError(t, err)
+	parser.TimeFunc = func() time.Time { return time.Unix(42, 0) }
+
+	metrics, err := parser.Parse([]byte(smartctlJSON))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"smart",
+			map[string]string{
+				"device": "/dev/sda",
+				"model":  "ST4000NM0035",
+			},
+			map[string]interface{}{
+				"temp_c": 34.0,
+				"passed": true,
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseQueryArrayWithRootPaths(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "smart_attribute",
+		Query:      "ata_smart_attributes.table",
+		TagPaths: map[string]string{
+			"device": "$.device.name",
+			"name":   "name",
+		},
+		FieldPaths: map[string]string{
+			"value": "value",
+			"raw":   "raw.value",
+		},
+	})
+	require.NoError(t, err)
+	parser.TimeFunc = func() time.Time { return time.Unix(42, 0) }
+
+	metrics, err := parser.Parse([]byte(smartctlJSON))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"smart_attribute",
+			map[string]string{
+				"device": "/dev/sda",
+				"name":   "Reallocated_Sector_Ct",
+			},
+			map[string]interface{}{
+				"value": 100.0,
+				"raw":   0.0,
+			},
+			time.Unix(42, 0),
+		),
+		testutil.MustMetric(
+			"smart_attribute",
+			map[string]string{
+				"device": "/dev/sda",
+				"name":   "Temperature_Celsius",
+			},
+			map[string]interface{}{
+				"value": 34.0,
+				"raw":   34.0,
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseJSONLines(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName:      "nvme",
+		MeasurementPath: "kind",
+		TimePath:        "ts",
+		TimeFormat:      "unix",
+		TagPaths: map[string]string{
+			"device": "dev",
+		},
+		FieldPaths: map[string]string{
+			"percent_used": "percent_used",
+		},
+	})
+	require.NoError(t, err)
+
+	input := `{"dev": "nvme0", "kind": "nvme_smart", "ts": 1560000000, "percent_used": 3}
+{"dev": "nvme1", "ts": 1560000001, "percent_used": 5}
+`
+	metrics, err := parser.Parse([]byte(input))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"nvme_smart",
+			map[string]string{"device": "nvme0"},
+			map[string]interface{}{"percent_used": 3.0},
+			time.Unix(1560000000, 0),
+		),
+		testutil.MustMetric(
+			"nvme",
+			map[string]string{"device": "nvme1"},
+			map[string]interface{}{"percent_used": 5.0},
+			time.Unix(1560000001, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseObjectFieldIsFlattened(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "smart",
+		FieldPaths: map[string]string{
+			"temp": "temperature",
+		},
+	})
+	require.NoError(t, err)
+	parser.TimeFunc = func() time.Time { return time.Unix(42, 0) }
+
+	metrics, err := parser.Parse([]byte(smartctlJSON))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]interface{}{"temp_current": 34.0}, metrics[0].Fields())
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "smart",
+		FieldPaths: map[string]string{
+			"temp_c": "temperature.current",
+		},
+	})
+	require.NoError(t, err)
+	parser.SetDefaultTags(map[string]string{"host": "nas01"})
+
+	metric, err := parser.ParseLine(`{"temperature": {"current": 41}}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "nas01"}, metric.Tags())
+}
+
+func TestParseMissingFieldsIsError(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "smart",
+		FieldPaths: map[string]string{
+			"temp_c": "does.not.exist",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(smartctlJSON))
+	require.Error(t, err)
+}
+
+func TestParseInvalidJSON(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName: "smart",
+		FieldPaths: map[string]string{
+			"a": "a",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte(`{"a": 5, "b": `))
+	require.Error(t, err)
+}
+
+func TestNewRequiresFields(t *testing.T) {
+	_, err := New(&Config{MetricName: "smart"})
+	require.Error(t, err)
+}
+
+func TestNewTimePathRequiresFormat(t *testing.T) {
+	_, err := New(&Config{
+		MetricName: "smart",
+		TimePath:   "ts",
+		FieldPaths: map[string]string{"a": "a"},
+	})
+	require.Error(t, err)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/jsonpath"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
@@ -148,6 +149,15 @@ type Config struct {
 
 	// FormData configuration
 	FormUrlencodedTagKeys []string `toml:"form_urlencoded_tag_keys"`
+
+	// jsonpath configuration
+	JSONPathQuery       string            `toml:"jsonpath_query"`
+	JSONPathMeasurement string            `toml:"jsonpath_measurement"`
+	JSONPathTime        string            `toml:"jsonpath_time"`
+	JSONPathTimeFormat  string            `toml:"jsonpath_time_format"`
+	JSONPathTimezone    string            `toml:"jsonpath_timezone"`
+	JSONPathTags        map[string]string `toml:"jsonpath_tags"`
+	JSONPathFields      map[string]string `toml:"jsonpath_fields"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -170,6 +180,20 @@ func NewParser(config *Config) (Parser, error) {
 				Strict:       config.JSONStrict,
 			},
 		)
+	case "jsonpath":
+		parser, err = jsonpath.New(
+			&jsonpath.Config{
+				MetricName:      config.MetricName,
+				Query:           config.JSONPathQuery,
+				MeasurementPath: config.JSONPathMeasurement,
+				TimePath:        config.JSONPathTime,
+				TimeFormat:      config.JSONPathTimeFormat,
+				Timezone:        config.JSONPathTimezone,
+				TagPaths:        config.JSONPathTags,
+				FieldPaths:      config.JSONPathFields,
+				DefaultTags:     config.DefaultTags,
+			},
+		)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
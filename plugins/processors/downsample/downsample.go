@@ -0,0 +1,264 @@
+package downsample
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Number of points of a series rolled up in each downsampled metric.
+  # points = 60
+
+  ## Aggregates computed over the points, the fields of the downsampled metric
+  ## are named <field>_<aggregate>.  Available aggregates are "mean", "min",
+  ## "max", "sum" and "count".
+  # aggregates = ["mean", "max"]
+
+  ## Tag set on the downsampled metrics, select them in an output with tagpass
+  ## and the full resolution metrics in another one with tagdrop.  Set tag to
+  ## "" for no tag, when the processor is applied to a single output.
+  # tag = "route"
+  # route = "downsampled"
+
+  ## If true, only the downsampled metrics are emitted.
+  # drop_original = false
+
+  ## The window of a series without points for this long is rolled up with
+  ## the points it has, and forgotten.  Set to 0 to keep the windows until
+  ## they are complete.
+  # expire_after = "1h"
+`
+
+var aggregates = map[string]bool{
+	"mean":  true,
+	"min":   true,
+	"max":   true,
+	"sum":   true,
+	"count": true,
+}
+
+type Downsample struct {
+	Points       int               `toml:"points"`
+	Aggregates   []string          `toml:"aggregates"`
+	Tag          string            `toml:"tag"`
+	Route        string            `toml:"route"`
+	DropOriginal bool              `toml:"drop_original"`
+	ExpireAfter  internal.Duration `toml:"expire_after"`
+
+	// mu guards the windows, Apply is called concurrently by the agent for
+	// the gathered and the aggregated metrics.
+	mu      sync.Mutex
+	cache   map[uint64]*window
+	expired time.Time
+	now     func() time.Time
+}
+
+// window holds the points of a series not yet rolled up.
+type window struct {
+	first  telegraf.Metric
+	points int
+	fields map[string]*stats
+	// seen is the time the last point was added.
+	seen time.Time
+}
+
+type stats struct {
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func (d *Downsample) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Downsample) Description() string {
+	return "Roll up the points of each series into their mean, min or max."
+}
+
+func (d *Downsample) Init() error {
+	if d.Points < 1 {
+		return fmt.Errorf("points must be positive")
+	}
+	if len(d.Aggregates) == 0 {
+		return fmt.Errorf("aggregates must not be empty")
+	}
+	for _, aggregate := range d.Aggregates {
+		if !aggregates[aggregate] {
+			return fmt.Errorf("unknown aggregate %q", aggregate)
+		}
+	}
+	if d.Tag != "" && d.Route == "" {
+		return fmt.Errorf("route must not be empty")
+	}
+
+	if d.now == nil {
+		d.now = time.Now
+	}
+	d.cache = make(map[uint64]*window)
+	d.expired = d.now()
+	return nil
+}
+
+func (d *Downsample) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		id := m.HashID()
+		w, ok := d.cache[id]
+		if !ok {
+			w = &window{first: m.Copy(), fields: make(map[string]*stats)}
+			d.cache[id] = w
+		}
+		w.add(m)
+		w.seen = now
+
+		if d.DropOriginal {
+			m.Drop()
+		} else {
+			out = append(out, m)
+		}
+
+		if w.points >= d.Points {
+			delete(d.cache, id)
+			if rollup := d.rollup(w); rollup != nil {
+				out = append(out, rollup)
+			}
+		}
+	}
+
+	return append(out, d.expire(now)...)
+}
+
+// expire rolls up and removes the windows without points within
+// expire_after.  The windows are scanned at most once per expire_after.
+func (d *Downsample) expire(now time.Time) []telegraf.Metric {
+	if d.ExpireAfter.Duration <= 0 || now.Sub(d.expired) < d.ExpireAfter.Duration {
+		return nil
+	}
+	d.expired = now
+
+	var out []telegraf.Metric
+	for id, w := range d.cache {
+		if now.Sub(w.seen) < d.ExpireAfter.Duration {
+			continue
+		}
+		delete(d.cache, id)
+		if rollup := d.rollup(w); rollup != nil {
+			out = append(out, rollup)
+		}
+	}
+	return out
+}
+
+// Flush rolls up the windows not complete when the processor stops.
+func (d *Downsample) Flush() []telegraf.Metric {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var out []telegraf.Metric
+	for id, w := range d.cache {
+		delete(d.cache, id)
+		if rollup := d.rollup(w); rollup != nil {
+			out = append(out, rollup)
+		}
+	}
+	return out
+}
+
+func (w *window) add(m telegraf.Metric) {
+	w.points++
+	for _, field := range m.FieldList() {
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+
+		s, ok := w.fields[field.Key]
+		if !ok {
+			w.fields[field.Key] = &stats{count: 1, sum: value, min: value, max: value}
+			continue
+		}
+		s.count++
+		s.sum += value
+		if value < s.min {
+			s.min = value
+		}
+		if value > s.max {
+			s.max = value
+		}
+	}
+}
+
+// rollup returns the downsampled metric of the window, timestamped with its
+// first point, or nil if it has no numeric fields.  It is untyped, as the
+// aggregates of the points of a counter are not a counter.
+func (d *Downsample) rollup(w *window) telegraf.Metric {
+	if len(w.fields) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(w.fields)*len(d.Aggregates))
+	for key, s := range w.fields {
+		for _, aggregate := range d.Aggregates {
+			switch aggregate {
+			case "mean":
+				fields[key+"_mean"] = s.sum / float64(s.count)
+			case "min":
+				fields[key+"_min"] = s.min
+			case "max":
+				fields[key+"_max"] = s.max
+			case "sum":
+				fields[key+"_sum"] = s.sum
+			case "count":
+				fields[key+"_count"] = s.count
+			}
+		}
+	}
+
+	tags := w.first.Tags()
+	if d.Tag != "" {
+		tags[d.Tag] = d.Route
+	}
+
+	m, err := metric.New(w.first.Name(), tags, fields, w.first.Time(), telegraf.Untyped)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	processors.Add("downsample", func() telegraf.Processor {
+		return &Downsample{
+			Points:      60,
+			Aggregates:  []string{"mean", "max"},
+			Tag:         "route",
+			Route:       "downsampled",
+			ExpireAfter: internal.Duration{Duration: time.Hour},
+		}
+	})
+}
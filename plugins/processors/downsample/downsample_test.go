@@ -0,0 +1,187 @@
+package downsample
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newDownsample(points int, aggregates ...string) *Downsample {
+	return &Downsample{
+		Points:     points,
+		Aggregates: aggregates,
+		Tag:        "route",
+		Route:      "downsampled",
+	}
+}
+
+func newMetric(disk string, value interface{}, sec int64) telegraf.Metric {
+	return testutil.MustMetric("diskio",
+		map[string]string{"name": disk},
+		map[string]interface{}{"io_time": value, "model": "WDC"},
+		time.Unix(sec, 0),
+	)
+}
+
+func TestDownsample(t *testing.T) {
+	d := newDownsample(3, "mean", "max")
+	require.NoError(t, d.Init())
+
+	var actual []telegraf.Metric
+	actual = append(actual, d.Apply(newMetric("sda", int64(1), 0), newMetric("sdb", int64(10), 0))...)
+	actual = append(actual, d.Apply(newMetric("sda", int64(5), 1), newMetric("sdb", int64(10), 1))...)
+	actual = append(actual, d.Apply(newMetric("sda", int64(3), 2))...)
+	require.Len(t, actual, 6)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("diskio",
+			map[string]string{"name": "sda", "route": "downsampled"},
+			map[string]interface{}{"io_time_mean": 3.0, "io_time_max": 5.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual[5:])
+
+	// The points of sdb are kept until its window is full.
+	actual = d.Apply(newMetric("sdb", int64(40), 2))
+	expected = []telegraf.Metric{
+		newMetric("sdb", int64(40), 2),
+		testutil.MustMetric("diskio",
+			map[string]string{"name": "sdb", "route": "downsampled"},
+			map[string]interface{}{"io_time_mean": 20.0, "io_time_max": 40.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestDownsampleUntyped(t *testing.T) {
+	d := newDownsample(2, "mean")
+	require.NoError(t, d.Init())
+
+	counter := func(value int64, sec int64) telegraf.Metric {
+		return testutil.MustMetric("diskio",
+			map[string]string{"name": "sda"},
+			map[string]interface{}{"io_time": value},
+			time.Unix(sec, 0),
+			telegraf.Counter,
+		)
+	}
+	actual := d.Apply(counter(1, 0), counter(3, 1))
+	require.Len(t, actual, 3)
+	require.Equal(t, telegraf.Untyped, actual[2].Type())
+}
+
+func TestDownsampleConcurrentApply(t *testing.T) {
+	d := newDownsample(2, "mean")
+	require.NoError(t, d.Init())
+
+	// The agent applies the processors to the gathered and to the aggregated
+	// metrics at the same time.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				d.Apply(newMetric("sda", int64(j), int64(j)))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Empty(t, d.Flush())
+}
+
+func TestDownsampleDropOriginal(t *testing.T) {
+	d := newDownsample(2, "min", "sum", "count")
+	d.DropOriginal = true
+	require.NoError(t, d.Init())
+
+	require.Len(t, d.Apply(newMetric("sda", 1.5, 0)), 0)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("diskio",
+			map[string]string{"name": "sda", "route": "downsampled"},
+			map[string]interface{}{"io_time_min": 1.5, "io_time_sum": 4.0, "io_time_count": int64(2)},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.Apply(newMetric("sda", 2.5, 1)))
+}
+
+func TestDownsampleInit(t *testing.T) {
+	require.Error(t, newDownsample(0, "mean").Init())
+	require.Error(t, newDownsample(10).Init())
+	require.Error(t, newDownsample(10, "median").Init())
+	require.NoError(t, newDownsample(10, "mean").Init())
+
+	d := newDownsample(10, "mean")
+	d.Route = ""
+	require.Error(t, d.Init())
+}
+
+func TestDownsampleWithoutTag(t *testing.T) {
+	d := newDownsample(2, "max")
+	d.Tag = ""
+	d.DropOriginal = true
+	require.NoError(t, d.Init())
+
+	d.Apply(newMetric("sda", int64(1), 0))
+	expected := []telegraf.Metric{
+		testutil.MustMetric("diskio",
+			map[string]string{"name": "sda"},
+			map[string]interface{}{"io_time_max": 2.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.Apply(newMetric("sda", int64(2), 1)))
+}
+
+func TestDownsampleExpire(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	d := newDownsample(4, "max")
+	d.DropOriginal = true
+	d.ExpireAfter = internal.Duration{Duration: time.Hour}
+	d.now = func() time.Time { return now }
+	require.NoError(t, d.Init())
+
+	require.Len(t, d.Apply(newMetric("sda", int64(1), 0), newMetric("sdb", int64(2), 0)), 0)
+
+	now = now.Add(30 * time.Minute)
+	require.Len(t, d.Apply(newMetric("sda", int64(3), 1)), 0)
+
+	// sdb has no points for more than an hour, its partial window is rolled
+	// up and forgotten.
+	now = now.Add(45 * time.Minute)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("diskio",
+			map[string]string{"name": "sdb", "route": "downsampled"},
+			map[string]interface{}{"io_time_max": 2.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.Apply(newMetric("sda", int64(5), 2)))
+	require.Len(t, d.cache, 1)
+}
+
+func TestDownsampleFlush(t *testing.T) {
+	d := newDownsample(3, "max")
+	d.DropOriginal = true
+	require.NoError(t, d.Init())
+
+	d.Apply(newMetric("sda", int64(1), 0), newMetric("sda", int64(4), 1))
+	expected := []telegraf.Metric{
+		testutil.MustMetric("diskio",
+			map[string]string{"name": "sda", "route": "downsampled"},
+			map[string]interface{}{"io_time_max": 4.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, d.Flush())
+	require.Len(t, d.Flush(), 0)
+}
@@ -0,0 +1,226 @@
+package execd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/processors"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon.  The program receives each batch of metrics on
+  ## STDIN in line protocol followed by an empty line, and must reply on
+  ## STDOUT with the resulting metrics followed by an empty line.
+  command = ["telegraf-pool-enrich", "--config", "/etc/pool-enrich.conf"]
+
+  ## Time to wait for the program to reply to a batch.  On timeout the
+  ## metrics are passed on unmodified.
+  # timeout = "5s"
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay is doubled after each consecutive failure up to
+  ## max_restart_delay, and reset once the process stays up for longer than
+  ## max_restart_delay.
+  # restart_delay = "10s"
+  # max_restart_delay = "5m"
+`
+
+type Execd struct {
+	Command         []string          `toml:"command"`
+	Timeout         internal.Duration `toml:"timeout"`
+	RestartDelay    internal.Duration `toml:"restart_delay"`
+	MaxRestartDelay internal.Duration `toml:"max_restart_delay"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	// mu is held for the whole exchange of a batch with the program, Apply
+	// is called concurrently by the agent for the gathered and the
+	// aggregated metrics, and the replies are not tied to their batch.
+	mu sync.Mutex
+
+	process    *process.Process
+	started    bool
+	parser     *influx.Parser
+	serializer *serializer.Serializer
+	lines      chan []byte
+
+	// skip is the number of replies still to be discarded after timeouts.
+	skip int
+	// exited is set when the process exits, the replies of the previous
+	// process are then not discarded from those of the next one.
+	exited int32
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running processor plugin"
+}
+
+func (e *Execd) Init() error {
+	var err error
+	e.process, err = process.New(e.Command)
+	if err != nil {
+		return err
+	}
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.MaxRestartDelay = e.MaxRestartDelay.Duration
+	e.process.Log = e.Log
+	e.process.ReadStdoutFn = e.cmdReadOut
+	e.process.ReadStderrFn = e.cmdReadErr
+	e.process.OnExitFn = func(err error) {
+		atomic.StoreInt32(&e.exited, 1)
+		e.Log.Error(err)
+	}
+
+	e.parser = influx.NewParser(influx.NewMetricHandler())
+	e.serializer = serializer.NewSerializer()
+	e.serializer.SetFieldTypeSupport(serializer.UintSupport)
+	e.lines = make(chan []byte, 1000)
+	return nil
+}
+
+// Stop stops the process, it is started by the first call to Apply so that
+// validating the configuration does not run it.
+func (e *Execd) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.started {
+		e.process.Stop()
+		e.started = false
+	}
+}
+
+func (e *Execd) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.started {
+		if err := e.process.Start(); err != nil {
+			e.Log.Errorf("Error starting process: %s", err)
+			return in
+		}
+		e.started = true
+	}
+
+	if atomic.CompareAndSwapInt32(&e.exited, 1, 0) {
+		e.resync()
+	}
+
+	out, err := e.apply(in)
+	if err != nil {
+		e.Log.Errorf("Error processing metrics: %s", err)
+		return in
+	}
+
+	for _, m := range in {
+		m.Accept()
+	}
+	return out
+}
+
+func (e *Execd) apply(in []telegraf.Metric) ([]telegraf.Metric, error) {
+	octets, err := e.serializer.SerializeBatch(in)
+	if err != nil {
+		return nil, err
+	}
+	octets = append(octets, '\n')
+
+	if _, err := e.process.Write(octets); err != nil {
+		return nil, fmt.Errorf("error writing to stdin: %s", err)
+	}
+
+	timeout := time.NewTimer(e.Timeout.Duration)
+	defer timeout.Stop()
+
+	var out []telegraf.Metric
+	for {
+		var line []byte
+		select {
+		case line = <-e.lines:
+		case <-timeout.C:
+			e.skip++
+			return nil, errors.New("timeout waiting for reply")
+		}
+
+		if len(line) == 0 {
+			if e.skip > 0 {
+				e.skip--
+				continue
+			}
+			return out, nil
+		}
+
+		if e.skip > 0 {
+			continue
+		}
+
+		metrics, err := e.parser.Parse(line)
+		if err != nil {
+			e.Log.Errorf("Parse error: %s", err)
+			continue
+		}
+		out = append(out, metrics...)
+	}
+}
+
+// resync discards the replies still expected from the previous process,
+// which will never come, and the lines it left unread.
+func (e *Execd) resync() {
+	e.skip = 0
+	for {
+		select {
+		case <-e.lines:
+		default:
+			return
+		}
+	}
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.lines <- bytes.TrimSpace(append([]byte(nil), scanner.Bytes()...))
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %s", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %s", err)
+	}
+}
+
+func init() {
+	processors.Add("execd", func() telegraf.Processor {
+		return &Execd{
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			RestartDelay:    internal.Duration{Duration: 10 * time.Second},
+			MaxRestartDelay: internal.Duration{Duration: 5 * time.Minute},
+		}
+	})
+}
@@ -0,0 +1,153 @@
+// +build !windows
+
+package execd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newExecd(script string) *Execd {
+	return &Execd{
+		Command:      []string{"/bin/sh", "-c", script},
+		Timeout:      internal.Duration{Duration: 5 * time.Second},
+		RestartDelay: internal.Duration{Duration: 10 * time.Millisecond},
+		Log:          testutil.Logger{},
+	}
+}
+
+func TestExecdApply(t *testing.T) {
+	// Tag every metric of the batch and acknowledge it with an empty line.
+	e := newExecd(`while read -r line; do
+  if [ -z "$line" ]; then echo; continue; fi
+  echo "$line" | sed 's/^zpool /zpool,checked=yes /'
+done`)
+	require.NoError(t, e.Init())
+	defer e.Stop()
+
+	in := testutil.MustMetric("zpool",
+		map[string]string{},
+		map[string]interface{}{"alloc": int64(42)},
+		time.Unix(0, 0))
+
+	out := e.Apply(in)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("zpool",
+			map[string]string{"checked": "yes"},
+			map[string]interface{}{"alloc": int64(42)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, out)
+}
+
+func TestExecdApplyConcurrent(t *testing.T) {
+	e := newExecd(`while read -r line; do
+  if [ -z "$line" ]; then echo; continue; fi
+  echo "$line" | sed 's/^zpool /zpool,checked=yes /'
+done`)
+	require.NoError(t, e.Init())
+	defer e.Stop()
+
+	// Each batch gets the replies of its own metrics.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(alloc int64) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				in := testutil.MustMetric("zpool",
+					map[string]string{},
+					map[string]interface{}{"alloc": alloc},
+					time.Unix(0, 0))
+				out := e.Apply(in)
+				if len(out) != 1 || out[0].Fields()["alloc"] != alloc {
+					t.Errorf("unexpected reply %v for alloc %d", out, alloc)
+					return
+				}
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+}
+
+func TestExecdApplyTimeout(t *testing.T) {
+	e := newExecd("cat > /dev/null")
+	e.Timeout = internal.Duration{Duration: 10 * time.Millisecond}
+	require.NoError(t, e.Init())
+	defer e.Stop()
+
+	in := testutil.MustMetric("zpool",
+		map[string]string{},
+		map[string]interface{}{"alloc": int64(42)},
+		time.Unix(0, 0))
+
+	out := e.Apply(in)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{in}, out)
+}
+
+func TestExecdStartsOnApply(t *testing.T) {
+	e := newExecd("cat")
+	require.NoError(t, e.Init())
+	require.False(t, e.started)
+
+	in := testutil.MustMetric("zpool",
+		map[string]string{},
+		map[string]interface{}{"alloc": int64(42)},
+		time.Unix(0, 0))
+	e.Apply(in)
+	require.True(t, e.started)
+
+	e.Stop()
+	require.False(t, e.started)
+}
+
+func TestExecdApplyAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "execd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// The first process exits without replying, the next one echoes the
+	// batches.
+	marker := filepath.Join(dir, "started")
+	e := newExecd(`if [ ! -e ` + marker + ` ]; then
+  touch ` + marker + `
+  read -r line
+  exit 1
+fi
+cat`)
+	e.Timeout = internal.Duration{Duration: 100 * time.Millisecond}
+	require.NoError(t, e.Init())
+	defer e.Stop()
+
+	in := testutil.MustMetric("zpool",
+		map[string]string{},
+		map[string]interface{}{"alloc": int64(42)},
+		time.Unix(0, 0))
+
+	out := e.Apply(in)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{in}, out)
+	require.Equal(t, 1, e.skip)
+
+	for atomic.LoadInt32(&e.exited) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	out = e.Apply(in)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{in}, out)
+	require.Equal(t, 0, e.skip)
+}
+
+func TestExecdInitWithoutCommand(t *testing.T) {
+	e := &Execd{Log: testutil.Logger{}}
+	require.Error(t, e.Init())
+}
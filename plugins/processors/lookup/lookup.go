@@ -0,0 +1,174 @@
+package lookup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## File with the tags to add, keyed on the value of the key tag.
+  file = "/etc/telegraf/pools.csv"
+
+  ## Format of the file, "csv" or "json".  By default it is given by the
+  ## extension of the file.
+  ##   csv: a header row, the column named after key holds the value of the
+  ##        key tag, the other columns are the tags to add.
+  ##   json: an object of objects, {"tank": {"owner": "storage"}}.
+  # format = ""
+
+  ## Tag whose value is looked up.
+  key = "pool"
+
+  ## How often the modification time of the file is checked, the file is
+  ## reloaded when it changed.
+  # reload_interval = "1m"
+`
+
+type Lookup struct {
+	File           string            `toml:"file"`
+	Format         string            `toml:"format"`
+	Key            string            `toml:"key"`
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	table   map[string]map[string]string
+	modTime time.Time
+	checked time.Time
+	now     func() time.Time
+}
+
+func (l *Lookup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Lookup) Description() string {
+	return "Add tags from a lookup file keyed on the value of a tag."
+}
+
+func (l *Lookup) Init() error {
+	if l.File == "" {
+		return fmt.Errorf("file must not be empty")
+	}
+	if l.Key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	if l.Format == "" {
+		l.Format = strings.TrimPrefix(filepath.Ext(l.File), ".")
+	}
+	switch l.Format {
+	case "csv", "json":
+	default:
+		return fmt.Errorf("invalid format %q", l.Format)
+	}
+	if l.now == nil {
+		l.now = time.Now
+	}
+
+	return l.load()
+}
+
+// load reads the file if it changed since it was last read.
+func (l *Lookup) load() error {
+	l.checked = l.now()
+
+	info, err := os.Stat(l.File)
+	if err != nil {
+		return err
+	}
+	if l.table != nil && info.ModTime().Equal(l.modTime) {
+		return nil
+	}
+
+	f, err := os.Open(l.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var table map[string]map[string]string
+	if l.Format == "csv" {
+		table, err = l.parseCSV(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&table)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %v", l.File, err)
+	}
+
+	l.table = table
+	l.modTime = info.ModTime()
+	return nil
+}
+
+func (l *Lookup) parseCSV(f *os.File) (map[string]map[string]string, error) {
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header")
+	}
+
+	header := records[0]
+	column := -1
+	for i, name := range header {
+		if name == l.Key {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("no column %q", l.Key)
+	}
+
+	table := make(map[string]map[string]string, len(records)-1)
+	for _, record := range records[1:] {
+		tags := make(map[string]string, len(record)-1)
+		for i, value := range record {
+			if i != column && value != "" {
+				tags[header[i]] = value
+			}
+		}
+		table[record[column]] = tags
+	}
+	return table, nil
+}
+
+func (l *Lookup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if l.ReloadInterval.Duration > 0 && l.now().Sub(l.checked) >= l.ReloadInterval.Duration {
+		// A file that cannot be read keeps the tags of the last one read.
+		if err := l.load(); err != nil {
+			l.Log.Errorf("Error reloading %s: %v", l.File, err)
+		}
+	}
+
+	for _, metric := range in {
+		value, ok := metric.GetTag(l.Key)
+		if !ok {
+			continue
+		}
+		for key, tag := range l.table[value] {
+			if !metric.HasTag(key) {
+				metric.AddTag(key, tag)
+			}
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("lookup", func() telegraf.Processor {
+		return &Lookup{
+			ReloadInterval: internal.Duration{Duration: time.Minute},
+		}
+	})
+}
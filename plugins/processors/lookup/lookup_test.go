@@ -0,0 +1,140 @@
+package lookup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric("zfs_pool", tags,
+		map[string]interface{}{"read_bytes": int64(1884160)},
+		time.Unix(0, 0),
+	)
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		input    map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "csv",
+			file:     "pools.csv",
+			content:  "owner,pool,site\nstorage,tank,paris\nbackup,backup,\n",
+			input:    map[string]string{"pool": "tank"},
+			expected: map[string]string{"pool": "tank", "owner": "storage", "site": "paris"},
+		},
+		{
+			name:     "csv empty column",
+			file:     "pools.csv",
+			content:  "owner,pool,site\nstorage,tank,paris\nbackup,backup,\n",
+			input:    map[string]string{"pool": "backup"},
+			expected: map[string]string{"pool": "backup", "owner": "backup"},
+		},
+		{
+			name:     "json",
+			file:     "pools.json",
+			content:  `{"tank": {"owner": "storage"}}`,
+			input:    map[string]string{"pool": "tank"},
+			expected: map[string]string{"pool": "tank", "owner": "storage"},
+		},
+		{
+			name:     "unknown key",
+			file:     "pools.json",
+			content:  `{"tank": {"owner": "storage"}}`,
+			input:    map[string]string{"pool": "rpool"},
+			expected: map[string]string{"pool": "rpool"},
+		},
+		{
+			name:     "existing tag kept",
+			file:     "pools.json",
+			content:  `{"tank": {"owner": "storage"}}`,
+			input:    map[string]string{"pool": "tank", "owner": "dba"},
+			expected: map[string]string{"pool": "tank", "owner": "dba"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Lookup{
+				File: writeFile(t, dir, tt.file, tt.content),
+				Key:  "pool",
+				Log:  testutil.Logger{},
+			}
+			require.NoError(t, l.Init())
+
+			actual := l.Apply(newMetric(tt.input))
+			require.Equal(t, tt.expected, actual[0].Tags())
+		})
+	}
+}
+
+func TestLookupInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l := &Lookup{File: writeFile(t, dir, "pools.txt", ""), Key: "pool"}
+	require.Error(t, l.Init())
+
+	l = &Lookup{File: writeFile(t, dir, "pools.csv", "owner,site\nstorage,paris\n"), Key: "pool"}
+	require.Error(t, l.Init())
+
+	l = &Lookup{File: filepath.Join(dir, "missing.json"), Key: "pool"}
+	require.Error(t, l.Init())
+}
+
+func TestLookupReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1600000000, 0)
+	path := writeFile(t, dir, "pools.json", `{"tank": {"owner": "storage"}}`)
+	l := &Lookup{
+		File:           path,
+		Key:            "pool",
+		ReloadInterval: internal.Duration{Duration: time.Minute},
+		Log:            testutil.Logger{},
+		now:            func() time.Time { return now },
+	}
+	require.NoError(t, l.Init())
+
+	writeFile(t, dir, "pools.json", `{"tank": {"owner": "dba"}}`)
+	require.NoError(t, os.Chtimes(path, now, now.Add(time.Second)))
+
+	// The file is not checked before reload_interval.
+	actual := l.Apply(newMetric(map[string]string{"pool": "tank"}))
+	require.Equal(t, "storage", actual[0].Tags()["owner"])
+
+	now = now.Add(time.Minute)
+	actual = l.Apply(newMetric(map[string]string{"pool": "tank"}))
+	require.Equal(t, "dba", actual[0].Tags()["owner"])
+
+	// A broken file keeps the last tags.
+	writeFile(t, dir, "pools.json", `{"tank":`)
+	require.NoError(t, os.Chtimes(path, now, now.Add(2*time.Second)))
+	now = now.Add(time.Minute)
+	actual = l.Apply(newMetric(map[string]string{"pool": "tank"}))
+	require.Equal(t, "dba", actual[0].Tags()["owner"])
+}
@@ -12,12 +12,20 @@ const (
   tag_key = "name"
   ## Field to use as the value of the new field.
   value_key = "value"
+
+  ## Fields to rotate instead of value_key, each named after the tag and
+  ## the field joined by separator, like sync_read_pend for the field pend
+  ## of the metric tagged sync_read.
+  # value_keys = []
+  # separator = "_"
 `
 )
 
 type Pivot struct {
-	TagKey   string `toml:"tag_key"`
-	ValueKey string `toml:"value_key"`
+	TagKey    string   `toml:"tag_key"`
+	ValueKey  string   `toml:"value_key"`
+	ValueKeys []string `toml:"value_keys"`
+	Separator string   `toml:"separator"`
 }
 
 func (p *Pivot) SampleConfig() string {
@@ -35,6 +43,11 @@ func (p *Pivot) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 			continue
 		}
 
+		if len(p.ValueKeys) > 0 {
+			p.pivotFields(m, key)
+			continue
+		}
+
 		value, ok := m.GetField(p.ValueKey)
 		if !ok {
 			continue
@@ -47,8 +60,24 @@ func (p *Pivot) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 	return metrics
 }
 
+func (p *Pivot) pivotFields(m telegraf.Metric, key string) {
+	pivoted := false
+	for _, valueKey := range p.ValueKeys {
+		value, ok := m.GetField(valueKey)
+		if !ok {
+			continue
+		}
+		m.RemoveField(valueKey)
+		m.AddField(key+p.Separator+valueKey, value)
+		pivoted = true
+	}
+	if pivoted {
+		m.RemoveTag(p.TagKey)
+	}
+}
+
 func init() {
 	processors.Add("pivot", func() telegraf.Processor {
-		return &Pivot{}
+		return &Pivot{Separator: "_"}
 	})
 }
@@ -101,6 +101,71 @@ func TestPivot(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "value keys",
+			pivot: &Pivot{
+				TagKey:    "class",
+				ValueKeys: []string{"pend", "activ"},
+				Separator: "_",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"pool":  "tank",
+						"class": "sync_read",
+					},
+					map[string]interface{}{
+						"pend":  int64(1),
+						"activ": int64(2),
+						"other": int64(3),
+					},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"pool": "tank",
+					},
+					map[string]interface{}{
+						"sync_read_pend":  int64(1),
+						"sync_read_activ": int64(2),
+						"other":           int64(3),
+					},
+					now,
+				),
+			},
+		},
+		{
+			name: "value keys missing",
+			pivot: &Pivot{
+				TagKey:    "class",
+				ValueKeys: []string{"pend", "activ"},
+				Separator: "_",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"class": "sync_read",
+					},
+					map[string]interface{}{
+						"other": int64(3),
+					},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"class": "sync_read",
+					},
+					map[string]interface{}{
+						"other": int64(3),
+					},
+					now,
+				),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,119 @@
+package route
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Tag used to carry the route, select the metrics of an output with
+  ## tagpass on this tag and remove it with tagexclude.
+  # tag = "route"
+
+  ## Route of the metrics not matching any rule, if empty the tag is not set.
+  # default = ""
+
+  ## Rules are tested in order, the first matching rule sets the route.  The
+  ## patterns are globs, or regular expressions when prefixed with "re:".
+  [[processors.route.rule]]
+    route = "events"
+    ## Measurement names matching the rule, any name if empty.
+    measurement = ["zfs_events"]
+    ## Tags matching the rule, all keys must have a matching value.
+    # [processors.route.rule.tags]
+    #   pool = ["tank*"]
+`
+
+type Rule struct {
+	Route       string              `toml:"route"`
+	Measurement []string            `toml:"measurement"`
+	Tags        map[string][]string `toml:"tags"`
+
+	measurement filter.Filter
+	tags        map[string]filter.Filter
+}
+
+type Route struct {
+	Tag     string  `toml:"tag"`
+	Default string  `toml:"default"`
+	Rules   []*Rule `toml:"rule"`
+}
+
+func (r *Route) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Route) Description() string {
+	return "Tag metrics with the route selecting their outputs."
+}
+
+func (r *Route) Init() error {
+	if r.Tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+
+	for i, rule := range r.Rules {
+		if rule.Route == "" {
+			return fmt.Errorf("rule %d: route must not be empty", i+1)
+		}
+
+		var err error
+		rule.measurement, err = filter.Compile(rule.Measurement)
+		if err != nil {
+			return fmt.Errorf("rule %d: error compiling measurement filter: %v", i+1, err)
+		}
+
+		rule.tags = make(map[string]filter.Filter, len(rule.Tags))
+		for key, patterns := range rule.Tags {
+			f, err := filter.Compile(patterns)
+			if err != nil {
+				return fmt.Errorf("rule %d: error compiling filter for tag %q: %v", i+1, key, err)
+			}
+			if f == nil {
+				return fmt.Errorf("rule %d: no patterns for tag %q", i+1, key)
+			}
+			rule.tags[key] = f
+		}
+	}
+	return nil
+}
+
+func (rule *Rule) match(metric telegraf.Metric) bool {
+	if rule.measurement != nil && !rule.measurement.Match(metric.Name()) {
+		return false
+	}
+	for key, f := range rule.tags {
+		value, ok := metric.GetTag(key)
+		if !ok || !f.Match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Route) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		route := r.Default
+		for _, rule := range r.Rules {
+			if rule.match(metric) {
+				route = rule.Route
+				break
+			}
+		}
+		if route != "" {
+			metric.AddTag(r.Tag, route)
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("route", func() telegraf.Processor {
+		return &Route{
+			Tag: "route",
+		}
+	})
+}
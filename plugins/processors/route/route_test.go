@@ -0,0 +1,100 @@
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(name string, tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric(name, tags,
+		map[string]interface{}{"value": int64(1)},
+		time.Unix(0, 0),
+	)
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    *Route
+		input    telegraf.Metric
+		expected map[string]string
+	}{
+		{
+			name: "measurement match",
+			route: &Route{
+				Tag:   "route",
+				Rules: []*Rule{{Route: "kafka", Measurement: []string{"zfs_events"}}},
+			},
+			input:    newMetric("zfs_events", map[string]string{}),
+			expected: map[string]string{"route": "kafka"},
+		},
+		{
+			name: "no match without default",
+			route: &Route{
+				Tag:   "route",
+				Rules: []*Rule{{Route: "kafka", Measurement: []string{"zfs_events"}}},
+			},
+			input:    newMetric("zfs", map[string]string{}),
+			expected: map[string]string{},
+		},
+		{
+			name: "no match with default",
+			route: &Route{
+				Tag:     "route",
+				Default: "influxdb",
+				Rules:   []*Rule{{Route: "kafka", Measurement: []string{"zfs_events"}}},
+			},
+			input:    newMetric("zfs", map[string]string{}),
+			expected: map[string]string{"route": "influxdb"},
+		},
+		{
+			name: "tags must all match",
+			route: &Route{
+				Tag: "route",
+				Rules: []*Rule{{
+					Route: "tank",
+					Tags: map[string][]string{
+						"pool":  {"tank*"},
+						"state": {"ONLINE"},
+					},
+				}},
+			},
+			input:    newMetric("zfs_pool", map[string]string{"pool": "tank0"}),
+			expected: map[string]string{"pool": "tank0"},
+		},
+		{
+			name: "first rule wins",
+			route: &Route{
+				Tag: "dest",
+				Rules: []*Rule{
+					{Route: "first", Tags: map[string][]string{"pool": {"re:tank[0-9]"}}},
+					{Route: "second", Measurement: []string{"zfs*"}},
+				},
+			},
+			input:    newMetric("zfs_pool", map[string]string{"pool": "tank0"}),
+			expected: map[string]string{"pool": "tank0", "dest": "first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.route.Init())
+			actual := tt.route.Apply(tt.input)
+			require.Len(t, actual, 1)
+			require.Equal(t, tt.expected, actual[0].Tags())
+		})
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	require.Error(t, (&Route{}).Init())
+	require.Error(t, (&Route{Tag: "route", Rules: []*Rule{{}}}).Init())
+	require.Error(t, (&Route{
+		Tag:   "route",
+		Rules: []*Rule{{Route: "a", Measurement: []string{"re:("}}},
+	}).Init())
+}
@@ -0,0 +1,347 @@
+package threshold
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Measurement name of the alert metrics.
+  # name = "alert"
+
+  ## When to emit alert metrics, one of:
+  ##   changes: only when an alert fires or clears.
+  ##   always:  on every evaluation of a rule.
+  # emit = "changes"
+
+  ## The state of a series not evaluated for this long is forgotten, without
+  ## emitting an alert clearing it.  Set to 0 to keep the states forever.
+  # expire_after = "1h"
+
+  [[processors.threshold.rule]]
+    ## Name of the alert, added as the "alert" tag.
+    name = "zpool_capacity"
+    ## Measurement names the rule applies to, any name if empty.
+    measurement = ["zfs_pool"]
+    ## Field compared to the threshold.
+    field = "capacity"
+    ## Comparison operator, one of ">", ">=", "<", "<=", "==" or "!=".
+    operator = ">"
+    value = 90.0
+    ## Margin the field must cross back beyond the threshold before the alert
+    ## clears, only with the ">", ">=", "<" and "<=" operators.
+    # hysteresis = 5.0
+    ## Number of consecutive evaluations the condition must hold before the
+    ## alert fires, and must not hold before it clears.
+    # for = 1
+    # clear_for = 1
+    ## Severity of the alert, added as the "severity" tag.
+    # severity = "warning"
+`
+
+const (
+	emitChanges = "changes"
+	emitAlways  = "always"
+
+	stateFiring = "firing"
+	stateOK     = "ok"
+)
+
+type Rule struct {
+	Name        string   `toml:"name"`
+	Measurement []string `toml:"measurement"`
+	Field       string   `toml:"field"`
+	Operator    string   `toml:"operator"`
+	Value       float64  `toml:"value"`
+	Hysteresis  float64  `toml:"hysteresis"`
+	For         int      `toml:"for"`
+	ClearFor    int      `toml:"clear_for"`
+	Severity    string   `toml:"severity"`
+
+	measurement filter.Filter
+	compare     func(a, b float64) bool
+	clearValue  float64
+}
+
+// state is the alert state of a rule for one series.
+type state struct {
+	firing bool
+	// count is the number of consecutive evaluations in favor of changing
+	// the state.
+	count int
+	// seen is the time of the last evaluation.
+	seen time.Time
+}
+
+type Threshold struct {
+	Name        string            `toml:"name"`
+	Emit        string            `toml:"emit"`
+	ExpireAfter internal.Duration `toml:"expire_after"`
+	Rules       []*Rule           `toml:"rule"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	// mu guards the states, Apply is called concurrently by the agent for
+	// the gathered and the aggregated metrics.
+	mu      sync.Mutex
+	states  map[stateKey]*state
+	expired time.Time
+	now     func() time.Time
+}
+
+type stateKey struct {
+	rule   int
+	series uint64
+}
+
+var operators = map[string]func(a, b float64) bool{
+	">":  func(a, b float64) bool { return a > b },
+	">=": func(a, b float64) bool { return a >= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// inverse is the operator of the clear condition.
+var inverse = map[string]string{
+	">":  "<=",
+	">=": "<",
+	"<":  ">=",
+	"<=": ">",
+	"==": "!=",
+	"!=": "==",
+}
+
+func (t *Threshold) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *Threshold) Description() string {
+	return "Emit alerts when fields cross thresholds."
+}
+
+func (t *Threshold) Init() error {
+	switch t.Emit {
+	case emitChanges, emitAlways:
+	default:
+		return fmt.Errorf("invalid emit %q", t.Emit)
+	}
+
+	for i, rule := range t.Rules {
+		if err := rule.init(); err != nil {
+			return fmt.Errorf("rule %d: %v", i+1, err)
+		}
+	}
+
+	if t.now == nil {
+		t.now = time.Now
+	}
+	t.states = make(map[stateKey]*state)
+	t.expired = t.now()
+	return nil
+}
+
+func (r *Rule) init() error {
+	if r.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	if r.Field == "" {
+		return fmt.Errorf("field must not be empty")
+	}
+
+	var ok bool
+	r.compare, ok = operators[r.Operator]
+	if !ok {
+		return fmt.Errorf("invalid operator %q", r.Operator)
+	}
+
+	switch r.Operator {
+	case ">", ">=":
+		r.clearValue = r.Value - r.Hysteresis
+	case "<", "<=":
+		r.clearValue = r.Value + r.Hysteresis
+	default:
+		if r.Hysteresis != 0 {
+			return fmt.Errorf("hysteresis is not supported with operator %q", r.Operator)
+		}
+		r.clearValue = r.Value
+	}
+
+	if r.For < 1 {
+		r.For = 1
+	}
+	if r.ClearFor < 1 {
+		r.ClearFor = 1
+	}
+
+	var err error
+	r.measurement, err = filter.Compile(r.Measurement)
+	if err != nil {
+		return fmt.Errorf("error compiling measurement filter: %v", err)
+	}
+	return nil
+}
+
+func (r *Rule) match(m telegraf.Metric) (float64, bool) {
+	if r.measurement != nil && !r.measurement.Match(m.Name()) {
+		return 0, false
+	}
+	v, ok := m.GetField(r.Field)
+	if !ok {
+		return 0, false
+	}
+	return convert(v)
+}
+
+// evaluate updates the state with a new value and returns whether the state
+// changed.
+func (r *Rule) evaluate(s *state, value float64) bool {
+	var change bool
+	if s.firing {
+		change = !r.compare(value, r.clearValue)
+	} else {
+		change = r.compare(value, r.Value)
+	}
+
+	if !change {
+		s.count = 0
+		return false
+	}
+
+	s.count++
+	need := r.For
+	if s.firing {
+		need = r.ClearFor
+	}
+	if s.count < need {
+		return false
+	}
+
+	s.firing = !s.firing
+	s.count = 0
+	return true
+}
+
+func (t *Threshold) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	out := in
+	for _, m := range in {
+		for i, rule := range t.Rules {
+			value, ok := rule.match(m)
+			if !ok {
+				continue
+			}
+
+			key := stateKey{rule: i, series: m.HashID()}
+			s, ok := t.states[key]
+			if !ok {
+				s = &state{}
+				t.states[key] = s
+			}
+			s.seen = now
+
+			changed := rule.evaluate(s, value)
+			if !changed && t.Emit != emitAlways {
+				continue
+			}
+			if changed {
+				t.Log.Debugf("Alert %q is %s for %s", rule.Name, stateName(s.firing), m.Name())
+			}
+
+			alert, err := t.alert(rule, m, s, value)
+			if err != nil {
+				t.Log.Errorf("Error creating alert metric: %v", err)
+				continue
+			}
+			out = append(out, alert)
+		}
+	}
+
+	t.expire(now)
+	return out
+}
+
+// expire removes the states of the series not evaluated within expire_after.
+// The states are scanned at most once per expire_after.
+func (t *Threshold) expire(now time.Time) {
+	if t.ExpireAfter.Duration <= 0 || now.Sub(t.expired) < t.ExpireAfter.Duration {
+		return
+	}
+	t.expired = now
+
+	for key, s := range t.states {
+		if now.Sub(s.seen) >= t.ExpireAfter.Duration {
+			delete(t.states, key)
+		}
+	}
+}
+
+// alert creates the alert metric, an event with the tags of the metric
+// crossing the threshold.
+func (t *Threshold) alert(rule *Rule, m telegraf.Metric, s *state, value float64) (telegraf.Metric, error) {
+	tags := m.Tags()
+	tags["alert"] = rule.Name
+	if rule.Severity != "" {
+		tags["severity"] = rule.Severity
+	}
+
+	title := fmt.Sprintf("%s is %s", rule.Name, stateName(s.firing))
+	text := fmt.Sprintf("%s.%s = %v, fires when %s %v, clears when %s %v",
+		m.Name(), rule.Field, value,
+		rule.Operator, rule.Value, inverse[rule.Operator], rule.clearValue)
+
+	alert, err := metric.NewEvent(t.Name, tags, title, text, m.Time())
+	if err != nil {
+		return nil, err
+	}
+	alert.AddField("state", stateName(s.firing))
+	alert.AddField("firing", s.firing)
+	alert.AddField("value", value)
+	return alert, nil
+}
+
+func stateName(firing bool) string {
+	if firing {
+		return stateFiring
+	}
+	return stateOK
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	processors.Add("threshold", func() telegraf.Processor {
+		return &Threshold{
+			Name:        "alert",
+			Emit:        emitChanges,
+			ExpireAfter: internal.Duration{Duration: time.Hour},
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package threshold
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newPool(pool string, capacity float64) telegraf.Metric {
+	return testutil.MustMetric("zfs_pool",
+		map[string]string{"pool": pool},
+		map[string]interface{}{"capacity": capacity},
+		time.Unix(0, 0),
+	)
+}
+
+func newThreshold(emit string, rule *Rule) *Threshold {
+	return &Threshold{
+		Name:  "alert",
+		Emit:  emit,
+		Rules: []*Rule{rule},
+		Log:   testutil.Logger{},
+	}
+}
+
+// states returns the state field of the alerts emitted for each value.
+func states(t *testing.T, p *Threshold, pool string, values ...float64) []string {
+	var result []string
+	for _, v := range values {
+		out := p.Apply(newPool(pool, v))
+		require.Equal(t, "zfs_pool", out[0].Name())
+		for _, m := range out[1:] {
+			require.Equal(t, "alert", m.Name())
+			state, ok := m.GetField("state")
+			require.True(t, ok)
+			result = append(result, state.(string))
+		}
+	}
+	return result
+}
+
+func TestThresholdChanges(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:     "capacity",
+		Field:    "capacity",
+		Operator: ">",
+		Value:    90,
+	})
+	require.NoError(t, p.Init())
+
+	require.Equal(t, []string{"firing", "ok", "firing"},
+		states(t, p, "tank", 50, 95, 96, 80, 91))
+}
+
+func TestThresholdFor(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:     "capacity",
+		Field:    "capacity",
+		Operator: ">",
+		Value:    90,
+		For:      3,
+		ClearFor: 2,
+	})
+	require.NoError(t, p.Init())
+
+	require.Nil(t, states(t, p, "tank", 95, 95, 50, 95, 95))
+	require.Equal(t, []string{"firing"}, states(t, p, "tank", 95))
+	require.Nil(t, states(t, p, "tank", 50, 95, 50))
+	require.Equal(t, []string{"ok"}, states(t, p, "tank", 50))
+}
+
+func TestThresholdHysteresis(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:       "capacity",
+		Field:      "capacity",
+		Operator:   ">",
+		Value:      90,
+		Hysteresis: 5,
+	})
+	require.NoError(t, p.Init())
+
+	require.Equal(t, []string{"firing"}, states(t, p, "tank", 91, 89, 86, 90))
+	require.Equal(t, []string{"ok"}, states(t, p, "tank", 85))
+}
+
+func TestThresholdSeries(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:     "capacity",
+		Field:    "capacity",
+		Operator: ">=",
+		Value:    90,
+	})
+	require.NoError(t, p.Init())
+
+	require.Equal(t, []string{"firing"}, states(t, p, "tank", 90))
+	require.Equal(t, []string{"firing"}, states(t, p, "backup", 90))
+	require.Nil(t, states(t, p, "tank", 95))
+}
+
+func TestThresholdExpire(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	p := newThreshold(emitChanges, &Rule{
+		Name:     "capacity",
+		Field:    "capacity",
+		Operator: ">",
+		Value:    90,
+	})
+	p.ExpireAfter = internal.Duration{Duration: time.Hour}
+	p.now = func() time.Time { return now }
+	require.NoError(t, p.Init())
+
+	require.Equal(t, []string{"firing"}, states(t, p, "tank", 95))
+	require.Equal(t, []string{"firing"}, states(t, p, "backup", 95))
+
+	now = now.Add(30 * time.Minute)
+	require.Nil(t, states(t, p, "tank", 95))
+	require.Len(t, p.states, 2)
+
+	// The backup pool is no longer evaluated and its state is forgotten.
+	now = now.Add(45 * time.Minute)
+	require.Nil(t, states(t, p, "tank", 95))
+	require.Len(t, p.states, 1)
+
+	require.Equal(t, []string{"firing"}, states(t, p, "backup", 95))
+}
+
+func TestThresholdConcurrentApply(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:     "capacity",
+		Field:    "capacity",
+		Operator: ">",
+		Value:    90,
+	})
+	require.NoError(t, p.Init())
+
+	// The agent applies the processors to the gathered and to the aggregated
+	// metrics at the same time.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				p.Apply(newPool(fmt.Sprintf("pool%d-%d", i, j), 95))
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Len(t, p.states, 400)
+}
+
+func TestThresholdAlways(t *testing.T) {
+	p := newThreshold(emitAlways, &Rule{
+		Name:     "capacity",
+		Field:    "capacity",
+		Operator: "<",
+		Value:    10,
+	})
+	require.NoError(t, p.Init())
+
+	require.Equal(t, []string{"ok", "firing", "firing", "ok"},
+		states(t, p, "tank", 50, 5, 5, 50))
+}
+
+func TestThresholdAlertMetric(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:        "capacity",
+		Measurement: []string{"zfs_*"},
+		Field:       "capacity",
+		Operator:    ">",
+		Value:       90,
+		Hysteresis:  5,
+		Severity:    "critical",
+	})
+	require.NoError(t, p.Init())
+
+	out := p.Apply(newPool("tank", 95))
+	expected := []telegraf.Metric{
+		newPool("tank", 95),
+		testutil.MustMetric("alert",
+			map[string]string{
+				"pool":     "tank",
+				"alert":    "capacity",
+				"severity": "critical",
+			},
+			map[string]interface{}{
+				"title":  "capacity is firing",
+				"text":   "zfs_pool.capacity = 95, fires when > 90, clears when <= 85",
+				"state":  "firing",
+				"firing": true,
+				"value":  95.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, out)
+}
+
+func TestThresholdMeasurement(t *testing.T) {
+	p := newThreshold(emitChanges, &Rule{
+		Name:        "capacity",
+		Measurement: []string{"disk"},
+		Field:       "capacity",
+		Operator:    ">",
+		Value:       90,
+	})
+	require.NoError(t, p.Init())
+
+	require.Nil(t, states(t, p, "tank", 95))
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *Rule
+	}{
+		{"missing name", &Rule{Field: "f", Operator: ">"}},
+		{"missing field", &Rule{Name: "a", Operator: ">"}},
+		{"invalid operator", &Rule{Name: "a", Field: "f", Operator: "=>"}},
+		{"hysteresis with ==", &Rule{Name: "a", Field: "f", Operator: "==", Hysteresis: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, newThreshold(emitChanges, tt.rule).Init())
+		})
+	}
+
+	require.Error(t, newThreshold("never", &Rule{Name: "a", Field: "f", Operator: ">"}).Init())
+}
@@ -1,6 +1,8 @@
 package unpivot
 
 import (
+	"strings"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
@@ -12,12 +14,19 @@ const (
   tag_key = "name"
   ## Field to use for the name of the value.
   value_key = "value"
+
+  ## Split the field names at the last separator instead, into the tag and
+  ## the name of the field, so that sync_read_pend and sync_read_activ give
+  ## a metric tagged sync_read with the fields pend and activ.  The fields
+  ## without the separator are rotated into value_key.
+  # separator = ""
 `
 )
 
 type Unpivot struct {
-	TagKey   string `toml:"tag_key"`
-	ValueKey string `toml:"value_key"`
+	TagKey    string `toml:"tag_key"`
+	ValueKey  string `toml:"value_key"`
+	Separator string `toml:"separator"`
 }
 
 func (p *Unpivot) SampleConfig() string {
@@ -53,6 +62,11 @@ func (p *Unpivot) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 
 	for _, m := range metrics {
 		base := copyWithoutFields(m)
+		if p.Separator != "" {
+			results = append(results, p.splitFields(m, base)...)
+			m.Accept()
+			continue
+		}
 		for _, field := range m.FieldList() {
 			newMetric := base.Copy()
 			newMetric.AddField(p.ValueKey, field.Value)
@@ -64,6 +78,32 @@ func (p *Unpivot) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 	return results
 }
 
+func (p *Unpivot) splitFields(m telegraf.Metric, base telegraf.Metric) []telegraf.Metric {
+	var results []telegraf.Metric
+	groups := make(map[string]telegraf.Metric)
+	for _, field := range m.FieldList() {
+		i := strings.LastIndex(field.Key, p.Separator)
+		if i < 1 || i+len(p.Separator) == len(field.Key) {
+			newMetric := base.Copy()
+			newMetric.AddField(p.ValueKey, field.Value)
+			newMetric.AddTag(p.TagKey, field.Key)
+			results = append(results, newMetric)
+			continue
+		}
+
+		name := field.Key[:i]
+		group, ok := groups[name]
+		if !ok {
+			group = base.Copy()
+			group.AddTag(p.TagKey, name)
+			groups[name] = group
+			results = append(results, group)
+		}
+		group.AddField(field.Key[i+len(p.Separator):], field.Value)
+	}
+	return results
+}
+
 func init() {
 	processors.Add("unpivot", func() telegraf.Processor {
 		return &Unpivot{}
@@ -80,6 +80,61 @@ func TestUnpivot(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "separator",
+			unpivot: &Unpivot{
+				TagKey:    "class",
+				ValueKey:  "value",
+				Separator: "_",
+			},
+			metrics: []telegraf.Metric{
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"pool": "tank",
+					},
+					map[string]interface{}{
+						"sync_read_pend":  int64(1),
+						"sync_read_activ": int64(2),
+						"sync_write_pend": int64(0),
+						"total":           int64(3),
+					},
+					now,
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"pool":  "tank",
+						"class": "sync_read",
+					},
+					map[string]interface{}{
+						"pend":  int64(1),
+						"activ": int64(2),
+					},
+					now,
+				),
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"pool":  "tank",
+						"class": "sync_write",
+					},
+					map[string]interface{}{
+						"pend": int64(0),
+					},
+					now,
+				),
+				testutil.MustMetric("zfs_pool_queue",
+					map[string]string{
+						"pool":  "tank",
+						"class": "total",
+					},
+					map[string]interface{}{
+						"value": int64(3),
+					},
+					now,
+				),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
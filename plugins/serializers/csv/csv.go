@@ -0,0 +1,157 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+)
+
+type serializer struct {
+	Header          bool
+	Delimiter       rune
+	TimestampFormat string
+}
+
+// NewSerializer returns a serializer writing a metric per row: the
+// timestamp, the measurement, the tags and the fields sorted by key.
+func NewSerializer(header bool, delimiter string, timestampFormat string) (*serializer, error) {
+	s := &serializer{
+		Header:          header,
+		Delimiter:       ',',
+		TimestampFormat: timestampFormat,
+	}
+
+	if delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) || r == '"' || r == '\r' || r == '\n' {
+			return nil, fmt.Errorf("invalid csv_delimiter %q", delimiter)
+		}
+		s.Delimiter = r
+	}
+
+	if s.TimestampFormat == "" {
+		s.TimestampFormat = "unix"
+	}
+	return s, nil
+}
+
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	w := s.newWriter(&buf)
+	if err := w.Write(s.row(metric)); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// SerializeBatch starts the batch with the header of its first metric when
+// the header is enabled.
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	w := s.newWriter(&buf)
+	if s.Header && len(metrics) > 0 {
+		if err := w.Write(columns(metrics[0])); err != nil {
+			return nil, err
+		}
+	}
+	for _, metric := range metrics {
+		if err := w.Write(s.row(metric)); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// SerializeHeader returns the header of the rows of the metric, or nil when
+// the header is disabled.
+func (s *serializer) SerializeHeader(metric telegraf.Metric) ([]byte, error) {
+	if !s.Header {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	w := s.newWriter(&buf)
+	if err := w.Write(columns(metric)); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (s *serializer) newWriter(buf *bytes.Buffer) *csv.Writer {
+	w := csv.NewWriter(buf)
+	w.Comma = s.Delimiter
+	return w
+}
+
+func columns(metric telegraf.Metric) []string {
+	record := []string{"timestamp", "measurement"}
+	for _, tag := range metric.TagList() {
+		record = append(record, tag.Key)
+	}
+	for _, field := range sortedFields(metric) {
+		record = append(record, field.Key)
+	}
+	return record
+}
+
+func (s *serializer) row(metric telegraf.Metric) []string {
+	record := []string{s.timestamp(metric.Time()), metric.Name()}
+	for _, tag := range metric.TagList() {
+		record = append(record, tag.Value)
+	}
+	for _, field := range sortedFields(metric) {
+		record = append(record, formatValue(field.Value))
+	}
+	return record
+}
+
+func (s *serializer) timestamp(t time.Time) string {
+	switch s.TimestampFormat {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		return t.UTC().Format(s.TimestampFormat)
+	}
+}
+
+func sortedFields(metric telegraf.Metric) []*telegraf.Field {
+	fields := metric.FieldList()
+	sorted := make([]*telegraf.Field, len(fields))
+	copy(sorted, fields)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
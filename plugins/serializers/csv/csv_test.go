@@ -0,0 +1,69 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newDiskio(name string, reads int64) telegraf.Metric {
+	return testutil.MustMetric("diskio",
+		map[string]string{"name": name},
+		map[string]interface{}{"reads": reads, "read_bytes": uint64(reads * 4096), "util": 1.5, "model": "WD Red, 4TB"},
+		time.Unix(1580000000, 123000000),
+	)
+}
+
+func TestSerialize(t *testing.T) {
+	s, err := NewSerializer(true, "", "")
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(newDiskio("sda", 10))
+	require.NoError(t, err)
+	require.Equal(t, "1580000000,diskio,sda,\"WD Red, 4TB\",40960,10,1.5\n", string(buf))
+
+	buf, err = s.SerializeHeader(newDiskio("sda", 10))
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,name,model,read_bytes,reads,util\n", string(buf))
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s, err := NewSerializer(true, ";", "2006-01-02T15:04:05.000Z07:00")
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{newDiskio("sda", 10), newDiskio("sdb", 20)})
+	require.NoError(t, err)
+	require.Equal(t,
+		"timestamp;measurement;name;model;read_bytes;reads;util\n"+
+			"2020-01-26T00:53:20.123Z;diskio;sda;WD Red, 4TB;40960;10;1.5\n"+
+			"2020-01-26T00:53:20.123Z;diskio;sdb;WD Red, 4TB;81920;20;1.5\n",
+		string(buf))
+}
+
+func TestNoHeader(t *testing.T) {
+	s, err := NewSerializer(false, "", "unix_ms")
+	require.NoError(t, err)
+
+	buf, err := s.SerializeHeader(newDiskio("sda", 10))
+	require.NoError(t, err)
+	require.Nil(t, buf)
+
+	buf, err = s.SerializeBatch([]telegraf.Metric{testutil.MustMetric("zfs_pool",
+		map[string]string{},
+		map[string]interface{}{"online": true},
+		time.Unix(1580000000, 123000000),
+	)})
+	require.NoError(t, err)
+	require.Equal(t, "1580000000123,zfs_pool,true\n", string(buf))
+}
+
+func TestInvalidDelimiter(t *testing.T) {
+	_, err := NewSerializer(false, "::", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer(false, "\"", "")
+	require.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 )
 
 const DEFAULT_TEMPLATE = "host.tags.measurement.field"
@@ -29,10 +30,18 @@ var (
 	fieldDeleter = strings.NewReplacer(".FIELDNAME", "", "FIELDNAME.", "")
 )
 
+// GraphiteTemplate is a template used for the measurements matching its
+// filter.
+type GraphiteTemplate struct {
+	Filter filter.Filter
+	Value  string
+}
+
 type GraphiteSerializer struct {
 	Prefix     string
 	Template   string
 	TagSupport bool
+	Templates  []*GraphiteTemplate
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -59,7 +68,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			out = append(out, point...)
 		}
 	default:
-		bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.Template, s.Prefix)
+		bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.GetTemplate(metric.Name()), s.Prefix)
 		if bucket == "" {
 			return out, nil
 		}
@@ -81,6 +90,51 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return out, nil
 }
 
+// GetTemplate returns the template of the first of the Templates matching
+// the measurement, or the Template.
+func (s *GraphiteSerializer) GetTemplate(measurement string) string {
+	for _, template := range s.Templates {
+		if template.Filter.Match(measurement) {
+			return template.Value
+		}
+	}
+	return s.Template
+}
+
+// InitGraphiteTemplates parses templates of the form "filter template", where
+// the filter is a glob matching the measurement name.  A template without a
+// filter is returned as the default template.
+func InitGraphiteTemplates(templates []string) ([]*GraphiteTemplate, string, error) {
+	var graphiteTemplates []*GraphiteTemplate
+	defaultTemplate := ""
+
+	for i, t := range templates {
+		parts := strings.Fields(t)
+		switch len(parts) {
+		case 0:
+			return nil, "", fmt.Errorf("missing template at position: %d", i)
+		case 1:
+			if defaultTemplate != "" {
+				return nil, "", fmt.Errorf("more than one template without a filter: %q", t)
+			}
+			defaultTemplate = parts[0]
+		case 2:
+			f, err := filter.Compile([]string{parts[0]})
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid filter of template %q: %v", t, err)
+			}
+			graphiteTemplates = append(graphiteTemplates, &GraphiteTemplate{
+				Filter: f,
+				Value:  parts[1],
+			})
+		default:
+			return nil, "", fmt.Errorf("invalid template %q, expected \"filter template\"", t)
+		}
+	}
+
+	return graphiteTemplates, defaultTemplate, nil
+}
+
 func (s *GraphiteSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	var batch bytes.Buffer
 	for _, m := range metrics {
@@ -952,3 +952,51 @@ func TestSerializeBatchWithTagsSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializeMetricWithTemplates(t *testing.T) {
+	now := time.Now()
+	fields := map[string]interface{}{
+		"usage_idle": float64(91.5),
+	}
+	cpu, err := metric.New("cpu", defaultTags, fields, now)
+	require.NoError(t, err)
+	mem, err := metric.New("mem", defaultTags, fields, now)
+	require.NoError(t, err)
+	zfs, err := metric.New("zfs_pool", map[string]string{"host": "localhost", "pool": "tank"}, fields, now)
+	require.NoError(t, err)
+
+	templates, defaultTemplate, err := InitGraphiteTemplates([]string{
+		"cpu tags.measurement.host.field",
+		"zfs* host.measurement.pool.field",
+		"measurement.field",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "measurement.field", defaultTemplate)
+
+	s := GraphiteSerializer{
+		Template:  defaultTemplate,
+		Templates: templates,
+	}
+	buf, err := s.SerializeBatch([]telegraf.Metric{cpu, mem, zfs})
+	require.NoError(t, err)
+
+	expS := []string{
+		fmt.Sprintf("cpu0.us-west-2.cpu.localhost.usage_idle 91.5 %d", now.Unix()),
+		fmt.Sprintf("mem.usage_idle 91.5 %d", now.Unix()),
+		fmt.Sprintf("localhost.zfs_pool.tank.usage_idle 91.5 %d", now.Unix()),
+	}
+	assert.Equal(t, expS, strings.Split(strings.TrimSpace(string(buf)), "\n"))
+}
+
+func TestInitGraphiteTemplatesErrors(t *testing.T) {
+	_, _, err := InitGraphiteTemplates([]string{"measurement.field", "host.measurement.field"})
+	require.Error(t, err)
+
+	_, _, err = InitGraphiteTemplates([]string{"cpu host.measurement.field extra"})
+	require.Error(t, err)
+
+	templates, defaultTemplate, err := InitGraphiteTemplates(nil)
+	require.NoError(t, err)
+	require.Empty(t, templates)
+	require.Empty(t, defaultTemplate)
+}
@@ -160,7 +160,8 @@ func (c *Collection) createLabels(metric telegraf.Metric) []LabelPair {
 func (c *Collection) Add(metric telegraf.Metric) {
 	labels := c.createLabels(metric)
 	for _, field := range metric.FieldList() {
-		metricName := MetricName(metric.Name(), field.Key, metric.Type())
+		valueType := fieldType(metric, field.Key)
+		metricName := MetricName(metric.Name(), field.Key, valueType)
 		metricName, ok := SanitizeName(metricName)
 		if !ok {
 			continue
@@ -168,7 +169,7 @@ func (c *Collection) Add(metric telegraf.Metric) {
 
 		family := MetricFamily{
 			Name: metricName,
-			Type: metric.Type(),
+			Type: valueType,
 		}
 
 		entry, ok := c.Entries[family]
@@ -193,7 +194,7 @@ func (c *Collection) Add(metric telegraf.Metric) {
 			}
 		}
 
-		switch metric.Type() {
+		switch valueType {
 		case telegraf.Counter:
 			fallthrough
 		case telegraf.Gauge:
@@ -5,6 +5,7 @@ import (
 	"unicode"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	dto "github.com/prometheus/client_model/go"
 )
 
@@ -102,6 +103,12 @@ func MetricName(measurement, fieldKey string, valueType telegraf.ValueType) stri
 	return measurement + "_" + fieldKey
 }
 
+// fieldType returns the value type of a field, taking the field types
+// declared by the inputs into account for untyped metrics.
+func fieldType(m telegraf.Metric, fieldKey string) telegraf.ValueType {
+	return metric.FieldType(m, fieldKey)
+}
+
 func MetricType(valueType telegraf.ValueType) *dto.MetricType {
 	switch valueType {
 	case telegraf.Counter:
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -171,6 +172,41 @@ cpu_time_idle{host="example.org"} 42 1574279268000
 	}
 }
 
+func TestSerializeFieldTypes(t *testing.T) {
+	metric.RegisterFieldType("arc", telegraf.Counter, "hits")
+	metric.RegisterFieldType("arc", telegraf.Gauge, "size")
+
+	s, err := NewSerializer(FormatConfig{
+		MetricSortOrder: SortMetrics,
+	})
+	require.NoError(t, err)
+
+	actual, err := s.Serialize(testutil.MustMetric(
+		"arc",
+		map[string]string{},
+		map[string]interface{}{
+			"hits":  42,
+			"size":  1024,
+			"other": 1,
+		},
+		time.Unix(0, 0),
+	))
+	require.NoError(t, err)
+
+	expected := `
+# HELP arc_hits Telegraf collected metric
+# TYPE arc_hits counter
+arc_hits 42
+# HELP arc_other Telegraf collected metric
+# TYPE arc_other untyped
+arc_other 1
+# HELP arc_size Telegraf collected metric
+# TYPE arc_size gauge
+arc_size 1024
+`
+	require.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(string(actual)))
+}
+
 func TestSerializeBatch(t *testing.T) {
 	tests := []struct {
 		name     string
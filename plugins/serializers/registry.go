@@ -6,6 +6,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
@@ -42,6 +43,15 @@ type Serializer interface {
 	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
 }
 
+// HeaderSerializer is implemented by the serializers whose files start with
+// a header, like the column names of CSV.  Outputs writing to files use it to
+// start each new file with the header.
+type HeaderSerializer interface {
+	// SerializeHeader returns the header of the metrics serialized like the
+	// given metric, or nil when the header is disabled.
+	SerializeHeader(metric telegraf.Metric) ([]byte, error)
+}
+
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
@@ -61,6 +71,16 @@ type Config struct {
 	// Support unsigned integer output; influx format only
 	InfluxUintSupport bool `toml:"influx_uint_support"`
 
+	// Write the column names before the rows; csv format only
+	CSVHeader bool `toml:"csv_header"`
+
+	// Field delimiter, defaults to a comma; csv format only
+	CSVDelimiter string `toml:"csv_delimiter"`
+
+	// Format of the timestamp column, unix, unix_ms, unix_us, unix_ns or a
+	// Go reference time layout; csv format only
+	CSVTimestampFormat string `toml:"csv_timestamp_format"`
+
 	// Prefix to add to all measurements, only supports Graphite
 	Prefix string `toml:"prefix"`
 
@@ -68,6 +88,10 @@ type Config struct {
 	// only supports Graphite
 	Template string `toml:"template"`
 
+	// Templates selected by measurement name for converting telegraf metrics
+	// into Graphite, only supports Graphite
+	Templates []string `toml:"templates"`
+
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration `toml:"timestamp_units"`
 
@@ -104,7 +128,7 @@ func NewSerializer(config *Config) (Serializer, error) {
 	case "influx":
 		serializer, err = NewInfluxSerializerConfig(config)
 	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport)
+		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport, config.Templates)
 	case "json":
 		serializer, err = NewJsonSerializer(config.TimestampUnits)
 	case "splunkmetric":
@@ -113,6 +137,8 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewNowSerializer()
 	case "carbon2":
 		serializer, err = NewCarbon2Serializer()
+	case "csv":
+		serializer, err = NewCSVSerializer(config.CSVHeader, config.CSVDelimiter, config.CSVTimestampFormat)
 	case "wavefront":
 		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	case "prometheus":
@@ -158,6 +184,10 @@ func NewCarbon2Serializer() (Serializer, error) {
 	return carbon2.NewSerializer()
 }
 
+func NewCSVSerializer(header bool, delimiter string, timestampFormat string) (Serializer, error) {
+	return csv.NewSerializer(header, delimiter, timestampFormat)
+}
+
 func NewSplunkmetricSerializer(splunkmetric_hec_routing bool, splunkmetric_multimetric bool) (Serializer, error) {
 	return splunkmetric.NewSerializer(splunkmetric_hec_routing, splunkmetric_multimetric)
 }
@@ -188,10 +218,20 @@ func NewInfluxSerializer() (Serializer, error) {
 	return influx.NewSerializer(), nil
 }
 
-func NewGraphiteSerializer(prefix, template string, tag_support bool) (Serializer, error) {
+func NewGraphiteSerializer(prefix, template string, tag_support bool, templates []string) (Serializer, error) {
+	graphiteTemplates, defaultTemplate, err := graphite.InitGraphiteTemplates(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	if defaultTemplate != "" {
+		template = defaultTemplate
+	}
+
 	return &graphite.GraphiteSerializer{
 		Prefix:     prefix,
 		Template:   template,
 		TagSupport: tag_support,
+		Templates:  graphiteTemplates,
 	}, nil
 }
@@ -10,3 +10,22 @@ type Processor interface {
 	// Apply the filter to the given metric.
 	Apply(in ...Metric) []Metric
 }
+
+// StoppableProcessor is a processor holding resources, such as a running
+// process, to release once the agent stops applying it.
+type StoppableProcessor interface {
+	Processor
+
+	// Stop is called once no more metrics are applied.
+	Stop()
+}
+
+// FlushingProcessor is a processor holding metrics back, such as the
+// partial windows of a rollup, to emit once the agent stops applying it.
+type FlushingProcessor interface {
+	Processor
+
+	// Flush returns the metrics held back.  It is called once no more
+	// metrics are applied, before Stop.
+	Flush() []Metric
+}